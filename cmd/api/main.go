@@ -1,17 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/seuuser/charges-service/docs"
+	"github.com/seuuser/charges-service/internal/auth"
 	"github.com/seuuser/charges-service/internal/config"
+	"github.com/seuuser/charges-service/internal/handler"
+	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/reconcile"
 	"github.com/seuuser/charges-service/internal/server"
 	"github.com/seuuser/charges-service/internal/supabase"
+	"github.com/seuuser/charges-service/internal/webhook"
 )
 
 // @title           Charges Service
@@ -19,9 +30,43 @@ import (
 // @description     Microservice para gestão de cobranças (inicialmente integração Asaas; no futuro bancos diversos).
 // @host            localhost:8083
 // @BasePath        /
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Supabase JWT: "Bearer <access_token>"
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+// @description                 Chave de serviço (SERVICE_API_KEYS) para chamadas service-to-service
 func main() {
 	cfg := config.Load()
 	supabase.InitClient()
+	supabase.Configure(supabase.Options{
+		QueryTimeout: cfg.Supabase.QueryTimeout,
+		RPCTimeout:   cfg.Supabase.RPCTimeout,
+	})
+
+	asaas.Configure(asaas.Options{
+		ReadTimeout:  cfg.Asaas.ReadTimeout,
+		WriteTimeout: cfg.Asaas.WriteTimeout,
+		MaxRetries:   cfg.Asaas.MaxRetries,
+		RetryBase:    cfg.Asaas.RetryBase,
+		RetryMax:     cfg.Asaas.RetryMax,
+		RateLimit:    cfg.Asaas.RateLimit,
+		MinRemaining: cfg.Asaas.MinRemaining,
+
+		BreakerFailures:       cfg.Asaas.BreakerFailures,
+		BreakerOpenTimeout:    cfg.Asaas.BreakerOpenTimeout,
+		BreakerHalfOpenProbes: cfg.Asaas.BreakerHalfOpenProbes,
+	})
+
+	// Billing providers available to billing integrations (iam.billing_integrations.provider).
+	provider.Register(asaas.ProviderName, asaas.NewChargeProvider)
+
+	// Subcommands (support tooling) run and exit instead of starting the server.
+	if len(os.Args) > 1 && os.Args[1] == "replay-webhooks" {
+		os.Exit(runReplayWebhooks(os.Args[2:]))
+	}
 
 	// Swagger host override (same pattern as other services)
 	swaggerHost := strings.TrimSpace(os.Getenv("SWAGGER_HOST"))
@@ -51,9 +96,63 @@ func main() {
 	// Avoid panics turning into "CORS errors" in browsers.
 	r.Use(middleware.Recoverer)
 
-	server.RegisterRoutes(r, cfg)
+	authn, err := auth.New(auth.Config{
+		Disabled:  cfg.Auth.Disabled,
+		JWTSecret: cfg.Auth.JWTSecret,
+		JWKSFile:  cfg.Auth.JWKSFile,
+		Issuer:    cfg.Auth.JWTIssuer,
+		Audience:  cfg.Auth.JWTAudience,
+		APIKeys:   cfg.Auth.APIKeys,
+	})
+	if err != nil {
+		log.Fatalf("auth: %v", err)
+	}
+	switch {
+	case authn.Disabled():
+		log.Printf("[auth] WARNING: AUTH_DISABLED=true, /v1 is open to any caller")
+	case !authn.Configured():
+		log.Printf("[auth] WARNING: no SUPABASE_JWT_SECRET, SUPABASE_JWKS_FILE or SERVICE_API_KEYS: every /v1 request will be rejected")
+	}
+
+	server.RegisterRoutes(r, cfg, authn)
 
-	log.Printf("listening on :%s …", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, r))
-}
+	// Async webhook processing: the HTTP handler only enqueues (logs.asaas_webhook_inbox).
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	pool := webhook.Start(ctx, webhook.Config{
+		Workers:      cfg.Webhook.Workers,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		PollInterval: cfg.Webhook.PollInterval,
+		RetryBase:    cfg.Webhook.RetryBase,
+		RetryMax:     cfg.Webhook.RetryMax,
+		LockTimeout:  cfg.Webhook.LockTimeout,
+	}, handler.ProcessAsaasWebhookEvent)
 
+	// Scheduled reconciliation provider ↔ iam.charges (drift left by lost webhooks).
+	reconciler := reconcile.Start(ctx, reconcile.Config{
+		Enabled:         cfg.Reconcile.Enabled,
+		Interval:        cfg.Reconcile.Interval,
+		CreatedLookback: cfg.Reconcile.CreatedLookback,
+		DueLookback:     cfg.Reconcile.DueLookback,
+		DueLookahead:    cfg.Reconcile.DueLookahead,
+	})
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	go func() {
+		log.Printf("listening on :%s …", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("shutting down …")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http shutdown: %v", err)
+	}
+	// Let in-flight webhook events finish; unclaimed ones stay in the inbox.
+	pool.Wait()
+	reconciler.Wait()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/handler"
+	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/supabase"
+	"github.com/seuuser/charges-service/internal/webhook"
+)
+
+// runReplayWebhooks implements the "replay-webhooks" subcommand: the CLI counterpart of
+// POST /v1/admin/webhooks/replay. Prints the JSON report and returns the exit code
+// (1 when any event failed again).
+//
+//	charges-service replay-webhooks -stage resolve_contract_context -from 2026-01-01 -dry-run
+func runReplayWebhooks(args []string) int {
+	fs := flag.NewFlagSet("replay-webhooks", flag.ContinueOnError)
+	ids := fs.String("ids", "", "comma-separated logs.asaas_webhook_events ids")
+	stage := fs.String("stage", "", "error_stage (e.g. resolve_contract_context, upsert_charge)")
+	paymentID := fs.String("payment", "", "payment id (pay_...)")
+	from := fs.String("from", "", "created_at >= (YYYY-MM-DD or RFC 3339)")
+	to := fs.String("to", "", "created_at <= (YYYY-MM-DD, the whole day, or RFC 3339)")
+	limit := fs.Int("limit", handler.DefaultWebhookReplayLimit, fmt.Sprintf("max events to replay (1..%d)", handler.MaxWebhookReplayLimit))
+	includeResolved := fs.Bool("include-resolved", false, "also replay events already resolved")
+	dryRun := fs.Bool("dry-run", false, "only list the events that would be replayed")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *limit < 1 || *limit > handler.MaxWebhookReplayLimit {
+		fmt.Fprintf(os.Stderr, "replay-webhooks: -limit must be between 1 and %d\n", handler.MaxWebhookReplayLimit)
+		return 2
+	}
+
+	fromTime, toTime, toExclusive, err := handler.ParseTimeRange(*from, *to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "replay-webhooks:", err)
+		return 2
+	}
+
+	f := supabase.WebhookEventLogFilter{
+		Stage:           strings.TrimSpace(*stage),
+		PaymentID:       strings.TrimSpace(*paymentID),
+		From:            fromTime,
+		To:              toTime,
+		ToExclusive:     toExclusive,
+		IncludeResolved: *includeResolved,
+		Limit:           *limit,
+	}
+	for _, id := range strings.Split(*ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			f.IDs = append(f.IDs, id)
+		}
+	}
+	if len(f.IDs) == 0 && f.Stage == "" && f.PaymentID == "" && f.From.IsZero() && f.To.IsZero() {
+		fmt.Fprintln(os.Stderr, "replay-webhooks: at least one filter is required (-ids, -stage, -payment, -from, -to)")
+		fs.Usage()
+		return 2
+	}
+
+	result, err := webhook.Replay(context.Background(), asaas.ProviderName, f, *dryRun, handler.ProcessAsaasWebhookEvent)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "replay-webhooks:", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(result)
+	if result.Failed > 0 {
+		return 1
+	}
+	return 0
+}
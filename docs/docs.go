@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/asaas/feecharges": {
             "post": {
-                "description": "Endpoint para receber notificações de atualização de status de cobranças do Asaas",
+                "description": "Endpoint para receber notificações de atualização de status de cobranças do Asaas.\nO evento é gravado em logs.asaas_webhook_inbox e processado de forma assíncrona pelo worker pool (com retry/backoff).\nAutenticação: o header asaas-access-token é comparado com o webhook_secret da integração\n(identificada pelo path ou pelo account.id do evento) ou, na falta dele, com ASAAS_WEBHOOK_SECRET.",
                 "consumes": [
                     "application/json"
                 ],
@@ -47,7 +47,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Evento recebido com sucesso",
+                        "description": "Evento recebido e enfileirado",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -61,14 +61,90 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Não autorizado",
+                        "description": "Não autorizado (token inválido, ou evento sem account.id de uma integração)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Evento sem integração com ASAAS_WEBHOOK_STRICT=true",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Falha ao enfileirar (o Asaas reenvia)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/asaas/feecharges/{integration_id}": {
+            "post": {
+                "description": "Igual a POST /asaas/feecharges, mas a integração (iam.billing_integrations) vem do path:\no asaas-access-token é comparado com o webhook_secret dela. Use uma URL por conta Asaas.\nSe a integração tiver provider_account_id, o evento precisa trazer o mesmo account.id.\nSó cobranças e contratos do escritório da integração são atualizados.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Recebe eventos do Asaas de uma integração específica",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da integração (iam.billing_integrations.id)",
+                        "name": "integration_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token de acesso configurado no webhook do Asaas",
+                        "name": "asaas-access-token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Evento do webhook",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasWebhookEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Evento recebido e enfileirado",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "JSON inválido",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Não autorizado (token inválido ou integração desconhecida)",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Erro interno",
+                        "description": "Falha ao enfileirar (o Asaas reenvia)",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -79,7 +155,7 @@ const docTemplate = `{
         },
         "/health": {
             "get": {
-                "description": "Check the health of the service",
+                "description": "Check the health of the service. Counts the circuit breakers of the billing integrations by state (closed, open, half_open); status is \"degraded\" while any of them is not closed. Always answers 200.",
                 "tags": [
                     "status"
                 ],
@@ -94,223 +170,148 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/asaas/charges": {
+        "/v1/admin/billing-integrations/{id}/webhook": {
             "get": {
-                "description": "Lista cobranças (payments) do Asaas com filtros. Se company_id for informado e customer não, o serviço resolve o customer_id do Asaas via mapeamento (RPC em public) e aplica o filtro customer automaticamente.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Compara o webhook cadastrado no provedor (Asaas /v3/webhooks) com a configuração esperada (URL /asaas/feecharges/{integration_id}, eventos, envio sequencial, auth token) sem alterar nada. Informa se a fila foi interrompida (interrupted) ou está penalizada. Requer o header X-Admin-Token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "asaas"
+                    "admin"
                 ],
-                "summary": "Listar cobranças no Asaas (paginado)",
+                "summary": "Verificar o webhook de uma integração no provedor",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID do accounting_office (UUID)",
-                        "name": "accounting_office_id",
-                        "in": "query",
+                        "description": "Token de administração (ADMIN_API_TOKEN)",
+                        "name": "X-Admin-Token",
+                        "in": "header",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "ID da empresa (UUID) - resolve customer automaticamente",
-                        "name": "company_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Elemento inicial da lista",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Número de elementos da lista (max: 100)",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar pelo Identificador único do cliente no Asaas",
-                        "name": "customer",
-                        "in": "query"
+                        "description": "ID da integração (iam.billing_integrations.id)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Filtrar pelo nome do grupo de cliente",
-                        "name": "customerGroupName",
+                        "description": "URL pública do serviço (default WEBHOOK_PUBLIC_URL)",
+                        "name": "base_url",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookProvisionResult"
+                        }
                     },
-                    {
-                        "enum": [
-                            "UNDEFINED",
-                            "BOLETO",
-                            "CREDIT_CARD",
-                            "PIX"
-                        ],
-                        "type": "string",
-                        "description": "Filtrar por forma de pagamento",
-                        "name": "billingType",
-                        "in": "query"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "enum": [
-                            "PENDING",
-                            "RECEIVED",
-                            "CONFIRMED",
-                            "OVERDUE",
-                            "REFUNDED",
-                            "RECEIVED_IN_CASH",
-                            "REFUND_REQUESTED",
-                            "REFUND_IN_PROGRESS",
-                            "CHARGEBACK_REQUESTED",
-                            "CHARGEBACK_DISPUTE",
-                            "AWAITING_CHARGEBACK_REVERSAL",
-                            "DUNNING_REQUESTED",
-                            "DUNNING_RECEIVED",
-                            "AWAITING_RISK_ANALYSIS"
-                        ],
-                        "type": "string",
-                        "description": "Filtrar por status",
-                        "name": "status",
-                        "in": "query"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Filtrar pelo Identificador único da assinatura",
-                        "name": "subscription",
-                        "in": "query"
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Filtrar pelo Identificador único do parcelamento",
-                        "name": "installment",
-                        "in": "query"
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Filtrar pelo identificador do seu sistema",
-                        "name": "externalReference",
-                        "in": "query"
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Cria o webhook do charges-service na conta do provedor da integração ou corrige o existente (URL, eventos, envio sequencial, habilitado, auth token). O auth token é o webhook_secret da integração; se ela não tiver, um secret é gerado e gravado. Com resume=true retoma uma fila interrompida pelo Asaas. Use dry_run=true para apenas simular. Requer o header X-Admin-Token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Criar ou reparar o webhook de uma integração no provedor",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Filtrar pela data de pagamento",
-                        "name": "paymentDate",
-                        "in": "query"
+                        "description": "Token de administração (ADMIN_API_TOKEN)",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "enum": [
-                            "SCHEDULED",
-                            "AUTHORIZED",
-                            "PROCESSING_CANCELLATION",
-                            "CANCELED",
-                            "CANCELLATION_DENIED",
-                            "ERROR"
-                        ],
                         "type": "string",
-                        "description": "Filtro para cobranças que possuam ou não nota fiscal",
-                        "name": "invoiceStatus",
-                        "in": "query"
+                        "description": "ID da integração (iam.billing_integrations.id)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Filtrar pela data estimada de crédito",
-                        "name": "estimatedCreditDate",
-                        "in": "query"
+                        "description": "Opções",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookProvisionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookProvisionResult"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Filtrar recebimentos originados de um QrCode estático utilizando o id",
-                        "name": "pixQrCodeId",
-                        "in": "query"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "type": "boolean",
-                        "description": "Filtrar registros antecipados ou não",
-                        "name": "anticipated",
-                        "in": "query"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "type": "boolean",
-                        "description": "Filtrar registros antecipáveis ou não",
-                        "name": "anticipable",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar a partir da data de criação inicial",
-                        "name": "dateCreated[ge]",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar até a data de criação final",
-                        "name": "dateCreated[le]",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar a partir da data de recebimento inicial",
-                        "name": "paymentDate[ge]",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar até a data de recebimento final",
-                        "name": "paymentDate[le]",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar a partir da data estimada de crédito inicial",
-                        "name": "estimatedCreditDate[ge]",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar até a data estimada de crédito final",
-                        "name": "estimatedCreditDate[le]",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar a partir da data de vencimento inicial",
-                        "name": "dueDate[ge]",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar até a data de vencimento final",
-                        "name": "dueDate[le]",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar pelo endereço de e-mail do usuário que criou a cobrança",
-                        "name": "user",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrar pelo identificador único da checkout",
-                        "name": "checkoutSession",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/model.AsaasPaymentsListResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -329,76 +330,55 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
-            },
-            "post": {
-                "description": "Cria uma cobrança (payment) no Asaas para uma empresa (company_id). O serviço resolve o customer_id no Asaas via mapeamento em company.asaas_integration (RPC em public) e usa a integração ativa (is_active=true) do escritório (accounting_office_id) em iam.billing_integrations.",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/v1/admin/circuit-breakers": {
+            "get": {
+                "description": "Lista o circuit breaker de cada integração de cobrança chamada desde o início do processo: estado, falhas seguidas, próxima tentativa e último erro do provedor. Requer o header X-Admin-Token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "asaas"
+                    "admin"
                 ],
-                "summary": "Criar cobrança no Asaas",
+                "summary": "Circuit breakers das integrações",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID do accounting_office (UUID)",
-                        "name": "accounting_office_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "ID da empresa (company_id, UUID)",
-                        "name": "company_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "ID do contrato (UUID)",
-                        "name": "contract_id",
-                        "in": "query",
+                        "description": "Token de administração (ADMIN_API_TOKEN)",
+                        "name": "X-Admin-Token",
+                        "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "Payload da cobrança",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AsaasCreateChargeRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasPaymentResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.CircuitBreakerStatus"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "502": {
-                        "description": "Bad Gateway",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -407,9 +387,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/asaas/charges/{id}": {
-            "put": {
-                "description": "Atualiza uma cobrança (payment) no Asaas. Somente é possível atualizar cobranças aguardando pagamento ou vencidas. Uma vez criada, não é possível alterar o cliente ao qual a cobrança pertence. Para atualizar split após confirmação, existem regras específicas (ver documentação Asaas).",
+        "/v1/admin/reconcile": {
+            "post": {
+                "description": "Executa agora a reconciliação (a mesma do agendamento RECONCILE_INTERVAL): pagina as cobranças do provedor de cada integração ativa (janelas de dateCreated e dueDate), compara com iam.charges por provider_charge_id, corrige status/valor/vencimento divergentes e insere cobranças ausentes cujo contrato pode ser resolvido. Retorna o relatório de divergências, também gravado em logs.charges_reconcile_runs. Use dry_run=true para apenas relatar. Requer o header X-Admin-Token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -417,31 +397,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "asaas"
+                    "admin"
                 ],
-                "summary": "Atualizar cobrança existente no Asaas",
+                "summary": "Reconciliar iam.charges com o provedor",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID da cobrança no Asaas (payment_id)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "ID do accounting_office (UUID)",
-                        "name": "accounting_office_id",
-                        "in": "query",
+                        "description": "Token de administração (ADMIN_API_TOKEN)",
+                        "name": "X-Admin-Token",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "description": "Payload da atualização",
+                        "description": "Opções",
                         "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasUpdateChargeRequest"
+                            "$ref": "#/definitions/model.ReconcileRequest"
                         }
                     }
                 ],
@@ -449,7 +421,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasPaymentResponse"
+                            "$ref": "#/definitions/model.ReconcileReport"
                         }
                     },
                     "400": {
@@ -459,6 +431,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -466,6 +445,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "502": {
                         "description": "Bad Gateway",
                         "schema": {
@@ -474,59 +460,62 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Exclui uma cobrança (payment) do Asaas e remove do banco de dados iam.charges",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/v1/admin/reconcile/runs": {
+            "get": {
+                "description": "Lista os relatórios de divergência das últimas execuções da reconciliação (logs.charges_reconcile_runs), mais recentes primeiro. Requer o header X-Admin-Token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "asaas"
+                    "admin"
                 ],
-                "summary": "Excluir cobrança do Asaas",
+                "summary": "Relatórios de reconciliação",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID da cobrança no Asaas (pay_xxx)",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Token de administração (ADMIN_API_TOKEN)",
+                        "name": "X-Admin-Token",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "ID do escritório contábil",
-                        "name": "accounting_office_id",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "description": "Offset (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limite 1-100 (default 20)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Cobrança excluída com sucesso",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/model.ReconcileRunPage"
                         }
                     },
                     "400": {
-                        "description": "Requisição inválida",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Cobrança não encontrada",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Erro interno do servidor",
+                    "502": {
+                        "description": "Bad Gateway",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -535,38 +524,72 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/asaas/charges/{id}/digitable-line": {
+        "/v1/admin/webhooks/events": {
             "get": {
-                "description": "Retorna a linha digitável (identificationField) para uma cobrança no Asaas.",
+                "description": "Lista logs.asaas_webhook_journal: todo evento recebido do provedor com o resultado do processamento (applied, duplicate, ignored_stale, ignored_foreign, retrying, dead_letter...), latência e a transição de status da cobrança. Ordenado por received_at. Requer ao menos um filtro e o header X-Admin-Token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "asaas"
+                    "admin"
                 ],
-                "summary": "Linha digitável do boleto (Asaas)",
+                "summary": "Histórico de eventos de webhook",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID do accounting_office (UUID)",
-                        "name": "accounting_office_id",
-                        "in": "query",
+                        "description": "Token de administração (ADMIN_API_TOKEN)",
+                        "name": "X-Admin-Token",
+                        "in": "header",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "ID da cobrança no Asaas (payment id)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "ID da cobrança no provedor (pay_...)",
+                        "name": "payment_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da assinatura no provedor (sub_...)",
+                        "name": "subscription_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da empresa (UUID)",
+                        "name": "company_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "received_at \u003e= (YYYY-MM-DD ou RFC 3339; data = início do dia em UTC)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "received_at \u003c= (YYYY-MM-DD ou RFC 3339; data = inclui o dia inteiro em UTC)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limite 1-100 (default 20)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/model.WebhookJournalPage"
                         }
                     },
                     "400": {
@@ -576,8 +599,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -593,38 +616,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/asaas/charges/{id}/pix-qrcode": {
-            "get": {
-                "description": "Retorna o QRCode Pix (encodedImage/payload) para uma cobrança no Asaas.",
+        "/v1/admin/webhooks/replay": {
+            "post": {
+                "description": "Seleciona eventos em logs.asaas_webhook_events (por ids, estágio do erro, payment_id e/ou período de created_at) e os executa novamente no pipeline do webhook. O resultado fica registrado na linha do log (resolved, replay_attempts, last_replay_error). Por padrão ignora eventos já resolvidos, eventos fora de ordem (ignored_stale) e de outro escritório (ignored_foreign). Requer o header X-Admin-Token.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "asaas"
+                    "admin"
                 ],
-                "summary": "QRCode Pix (Asaas)",
+                "summary": "Reprocessar eventos de webhook com falha",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID do accounting_office (UUID)",
-                        "name": "accounting_office_id",
-                        "in": "query",
+                        "description": "Token de administração (ADMIN_API_TOKEN)",
+                        "name": "X-Admin-Token",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "ID da cobrança no Asaas (payment id)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Filtros",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookReplayRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/model.WebhookReplayResult"
                         }
                     },
                     "400": {
@@ -634,8 +661,15 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -651,9 +685,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/asaas/customers": {
-            "post": {
-                "description": "Cria um cliente no Asaas usando a integração ativa (is_active=true) do escritório (accounting_office_id) cadastrada no Supabase (iam.billing_integrations). Quando existir mais de uma integração ativa, o serviço prioriza is_default=true e depois a mais recente.",
+        "/v1/asaas/charges": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lista cobranças (payments) do Asaas com filtros. Se company_id for informado e customer não, o serviço resolve o customer_id do Asaas via mapeamento (RPC em public) e aplica o filtro customer automaticamente.",
                 "consumes": [
                     "application/json"
                 ],
@@ -663,7 +705,7 @@ const docTemplate = `{
                 "tags": [
                     "asaas"
                 ],
-                "summary": "Criar novo cliente no Asaas",
+                "summary": "Listar cobranças no Asaas (paginado)",
                 "parameters": [
                     {
                         "type": "string",
@@ -674,26 +716,202 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "ID da empresa (company_id, UUID)",
+                        "description": "Contrato que seleciona a integração; sem ele a integração vem da assinatura (subscription), da empresa (company_id) ou do padrão do escritório",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da empresa (UUID) - resolve customer automaticamente",
                         "name": "company_id",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "description": "Payload (campos mínimos)",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AsaasCreateCustomerRequest"
-                        }
+                        "type": "integer",
+                        "description": "Elemento inicial da lista",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Número de elementos da lista (max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo Identificador único do cliente no Asaas",
+                        "name": "customer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo nome do grupo de cliente",
+                        "name": "customerGroupName",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "UNDEFINED",
+                            "BOLETO",
+                            "CREDIT_CARD",
+                            "PIX"
+                        ],
+                        "type": "string",
+                        "description": "Filtrar por forma de pagamento",
+                        "name": "billingType",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "PENDING",
+                            "RECEIVED",
+                            "CONFIRMED",
+                            "OVERDUE",
+                            "REFUNDED",
+                            "RECEIVED_IN_CASH",
+                            "REFUND_REQUESTED",
+                            "REFUND_IN_PROGRESS",
+                            "CHARGEBACK_REQUESTED",
+                            "CHARGEBACK_DISPUTE",
+                            "AWAITING_CHARGEBACK_REVERSAL",
+                            "DUNNING_REQUESTED",
+                            "DUNNING_RECEIVED",
+                            "AWAITING_RISK_ANALYSIS"
+                        ],
+                        "type": "string",
+                        "description": "Filtrar por status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo Identificador único da assinatura",
+                        "name": "subscription",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo Identificador único do parcelamento",
+                        "name": "installment",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo identificador do seu sistema",
+                        "name": "externalReference",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pela data de pagamento",
+                        "name": "paymentDate",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "SCHEDULED",
+                            "AUTHORIZED",
+                            "PROCESSING_CANCELLATION",
+                            "CANCELED",
+                            "CANCELLATION_DENIED",
+                            "ERROR"
+                        ],
+                        "type": "string",
+                        "description": "Filtro para cobranças que possuam ou não nota fiscal",
+                        "name": "invoiceStatus",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pela data estimada de crédito",
+                        "name": "estimatedCreditDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar recebimentos originados de um QrCode estático utilizando o id",
+                        "name": "pixQrCodeId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filtrar registros antecipados ou não",
+                        "name": "anticipated",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filtrar registros antecipáveis ou não",
+                        "name": "anticipable",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar a partir da data de criação inicial",
+                        "name": "dateCreated[ge]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar até a data de criação final",
+                        "name": "dateCreated[le]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar a partir da data de recebimento inicial",
+                        "name": "paymentDate[ge]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar até a data de recebimento final",
+                        "name": "paymentDate[le]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar a partir da data estimada de crédito inicial",
+                        "name": "estimatedCreditDate[ge]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar até a data estimada de crédito final",
+                        "name": "estimatedCreditDate[le]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar a partir da data de vencimento inicial",
+                        "name": "dueDate[ge]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar até a data de vencimento final",
+                        "name": "dueDate[le]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo endereço de e-mail do usuário que criou a cobrança",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo identificador único da checkout",
+                        "name": "checkoutSession",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                            "$ref": "#/definitions/model.AsaasPaymentsListResponse"
                         }
                     },
                     "400": {
@@ -703,6 +921,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -716,13 +948,26 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
-            }
-        },
-        "/v1/asaas/customers/by-company": {
-            "get": {
-                "description": "Recupera o customer do Asaas usando o mapeamento salvo em company.asaas_integration (via RPC em public), e então consulta o Asaas. Útil porque o front normalmente tem company_id. Referência Asaas: https://docs.asaas.com/reference/recuperar-um-unico-cliente",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cria uma cobrança (payment) no Asaas para uma empresa (company_id). O serviço resolve o customer_id no Asaas via mapeamento em company.asaas_integration (RPC em public) e usa a integração do contrato (billing_integration_id → provider_environment → padrão do escritório) em iam.billing_integrations.",
                 "consumes": [
                     "application/json"
                 ],
@@ -732,7 +977,7 @@ const docTemplate = `{
                 "tags": [
                     "asaas"
                 ],
-                "summary": "Recuperar cliente no Asaas por company_id (mapeamento interno)",
+                "summary": "Criar cobrança no Asaas",
                 "parameters": [
                     {
                         "type": "string",
@@ -747,13 +992,35 @@ const docTemplate = `{
                         "name": "company_id",
                         "in": "query",
                         "required": true
-                    }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do contrato (UUID)",
+                        "name": "contract_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Payload da cobrança",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasCreateChargeRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                            "$ref": "#/definitions/model.AsaasPaymentResponse"
                         }
                     },
                     "400": {
@@ -763,6 +1030,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -776,11 +1057,28 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/v1/asaas/charges/{id}": {
             "put": {
-                "description": "Resolve o customer id do Asaas via company.asaas_integration (RPC em public) e atualiza o cliente no Asaas. Referência Asaas: https://docs.asaas.com/reference/atualizar-cliente-existente",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Atualiza uma cobrança (payment) no Asaas. Somente é possível atualizar cobranças aguardando pagamento ou vencidas. Uma vez criada, não é possível alterar o cliente ao qual a cobrança pertence. Para atualizar split após confirmação, existem regras específicas (ver documentação Asaas).",
                 "consumes": [
                     "application/json"
                 ],
@@ -790,29 +1088,29 @@ const docTemplate = `{
                 "tags": [
                     "asaas"
                 ],
-                "summary": "Atualizar cliente existente no Asaas por company_id (mapeamento interno)",
+                "summary": "Atualizar cobrança existente no Asaas",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID do accounting_office (UUID)",
-                        "name": "accounting_office_id",
-                        "in": "query",
+                        "description": "ID da cobrança no Asaas (payment_id)",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "ID da empresa (company_id, UUID)",
-                        "name": "company_id",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Campos para atualização (parcial)",
+                        "description": "Payload da atualização",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasUpdateCustomerRequest"
+                            "$ref": "#/definitions/model.AsaasUpdateChargeRequest"
                         }
                     }
                 ],
@@ -820,7 +1118,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                            "$ref": "#/definitions/model.AsaasPaymentResponse"
                         }
                     },
                     "400": {
@@ -830,6 +1128,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -843,13 +1155,26 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
-            }
-        },
-        "/v1/asaas/customers/{id}": {
-            "get": {
-                "description": "Recupera um cliente no Asaas pelo ID (Asaas customer id), usando a integração ativa (is_active=true) do escritório (accounting_office_id) cadastrada no Supabase (iam.billing_integrations). Referência Asaas: https://docs.asaas.com/reference/recuperar-um-unico-cliente",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Exclui uma cobrança (payment) do Asaas e marca a cobrança como excluída em iam.charges (deleted_at, deleted_reason), mantendo o histórico; pode ser restaurada com POST /v1/asaas/charges/{id}/restore. A integração é a do contrato da cobrança (iam.charges → contrato).",
                 "consumes": [
                     "application/json"
                 ],
@@ -859,65 +1184,114 @@ const docTemplate = `{
                 "tags": [
                     "asaas"
                 ],
-                "summary": "Recuperar um único cliente no Asaas",
+                "summary": "Excluir cobrança do Asaas",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID do accounting_office (UUID)",
+                        "description": "ID da cobrança no Asaas (pay_xxx)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do escritório contábil",
                         "name": "accounting_office_id",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "ID do cliente no Asaas",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Motivo da exclusão (gravado em iam.charges.deleted_reason)",
+                        "name": "reason",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Cobrança excluída com sucesso",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Requisição inválida",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Não autenticado (code: unauthenticated)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Escritório de outro tenant (code: cross_tenant_access)",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Cobrança não encontrada",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Cobrança recusada pelo provedor (code: provider_validation_error)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Erro interno do servidor",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "502": {
-                        "description": "Bad Gateway",
+                        "description": "Falha no provedor (code: provider_unavailable)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Circuit breaker da integração aberto (code: provider_circuit_open)",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Atualiza um cliente existente no Asaas pelo ID (Asaas customer id), usando a integração ativa (is_active=true) do escritório (accounting_office_id) cadastrada no Supabase (iam.billing_integrations). Referência Asaas: https://docs.asaas.com/reference/atualizar-cliente-existente",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/v1/asaas/charges/{id}/digitable-line": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Retorna a linha digitável (identificationField) para uma cobrança no Asaas. A integração é a do contrato da cobrança (iam.charges → contrato).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "asaas"
                 ],
-                "summary": "Atualizar cliente existente no Asaas",
+                "summary": "Linha digitável do boleto (Asaas)",
                 "parameters": [
                     {
                         "type": "string",
@@ -928,26 +1302,18 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "ID do cliente no Asaas",
+                        "description": "ID da cobrança no Asaas (payment id)",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Campos para atualização (parcial)",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AsaasUpdateCustomerRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -957,6 +1323,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -970,46 +1350,57 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/v1/asaas/subscriptions": {
-            "post": {
-                "description": "Cria uma assinatura (subscription) no Asaas para um contrato. O serviço resolve a integração ativa a partir do contrato (billing_integration_id / provider_environment) e resolve o customer_id via mapeamento em company.asaas_integration (RPC em public). Se não existir, auto-cria o customer com dados da empresa e persiste o mapping.",
-                "consumes": [
-                    "application/json"
+        "/v1/asaas/charges/{id}/pix-qrcode": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Retorna o QRCode Pix (encodedImage/payload) para uma cobrança no Asaas. A integração é a do contrato da cobrança (iam.charges → contrato).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "asaas"
                 ],
-                "summary": "Criar assinatura (subscription) no Asaas",
+                "summary": "QRCode Pix (Asaas)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID do contrato (UUID)",
-                        "name": "contract_id",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Payload da assinatura",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AsaasCreateSubscriptionRequest"
-                        }
+                        "type": "string",
+                        "description": "ID da cobrança no Asaas (payment id)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasSubscriptionResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1019,6 +1410,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1032,13 +1437,28 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/v1/asaas/subscriptions/{id}": {
-            "put": {
-                "description": "Atualiza uma assinatura (subscription) no Asaas. O parâmetro nextDueDate indica o vencimento da PRÓXIMA mensalidade a ser gerada (não altera a já existente). Para atualizar mensalidades pendentes existentes com o novo valor/forma de pagamento, passe updatePendingPayments=true.",
+        "/v1/asaas/charges/{id}/receive-in-cash": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Marca uma cobrança PENDING/OVERDUE como recebida fora do Asaas (dinheiro, transferência...), status RECEIVED_IN_CASH. Sem value, usa o valor da cobrança. O resultado é sincronizado em iam.charges e, para cobranças avulsas, em iam.fee_contract_one_off_charges.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1048,37 +1468,260 @@ const docTemplate = `{
                 "tags": [
                     "asaas"
                 ],
-                "summary": "Atualizar assinatura existente no Asaas",
+                "summary": "Confirmar recebimento em dinheiro no Asaas",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID da assinatura no Asaas (sub_...)",
+                        "description": "ID da cobrança no Asaas (payment_id)",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "ID do contrato (UUID) — usado para resolver a integração",
-                        "name": "contract_id",
-                        "in": "query",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Payload da atualização (todos os campos são opcionais)",
+                        "description": "Data de pagamento, valor e notificação do cliente",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasUpdateSubscriptionRequest"
+                            "$ref": "#/definitions/model.AsaasReceiveInCashRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AsaasSubscriptionResponse"
+                            "$ref": "#/definitions/model.AsaasPaymentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Cobrança não está aguardando pagamento (code: charge_invalid_status)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/charges/{id}/refund": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Estorna uma cobrança recebida (RECEIVED/CONFIRMED) no Asaas. Sem value, estorna todo o saldo estornável; com value, estorna parcialmente (vários estornos parciais são permitidos). O saldo estornável é o valor líquido recebido (net_value, ou value quando não informado) menos o já estornado (iam.charges.refunded_value). Cada estorno é registrado no histórico da cobrança (iam.charges.refunds).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Estornar cobrança no Asaas (total ou parcial)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da cobrança no Asaas (payment_id)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Valor e descrição do estorno (opcional)",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasRefundChargeRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasPaymentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Cobrança não pode ser estornada no status atual (code: charge_not_refundable)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Valor acima do saldo estornável (code: refund_exceeds_refundable) ou recusado pelo provedor",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/charges/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restaura uma cobrança (payment) excluída no Asaas e limpa a exclusão em iam.charges (deleted_at, deleted_reason). A integração é a do contrato da cobrança (iam.charges → contrato); cobranças ausentes de iam.charges são restauradas no provedor e voltam a iam.charges pelo webhook PAYMENT_RESTORED.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Restaurar cobrança excluída no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da cobrança no Asaas (payment_id)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasPaymentResponse"
                         }
                     },
                     "400": {
@@ -1088,6 +1731,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1095,235 +1752,3835 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "422": {
+                        "description": "Cobrança não está excluída no provedor (code: provider_validation_error)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "502": {
                         "description": "Bad Gateway",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/charges/{id}/undo-received-in-cash": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Desfaz a confirmação de recebimento em dinheiro (RECEIVED_IN_CASH): a cobrança volta a aguardar pagamento. O resultado é sincronizado em iam.charges e, para cobranças avulsas, em iam.fee_contract_one_off_charges.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Desfazer recebimento em dinheiro no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da cobrança no Asaas (payment_id)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
                     }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasPaymentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Cobrança não foi recebida em dinheiro (code: charge_invalid_status)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/customers": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cria um cliente no Asaas na integração (iam.billing_integrations) do contrato informado (contract_id) ou do contrato mais recente da empresa no escritório; sem contrato, usa a integração ativa padrão do escritório (is_default=true, depois a mais recente).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Criar novo cliente no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da empresa (company_id, UUID)",
+                        "name": "company_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Payload (campos mínimos)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasCreateCustomerRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/customers/by-company": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recupera o customer do Asaas usando o mapeamento salvo em company.asaas_integration (via RPC em public), e então consulta o Asaas. Útil porque o front normalmente tem company_id. Referência Asaas: https://docs.asaas.com/reference/recuperar-um-unico-cliente",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Recuperar cliente no Asaas por company_id (mapeamento interno)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da empresa (company_id, UUID)",
+                        "name": "company_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Resolve o customer id do Asaas via company.asaas_integration (RPC em public) e atualiza o cliente no Asaas. Referência Asaas: https://docs.asaas.com/reference/atualizar-cliente-existente",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Atualizar cliente existente no Asaas por company_id (mapeamento interno)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da empresa (company_id, UUID)",
+                        "name": "company_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Campos para atualização (parcial)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasUpdateCustomerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/customers/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recupera um cliente no Asaas pelo ID (Asaas customer id), usando a integração do contrato informado (contract_id) ou, sem ele, a integração ativa padrão do escritório (accounting_office_id) em iam.billing_integrations. Referência Asaas: https://docs.asaas.com/reference/recuperar-um-unico-cliente",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Recuperar um único cliente no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do cliente no Asaas",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Atualiza um cliente existente no Asaas pelo ID (Asaas customer id), usando a integração do contrato informado (contract_id) ou, sem ele, a integração ativa padrão do escritório (accounting_office_id) em iam.billing_integrations. Referência Asaas: https://docs.asaas.com/reference/atualizar-cliente-existente",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Atualizar cliente existente no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do cliente no Asaas",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Campos para atualização (parcial)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasUpdateCustomerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasCustomerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/installments/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retorna o parcelamento (installment) do Asaas: valor total, valor de cada parcela e quantidade de parcelas. A integração é a do contrato das parcelas (iam.charges.provider_installment_id → contrato).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Consultar parcelamento no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do parcelamento no Asaas (installment)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasInstallmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/installments/{id}/payment-book": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Baixa o carnê (PDF com os boletos de todas as parcelas) de um parcelamento do Asaas.",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Carnê do parcelamento (Asaas)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do parcelamento no Asaas (installment)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/installments/{id}/payments": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lista as cobranças (payments) de um parcelamento do Asaas. A integração é a do contrato das parcelas (iam.charges.provider_installment_id → contrato).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Listar parcelas de um parcelamento no Asaas (paginado)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do parcelamento no Asaas (installment)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elemento inicial da lista",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Número de elementos da lista (max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "PENDING",
+                            "RECEIVED",
+                            "CONFIRMED",
+                            "OVERDUE",
+                            "REFUNDED",
+                            "RECEIVED_IN_CASH",
+                            "REFUND_REQUESTED",
+                            "REFUND_IN_PROGRESS",
+                            "CHARGEBACK_REQUESTED",
+                            "CHARGEBACK_DISPUTE",
+                            "AWAITING_CHARGEBACK_REVERSAL",
+                            "DUNNING_REQUESTED",
+                            "DUNNING_RECEIVED",
+                            "AWAITING_RISK_ANALYSIS"
+                        ],
+                        "type": "string",
+                        "description": "Filtrar por status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasPaymentsListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Exclui no Asaas as parcelas pendentes e vencidas (PENDING/OVERDUE) do parcelamento; as parcelas recebidas são mantidas. As parcelas excluídas são marcadas como excluídas em iam.charges (deleted_at, deleted_reason), mantendo o histórico.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Cancelar parcelas pendentes de um parcelamento no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do parcelamento no Asaas (installment)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/installments/{id}/refund": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Estorna todas as parcelas recebidas do parcelamento (cartão de crédito). Em iam.charges, cada parcela estornada recebe o novo status e um registro no histórico de estornos (refunds) com o saldo que ainda era estornável.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Estornar parcelamento no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do parcelamento no Asaas (installment)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasInstallmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Parcelamento recusado pelo provedor (code: provider_validation_error)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/subscriptions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lista assinaturas (subscriptions) do Asaas com filtros. Se company_id for informado e customer não, o serviço resolve o customer_id do Asaas via mapeamento (RPC em public) e aplica o filtro customer automaticamente.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Listar assinaturas no Asaas (paginado)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração; sem ele a integração vem da empresa (company_id) ou do padrão do escritório",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da empresa (UUID) - resolve customer automaticamente",
+                        "name": "company_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elemento inicial da lista",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Número de elementos da lista (max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo Identificador único do cliente no Asaas",
+                        "name": "customer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo nome do grupo de cliente",
+                        "name": "customerGroupName",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "UNDEFINED",
+                            "BOLETO",
+                            "CREDIT_CARD",
+                            "PIX"
+                        ],
+                        "type": "string",
+                        "description": "Filtrar por forma de pagamento",
+                        "name": "billingType",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ACTIVE",
+                            "EXPIRED",
+                            "INACTIVE"
+                        ],
+                        "type": "string",
+                        "description": "Filtrar por status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo identificador do seu sistema",
+                        "name": "externalReference",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Incluir assinaturas excluídas",
+                        "name": "includeDeleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Listar apenas assinaturas excluídas",
+                        "name": "deletedOnly",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasSubscriptionsListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cria uma assinatura (subscription) no Asaas para um contrato. O serviço resolve a integração ativa a partir do contrato (billing_integration_id / provider_environment) e resolve o customer_id via mapeamento em company.asaas_integration (RPC em public). Se não existir, auto-cria o customer com dados da empresa e persiste o mapping.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Criar assinatura (subscription) no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do contrato (UUID)",
+                        "name": "contract_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Payload da assinatura",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasCreateSubscriptionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasSubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/subscriptions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retorna uma assinatura (subscription) do Asaas, inclusive excluídas (deleted=true). A integração é a do contrato da assinatura (iam.fee_contract_subscriptions → contrato) ou do contract_id informado.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Consultar assinatura no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da assinatura no Asaas (sub_...)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração (padrão: contrato vinculado à assinatura)",
+                        "name": "contract_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasSubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Atualiza uma assinatura (subscription) no Asaas. O parâmetro nextDueDate indica o vencimento da PRÓXIMA mensalidade a ser gerada (não altera a já existente). Para atualizar mensalidades pendentes existentes com o novo valor/forma de pagamento, passe updatePendingPayments=true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Atualizar assinatura existente no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da assinatura no Asaas (sub_...)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do contrato (UUID) — usado para resolver a integração",
+                        "name": "contract_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Payload da atualização (todos os campos são opcionais)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasUpdateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasSubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Exclui uma assinatura (subscription) no Asaas: novas cobranças deixam de ser geradas e iam.fee_contract_subscriptions passa a status DELETED. Com cancel_pending_payments=true, as cobranças pendentes e vencidas (PENDING/OVERDUE) da assinatura também são excluídas no Asaas e marcadas como excluídas em iam.charges (deleted_at, deleted_reason). A integração é a do contrato da assinatura (iam.fee_contract_subscriptions → contrato) ou do contract_id informado.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Excluir assinatura no Asaas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da assinatura no Asaas (sub_...)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração (padrão: contrato vinculado à assinatura)",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Também excluir as cobranças pendentes e vencidas da assinatura",
+                        "name": "cancel_pending_payments",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Assinatura excluída (cancelled_payments / failed_payments: cobranças pendentes excluídas ou não)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Assinatura recusada pelo provedor (code: provider_validation_error)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/asaas/subscriptions/{id}/payments": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lista as cobranças (payments) geradas por uma assinatura do Asaas. A integração é a do contrato da assinatura (iam.fee_contract_subscriptions → contrato) ou do contract_id informado.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "asaas"
+                ],
+                "summary": "Listar cobranças de uma assinatura no Asaas (paginado)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da assinatura no Asaas (sub_...)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID)",
+                        "name": "accounting_office_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Contrato que seleciona a integração (padrão: contrato vinculado à assinatura)",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elemento inicial da lista",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Número de elementos da lista (max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "PENDING",
+                            "RECEIVED",
+                            "CONFIRMED",
+                            "OVERDUE",
+                            "REFUNDED",
+                            "RECEIVED_IN_CASH",
+                            "REFUND_REQUESTED",
+                            "REFUND_IN_PROGRESS",
+                            "CHARGEBACK_REQUESTED",
+                            "CHARGEBACK_DISPUTE",
+                            "AWAITING_CHARGEBACK_REVERSAL",
+                            "DUNNING_REQUESTED",
+                            "DUNNING_RECEIVED",
+                            "AWAITING_RISK_ANALYSIS"
+                        ],
+                        "type": "string",
+                        "description": "Filtrar por status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AsaasPaymentsListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/charges": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lista as cobranças persistidas em iam.charges de um contrato (contract_id) ou de uma empresa (company_id), no formato normalizado do serviço (independente do provedor).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "charges"
+                ],
+                "summary": "Listar cobranças (neutro)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do contrato (UUID)",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da empresa (UUID)",
+                        "name": "company_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar pelo status original do provedor (ex.: PENDING, RECEIVED)",
+                        "name": "provider_status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Incluir cobranças excluídas (deleted_at preenchido); default false",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elemento inicial da lista",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Número de elementos da lista (max: 100, default: 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ChargeListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cria uma cobrança (ou parcelamento) para um contrato no provedor configurado no contrato (billing_integration_id / provider_environment / integração padrão do escritório). O customer é resolvido (ou auto-criado) a partir da empresa do contrato. Retorna as cobranças persistidas em iam.charges no formato normalizado.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "charges"
+                ],
+                "summary": "Criar cobrança (neutro)",
+                "parameters": [
+                    {
+                        "description": "Payload da cobrança",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateChargeRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.ChargeListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/charges/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recupera uma cobrança de iam.charges pelo ID interno (UUID), no formato normalizado do serviço.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "charges"
+                ],
+                "summary": "Recuperar cobrança (neutro)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da cobrança em iam.charges (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Charge"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/contracts/{id}/billing/generate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lê iam.fee_contract_service_items do contrato e cria no provedor as assinaturas (itens RECURRING agrupados por periodicity + due_day + payment_method) e as cobranças avulsas (itens ONE_TIME). Idempotente: cada assinatura/cobrança recebe um externalReference determinístico (fee_contract:{id}:recurring:... / fee_contract:{id}:service_item:{item_id}) e é procurada antes de ser criada, então reexecutar nunca duplica. Use dry_run=true para apenas simular.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Gerar cobranças a partir dos itens de serviço do contrato",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do contrato (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Apenas simula (não cria nada no provedor)",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BillingRunResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/customers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recupera o customer da empresa no provedor de cobrança, no formato normalizado. Informe contract_id (resolve empresa e integração pelo contrato) ou company_id + accounting_office_id (integração do contrato mais recente da empresa no escritório, ou a padrão do escritório).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Recuperar customer da empresa (neutro)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do contrato (UUID)",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da empresa (UUID)",
+                        "name": "company_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do accounting_office (UUID) - obrigatório com company_id",
+                        "name": "accounting_office_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Customer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Garante que a empresa possui customer no provedor de cobrança: reutiliza o mapeamento existente ou cria o customer a partir dos dados da empresa. Informe contract_id ou company_id + accounting_office_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Garantir customer da empresa (neutro)",
+                "parameters": [
+                    {
+                        "description": "contract_id ou company_id + accounting_office_id",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.EnsureCustomerRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Customer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/subscriptions": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cria uma assinatura recorrente para um contrato no provedor configurado no contrato. Juros/multa/desconto são herdados do contrato. A assinatura é vinculada ao contrato em iam.fee_contract_subscriptions; sem external_reference, usa fee_contract:{contract_id}:subscription:{cycle}:{payment_method}. As cobranças geradas são persistidas em iam.charges e retornadas no formato normalizado.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Criar assinatura (neutro)",
+                "parameters": [
+                    {
+                        "description": "Payload da assinatura",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateSubscriptionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/subscriptions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retorna uma assinatura vinculada a um contrato (iam.fee_contract_subscriptions) no formato normalizado, com as cobranças persistidas em iam.charges (até 100; use /v1/subscriptions/{id}/charges para paginar).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Consultar assinatura (neutro)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da assinatura no provedor",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/subscriptions/{id}/charges": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lista as cobranças persistidas em iam.charges vinculadas a uma assinatura (provider_subscription_id), no formato normalizado.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Listar cobranças de uma assinatura (neutro)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da assinatura no provedor",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elemento inicial da lista",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Número de elementos da lista (max: 100, default: 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ChargeListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "circuit_breakers": {
+                    "description": "CircuitBreakers counts the breakers of the billing integrations called since startup\nby state. Details (integration, last error) are on GET /v1/admin/circuit-breakers.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.CircuitBreakerCounts"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "ok | degraded (some circuit breaker is not closed)",
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AsaasBillingType": {
+            "type": "string",
+            "enum": [
+                "BOLETO",
+                "CREDIT_CARD",
+                "PIX",
+                "UNDEFINED"
+            ],
+            "x-enum-varnames": [
+                "AsaasBillingTypeBoleto",
+                "AsaasBillingTypeCreditCard",
+                "AsaasBillingTypePix",
+                "AsaasBillingTypeUndefined"
+            ]
+        },
+        "model.AsaasChargeDiscount": {
+            "type": "object",
+            "properties": {
+                "dueDateLimitDays": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "FIXED | PERCENTAGE",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasDiscountType"
+                        }
+                    ]
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasChargeFine": {
+            "type": "object",
+            "properties": {
+                "type": {
+                    "description": "FIXED | PERCENTAGE",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasFineType"
+                        }
+                    ]
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasChargeInterest": {
+            "type": "object",
+            "properties": {
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasCreateChargeRequest": {
+            "type": "object",
+            "properties": {
+                "billingType": {
+                    "description": "BOLETO | CREDIT_CARD | PIX | UNDEFINED",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasBillingType"
+                        }
+                    ]
+                },
+                "daysAfterDueDateToRegistrationCancellation": {
+                    "description": "boleto only",
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "$ref": "#/definitions/model.AsaasChargeDiscount"
+                },
+                "dueDate": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "externalReference": {
+                    "type": "string"
+                },
+                "fine": {
+                    "$ref": "#/definitions/model.AsaasChargeFine"
+                },
+                "installmentCount": {
+                    "type": "integer"
+                },
+                "installmentValue": {
+                    "type": "number"
+                },
+                "interest": {
+                    "$ref": "#/definitions/model.AsaasChargeInterest"
+                },
+                "postalService": {
+                    "type": "boolean"
+                },
+                "totalValue": {
+                    "type": "number"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasCreateCustomerRequest": {
+            "type": "object",
+            "properties": {
+                "company": {
+                    "type": "boolean"
+                },
+                "cpfCnpj": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "mobilePhone": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notificationDisabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.AsaasCreateSubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "billingType": {
+                    "description": "BOLETO | CREDIT_CARD | PIX | UNDEFINED",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasBillingType"
+                        }
+                    ]
+                },
+                "cycle": {
+                    "description": "WEEKLY | BIWEEKLY | MONTHLY | BIMONTHLY | QUARTERLY | SEMIANNUALLY | YEARLY",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "$ref": "#/definitions/model.AsaasSubscriptionDiscount"
+                },
+                "endDate": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "externalReference": {
+                    "type": "string"
+                },
+                "fine": {
+                    "$ref": "#/definitions/model.AsaasSubscriptionFine"
+                },
+                "interest": {
+                    "$ref": "#/definitions/model.AsaasSubscriptionInterest"
+                },
+                "maxPayments": {
+                    "type": "integer"
+                },
+                "nextDueDate": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasCustomerResponse": {
+            "type": "object",
+            "properties": {
+                "additionalEmails": {
+                    "type": "string"
+                },
+                "address": {
+                    "type": "string"
+                },
+                "addressNumber": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "integer"
+                },
+                "cityName": {
+                    "type": "string"
+                },
+                "complement": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "cpfCnpj": {
+                    "type": "string"
+                },
+                "dateCreated": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "externalReference": {
+                    "type": "string"
+                },
+                "foreignCustomer": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mobilePhone": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notificationDisabled": {
+                    "type": "boolean"
+                },
+                "object": {
+                    "type": "string"
+                },
+                "observations": {
+                    "type": "string"
+                },
+                "personType": {
+                    "description": "JURIDICA | FISICA",
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "postalCode": {
+                    "type": "string"
+                },
+                "province": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AsaasDiscountType": {
+            "type": "string",
+            "enum": [
+                "FIXED",
+                "PERCENTAGE"
+            ],
+            "x-enum-varnames": [
+                "AsaasDiscountTypeFixed",
+                "AsaasDiscountTypePercentage"
+            ]
+        },
+        "model.AsaasFineType": {
+            "type": "string",
+            "enum": [
+                "FIXED",
+                "PERCENTAGE"
+            ],
+            "x-enum-varnames": [
+                "AsaasFineTypeFixed",
+                "AsaasFineTypePercentage"
+            ]
+        },
+        "model.AsaasInstallmentResponse": {
+            "type": "object",
+            "properties": {
+                "billingType": {
+                    "type": "string"
+                },
+                "customer": {
+                    "type": "string"
+                },
+                "dateCreated": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "expirationDay": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "installmentCount": {
+                    "type": "integer"
+                },
+                "netValue": {
+                    "type": "number"
+                },
+                "object": {
+                    "description": "\"installment\"",
+                    "type": "string"
+                },
+                "paymentDate": {
+                    "type": "string"
+                },
+                "paymentLink": {
+                    "type": "string"
+                },
+                "paymentValue": {
+                    "type": "number"
+                },
+                "transactionReceiptUrl": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasPaymentResponse": {
+            "type": "object",
+            "properties": {
+                "anticipable": {
+                    "type": "boolean"
+                },
+                "anticipated": {
+                    "type": "boolean"
+                },
+                "bankSlipUrl": {
+                    "type": "string"
+                },
+                "billingType": {
+                    "type": "string"
+                },
+                "canBePaidAfterDueDate": {
+                    "type": "boolean"
+                },
+                "checkoutSession": {},
+                "clientPaymentDate": {
+                    "type": "string"
+                },
+                "confirmedDate": {
+                    "type": "string"
+                },
+                "creditDate": {
+                    "type": "string"
+                },
+                "customer": {
+                    "type": "string"
+                },
+                "dateCreated": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {},
+                "dueDate": {
+                    "type": "string"
+                },
+                "escrow": {},
+                "estimatedCreditDate": {
+                    "type": "string"
+                },
+                "externalReference": {
+                    "type": "string"
+                },
+                "fine": {},
+                "id": {
+                    "type": "string"
+                },
+                "installment": {
+                    "type": "string"
+                },
+                "installmentNumber": {
+                    "type": "integer"
+                },
+                "interest": {},
+                "interestValue": {},
+                "invoiceNumber": {
+                    "type": "string"
+                },
+                "invoiceUrl": {
+                    "type": "string"
+                },
+                "lastBankSlipViewedDate": {},
+                "lastInvoiceViewedDate": {},
+                "netValue": {
+                    "type": "number"
+                },
+                "nossoNumero": {
+                    "type": "string"
+                },
+                "object": {
+                    "type": "string"
+                },
+                "originalDueDate": {
+                    "type": "string"
+                },
+                "originalValue": {},
+                "paymentDate": {
+                    "type": "string"
+                },
+                "paymentLink": {},
+                "pixTransaction": {},
+                "postalService": {
+                    "type": "boolean"
+                },
+                "refunds": {},
+                "status": {
+                    "type": "string"
+                },
+                "subscription": {
+                    "description": "present when payment belongs to a subscription",
+                    "type": "string"
+                },
+                "transactionReceiptUrl": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasPaymentsListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AsaasPaymentResponse"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "object": {
+                    "description": "\"list\"",
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AsaasPersonType": {
+            "type": "string",
+            "enum": [
+                "JURIDICA",
+                "FISICA"
+            ],
+            "x-enum-varnames": [
+                "AsaasPersonTypeJuridica",
+                "AsaasPersonTypeFisica"
+            ]
+        },
+        "model.AsaasReceiveInCashRequest": {
+            "type": "object",
+            "properties": {
+                "notifyCustomer": {
+                    "type": "boolean"
+                },
+                "paymentDate": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string",
+                    "example": "2026-01-10"
+                },
+                "value": {
+                    "type": "number",
+                    "example": 129.9
+                }
+            }
+        },
+        "model.AsaasRefundChargeRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Pagamento em duplicidade"
+                },
+                "value": {
+                    "type": "number",
+                    "example": 50
+                }
+            }
+        },
+        "model.AsaasSubscriptionDiscount": {
+            "type": "object",
+            "properties": {
+                "dueDateLimitDays": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "FIXED | PERCENTAGE",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasDiscountType"
+                        }
+                    ]
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasSubscriptionFine": {
+            "type": "object",
+            "properties": {
+                "type": {
+                    "description": "FIXED | PERCENTAGE",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasFineType"
+                        }
+                    ]
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasSubscriptionInterest": {
+            "type": "object",
+            "properties": {
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasSubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "billingType": {
+                    "type": "string"
+                },
+                "customer": {
+                    "type": "string"
+                },
+                "cycle": {
+                    "type": "string"
+                },
+                "dateCreated": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {},
+                "endDate": {
+                    "type": "string"
+                },
+                "externalReference": {
+                    "type": "string"
+                },
+                "fine": {},
+                "id": {
+                    "type": "string"
+                },
+                "interest": {},
+                "maxPayments": {
+                    "type": "integer"
+                },
+                "nextDueDate": {
+                    "type": "string"
+                },
+                "object": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasSubscriptionsListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AsaasSubscriptionResponse"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "object": {
+                    "description": "\"list\"",
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AsaasUpdateChargeRequest": {
+            "type": "object",
+            "properties": {
+                "billingType": {
+                    "description": "BOLETO | PIX | CREDIT_CARD | UNDEFINED",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasBillingType"
+                        }
+                    ]
+                },
+                "discount": {
+                    "$ref": "#/definitions/model.AsaasChargeDiscount"
+                },
+                "dueDate": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "fine": {
+                    "$ref": "#/definitions/model.AsaasChargeFine"
+                },
+                "interest": {
+                    "$ref": "#/definitions/model.AsaasChargeInterest"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.AsaasUpdateCustomerRequest": {
+            "type": "object",
+            "properties": {
+                "additionalEmails": {
+                    "description": "Metadados",
+                    "type": "string"
+                },
+                "address": {
+                    "description": "Endereço",
+                    "type": "string"
+                },
+                "addressNumber": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "integer"
+                },
+                "company": {
+                    "type": "boolean"
+                },
+                "complement": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "cpfCnpj": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "externalReference": {
+                    "type": "string"
+                },
+                "foreignCustomer": {
+                    "type": "boolean"
+                },
+                "mobilePhone": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Identificação",
+                    "type": "string"
+                },
+                "notificationDisabled": {
+                    "description": "Use pointers for booleans so \"omit vs false\" is preserved.",
+                    "type": "boolean"
+                },
+                "observations": {
+                    "type": "string"
+                },
+                "personType": {
+                    "description": "Flags / enums",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasPersonType"
+                        }
+                    ]
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "postalCode": {
+                    "type": "string"
+                },
+                "province": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AsaasUpdateSubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "billingType": {
+                    "description": "BOLETO | CREDIT_CARD | PIX | UNDEFINED",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasBillingType"
+                        }
+                    ]
+                },
+                "cycle": {
+                    "description": "WEEKLY | BIWEEKLY | MONTHLY | BIMONTHLY | QUARTERLY | SEMIANNUALLY | YEARLY",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "$ref": "#/definitions/model.AsaasSubscriptionDiscount"
+                },
+                "endDate": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "externalReference": {
+                    "type": "string"
+                },
+                "fine": {
+                    "$ref": "#/definitions/model.AsaasSubscriptionFine"
+                },
+                "interest": {
+                    "$ref": "#/definitions/model.AsaasSubscriptionInterest"
+                },
+                "nextDueDate": {
+                    "description": "YYYY-MM-DD — next installment due date",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "ACTIVE | INACTIVE",
+                    "type": "string"
+                },
+                "updatePendingPayments": {
+                    "description": "When true, updates pending (unpaid) payments with the new billingType and/or value.",
+                    "type": "boolean"
+                },
+                "value": {
+                    "type": "number"
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.HealthResponse": {
+        },
+        "model.AsaasWebhookAccount": {
             "type": "object",
             "properties": {
-                "status": {
+                "id": {
                     "type": "string"
                 },
-                "time": {
+                "ownerId": {
                     "type": "string"
                 }
             }
         },
-        "model.AsaasBillingType": {
-            "type": "string",
-            "enum": [
-                "BOLETO",
-                "CREDIT_CARD",
-                "PIX",
-                "UNDEFINED"
-            ],
-            "x-enum-varnames": [
-                "AsaasBillingTypeBoleto",
-                "AsaasBillingTypeCreditCard",
-                "AsaasBillingTypePix",
-                "AsaasBillingTypeUndefined"
-            ]
-        },
-        "model.AsaasChargeDiscount": {
+        "model.AsaasWebhookEvent": {
             "type": "object",
             "properties": {
-                "dueDateLimitDays": {
-                    "type": "integer"
+                "account": {
+                    "$ref": "#/definitions/model.AsaasWebhookAccount"
                 },
-                "type": {
-                    "description": "FIXED | PERCENTAGE",
+                "dateCreated": {
+                    "description": "Format: \"2026-01-24 16:13:02\"",
+                    "type": "string"
+                },
+                "event": {
+                    "description": "e.g., PAYMENT_CREATED, PAYMENT_RECEIVED, PAYMENT_UPDATED, etc.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "payment": {
+                    "description": "The payment object (when event is payment-related)",
                     "allOf": [
                         {
-                            "$ref": "#/definitions/model.AsaasDiscountType"
+                            "$ref": "#/definitions/model.AsaasPaymentResponse"
                         }
                     ]
                 },
-                "value": {
-                    "type": "number"
+                "subscription": {
+                    "description": "The subscription object (SUBSCRIPTION_* events)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.AsaasSubscriptionResponse"
+                        }
+                    ]
                 }
             }
         },
-        "model.AsaasChargeFine": {
+        "model.BillingRunItem": {
             "type": "object",
             "properties": {
-                "type": {
-                    "description": "FIXED | PERCENTAGE",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.AsaasFineType"
-                        }
-                    ]
+                "action": {
+                    "type": "string",
+                    "example": "created"
+                },
+                "cycle": {
+                    "type": "string",
+                    "example": "MONTHLY"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "due_date": {
+                    "description": "nextDueDate for subscriptions",
+                    "type": "string",
+                    "example": "2026-01-10"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "external_reference": {
+                    "type": "string",
+                    "example": "fee_contract:2f8c7a1e-1111-2222-3333-444455556666:recurring:MONTHLY:10:BOLETO"
+                },
+                "payment_method": {
+                    "type": "string",
+                    "example": "BOLETO"
+                },
+                "provider_id": {
+                    "type": "string",
+                    "example": "sub_VXJBYgP2u0eO"
+                },
+                "service_item_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "value": {
-                    "type": "number"
+                    "type": "number",
+                    "example": 450
                 }
             }
         },
-        "model.AsaasChargeInterest": {
+        "model.BillingRunResult": {
             "type": "object",
             "properties": {
-                "value": {
-                    "type": "number"
+                "charges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BillingRunItem"
+                    }
+                },
+                "contract_id": {
+                    "type": "string"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "ASAAS"
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BillingRunSkipped"
+                    }
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BillingRunItem"
+                    }
                 }
             }
         },
-        "model.AsaasCreateChargeRequest": {
+        "model.BillingRunSkipped": {
             "type": "object",
             "properties": {
-                "billingType": {
-                    "description": "BOLETO | CREDIT_CARD | PIX | UNDEFINED",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.AsaasBillingType"
-                        }
-                    ]
+                "name": {
+                    "type": "string"
                 },
-                "daysAfterDueDateToRegistrationCancellation": {
-                    "description": "boleto only",
-                    "type": "integer"
+                "reason": {
+                    "type": "string"
                 },
-                "description": {
+                "service_item_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Charge": {
+            "type": "object",
+            "properties": {
+                "company_id": {
                     "type": "string"
                 },
-                "discount": {
-                    "$ref": "#/definitions/model.AsaasChargeDiscount"
+                "contract_id": {
+                    "type": "string"
                 },
-                "dueDate": {
-                    "description": "YYYY-MM-DD",
+                "deleted_at": {
                     "type": "string"
                 },
-                "externalReference": {
+                "deleted_reason": {
                     "type": "string"
                 },
-                "fine": {
-                    "$ref": "#/definitions/model.AsaasChargeFine"
+                "description": {
+                    "type": "string"
                 },
-                "installmentCount": {
+                "due_date": {
+                    "type": "string",
+                    "example": "2026-01-10"
+                },
+                "external_reference": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "7b0c2c7e-9a3e-4f51-9a55-3c2b8c1d0e11"
+                },
+                "installment_id": {
+                    "type": "string"
+                },
+                "installment_number": {
                     "type": "integer"
                 },
-                "installmentValue": {
+                "invoice_number": {
+                    "type": "string"
+                },
+                "invoice_url": {
+                    "type": "string"
+                },
+                "net_value": {
                     "type": "number"
                 },
-                "interest": {
-                    "$ref": "#/definitions/model.AsaasChargeInterest"
+                "original_due_date": {
+                    "type": "string"
                 },
-                "postalService": {
-                    "type": "boolean"
+                "payment_method": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ChargePaymentMethod"
+                        }
+                    ],
+                    "example": "BOLETO"
                 },
-                "totalValue": {
+                "provider": {
+                    "type": "string",
+                    "example": "ASAAS"
+                },
+                "provider_charge_id": {
+                    "type": "string",
+                    "example": "pay_080225913252"
+                },
+                "provider_status": {
+                    "type": "string",
+                    "example": "PENDING"
+                },
+                "refunded_value": {
                     "type": "number"
                 },
+                "refunds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ChargeRefund"
+                    }
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ChargeStatus"
+                        }
+                    ],
+                    "example": "PENDING"
+                },
+                "subscription_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
                 "value": {
-                    "type": "number"
+                    "type": "number",
+                    "example": 129.9
                 }
             }
         },
-        "model.AsaasCreateCustomerRequest": {
+        "model.ChargeListResponse": {
             "type": "object",
             "properties": {
-                "company": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Charge"
+                    }
+                },
+                "has_more": {
                     "type": "boolean"
                 },
-                "cpfCnpj": {
-                    "type": "string"
+                "limit": {
+                    "type": "integer"
                 },
-                "email": {
+                "offset": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ChargePaymentMethod": {
+            "type": "string",
+            "enum": [
+                "BOLETO",
+                "PIX",
+                "CREDIT_CARD",
+                "UNDEFINED"
+            ],
+            "x-enum-varnames": [
+                "ChargePaymentMethodBoleto",
+                "ChargePaymentMethodPix",
+                "ChargePaymentMethodCreditCard",
+                "ChargePaymentMethodUndefined"
+            ]
+        },
+        "model.ChargeRefund": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "ISO 8601 timestamp",
+                    "type": "string",
+                    "example": "2026-01-10T12:00:00Z"
+                },
+                "description": {
                     "type": "string"
                 },
-                "mobilePhone": {
+                "provider_status": {
+                    "description": "payment status answered by the provider",
+                    "type": "string",
+                    "example": "REFUND_REQUESTED"
+                },
+                "request_id": {
                     "type": "string"
                 },
-                "name": {
+                "requested_by": {
+                    "description": "authenticated subject (user id or service name)",
                     "type": "string"
                 },
-                "notificationDisabled": {
-                    "type": "boolean"
+                "value": {
+                    "type": "number",
+                    "example": 50
                 }
             }
         },
-        "model.AsaasCreateSubscriptionRequest": {
+        "model.ChargeStatus": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "PAID",
+                "OVERDUE",
+                "REFUNDED",
+                "PROCESSING",
+                "CANCELLED",
+                "UNKNOWN"
+            ],
+            "x-enum-varnames": [
+                "ChargeStatusPending",
+                "ChargeStatusPaid",
+                "ChargeStatusOverdue",
+                "ChargeStatusRefunded",
+                "ChargeStatusProcessing",
+                "ChargeStatusCancelled",
+                "ChargeStatusUnknown"
+            ]
+        },
+        "model.CircuitBreakerCounts": {
             "type": "object",
             "properties": {
-                "billingType": {
-                    "description": "BOLETO | CREDIT_CARD | PIX | UNDEFINED",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.AsaasBillingType"
-                        }
-                    ]
+                "closed": {
+                    "type": "integer"
                 },
-                "cycle": {
-                    "description": "WEEKLY | BIWEEKLY | MONTHLY | BIMONTHLY | QUARTERLY | SEMIANNUALLY | YEARLY",
+                "half_open": {
+                    "type": "integer"
+                },
+                "open": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CircuitBreakerStatus": {
+            "type": "object",
+            "properties": {
+                "base_api": {
+                    "type": "string"
+                },
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "integration_id": {
+                    "type": "string"
+                },
+                "last_error": {
                     "type": "string"
                 },
+                "opened_at": {
+                    "description": "RFC3339",
+                    "type": "string"
+                },
+                "retry_at": {
+                    "description": "when the next probe is allowed (open)",
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreateChargeRequest": {
+            "type": "object",
+            "properties": {
+                "contract_id": {
+                    "type": "string",
+                    "example": "2f8c7a1e-1111-2222-3333-444455556666"
+                },
                 "description": {
                     "type": "string"
                 },
-                "discount": {
-                    "$ref": "#/definitions/model.AsaasSubscriptionDiscount"
-                },
-                "endDate": {
-                    "description": "YYYY-MM-DD",
-                    "type": "string"
+                "due_date": {
+                    "type": "string",
+                    "example": "2026-01-10"
                 },
-                "externalReference": {
+                "external_reference": {
                     "type": "string"
                 },
-                "fine": {
-                    "$ref": "#/definitions/model.AsaasSubscriptionFine"
+                "installment_count": {
+                    "description": "Parcelamento (opcional)",
+                    "type": "integer"
                 },
-                "interest": {
-                    "$ref": "#/definitions/model.AsaasSubscriptionInterest"
+                "installment_value": {
+                    "type": "number"
                 },
-                "maxPayments": {
-                    "type": "integer"
+                "payment_method": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ChargePaymentMethod"
+                        }
+                    ],
+                    "example": "BOLETO"
                 },
-                "nextDueDate": {
-                    "description": "YYYY-MM-DD",
-                    "type": "string"
+                "total_value": {
+                    "type": "number"
                 },
                 "value": {
-                    "type": "number"
+                    "type": "number",
+                    "example": 129.9
                 }
             }
         },
-        "model.AsaasCustomerResponse": {
+        "model.CreateSubscriptionRequest": {
             "type": "object",
             "properties": {
-                "additionalEmails": {
+                "contract_id": {
+                    "type": "string",
+                    "example": "2f8c7a1e-1111-2222-3333-444455556666"
+                },
+                "cycle": {
+                    "description": "WEEKLY | BIWEEKLY | MONTHLY | BIMONTHLY | QUARTERLY | SEMIANNUALLY | YEARLY",
+                    "type": "string",
+                    "example": "MONTHLY"
+                },
+                "description": {
                     "type": "string"
                 },
-                "address": {
+                "end_date": {
                     "type": "string"
                 },
-                "addressNumber": {
+                "external_reference": {
+                    "description": "default: fee_contract:{contract_id}:subscription:{cycle}:{payment_method}",
                     "type": "string"
                 },
-                "city": {
+                "max_payments": {
                     "type": "integer"
                 },
-                "cityName": {
-                    "type": "string"
-                },
-                "complement": {
-                    "type": "string"
+                "next_due_date": {
+                    "type": "string",
+                    "example": "2026-01-10"
                 },
-                "country": {
-                    "type": "string"
+                "payment_method": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ChargePaymentMethod"
+                        }
+                    ],
+                    "example": "BOLETO"
                 },
-                "cpfCnpj": {
+                "value": {
+                    "type": "number",
+                    "example": 129.9
+                }
+            }
+        },
+        "model.Customer": {
+            "type": "object",
+            "properties": {
+                "company_id": {
                     "type": "string"
                 },
-                "dateCreated": {
+                "cpf_cnpj": {
                     "type": "string"
                 },
                 "deleted": {
@@ -1332,492 +5589,600 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
-                "externalReference": {
+                "external_reference": {
                     "type": "string"
                 },
-                "foreignCustomer": {
-                    "type": "boolean"
-                },
                 "id": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "cus_000005219613"
                 },
-                "mobilePhone": {
+                "mobile_phone": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
-                "notificationDisabled": {
-                    "type": "boolean"
-                },
-                "object": {
-                    "type": "string"
-                },
-                "observations": {
-                    "type": "string"
-                },
-                "personType": {
-                    "description": "JURIDICA | FISICA",
-                    "type": "string"
-                },
-                "phone": {
-                    "type": "string"
-                },
-                "postalCode": {
+                "provider": {
+                    "type": "string",
+                    "example": "ASAAS"
+                }
+            }
+        },
+        "model.EnsureCustomerRequest": {
+            "type": "object",
+            "properties": {
+                "accounting_office_id": {
                     "type": "string"
                 },
-                "province": {
+                "company_id": {
                     "type": "string"
                 },
-                "state": {
+                "contract_id": {
                     "type": "string"
                 }
             }
         },
-        "model.AsaasDiscountType": {
-            "type": "string",
-            "enum": [
-                "FIXED",
-                "PERCENTAGE"
-            ],
-            "x-enum-varnames": [
-                "AsaasDiscountTypeFixed",
-                "AsaasDiscountTypePercentage"
-            ]
-        },
-        "model.AsaasFineType": {
-            "type": "string",
-            "enum": [
-                "FIXED",
-                "PERCENTAGE"
-            ],
-            "x-enum-varnames": [
-                "AsaasFineTypeFixed",
-                "AsaasFineTypePercentage"
-            ]
-        },
-        "model.AsaasPaymentResponse": {
+        "model.ReconcileDriftItem": {
             "type": "object",
             "properties": {
-                "anticipable": {
-                    "type": "boolean"
-                },
-                "anticipated": {
-                    "type": "boolean"
-                },
-                "bankSlipUrl": {
+                "action": {
                     "type": "string"
                 },
-                "billingType": {
+                "error": {
                     "type": "string"
                 },
-                "canBePaidAfterDueDate": {
-                    "type": "boolean"
-                },
-                "checkoutSession": {},
-                "clientPaymentDate": {
-                    "type": "string"
+                "kinds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "confirmedDate": {
+                "local": {
+                    "description": "\"status=PENDING value=100.00 due_date=2026-01-10\"",
                     "type": "string"
                 },
-                "creditDate": {
+                "payment_id": {
                     "type": "string"
                 },
-                "customer": {
+                "remote": {
                     "type": "string"
-                },
-                "dateCreated": {
+                }
+            }
+        },
+        "model.ReconcileIntegrationReport": {
+            "type": "object",
+            "properties": {
+                "accounting_office_id": {
                     "type": "string"
                 },
-                "deleted": {
-                    "type": "boolean"
+                "drifted": {
+                    "type": "integer"
                 },
-                "description": {
+                "error": {
                     "type": "string"
                 },
-                "discount": {},
-                "dueDate": {
-                    "type": "string"
+                "failed": {
+                    "type": "integer"
                 },
-                "escrow": {},
-                "estimatedCreditDate": {
-                    "type": "string"
+                "fixed": {
+                    "type": "integer"
                 },
-                "externalReference": {
-                    "type": "string"
+                "inserted": {
+                    "type": "integer"
                 },
-                "fine": {},
-                "id": {
+                "integration_id": {
                     "type": "string"
                 },
-                "installment": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ReconcileDriftItem"
+                    }
+                },
+                "provider": {
                     "type": "string"
                 },
-                "installmentNumber": {
+                "scanned": {
                     "type": "integer"
                 },
-                "interest": {},
-                "interestValue": {},
-                "invoiceNumber": {
-                    "type": "string"
-                },
-                "invoiceUrl": {
+                "unresolved": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ReconcileReport": {
+            "type": "object",
+            "properties": {
+                "created_from": {
+                    "description": "dateCreated window (YYYY-MM-DD)",
                     "type": "string"
                 },
-                "lastBankSlipViewedDate": {},
-                "lastInvoiceViewedDate": {},
-                "netValue": {
-                    "type": "number"
+                "drifted": {
+                    "type": "integer"
                 },
-                "nossoNumero": {
-                    "type": "string"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "object": {
+                "due_from": {
+                    "description": "dueDate window (YYYY-MM-DD)",
                     "type": "string"
                 },
-                "originalDueDate": {
+                "due_to": {
                     "type": "string"
                 },
-                "originalValue": {},
-                "paymentDate": {
-                    "type": "string"
+                "failed": {
+                    "type": "integer"
                 },
-                "paymentLink": {
-                    "description": "duplicated field name workaround",
+                "finished_at": {
                     "type": "string"
                 },
-                "pixTransaction": {},
-                "postalService": {
-                    "type": "boolean"
+                "fixed": {
+                    "type": "integer"
                 },
-                "refunds": {},
-                "status": {
+                "id": {
+                    "description": "generated by the database",
                     "type": "string"
                 },
-                "transactionReceiptUrl": {
-                    "type": "string"
+                "inserted": {
+                    "type": "integer"
                 },
-                "value": {
-                    "type": "number"
-                }
-            }
-        },
-        "model.AsaasPaymentsListResponse": {
-            "type": "object",
-            "properties": {
-                "data": {
+                "integrations": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.AsaasPaymentResponse"
+                        "$ref": "#/definitions/model.ReconcileIntegrationReport"
                     }
                 },
-                "hasMore": {
-                    "type": "boolean"
-                },
-                "limit": {
+                "scanned": {
                     "type": "integer"
                 },
-                "object": {
-                    "description": "\"list\"",
+                "started_at": {
                     "type": "string"
                 },
-                "offset": {
-                    "type": "integer"
+                "trigger": {
+                    "description": "schedule | manual",
+                    "type": "string"
                 },
-                "totalCount": {
-                    "type": "integer"
-                }
-            }
-        },
-        "model.AsaasPersonType": {
-            "type": "string",
-            "enum": [
-                "JURIDICA",
-                "FISICA"
-            ],
-            "x-enum-varnames": [
-                "AsaasPersonTypeJuridica",
-                "AsaasPersonTypeFisica"
-            ]
-        },
-        "model.AsaasSubscriptionDiscount": {
-            "type": "object",
-            "properties": {
-                "dueDateLimitDays": {
+                "unresolved": {
                     "type": "integer"
-                },
-                "type": {
-                    "description": "FIXED | PERCENTAGE",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.AsaasDiscountType"
-                        }
-                    ]
-                },
-                "value": {
-                    "type": "number"
                 }
             }
         },
-        "model.AsaasSubscriptionFine": {
+        "model.ReconcileRequest": {
             "type": "object",
             "properties": {
-                "type": {
-                    "description": "FIXED | PERCENTAGE",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.AsaasFineType"
-                        }
-                    ]
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "value": {
-                    "type": "number"
+                "integration_id": {
+                    "description": "default: every active integration",
+                    "type": "string"
                 }
             }
         },
-        "model.AsaasSubscriptionInterest": {
+        "model.ReconcileRunPage": {
             "type": "object",
             "properties": {
-                "value": {
-                    "type": "number"
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ReconcileReport"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
                 }
             }
         },
-        "model.AsaasSubscriptionResponse": {
+        "model.Subscription": {
             "type": "object",
             "properties": {
-                "billingType": {
-                    "type": "string"
-                },
-                "customer": {
-                    "type": "string"
+                "charges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Charge"
+                    }
                 },
-                "cycle": {
+                "company_id": {
                     "type": "string"
                 },
-                "dateCreated": {
+                "contract_id": {
                     "type": "string"
                 },
-                "deleted": {
-                    "type": "boolean"
+                "cycle": {
+                    "type": "string",
+                    "example": "MONTHLY"
                 },
                 "description": {
                     "type": "string"
                 },
-                "discount": {},
-                "endDate": {
+                "end_date": {
                     "type": "string"
                 },
-                "externalReference": {
+                "external_reference": {
                     "type": "string"
                 },
-                "fine": {},
                 "id": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "sub_VXJBYgP2u0eO"
                 },
-                "interest": {},
-                "maxPayments": {
-                    "type": "integer"
+                "next_due_date": {
+                    "type": "string",
+                    "example": "2026-01-10"
                 },
-                "nextDueDate": {
-                    "type": "string"
+                "payment_method": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ChargePaymentMethod"
+                        }
+                    ],
+                    "example": "BOLETO"
                 },
-                "object": {
-                    "type": "string"
+                "provider": {
+                    "type": "string",
+                    "example": "ASAAS"
                 },
                 "status": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "ACTIVE"
                 },
                 "value": {
-                    "type": "number"
+                    "type": "number",
+                    "example": 129.9
                 }
             }
         },
-        "model.AsaasUpdateChargeRequest": {
+        "model.WebhookEndpointStatus": {
             "type": "object",
             "properties": {
-                "discount": {
-                    "$ref": "#/definitions/model.AsaasChargeDiscount"
+                "email": {
+                    "type": "string"
                 },
-                "dueDate": {
-                    "description": "YYYY-MM-DD",
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "has_auth_token": {
+                    "type": "boolean"
+                },
+                "id": {
                     "type": "string"
                 },
-                "fine": {
-                    "$ref": "#/definitions/model.AsaasChargeFine"
+                "interrupted": {
+                    "type": "boolean"
                 },
-                "interest": {
-                    "$ref": "#/definitions/model.AsaasChargeInterest"
+                "name": {
+                    "type": "string"
                 },
-                "value": {
-                    "type": "number"
+                "penalized_requests": {
+                    "type": "integer"
+                },
+                "send_type": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "model.AsaasUpdateCustomerRequest": {
+        "model.WebhookJournalEntry": {
             "type": "object",
             "properties": {
-                "additionalEmails": {
-                    "description": "Metadados",
+                "attempts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "company_id": {
                     "type": "string"
                 },
-                "address": {
-                    "description": "Endereço",
+                "contract_id": {
                     "type": "string"
                 },
-                "addressNumber": {
+                "created_at": {
+                    "description": "ISO 8601 timestamp",
                     "type": "string"
                 },
-                "city": {
-                    "type": "integer"
+                "error_message": {
+                    "type": "string"
                 },
-                "company": {
-                    "type": "boolean"
+                "error_stage": {
+                    "type": "string"
                 },
-                "complement": {
+                "event_created_at": {
+                    "description": "provider dateCreated (ISO 8601)",
                     "type": "string"
                 },
-                "country": {
+                "event_id": {
+                    "type": "string",
+                    "example": "evt_05b708f961d739ea7eba7e4db318f621"
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "PAYMENT_RECEIVED"
+                },
+                "external_reference": {
                     "type": "string"
                 },
-                "cpfCnpj": {
+                "id": {
+                    "description": "generated by the database",
                     "type": "string"
                 },
-                "email": {
+                "inbox_id": {
+                    "description": "logs.asaas_webhook_inbox.id (deliveries)",
                     "type": "string"
                 },
-                "externalReference": {
+                "latency_ms": {
+                    "description": "received → processed",
+                    "type": "integer"
+                },
+                "outcome": {
+                    "type": "string",
+                    "example": "applied"
+                },
+                "payment_id": {
+                    "type": "string",
+                    "example": "pay_080225913252"
+                },
+                "processed_at": {
+                    "description": "ISO 8601 timestamp (last attempt)",
                     "type": "string"
                 },
-                "foreignCustomer": {
-                    "type": "boolean"
+                "processing_ms": {
+                    "description": "duration of the last attempt",
+                    "type": "integer"
                 },
-                "mobilePhone": {
+                "provider": {
+                    "type": "string",
+                    "example": "ASAAS"
+                },
+                "raw_payload": {
+                    "type": "object"
+                },
+                "received_at": {
+                    "description": "ISO 8601 timestamp",
                     "type": "string"
                 },
-                "name": {
-                    "description": "Identificação",
+                "source": {
+                    "type": "string",
+                    "example": "webhook"
+                },
+                "status_from": {
+                    "type": "string",
+                    "example": "PENDING"
+                },
+                "status_to": {
+                    "type": "string",
+                    "example": "RECEIVED"
+                },
+                "subscription_id": {
                     "type": "string"
+                }
+            }
+        },
+        "model.WebhookJournalPage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WebhookJournalEntry"
+                    }
                 },
-                "notificationDisabled": {
-                    "description": "Use pointers for booleans so \"omit vs false\" is preserved.",
+                "has_more": {
                     "type": "boolean"
                 },
-                "observations": {
-                    "type": "string"
+                "limit": {
+                    "type": "integer"
                 },
-                "personType": {
-                    "description": "Flags / enums",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.AsaasPersonType"
-                        }
-                    ]
+                "offset": {
+                    "type": "integer"
                 },
-                "phone": {
-                    "type": "string"
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.WebhookProvisionRequest": {
+            "type": "object",
+            "properties": {
+                "base_url": {
+                    "description": "BaseURL is the public URL of this service (default WEBHOOK_PUBLIC_URL).",
+                    "type": "string",
+                    "example": "https://api-charges.carteiracontabil.com"
                 },
-                "postalCode": {
-                    "type": "string"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "province": {
-                    "type": "string"
+                "email": {
+                    "description": "Email receives the provider's failure notifications (default ASAAS_WEBHOOK_EMAIL or the current one).",
+                    "type": "string",
+                    "example": "financeiro@empresa.com"
                 },
-                "state": {
-                    "type": "string"
+                "force": {
+                    "description": "Force re-sends the whole configuration (including the auth token) even without drift.",
+                    "type": "boolean"
+                },
+                "resume": {
+                    "description": "Resume restarts a delivery queue interrupted by the provider.",
+                    "type": "boolean"
                 }
             }
         },
-        "model.AsaasUpdateSubscriptionRequest": {
+        "model.WebhookProvisionResult": {
             "type": "object",
             "properties": {
-                "billingType": {
-                    "description": "BOLETO | CREDIT_CARD | PIX | UNDEFINED",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.AsaasBillingType"
-                        }
-                    ]
+                "action": {
+                    "type": "string"
                 },
-                "cycle": {
-                    "description": "WEEKLY | BIWEEKLY | MONTHLY | BIMONTHLY | QUARTERLY | SEMIANNUALLY | YEARLY",
+                "changes": {
+                    "description": "fields that differ from the expected configuration",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "expected_url": {
                     "type": "string"
                 },
-                "description": {
+                "integration_id": {
                     "type": "string"
                 },
-                "discount": {
-                    "$ref": "#/definitions/model.AsaasSubscriptionDiscount"
+                "interrupted": {
+                    "description": "provider stopped delivering (see resume)",
+                    "type": "boolean"
                 },
-                "endDate": {
-                    "description": "YYYY-MM-DD",
+                "penalized": {
+                    "description": "provider is holding back deliveries after failures",
+                    "type": "boolean"
+                },
+                "provider": {
                     "type": "string"
                 },
-                "externalReference": {
+                "resumed": {
+                    "type": "boolean"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "webhook": {
+                    "$ref": "#/definitions/model.WebhookEndpointStatus"
+                }
+            }
+        },
+        "model.WebhookReplayItem": {
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string"
                 },
-                "fine": {
-                    "$ref": "#/definitions/model.AsaasSubscriptionFine"
+                "error_stage": {
+                    "description": "stage of the original failure",
+                    "type": "string"
                 },
-                "interest": {
-                    "$ref": "#/definitions/model.AsaasSubscriptionInterest"
+                "event_id": {
+                    "type": "string"
                 },
-                "nextDueDate": {
-                    "description": "YYYY-MM-DD — next installment due date",
+                "event_type": {
+                    "type": "string",
+                    "example": "PAYMENT_CREATED"
+                },
+                "log_id": {
                     "type": "string"
                 },
-                "status": {
-                    "description": "ACTIVE | INACTIVE",
+                "payment_id": {
                     "type": "string"
                 },
-                "updatePendingPayments": {
-                    "description": "When true, updates pending (unpaid) payments with the new billingType and/or value.",
-                    "type": "boolean"
+                "replay_attempts": {
+                    "type": "integer"
                 },
-                "value": {
-                    "type": "number"
+                "result": {
+                    "type": "string",
+                    "example": "resolved"
+                },
+                "stage": {
+                    "description": "stage where the replay failed",
+                    "type": "string"
                 }
             }
         },
-        "model.AsaasWebhookAccount": {
+        "model.WebhookReplayRequest": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "string"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "ownerId": {
-                    "type": "string"
+                "from": {
+                    "description": "created_at \u003e= (YYYY-MM-DD or RFC 3339)",
+                    "type": "string",
+                    "example": "2026-01-01"
+                },
+                "ids": {
+                    "description": "logs.asaas_webhook_events.id",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "include_resolved": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "description": "1..500, default 50",
+                    "type": "integer",
+                    "example": 50
+                },
+                "payment_id": {
+                    "type": "string",
+                    "example": "pay_080225913252"
+                },
+                "stage": {
+                    "description": "error_stage",
+                    "type": "string",
+                    "example": "resolve_contract_context"
+                },
+                "to": {
+                    "description": "created_at \u003c= (YYYY-MM-DD = the whole day, or RFC 3339)",
+                    "type": "string",
+                    "example": "2026-01-31T23:59:59Z"
                 }
             }
         },
-        "model.AsaasWebhookEvent": {
+        "model.WebhookReplayResult": {
             "type": "object",
             "properties": {
-                "account": {
-                    "$ref": "#/definitions/model.AsaasWebhookAccount"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "dateCreated": {
-                    "description": "Format: \"2026-01-24 16:13:02\"",
-                    "type": "string"
+                "failed": {
+                    "type": "integer"
                 },
-                "event": {
-                    "description": "e.g., PAYMENT_CREATED, PAYMENT_RECEIVED, PAYMENT_UPDATED, etc.",
-                    "type": "string"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WebhookReplayItem"
+                    }
                 },
-                "id": {
-                    "type": "string"
+                "resolved": {
+                    "type": "integer"
                 },
-                "payment": {
-                    "description": "The payment object (when event is payment-related)",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/model.AsaasPaymentResponse"
-                        }
-                    ]
+                "selected": {
+                    "type": "integer"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "Chave de serviço (SERVICE_API_KEYS) para chamadas service-to-service",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "Supabase JWT: \"Bearer \u003caccess_token\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,202 @@
+// Package auth authenticates callers of the /v1 API (Supabase JWTs or service API keys)
+// and tells which tenants and accounting offices they may access.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIKeyHeader carries the key of service-to-service calls.
+const APIKeyHeader = "X-API-Key"
+
+// Roles of a Principal.
+const (
+	RoleService       = "service"       // API key or Supabase service_role token: not bound to a tenant
+	RoleAuthenticated = "authenticated" // Supabase user
+)
+
+// ErrNoCredentials is returned when the request has neither a Bearer token nor an API key.
+var ErrNoCredentials = errors.New("missing credentials (Authorization: Bearer <jwt> or " + APIKeyHeader + ")")
+
+// Config selects how callers are authenticated.
+type Config struct {
+	// Disabled lets every request through as a service principal (local development only).
+	Disabled bool
+
+	JWTSecret string // Supabase project JWT secret (HS256)
+	JWKSFile  string // JWKS file with the public keys of asymmetric (RS256/ES256) tokens
+	Issuer    string // expected "iss" (empty = not checked)
+	Audience  string // expected "aud" of user tokens (Supabase: "authenticated")
+
+	// APIKeys maps a service name to its key, for service-to-service calls.
+	APIKeys map[string]string
+}
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Subject string // user id (sub) or service name
+	Email   string
+	Role    string // RoleService | RoleAuthenticated
+
+	// Tenants and offices the caller belongs to (from the token claims).
+	TenantIDs           []string
+	AccountingOfficeIDs []string
+}
+
+// IsService reports whether the caller is a trusted service (full access).
+func (p *Principal) IsService() bool {
+	return p != nil && p.Role == RoleService
+}
+
+// CanAccess reports whether the caller may act on a resource of tenantID and/or
+// accountingOfficeID (empty values are not checked). A user needs one match: the
+// resource's office among its offices, or the resource's tenant among its tenants.
+func (p *Principal) CanAccess(tenantID, accountingOfficeID string) bool {
+	if p == nil {
+		return false
+	}
+	if p.IsService() {
+		return true
+	}
+	if accountingOfficeID != "" && contains(p.AccountingOfficeIDs, accountingOfficeID) {
+		return true
+	}
+	return tenantID != "" && contains(p.TenantIDs, tenantID)
+}
+
+// Authenticator validates the credentials of a request.
+type Authenticator struct {
+	disabled bool
+	jwt      *jwtVerifier
+	apiKeys  map[string]string
+}
+
+// New builds an Authenticator, loading the JWKS file when configured.
+func New(cfg Config) (*Authenticator, error) {
+	a := &Authenticator{disabled: cfg.Disabled, apiKeys: map[string]string{}}
+	for name, key := range cfg.APIKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			a.apiKeys[name] = key
+		}
+	}
+
+	secret := strings.TrimSpace(cfg.JWTSecret)
+	var keys map[string]crypto.PublicKey
+	if path := strings.TrimSpace(cfg.JWKSFile); path != "" {
+		var err error
+		if keys, err = loadJWKS(path); err != nil {
+			return nil, err
+		}
+	}
+	if secret != "" || len(keys) > 0 {
+		a.jwt = &jwtVerifier{
+			secret:   []byte(secret),
+			keys:     keys,
+			issuer:   strings.TrimSpace(cfg.Issuer),
+			audience: strings.TrimSpace(cfg.Audience),
+		}
+	}
+	return a, nil
+}
+
+// Disabled reports whether authentication is turned off.
+func (a *Authenticator) Disabled() bool { return a.disabled }
+
+// Configured reports whether any credential can be accepted (JWT verification or API keys).
+func (a *Authenticator) Configured() bool { return a.jwt != nil || len(a.apiKeys) > 0 }
+
+// Authenticate returns the caller of r. An API key wins over a Bearer token.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if a.disabled {
+		return &Principal{Subject: "anonymous", Role: RoleService}, nil
+	}
+
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		for name, want := range a.apiKeys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(want)) == 1 {
+				return &Principal{Subject: name, Role: RoleService}, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	if a.jwt == nil {
+		return nil, fmt.Errorf("JWT authentication not configured (SUPABASE_JWT_SECRET / SUPABASE_JWKS_FILE)")
+	}
+	c, err := a.jwt.verify(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Role {
+	case "service_role":
+		return &Principal{Subject: "service_role", Role: RoleService}, nil
+	case "", "anon":
+		return nil, fmt.Errorf("anonymous tokens are not accepted")
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+	return &Principal{
+		Subject:             c.Subject,
+		Email:               c.Email,
+		Role:                RoleAuthenticated,
+		TenantIDs:           collect(c.AppMeta.TenantID, c.AppMeta.TenantIDs, c.TenantID, c.TenantIDs),
+		AccountingOfficeIDs: collect(c.AppMeta.AccountingOfficeID, c.AppMeta.AccountingOfficeIDs, c.AccountingOfficeID, c.AccountingOfficeIDs),
+	}, nil
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller stored by WithPrincipal (nil when there is none).
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(h[7:])
+	return token, token != ""
+}
+
+// collect merges single and list claims, dropping blanks and duplicates.
+func collect(one1 string, many1 []string, one2 string, many2 []string) []string {
+	var out []string
+	for _, group := range [][]string{{one1}, many1, {one2}, many2} {
+		for _, v := range group {
+			if v = strings.TrimSpace(v); v != "" && !contains(out, v) {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	a, err := New(Config{
+		JWTSecret: testSecret,
+		Audience:  "authenticated",
+		APIKeys:   map[string]string{"billing-worker": "key-123", "blank": "  "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Authenticate checks exp against the wall clock.
+	valid := func(extra map[string]any) string {
+		c := userClaims(extra)
+		if _, ok := extra["exp"]; !ok {
+			c["exp"] = time.Now().Add(time.Hour).Unix()
+		}
+		return signHS256(t, testSecret, c)
+	}
+
+	tests := []struct {
+		name    string
+		header  map[string]string
+		want    *Principal
+		wantErr string
+	}{
+		{
+			name:   "api key",
+			header: map[string]string{APIKeyHeader: "key-123"},
+			want:   &Principal{Subject: "billing-worker", Role: RoleService},
+		},
+		{
+			name:    "wrong api key",
+			header:  map[string]string{APIKeyHeader: "nope"},
+			wantErr: "invalid API key",
+		},
+		{
+			name:    "api key wins over a valid token",
+			header:  map[string]string{APIKeyHeader: "nope", "Authorization": "Bearer " + valid(nil)},
+			wantErr: "invalid API key",
+		},
+		{
+			name:    "no credentials",
+			wantErr: "missing credentials",
+		},
+		{
+			name:    "basic auth is not a bearer token",
+			header:  map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			wantErr: "missing credentials",
+		},
+		{
+			name:   "service_role token",
+			header: map[string]string{"Authorization": "Bearer " + valid(map[string]any{"role": "service_role", "aud": nil})},
+			want:   &Principal{Subject: "service_role", Role: RoleService},
+		},
+		{
+			name:    "anon token",
+			header:  map[string]string{"Authorization": "Bearer " + valid(map[string]any{"role": "anon"})},
+			wantErr: "anonymous",
+		},
+		{
+			name:    "token without role",
+			header:  map[string]string{"Authorization": "Bearer " + valid(map[string]any{"role": nil})},
+			wantErr: "anonymous",
+		},
+		{
+			name:    "user token without subject",
+			header:  map[string]string{"Authorization": "Bearer " + valid(map[string]any{"sub": nil})},
+			wantErr: "no subject",
+		},
+		{
+			name:    "user token for another audience",
+			header:  map[string]string{"Authorization": "Bearer " + valid(map[string]any{"aud": "other"})},
+			wantErr: "audience",
+		},
+		{
+			name: "user token merges app_metadata and custom claims",
+			header: map[string]string{"Authorization": "bearer " + valid(map[string]any{
+				"email":                 "ana@example.com",
+				"app_metadata":          map[string]any{"tenant_id": "t1", "accounting_office_ids": []string{"o1", "o2"}},
+				"tenant_ids":            []string{"t2", "t1", " "},
+				"accounting_office_id":  "o2",
+				"accounting_office_ids": []string{"o3"},
+			})},
+			want: &Principal{
+				Subject:             "user-1",
+				Email:               "ana@example.com",
+				Role:                RoleAuthenticated,
+				TenantIDs:           []string{"t1", "t2"},
+				AccountingOfficeIDs: []string{"o1", "o2", "o3"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/charges", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			got, err := a.Authenticate(r)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Authenticate() = %+v, %v; want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Authenticate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateConfig(t *testing.T) {
+	disabled, _ := New(Config{Disabled: true})
+	if p, err := disabled.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil)); err != nil || !p.IsService() {
+		t.Fatalf("disabled auth: Authenticate() = %+v, %v; want a service principal", p, err)
+	}
+
+	keysOnly, _ := New(Config{APIKeys: map[string]string{"svc": "k"}})
+	if !keysOnly.Configured() {
+		t.Fatal("API keys alone should count as configured")
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+signHS256(t, testSecret, userClaims(nil)))
+	if _, err := keysOnly.Authenticate(r); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Fatalf("token without JWT config: err = %v", err)
+	}
+
+	if none, _ := New(Config{}); none.Configured() {
+		t.Fatal("empty config should not count as configured")
+	}
+	if _, err := New(Config{JWKSFile: "/nonexistent/jwks.json"}); err == nil {
+		t.Fatal("New() with a missing JWKS file should fail")
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	user := &Principal{
+		Subject:             "user-1",
+		Role:                RoleAuthenticated,
+		TenantIDs:           []string{"t1"},
+		AccountingOfficeIDs: []string{"o1"},
+	}
+	service := &Principal{Subject: "svc", Role: RoleService}
+
+	tests := []struct {
+		name   string
+		p      *Principal
+		tenant string
+		office string
+		want   bool
+	}{
+		{"nil principal", nil, "t1", "o1", false},
+		{"service reaches everything", service, "t9", "o9", true},
+		{"service without ids", service, "", "", true},
+		{"own office", user, "", "o1", true},
+		{"own tenant", user, "t1", "", true},
+		{"own office, other tenant", user, "t9", "o1", true},
+		{"own tenant, other office", user, "t1", "o9", true},
+		{"other tenant and office", user, "t9", "o9", false},
+		{"nothing to match", user, "", "", false},
+		{"user without memberships", &Principal{Subject: "u", Role: RoleAuthenticated}, "t1", "o1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.CanAccess(tt.tenant, tt.office); got != tt.want {
+				t.Fatalf("CanAccess(%q, %q) = %v, want %v", tt.tenant, tt.office, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrincipalContext(t *testing.T) {
+	if FromContext(context.Background()) != nil {
+		t.Fatal("FromContext() of an empty context should be nil")
+	}
+	p := &Principal{Subject: "user-1", Role: RoleAuthenticated}
+	if got := FromContext(WithPrincipal(context.Background(), p)); got != p {
+		t.Fatalf("FromContext() = %+v, want %+v", got, p)
+	}
+}
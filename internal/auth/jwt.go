@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// jwtLeeway tolerates clock skew between Supabase Auth and this service.
+const jwtLeeway = 30 * time.Second
+
+var (
+	errMalformedToken = errors.New("malformed token")
+	errBadSignature   = errors.New("invalid token signature")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// claims are the Supabase JWT claims we read. Memberships may come from app_metadata
+// (set by the backend) or from top-level custom claims (custom access token hook).
+type claims struct {
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email"`
+	Role      string   `json:"role"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	AppMeta   tenancy  `json:"app_metadata"`
+	tenancy            // top-level custom claims
+}
+
+type tenancy struct {
+	TenantID            string   `json:"tenant_id"`
+	TenantIDs           []string `json:"tenant_ids"`
+	AccountingOfficeID  string   `json:"accounting_office_id"`
+	AccountingOfficeIDs []string `json:"accounting_office_ids"`
+}
+
+// audience accepts "aud" as a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// jwtVerifier checks HS256 tokens with the project JWT secret and RS256/ES256 tokens
+// with the keys of a JWKS file.
+type jwtVerifier struct {
+	secret   []byte
+	keys     map[string]crypto.PublicKey // by kid
+	issuer   string
+	audience string
+}
+
+func (v *jwtVerifier) verify(token string, now time.Time) (*claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+	var h jwtHeader
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, errMalformedToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	switch h.Alg {
+	case "HS256":
+		if len(v.secret) == 0 {
+			return nil, fmt.Errorf("HS256 tokens not accepted (SUPABASE_JWT_SECRET not configured)")
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write(signed)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return nil, errBadSignature
+		}
+	case "RS256", "ES256":
+		key, err := v.key(h.Kid)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256(signed)
+		if err := verifyAsymmetric(h.Alg, key, digest[:], sig); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported token algorithm %q", h.Alg)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, errMalformedToken
+	}
+	if c.ExpiresAt == nil {
+		return nil, fmt.Errorf("token has no exp")
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(jwtLeeway)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if c.NotBefore != nil && now.Add(jwtLeeway).Before(unixTime(*c.NotBefore)) {
+		return nil, fmt.Errorf("token not valid yet")
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return nil, fmt.Errorf("unexpected token issuer %q", c.Issuer)
+	}
+	// Service keys (role service_role) carry no audience; user tokens must match it.
+	if v.audience != "" && len(c.Audience) > 0 && !contains(c.Audience, v.audience) {
+		return nil, fmt.Errorf("unexpected token audience %v", []string(c.Audience))
+	}
+	return &c, nil
+}
+
+func (v *jwtVerifier) key(kid string) (crypto.PublicKey, error) {
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("asymmetric tokens not accepted (SUPABASE_JWKS_FILE not configured)")
+	}
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown token key id %q", kid)
+}
+
+func verifyAsymmetric(alg string, key crypto.PublicKey, digest, sig []byte) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg != "RS256" || rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, sig) != nil {
+			return errBadSignature
+		}
+	case *ecdsa.PublicKey:
+		// JWS ES256 signatures are r||s, 32 bytes each.
+		if alg != "ES256" || len(sig) != 64 {
+			return errBadSignature
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errBadSignature
+		}
+	default:
+		return errBadSignature
+	}
+	return nil
+}
+
+// loadJWKS reads the public keys of a JWKS file ({"keys":[{"kty","kid","n","e"|"crv","x","y"}]}),
+// e.g. a copy of https://<project>.supabase.co/auth/v1/.well-known/jwks.json.
+func loadJWKS(path string) (map[string]crypto.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS file: %w", err)
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS file: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid RSA parameters", k.Kid)
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			if k.Crv != "P-256" {
+				return nil, fmt.Errorf("JWKS key %q: unsupported curve %q", k.Kid, k.Crv)
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid EC parameters", k.Kid)
+			}
+			pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+				return nil, fmt.Errorf("JWKS key %q: point not on curve", k.Kid)
+			}
+			keys[k.Kid] = pub
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file has no RSA/EC signing keys")
+	}
+	return keys, nil
+}
+
+func decodeSegment(seg string, out any) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func unixTime(secs float64) time.Time {
+	return time.Unix(int64(secs), 0)
+}
@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "super-secret-jwt-token-with-at-least-32-characters"
+
+var testNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func signingInput(t *testing.T, header, payload map[string]any) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b64(h) + "." + b64(p)
+}
+
+func signHS256(t *testing.T, secret string, payload map[string]any) string {
+	in := signingInput(t, map[string]any{"alg": "HS256", "typ": "JWT"}, payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(in))
+	return in + "." + b64(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, payload map[string]any) string {
+	in := signingInput(t, map[string]any{"alg": "RS256", "kid": kid}, payload)
+	digest := sha256.Sum256([]byte(in))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return in + "." + b64(sig)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, payload map[string]any) string {
+	in := signingInput(t, map[string]any{"alg": "ES256", "kid": kid}, payload)
+	digest := sha256.Sum256([]byte(in))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return in + "." + b64(sig)
+}
+
+// writeJWKS writes the public keys as a JWKS file and returns its path.
+func writeJWKS(t *testing.T, rsaKid string, rsaKey *rsa.PublicKey, ecKid string, ecKey *ecdsa.PublicKey) string {
+	t.Helper()
+	var keys []map[string]string
+	if rsaKey != nil {
+		keys = append(keys, map[string]string{
+			"kty": "RSA", "kid": rsaKid, "use": "sig",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		})
+	}
+	if ecKey != nil {
+		x, y := make([]byte, 32), make([]byte, 32)
+		ecKey.X.FillBytes(x)
+		ecKey.Y.FillBytes(y)
+		keys = append(keys, map[string]string{"kty": "EC", "kid": ecKid, "crv": "P-256", "x": b64(x), "y": b64(y)})
+	}
+	raw, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func userClaims(extra map[string]any) map[string]any {
+	c := map[string]any{
+		"sub":  "user-1",
+		"role": "authenticated",
+		"aud":  "authenticated",
+		"iss":  "https://project.supabase.co/auth/v1",
+		"exp":  testNow.Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func TestJWTVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := loadJWKS(writeJWKS(t, "rsa-1", &rsaKey.PublicKey, "ec-1", &ecKey.PublicKey))
+	if err != nil {
+		t.Fatalf("loadJWKS() error = %v", err)
+	}
+	v := &jwtVerifier{
+		secret:   []byte(testSecret),
+		keys:     keys,
+		issuer:   "https://project.supabase.co/auth/v1",
+		audience: "authenticated",
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string // substring; "" = valid
+	}{
+		{"HS256", signHS256(t, testSecret, userClaims(nil)), ""},
+		{"HS256 wrong secret", signHS256(t, "another-secret", userClaims(nil)), "signature"},
+		{"RS256", signRS256(t, rsaKey, "rsa-1", userClaims(nil)), ""},
+		{"RS256 signed by another key", signRS256(t, otherRSA, "rsa-1", userClaims(nil)), "signature"},
+		{"RS256 unknown kid", signRS256(t, rsaKey, "rsa-2", userClaims(nil)), "unknown token key id"},
+		{"ES256", signES256(t, ecKey, "ec-1", userClaims(nil)), ""},
+		{"ES256 with the RSA kid", signES256(t, ecKey, "rsa-1", userClaims(nil)), "signature"},
+		{"alg none", signingInput(t, map[string]any{"alg": "none"}, userClaims(nil)) + ".", "unsupported token algorithm"},
+		{"malformed", "not-a-jwt", "malformed"},
+		{"tampered payload", func() string {
+			parts := strings.Split(signHS256(t, testSecret, userClaims(nil)), ".")
+			p, _ := json.Marshal(userClaims(map[string]any{"sub": "admin"}))
+			return parts[0] + "." + b64(p) + "." + parts[2]
+		}(), "signature"},
+		{"expired", signHS256(t, testSecret, userClaims(map[string]any{"exp": testNow.Add(-time.Minute).Unix()})), "expired"},
+		{"expired within leeway", signHS256(t, testSecret, userClaims(map[string]any{"exp": testNow.Add(-10 * time.Second).Unix()})), ""},
+		{"no exp", signHS256(t, testSecret, userClaims(map[string]any{"exp": nil})), "no exp"},
+		{"not valid yet", signHS256(t, testSecret, userClaims(map[string]any{"nbf": testNow.Add(time.Minute).Unix()})), "not valid yet"},
+		{"wrong issuer", signHS256(t, testSecret, userClaims(map[string]any{"iss": "https://other.supabase.co/auth/v1"})), "issuer"},
+		{"wrong audience", signHS256(t, testSecret, userClaims(map[string]any{"aud": "other"})), "audience"},
+		{"audience list", signHS256(t, testSecret, userClaims(map[string]any{"aud": []string{"other", "authenticated"}})), ""},
+		{"service token without audience", signHS256(t, testSecret, userClaims(map[string]any{"aud": nil, "role": "service_role"})), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.verify(tt.token, testNow)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("verify() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTVerifyNotConfigured(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		v       *jwtVerifier
+		token   string
+		wantErr string
+	}{
+		{"HS256 without secret", &jwtVerifier{keys: map[string]crypto.PublicKey{"rsa-1": &rsaKey.PublicKey}}, signHS256(t, "", userClaims(nil)), "SUPABASE_JWT_SECRET"},
+		{"RS256 without JWKS", &jwtVerifier{secret: []byte(testSecret)}, signRS256(t, rsaKey, "rsa-1", userClaims(nil)), "SUPABASE_JWKS_FILE"},
+		{"single key matches an empty kid", &jwtVerifier{keys: map[string]crypto.PublicKey{"rsa-1": &rsaKey.PublicKey}}, signRS256(t, rsaKey, "", userClaims(nil)), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.v.verify(tt.token, testNow)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("verify() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadJWKS(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"no keys", `{"keys":[]}`, true},
+		{"encryption key only", `{"keys":[{"kty":"RSA","kid":"k","use":"enc","n":"AQAB","e":"AQAB"}]}`, true},
+		{"unsupported curve", `{"keys":[{"kty":"EC","kid":"k","crv":"P-384","x":"AA","y":"AA"}]}`, true},
+		{"point not on curve", `{"keys":[{"kty":"EC","kid":"k","crv":"P-256","x":"AQ","y":"AQ"}]}`, true},
+		{"not json", `keys`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadJWKS(path); (err != nil) != tt.wantErr {
+				t.Fatalf("loadJWKS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := loadJWKS(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("loadJWKS() of a missing file should fail")
+	}
+}
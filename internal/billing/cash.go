@@ -0,0 +1,29 @@
+package billing
+
+import (
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// AwaitingPayment reports whether a charge with this provider status is still waiting to
+// be paid (PENDING or OVERDUE).
+func AwaitingPayment(status string) bool {
+	switch model.AsaasPaymentStatus(strings.ToUpper(strings.TrimSpace(status))) {
+	case model.AsaasPaymentStatusPending, model.AsaasPaymentStatusOverdue:
+		return true
+	}
+	return false
+}
+
+// CanReceiveInCash reports whether a charge with this provider status can be confirmed as
+// paid outside the provider (only charges still awaiting payment).
+func CanReceiveInCash(status *string) bool {
+	return status != nil && AwaitingPayment(*status)
+}
+
+// CanUndoReceivedInCash reports whether a charge with this provider status was confirmed with
+// receive-in-cash and can be reverted.
+func CanUndoReceivedInCash(status *string) bool {
+	return status != nil && model.AsaasPaymentStatus(strings.ToUpper(strings.TrimSpace(*status))) == model.AsaasPaymentStatusReceivedInCash
+}
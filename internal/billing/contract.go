@@ -0,0 +1,148 @@
+// Package billing holds the contract-level billing rules shared by the HTTP
+// handlers and background jobs: which integration bills a contract, how
+// provider payments map to iam.charges and which charges a contract generates.
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// DefaultProviderName is used for contracts created before multi-provider support (provider IS NULL).
+const DefaultProviderName = asaas.ProviderName
+
+// ResolveContractContextFromPayment attempts to find the fee contract associated with
+// a payment by looking up the subscription ID in iam.fee_contract_subscriptions.
+// Falls back to parsing the external_reference field as "fee_contract:{uuid}:...".
+//
+// Shared by the webhook pipeline and the reconciler (see internal/reconcile).
+func ResolveContractContextFromPayment(ctx context.Context, p *provider.Payment) (*model.FeeContractRow, error) {
+	// ── Strategy 1: via subscription ID (most reliable for recurring charges) ──
+	if subID := strings.TrimSpace(p.SubscriptionID); subID != "" {
+		log.Printf("🔗 [resolveContext] Tentando resolver via subscription id=%s", subID)
+		contract, err := supabase.GetFeeContractBySubscriptionProviderIDContext(ctx, subID)
+		if err == nil && contract != nil {
+			return contract, nil
+		}
+		log.Printf("⚠️  [resolveContext] Lookup por subscription falhou (sub=%s): %v", subID, err)
+	}
+
+	// ── Strategy 2: via external_reference "fee_contract:{uuid}:..." ─────────
+	if contractID := ContractIDFromExtRef(p.ExternalReference); contractID != "" {
+		log.Printf("🔗 [resolveContext] Tentando resolver via external_reference contract_id=%s", contractID)
+		contract, err := supabase.GetFeeContractByIDContext(ctx, contractID)
+		if err == nil && contract != nil {
+			return contract, nil
+		}
+		log.Printf("⚠️  [resolveContext] Lookup por external_reference falhou (contract_id=%s): %v", contractID, err)
+	}
+
+	return nil, fmt.Errorf("não foi possível resolver o contrato para payment=%s sub=%q extRef=%q",
+		p.ID, p.SubscriptionID, p.ExternalReference)
+}
+
+// ContractIDFromExtRef extracts the contract UUID from the external_reference format
+// "fee_contract:{uuid}:..." used when creating charges/subscriptions.
+func ContractIDFromExtRef(extRef string) string {
+	parts := strings.SplitN(strings.TrimSpace(extRef), ":", 3)
+	if len(parts) >= 2 && parts[0] == "fee_contract" {
+		return strings.TrimSpace(parts[1])
+	}
+	return ""
+}
+
+// ChargeRowFromPayment maps a provider payment to an iam.charges row.
+// Tenant/office/company/contract context must be filled by the caller.
+func ChargeRowFromPayment(providerName string, p provider.Payment) model.IamChargeRow {
+	strPtr := func(s string) *string {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+
+	row := model.IamChargeRow{
+		Provider:               providerName,
+		ProviderChargeID:       p.ID,
+		ProviderInstallmentID:  strPtr(p.InstallmentID),
+		ProviderSubscriptionID: strPtr(p.SubscriptionID),
+		Value:                  p.Value,
+		Description:            strPtr(p.Description),
+		BillingType:            strPtr(p.BillingType),
+		Status:                 strPtr(p.Status),
+		DueDate:                strPtr(p.DueDate),
+		OriginalDueDate:        strPtr(p.OriginalDueDate),
+		InvoiceURL:             strPtr(p.InvoiceURL),
+		InvoiceNumber:          strPtr(p.InvoiceNumber),
+		ExternalReference:      strPtr(p.ExternalReference),
+		ProviderPayload:        p.Raw,
+	}
+	if p.NetValue != 0 {
+		v := p.NetValue
+		row.NetValue = &v
+	}
+	if p.InstallmentNumber != 0 {
+		v := p.InstallmentNumber
+		row.InstallmentNumber = &v
+	}
+	return row
+}
+
+// ChargeRowsForContract maps provider payments to iam.charges rows bound to a contract.
+func ChargeRowsForContract(providerName string, contract *model.FeeContractRow, payments []provider.Payment) []model.IamChargeRow {
+	rows := make([]model.IamChargeRow, 0, len(payments))
+	for _, p := range payments {
+		if strings.TrimSpace(p.ID) == "" {
+			continue
+		}
+		row := ChargeRowFromPayment(providerName, p)
+		row.TenantID = contract.TenantID
+		row.AccountingOfficeID = contract.AccountingOfficeID
+		row.CompanyID = contract.CompanyID
+		row.ContractID = contract.ID
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// FinancialTermsFromContract fills discount/interest/fine from the contract-level
+// settings when the caller did not provide them.
+func FinancialTermsFromContract(contract *model.FeeContractRow, discount **provider.Discount, interest **provider.Interest, fine **provider.Fine) {
+	if *discount == nil && contract.DiscountType != nil && strings.TrimSpace(*contract.DiscountType) != "" {
+		var v *float64
+		t := strings.TrimSpace(strings.ToUpper(*contract.DiscountType))
+		if t == "PERCENTAGE" && contract.DiscountPercentage != nil {
+			v = contract.DiscountPercentage
+		}
+		if t == "FIXED" && contract.DiscountValue != nil {
+			v = contract.DiscountValue
+		}
+		if v != nil {
+			*discount = &provider.Discount{Value: v, DueDateLimitDays: contract.DiscountDueLimitDays, Type: &t}
+		}
+	}
+	if *interest == nil && contract.InterestPercentage != nil {
+		*interest = &provider.Interest{Value: contract.InterestPercentage}
+	}
+	if *fine == nil && contract.FineType != nil && strings.TrimSpace(*contract.FineType) != "" {
+		t := strings.TrimSpace(strings.ToUpper(*contract.FineType))
+		var v *float64
+		if t == "PERCENTAGE" && contract.FinePercentage != nil {
+			v = contract.FinePercentage
+		}
+		if t == "FIXED" && contract.FineValue != nil {
+			v = contract.FineValue
+		}
+		if v != nil {
+			*fine = &provider.Fine{Value: v, Type: &t}
+		}
+	}
+}
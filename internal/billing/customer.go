@@ -0,0 +1,82 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// EnsureCustomer steps, reported in CustomerError.Stage.
+const (
+	CustomerStageResolve = "resolve_customer" // reading the company → customer mapping
+	CustomerStageLoad    = "load_company"     // loading the company data to create the customer
+	CustomerStageCreate  = "create_customer"  // provider call
+	CustomerStagePersist = "persist_customer" // storing the new mapping
+)
+
+// ErrCompanyNotFound is returned (inside a *CustomerError) when the company to create the
+// customer from does not exist.
+var ErrCompanyNotFound = errors.New("company not found to create customer")
+
+// CustomerError is a failed EnsureCustomer step. Resp is the provider answer of
+// CustomerStageCreate, when the provider answered, so handlers can pass it through.
+type CustomerError struct {
+	Stage string
+	Resp  *provider.Response
+	Err   error
+}
+
+func (e *CustomerError) Error() string { return e.Err.Error() }
+func (e *CustomerError) Unwrap() error { return e.Err }
+
+// EnsureCustomer resolves the provider customer id of a company, creating the customer
+// from company data (and persisting the mapping) when it does not exist yet.
+// Failures are *CustomerError; handlers map them with ensureProviderCustomer.
+func EnsureCustomer(ctx context.Context, p provider.ChargeProvider, companyID string) (string, error) {
+	customerID, err := supabase.GetCompanyAsaasCustomerIDContext(ctx, companyID)
+	if err != nil {
+		return "", &CustomerError{Stage: CustomerStageResolve, Err: fmt.Errorf("resolve customer id: %w", err)}
+	}
+	if strings.TrimSpace(customerID) != "" {
+		return customerID, nil
+	}
+
+	payload, err := supabase.GetCompanyAsaasCustomerPayloadContext(ctx, companyID)
+	if err != nil {
+		return "", &CustomerError{Stage: CustomerStageLoad, Err: fmt.Errorf("load company data to create customer: %w", err)}
+	}
+	if payload == nil {
+		return "", &CustomerError{Stage: CustomerStageLoad, Err: fmt.Errorf("%w (company_id=%s)", ErrCompanyNotFound, companyID)}
+	}
+
+	notificationDisabled := payload.NotificationDisabled
+	company := payload.Company
+	created, resp, err := p.CreateCustomer(provider.CustomerInput{
+		Name:                 payload.Name,
+		CpfCnpj:              payload.CpfCnpj,
+		Email:                payload.Email,
+		MobilePhone:          payload.MobilePhone,
+		NotificationDisabled: &notificationDisabled,
+		Company:              &company,
+	})
+	if err != nil && resp == nil {
+		return "", &CustomerError{Stage: CustomerStageCreate, Err: fmt.Errorf("create customer: %w", err)}
+	}
+	if !resp.OK() {
+		return "", &CustomerError{Stage: CustomerStageCreate, Resp: resp, Err: fmt.Errorf("create customer: %s", resp.ErrorMessage())}
+	}
+	if created == nil || strings.TrimSpace(created.ID) == "" {
+		return "", &CustomerError{Stage: CustomerStageCreate, Resp: resp, Err: errors.New("create customer: provider response without customer id")}
+	}
+
+	if err := supabase.UpsertCompanyAsaasIntegrationContext(ctx, companyID, created.ID); err != nil {
+		return "", &CustomerError{Stage: CustomerStagePersist, Err: fmt.Errorf("persist customer mapping (customer=%s): %w", created.ID, err)}
+	}
+	log.Printf("[billing] customer created: company_id=%s customer=%s", companyID, created.ID)
+	return created.ID, nil
+}
@@ -0,0 +1,292 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// ErrContractNotFound is returned by GenerateContractCharges when the contract does not exist.
+var ErrContractNotFound = errors.New("contract not found")
+
+// contractLocks serializes runs per contract so two concurrent calls cannot both
+// miss the existing subscription and create it twice.
+var contractLocks StripedLock
+
+// GenerateContractCharges reads the contract's service items and creates the matching
+// subscriptions (RECURRING groups) and one-off charges (ONE_TIME items) in the contract's provider.
+//
+// Idempotent: every subscription/charge carries a deterministic external reference and is
+// looked up (iam.charges and provider) before creation, so re-running never duplicates.
+// With dryRun=true nothing is created; the result shows what would happen.
+func GenerateContractCharges(ctx context.Context, contractID string, dryRun bool) (*model.BillingRunResult, error) {
+	contractID = strings.TrimSpace(contractID)
+	defer contractLocks.Lock(contractID)()
+
+	contract, err := supabase.GetFeeContractByIDContext(ctx, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("load contract: %w", err)
+	}
+	if contract == nil {
+		return nil, ErrContractNotFound
+	}
+	items, err := supabase.ListFeeContractServiceItemsContext(ctx, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("load service items: %w", err)
+	}
+
+	plan := BuildPlan(contract, items, time.Now())
+	result := &model.BillingRunResult{
+		ContractID:    contractID,
+		DryRun:        dryRun,
+		Subscriptions: plan.Subscriptions,
+		Charges:       plan.Charges,
+		Skipped:       plan.Skipped,
+	}
+	if len(plan.Subscriptions) == 0 && len(plan.Charges) == 0 {
+		return result, nil
+	}
+
+	cfg, providerName, err := ResolveContractIntegration(ctx, contract)
+	if err != nil {
+		return nil, fmt.Errorf("billing integration not found for contract/office/provider (provider=%s): %w", providerName, err)
+	}
+	p, err := provider.NewContext(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	result.Provider = p.Name()
+
+	customerID, err := supabase.GetCompanyAsaasCustomerIDContext(ctx, contract.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve customer id: %w", err)
+	}
+	if strings.TrimSpace(customerID) == "" && !dryRun {
+		if customerID, err = EnsureCustomer(ctx, p, contract.CompanyID); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range result.Subscriptions {
+		runSubscription(ctx, p, contract, customerID, &result.Subscriptions[i], dryRun)
+	}
+	for i := range result.Charges {
+		runOneTimeCharge(ctx, p, contract, customerID, &result.Charges[i], dryRun)
+	}
+
+	log.Printf("[billing] run finished: contract_id=%s provider=%s dry_run=%v subscriptions=%d charges=%d skipped=%d",
+		contractID, result.Provider, dryRun, len(result.Subscriptions), len(result.Charges), len(result.Skipped))
+	return result, nil
+}
+
+func runSubscription(ctx context.Context, p provider.ChargeProvider, contract *model.FeeContractRow, customerID string, item *model.BillingRunItem, dryRun bool) {
+	if customerID != "" {
+		page, resp, err := p.ListSubscriptions(provider.SubscriptionFilter{
+			CustomerID:        customerID,
+			ExternalReference: item.ExternalReference,
+			Limit:             10,
+		})
+		if err != nil || !resp.OK() {
+			failItem(item, "lookup existing subscription", err, resp)
+			return
+		}
+		for _, s := range page.Data {
+			if s.Deleted {
+				continue
+			}
+			item.Action = model.BillingRunActionExisting
+			item.ProviderID = s.ID
+			if !dryRun {
+				linkSubscription(ctx, p, contract, customerID, &s)
+			}
+			return
+		}
+	}
+	if dryRun {
+		return
+	}
+
+	desc := item.Description
+	ref := item.ExternalReference
+	in := provider.SubscriptionInput{
+		CustomerID:        customerID,
+		BillingType:       item.PaymentMethod,
+		Value:             item.Value,
+		NextDueDate:       item.DueDate,
+		Cycle:             item.Cycle,
+		Description:       &desc,
+		EndDate:           contract.EndDate,
+		ExternalReference: &ref,
+	}
+	FinancialTermsFromContract(contract, &in.Discount, &in.Interest, &in.Fine)
+
+	created, resp, err := p.CreateSubscription(in)
+	if err != nil || !resp.OK() || created == nil {
+		failItem(item, "create subscription", err, resp)
+		return
+	}
+	item.Action = model.BillingRunActionCreated
+	item.ProviderID = created.ID
+	log.Printf("[billing] subscription created: contract_id=%s sub=%s ref=%s value=%v cycle=%s",
+		contract.ID, created.ID, ref, item.Value, item.Cycle)
+
+	linkSubscription(ctx, p, contract, customerID, created)
+}
+
+// linkSubscription records the subscription in iam.fee_contract_subscriptions and
+// persists its generated payments in iam.charges. Best-effort: failures are logged.
+func linkSubscription(ctx context.Context, p provider.ChargeProvider, contract *model.FeeContractRow, customerID string, s *provider.Subscription) {
+	if err := LinkSubscription(ctx, p.Name(), contract.ID, s); err != nil {
+		log.Printf("[billing] ERROR linking subscription to contract: contract_id=%s sub=%s err=%v", contract.ID, s.ID, err)
+	}
+
+	payments, err := provider.Payments(p, provider.PaymentFilter{CustomerID: customerID, SubscriptionID: s.ID}).All()
+	if err != nil {
+		log.Printf("[billing] ERROR listing subscription payments: contract_id=%s sub=%s err=%v", contract.ID, s.ID, err)
+		return
+	}
+	for i := range payments {
+		payments[i].SubscriptionID = s.ID
+	}
+	if err := supabase.UpsertChargesContext(ctx, ChargeRowsForContract(p.Name(), contract, payments)); err != nil {
+		log.Printf("[billing] ERROR upserting subscription charges: contract_id=%s sub=%s err=%v", contract.ID, s.ID, err)
+	}
+}
+
+// LinkSubscription records a subscription created for a contract in
+// iam.fee_contract_subscriptions, so webhooks and the subscription endpoints find the contract.
+func LinkSubscription(ctx context.Context, providerName, contractID string, s *provider.Subscription) error {
+	row := model.FeeContractSubscriptionRow{
+		ContractID:             contractID,
+		Provider:               providerName,
+		ProviderSubscriptionID: s.ID,
+		Value:                  &s.Value,
+	}
+	if s.ExternalReference != "" {
+		row.ExternalReference = &s.ExternalReference
+	}
+	if s.Status != "" {
+		row.Status = &s.Status
+	}
+	if s.Cycle != "" {
+		row.Cycle = &s.Cycle
+	}
+	if s.NextDueDate != "" {
+		row.NextDueDate = &s.NextDueDate
+	}
+	return supabase.UpsertFeeContractSubscriptionContext(ctx, row)
+}
+
+// runOneTimeCharge creates the one-off charge of a ONE_TIME item unless a charge with its
+// external reference already exists. A charge deleted on purpose (soft-deleted in
+// iam.charges or deleted in the provider) also counts as handled: issuing it again on the
+// next run would bill a cancelled item.
+func runOneTimeCharge(ctx context.Context, p provider.ChargeProvider, contract *model.FeeContractRow, customerID string, item *model.BillingRunItem, dryRun bool) {
+	// Local first: the charge may already be in iam.charges, deleted or not.
+	rows, _, err := supabase.ListChargesContext(ctx, supabase.ChargeListFilter{
+		ContractID:        contract.ID,
+		ExternalReference: item.ExternalReference,
+		IncludeDeleted:    true,
+		Limit:             10,
+	})
+	if err != nil {
+		failItem(item, "lookup existing charge", err, nil)
+		return
+	}
+	if len(rows) > 0 {
+		item.Action = model.BillingRunActionDeleted
+		item.ProviderID = rows[0].ProviderChargeID
+		for _, row := range rows {
+			if row.DeletedAt == nil {
+				item.Action = model.BillingRunActionExisting
+				item.ProviderID = row.ProviderChargeID
+				break
+			}
+		}
+		return
+	}
+
+	// Then the provider: a previous run may have created it without persisting locally.
+	if customerID != "" {
+		page, resp, err := p.ListPayments(provider.PaymentFilter{
+			CustomerID:        customerID,
+			ExternalReference: item.ExternalReference,
+			Limit:             10,
+		})
+		if err != nil || !resp.OK() {
+			failItem(item, "lookup existing charge", err, resp)
+			return
+		}
+		deletedID := ""
+		for _, pay := range page.Data {
+			if pay.Deleted {
+				deletedID = pay.ID
+				continue
+			}
+			item.Action = model.BillingRunActionExisting
+			item.ProviderID = pay.ID
+			if !dryRun {
+				persistPayments(ctx, p, contract, []provider.Payment{pay})
+			}
+			return
+		}
+		if deletedID != "" {
+			item.Action = model.BillingRunActionDeleted
+			item.ProviderID = deletedID
+			return
+		}
+	}
+	if dryRun {
+		return
+	}
+
+	desc := item.Description
+	ref := item.ExternalReference
+	in := provider.PaymentInput{
+		CustomerID:        customerID,
+		BillingType:       item.PaymentMethod,
+		Value:             item.Value,
+		DueDate:           item.DueDate,
+		Description:       &desc,
+		ExternalReference: &ref,
+	}
+	FinancialTermsFromContract(contract, &in.Discount, &in.Interest, &in.Fine)
+
+	created, resp, err := p.CreatePayment(in)
+	if err != nil || !resp.OK() || created == nil {
+		failItem(item, "create charge", err, resp)
+		return
+	}
+	item.Action = model.BillingRunActionCreated
+	item.ProviderID = created.ID
+	log.Printf("[billing] charge created: contract_id=%s payment=%s ref=%s value=%v due=%s",
+		contract.ID, created.ID, ref, item.Value, item.DueDate)
+
+	persistPayments(ctx, p, contract, []provider.Payment{*created})
+}
+
+func persistPayments(ctx context.Context, p provider.ChargeProvider, contract *model.FeeContractRow, payments []provider.Payment) {
+	if err := supabase.UpsertChargesContext(ctx, ChargeRowsForContract(p.Name(), contract, payments)); err != nil {
+		log.Printf("[billing] ERROR upserting iam.charges: contract_id=%s err=%v", contract.ID, err)
+	}
+}
+
+func failItem(item *model.BillingRunItem, stage string, err error, resp *provider.Response) {
+	item.Action = model.BillingRunActionFailed
+	switch {
+	case err != nil:
+		item.Error = stage + ": " + err.Error()
+	case resp != nil:
+		item.Error = fmt.Sprintf("%s: %s", stage, resp.ErrorMessage())
+	default:
+		item.Error = stage + ": unexpected empty response"
+	}
+	log.Printf("[billing] ERROR %s: ref=%s err=%s", stage, item.ExternalReference, item.Error)
+}
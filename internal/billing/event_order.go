@@ -0,0 +1,154 @@
+package billing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// statusPrecedence ranks provider payment statuses by how far along the charge lifecycle
+// they are. It decides between events with the same (or unknown) timestamp: a lower rank
+// never overwrites a higher one, so a late PENDING/OVERDUE cannot roll back a paid charge.
+// Unknown statuses rank 0 and never block an update.
+var statusPrecedence = map[string]int{
+	"PENDING":                      10,
+	"AWAITING_RISK_ANALYSIS":       10,
+	"OVERDUE":                      20,
+	"DUNNING_REQUESTED":            25,
+	"CONFIRMED":                    30,
+	"RECEIVED":                     40,
+	"RECEIVED_IN_CASH":             40,
+	"DUNNING_RECEIVED":             40,
+	"REFUND_REQUESTED":             50,
+	"REFUND_IN_PROGRESS":           50,
+	"CHARGEBACK_REQUESTED":         50,
+	"CHARGEBACK_DISPUTE":           50,
+	"AWAITING_CHARGEBACK_REVERSAL": 50,
+	"REFUNDED":                     60,
+}
+
+// StatusRank returns the precedence of a provider payment status (0 when unknown).
+func StatusRank(status string) int {
+	return statusPrecedence[strings.ToUpper(strings.TrimSpace(status))]
+}
+
+// eventLocation is the timezone of Asaas event dateCreated ("2006-01-02 15:04:05", no offset).
+// Brazil has no DST since 2019, so a fixed offset avoids depending on tzdata in the image.
+var eventLocation = time.FixedZone("BRT", -3*60*60)
+
+// ParseEventTime parses a webhook dateCreated. Accepts Asaas' "2006-01-02 15:04:05"
+// (America/Sao_Paulo) and RFC 3339. ok=false when empty or unparseable.
+func ParseEventTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, eventLocation); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// StaleEvent tells whether an event must not be applied to the stored charge:
+//  1. both timestamps known → an event older than charge.last_event_at is stale;
+//  2. same second (or a timestamp is missing) → stale when its status ranks below the stored one.
+//
+// reason explains the decision for logs; it is empty when the event can be applied.
+func StaleEvent(current *model.IamChargeRow, eventAt time.Time, hasEventAt bool, incomingStatus string) (stale bool, reason string) {
+	if current == nil {
+		return false, ""
+	}
+
+	if hasEventAt && current.LastEventAt != nil {
+		if last, ok := parseTimestamp(*current.LastEventAt); ok {
+			switch {
+			case eventAt.Before(last):
+				return true, fmt.Sprintf("event at %s is older than last applied event at %s",
+					eventAt.Format(time.RFC3339), last.Format(time.RFC3339))
+			case eventAt.After(last):
+				return false, ""
+			}
+		}
+	}
+
+	currentStatus := ""
+	if current.Status != nil {
+		currentStatus = *current.Status
+	}
+	if in, cur := StatusRank(incomingStatus), StatusRank(currentStatus); in > 0 && in < cur {
+		return true, fmt.Sprintf("status %s has lower precedence than stored status %s", incomingStatus, currentStatus)
+	}
+	return false, ""
+}
+
+// ChangedSince tells whether the stored charge was written after t (a webhook applied an
+// event dated after t, or updated_at is after t). The reconciler uses it instead of
+// StaleEvent: a listing taken at t already includes every change dated before it, so an
+// unchanged row takes the provider status as is, even if that lowers its precedence
+// (e.g. RECEIVED_IN_CASH back to PENDING after the receipt is undone). Timestamps are compared at
+// second precision and a tie counts as changed.
+func ChangedSince(current *model.IamChargeRow, t time.Time) (changed bool, reason string) {
+	if current == nil {
+		return false, ""
+	}
+	since := t.Truncate(time.Second)
+	for _, c := range []struct {
+		column string
+		value  *string
+	}{
+		{"last_event_at", current.LastEventAt},
+		{"updated_at", current.UpdatedAt},
+	} {
+		if c.value == nil {
+			continue
+		}
+		if at, ok := parseTimestamp(*c.value); ok && !at.Before(since) {
+			return true, fmt.Sprintf("%s %s is not older than %s", c.column, at.Format(time.RFC3339), since.Format(time.RFC3339))
+		}
+	}
+	return false, ""
+}
+
+// SubscriptionStatusDeleted is stored in iam.fee_contract_subscriptions.status for
+// subscriptions removed in the provider (Asaas keeps status ACTIVE with deleted=true).
+const SubscriptionStatusDeleted = "DELETED"
+
+// StaleSubscriptionEvent is StaleEvent for iam.fee_contract_subscriptions: an event older
+// than last_event_at is stale and, within the same second (or without timestamps),
+// nothing overrides DELETED.
+func StaleSubscriptionEvent(current *model.FeeContractSubscriptionRow, eventAt time.Time, hasEventAt bool, incomingStatus string) (stale bool, reason string) {
+	if current == nil {
+		return false, ""
+	}
+	if hasEventAt && current.LastEventAt != nil {
+		if last, ok := parseTimestamp(*current.LastEventAt); ok {
+			switch {
+			case eventAt.Before(last):
+				return true, fmt.Sprintf("event at %s is older than last applied event at %s",
+					eventAt.Format(time.RFC3339), last.Format(time.RFC3339))
+			case eventAt.After(last):
+				return false, ""
+			}
+		}
+	}
+	if current.Status != nil && *current.Status == SubscriptionStatusDeleted && incomingStatus != SubscriptionStatusDeleted {
+		return true, fmt.Sprintf("subscription already DELETED, ignoring status %s", incomingStatus)
+	}
+	return false, ""
+}
+
+// parseTimestamp parses a timestamptz as returned by PostgREST.
+func parseTimestamp(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999-07", "2006-01-02T15:04:05-07"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,159 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestParseEventTime(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"asaas format is BRT", "2026-03-10 12:00:00", time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), true},
+		{"rfc3339", "2026-03-10T12:00:00Z", time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), true},
+		{"surrounding spaces", "  2026-03-10 12:00:00 ", time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), true},
+		{"empty", "", time.Time{}, false},
+		{"garbage", "10/03/2026", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseEventTime(tt.in)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Fatalf("ParseEventTime(%q) = %s, %v; want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStatusRank(t *testing.T) {
+	tests := []struct {
+		lower, higher string
+	}{
+		{"PENDING", "OVERDUE"},
+		{"OVERDUE", "CONFIRMED"},
+		{"CONFIRMED", "RECEIVED"},
+		{"RECEIVED", "REFUND_REQUESTED"},
+		{"REFUND_IN_PROGRESS", "REFUNDED"},
+	}
+	for _, tt := range tests {
+		if StatusRank(tt.lower) >= StatusRank(tt.higher) {
+			t.Errorf("StatusRank(%s)=%d, want below StatusRank(%s)=%d",
+				tt.lower, StatusRank(tt.lower), tt.higher, StatusRank(tt.higher))
+		}
+	}
+	if StatusRank(" received ") != StatusRank("RECEIVED") {
+		t.Errorf("StatusRank should ignore case and spaces")
+	}
+	if StatusRank("SOMETHING_NEW") != 0 {
+		t.Errorf("unknown status should rank 0")
+	}
+}
+
+func TestStaleEvent(t *testing.T) {
+	last := "2026-03-10T15:00:00+00:00"
+	at := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		current    *model.IamChargeRow
+		eventAt    time.Time
+		hasEventAt bool
+		status     string
+		stale      bool
+	}{
+		{"no stored charge", nil, at, true, "PENDING", false},
+		{"older event", &model.IamChargeRow{Status: strPtr("PENDING"), LastEventAt: &last}, at.Add(-time.Minute), true, "RECEIVED", true},
+		{"newer event lowers status", &model.IamChargeRow{Status: strPtr("RECEIVED"), LastEventAt: &last}, at.Add(time.Minute), true, "PENDING", false},
+		{"same second, lower status", &model.IamChargeRow{Status: strPtr("RECEIVED"), LastEventAt: &last}, at, true, "OVERDUE", true},
+		{"same second, higher status", &model.IamChargeRow{Status: strPtr("PENDING"), LastEventAt: &last}, at, true, "RECEIVED", false},
+		{"same second, same status", &model.IamChargeRow{Status: strPtr("RECEIVED"), LastEventAt: &last}, at, true, "RECEIVED", false},
+		{"no event time, lower status", &model.IamChargeRow{Status: strPtr("REFUNDED"), LastEventAt: &last}, time.Time{}, false, "RECEIVED", true},
+		{"no last_event_at, lower status", &model.IamChargeRow{Status: strPtr("CONFIRMED")}, at, true, "PENDING", true},
+		{"no last_event_at, higher status", &model.IamChargeRow{Status: strPtr("PENDING")}, at, true, "CONFIRMED", false},
+		{"unknown incoming status", &model.IamChargeRow{Status: strPtr("RECEIVED")}, at, true, "SOMETHING_NEW", false},
+		{"no stored status", &model.IamChargeRow{}, at, true, "PENDING", false},
+		{"unparseable last_event_at", &model.IamChargeRow{Status: strPtr("PENDING"), LastEventAt: strPtr("yesterday")}, at, true, "RECEIVED", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stale, reason := StaleEvent(tt.current, tt.eventAt, tt.hasEventAt, tt.status)
+			if stale != tt.stale {
+				t.Fatalf("StaleEvent() = %v (%s), want %v", stale, reason, tt.stale)
+			}
+			if stale && reason == "" {
+				t.Fatalf("StaleEvent() returned no reason for a stale event")
+			}
+		})
+	}
+}
+
+func TestStaleSubscriptionEvent(t *testing.T) {
+	last := "2026-03-10T15:00:00Z"
+	at := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	deleted := SubscriptionStatusDeleted
+
+	tests := []struct {
+		name    string
+		current *model.FeeContractSubscriptionRow
+		eventAt time.Time
+		status  string
+		stale   bool
+	}{
+		{"no stored subscription", nil, at, "ACTIVE", false},
+		{"older event", &model.FeeContractSubscriptionRow{LastEventAt: &last}, at.Add(-time.Second), "ACTIVE", true},
+		{"newer event revives deleted", &model.FeeContractSubscriptionRow{Status: &deleted, LastEventAt: &last}, at.Add(time.Second), "ACTIVE", false},
+		{"same second keeps deleted", &model.FeeContractSubscriptionRow{Status: &deleted, LastEventAt: &last}, at, "ACTIVE", true},
+		{"deleted again", &model.FeeContractSubscriptionRow{Status: &deleted}, at, deleted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if stale, reason := StaleSubscriptionEvent(tt.current, tt.eventAt, true, tt.status); stale != tt.stale {
+				t.Fatalf("StaleSubscriptionEvent() = %v (%s), want %v", stale, reason, tt.stale)
+			}
+		})
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	listedAt := time.Date(2026, 3, 10, 15, 0, 0, 500_000_000, time.UTC)
+
+	tests := []struct {
+		name    string
+		current *model.IamChargeRow
+		changed bool
+	}{
+		{"no stored charge", nil, false},
+		{"never touched by events", &model.IamChargeRow{Status: strPtr("RECEIVED")}, false},
+		{"last event before the listing", &model.IamChargeRow{Status: strPtr("RECEIVED"), LastEventAt: strPtr("2026-03-10T14:59:59Z")}, false},
+		{"last event in the listing's second", &model.IamChargeRow{LastEventAt: strPtr("2026-03-10T15:00:00Z")}, true},
+		{"last event after the listing", &model.IamChargeRow{LastEventAt: strPtr("2026-03-10T15:00:01Z")}, true},
+		{"updated after the listing", &model.IamChargeRow{LastEventAt: strPtr("2026-03-10T14:00:00Z"), UpdatedAt: strPtr("2026-03-10T15:00:02.123456+00:00")}, true},
+		{"updated before the listing", &model.IamChargeRow{UpdatedAt: strPtr("2026-03-10T14:00:00+00:00")}, false},
+		{"unparseable timestamps", &model.IamChargeRow{LastEventAt: strPtr("soon"), UpdatedAt: strPtr("")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, reason := ChangedSince(tt.current, listedAt)
+			if changed != tt.changed {
+				t.Fatalf("ChangedSince() = %v (%s), want %v", changed, reason, tt.changed)
+			}
+		})
+	}
+
+	// The listing wins over precedence for rows that did not change: StaleEvent would keep
+	// RECEIVED_IN_CASH, ChangedSince lets the reconciler lower it to PENDING.
+	row := &model.IamChargeRow{Status: strPtr("RECEIVED_IN_CASH")}
+	if stale, _ := StaleEvent(row, listedAt, true, "PENDING"); !stale {
+		t.Fatalf("StaleEvent should block lowering the status")
+	}
+	if changed, _ := ChangedSince(row, listedAt); changed {
+		t.Fatalf("ChangedSince should let the listing lower the status")
+	}
+}
@@ -0,0 +1,288 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// Rules that pick the billing integration of a request (IntegrationResolution.Rule).
+const (
+	RuleContractIntegration = "contract_billing_integration"  // iam.fee_contracts.billing_integration_id
+	RuleContractEnvironment = "contract_provider_environment" // office + provider + fee_contracts.provider_environment
+	RuleOfficeDefault       = "office_default"                // active integration of the office (is_default first)
+)
+
+// IntegrationRequest is what an endpoint knows about the resource it acts on. The
+// resolver walks charge → contract → integration; the office default is only used
+// when no contract can be found.
+type IntegrationRequest struct {
+	AccountingOfficeID     string
+	Provider               string // empty = contract provider, else DefaultProviderName
+	ContractID             string // explicit contract
+	ProviderChargeID       string // provider payment: iam.charges → contract_id
+	ProviderInstallmentID  string // provider installment plan: its iam.charges rows → contract_id
+	ProviderSubscriptionID string // provider subscription: iam.fee_contract_subscriptions → contract
+	CompanyID              string // company: its latest contract in the office
+}
+
+// IntegrationResolution is the integration picked for a request and why.
+type IntegrationResolution struct {
+	Integration *model.BillingIntegrationRow
+	Provider    string
+	Rule        string                // Rule* constant
+	Contract    *model.FeeContractRow // nil when no contract was found
+	Charge      *model.IamChargeRow   // set when resolved through ProviderChargeID
+	Charges     []model.IamChargeRow  // rows of the plan when ProviderInstallmentID was looked up
+	Trail       []string              // every lookup made, in order
+}
+
+// Explain describes the resolution in one line (logs and error responses).
+func (r *IntegrationResolution) Explain() string {
+	return r.Rule + ": " + strings.Join(r.Trail, "; ")
+}
+
+// IntegrationError is returned when no usable integration was found. Rule is the
+// last rule tried (empty when resolution stopped before any) and Trail the lookups
+// that led to it.
+type IntegrationError struct {
+	Rule  string
+	Trail []string
+}
+
+func (e *IntegrationError) Error() string {
+	if e.Rule == "" {
+		return "billing integration not found: " + strings.Join(e.Trail, "; ")
+	}
+	return fmt.Sprintf("billing integration not found (rule %s: %s)", e.Rule, strings.Join(e.Trail, "; "))
+}
+
+// ResolveIntegration resolves the billing integration of a request. A missing
+// contract or integration is an *IntegrationError; Supabase failures are returned
+// as plain errors. The resolution (lookups made so far) is returned in both cases.
+func ResolveIntegration(ctx context.Context, req IntegrationRequest) (*IntegrationResolution, error) {
+	res := &IntegrationResolution{}
+	officeID := strings.TrimSpace(req.AccountingOfficeID)
+
+	contract, err := findContract(ctx, req, officeID, res)
+	if err != nil {
+		return res, err
+	}
+	if contract != nil {
+		if officeID != "" && contract.AccountingOfficeID != officeID {
+			res.trace("contract %s belongs to office %s, not %s", contract.ID, contract.AccountingOfficeID, officeID)
+			return res, &IntegrationError{Trail: res.Trail}
+		}
+		return res, resolveForContract(ctx, contract, req.Provider, res)
+	}
+
+	if officeID == "" {
+		res.trace("no contract and no accounting_office_id")
+		return res, &IntegrationError{Trail: res.Trail}
+	}
+	res.Provider = providerOr(req.Provider, DefaultProviderName)
+	return res, resolveOfficeDefault(ctx, officeID, res)
+}
+
+// ResolveIntegrationForContract is ResolveIntegration for an already loaded contract.
+func ResolveIntegrationForContract(ctx context.Context, contract *model.FeeContractRow) (*IntegrationResolution, error) {
+	res := &IntegrationResolution{}
+	res.trace("contract %s", contract.ID)
+	return res, resolveForContract(ctx, contract, "", res)
+}
+
+// ResolveContractIntegration resolves the billing integration of a contract:
+// 1) If contract has billing_integration_id → use it directly.
+// 2) Else if contract has provider_environment → match by office + provider + environment.
+// 3) Fallback → default/active integration for the office + provider.
+func ResolveContractIntegration(ctx context.Context, contract *model.FeeContractRow) (*model.BillingIntegrationRow, string, error) {
+	res, err := ResolveIntegrationForContract(ctx, contract)
+	if err != nil {
+		return nil, res.Provider, err
+	}
+	return res.Integration, res.Provider, nil
+}
+
+// findContract follows the request to its contract: explicit contract, then charge,
+// then installment plan, then subscription, then company. Returns (nil, nil) when none is found.
+func findContract(ctx context.Context, req IntegrationRequest, officeID string, res *IntegrationResolution) (*model.FeeContractRow, error) {
+	if id := strings.TrimSpace(req.ContractID); id != "" {
+		contract, err := supabase.GetFeeContractByIDContext(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("load contract %s: %w", id, err)
+		}
+		if contract == nil {
+			res.trace("contract %s not found", id)
+			return nil, &IntegrationError{Trail: res.Trail}
+		}
+		res.trace("contract %s", id)
+		return contract, nil
+	}
+
+	if id := strings.TrimSpace(req.ProviderChargeID); id != "" {
+		providerName := providerOr(req.Provider, DefaultProviderName)
+		var (
+			charge *model.IamChargeRow
+			err    error
+		)
+		// Both lookups fail (not only nil) when the row does not exist.
+		if officeID != "" {
+			charge, err = supabase.GetChargeByProviderIDAndOfficeContext(ctx, providerName, id, officeID)
+		} else {
+			charge, err = supabase.GetChargeByProviderIDContext(ctx, providerName, id)
+		}
+		switch {
+		case err != nil || charge == nil:
+			res.trace("charge %s not in iam.charges", id)
+		case strings.TrimSpace(charge.ContractID) == "":
+			res.Charge = charge
+			res.trace("charge %s has no contract", id)
+		default:
+			res.Charge = charge
+			contract, err := supabase.GetFeeContractByIDContext(ctx, charge.ContractID)
+			if err != nil {
+				return nil, fmt.Errorf("load contract %s: %w", charge.ContractID, err)
+			}
+			if contract != nil {
+				res.trace("charge %s → contract %s", id, contract.ID)
+				return contract, nil
+			}
+			res.trace("charge %s → contract %s not found", id, charge.ContractID)
+		}
+	}
+
+	if id := strings.TrimSpace(req.ProviderInstallmentID); id != "" {
+		providerName := providerOr(req.Provider, DefaultProviderName)
+		charges, err := supabase.ListChargesByInstallmentIDContext(ctx, providerName, id, officeID)
+		if err != nil {
+			return nil, fmt.Errorf("load charges of installment %s: %w", id, err)
+		}
+		res.Charges = charges
+		contractID := ""
+		for _, c := range charges {
+			if contractID = strings.TrimSpace(c.ContractID); contractID != "" {
+				break
+			}
+		}
+		switch {
+		case len(charges) == 0:
+			res.trace("installment %s not in iam.charges", id)
+		case contractID == "":
+			res.trace("installment %s has no contract", id)
+		default:
+			contract, err := supabase.GetFeeContractByIDContext(ctx, contractID)
+			if err != nil {
+				return nil, fmt.Errorf("load contract %s: %w", contractID, err)
+			}
+			if contract != nil {
+				res.trace("installment %s → contract %s", id, contract.ID)
+				return contract, nil
+			}
+			res.trace("installment %s → contract %s not found", id, contractID)
+		}
+	}
+
+	if id := strings.TrimSpace(req.ProviderSubscriptionID); id != "" {
+		// Only a missing link falls through to the company rule: on a lookup error the
+		// fallback could pick another account of the office (HML instead of PRD).
+		link, err := supabase.GetFeeContractSubscriptionContext(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("load subscription %s: %w", id, err)
+		}
+		switch {
+		case link == nil || strings.TrimSpace(link.ContractID) == "":
+			res.trace("subscription %s not linked to a contract", id)
+		default:
+			contract, err := supabase.GetFeeContractByIDContext(ctx, link.ContractID)
+			if err != nil {
+				return nil, fmt.Errorf("load contract %s: %w", link.ContractID, err)
+			}
+			if contract != nil {
+				res.trace("subscription %s → contract %s", id, contract.ID)
+				return contract, nil
+			}
+			res.trace("subscription %s → contract %s not found", id, link.ContractID)
+		}
+	}
+
+	if id := strings.TrimSpace(req.CompanyID); id != "" && officeID != "" {
+		contract, err := supabase.GetLatestFeeContractForCompanyContext(ctx, id, officeID)
+		if err != nil {
+			return nil, fmt.Errorf("load contracts of company %s: %w", id, err)
+		}
+		if contract != nil {
+			res.trace("company %s → contract %s", id, contract.ID)
+			return contract, nil
+		}
+		res.trace("company %s has no contract in the office", id)
+	}
+	return nil, nil
+}
+
+// resolveForContract applies the contract rules (billing_integration_id, then
+// provider_environment, then office default).
+func resolveForContract(ctx context.Context, contract *model.FeeContractRow, providerName string, res *IntegrationResolution) error {
+	res.Contract = contract
+	contractProvider := ""
+	if contract.Provider != nil {
+		contractProvider = *contract.Provider
+	}
+	res.Provider = providerOr(contractProvider, providerOr(providerName, DefaultProviderName))
+
+	if contract.BillingIntegrationID != nil && strings.TrimSpace(*contract.BillingIntegrationID) != "" {
+		id := strings.TrimSpace(*contract.BillingIntegrationID)
+		res.Rule = RuleContractIntegration
+		cfg, err := supabase.GetBillingIntegrationByIDContext(ctx, id)
+		if err != nil || cfg == nil {
+			res.trace("billing_integration_id %s not found", id)
+			return &IntegrationError{Rule: res.Rule, Trail: res.Trail}
+		}
+		if cfg.AccountingOfficeID != contract.AccountingOfficeID {
+			res.trace("billing_integration_id %s belongs to office %s", id, cfg.AccountingOfficeID)
+			return &IntegrationError{Rule: res.Rule, Trail: res.Trail}
+		}
+		res.trace("billing_integration_id %s (%s)", id, cfg.Environment)
+		res.Integration = cfg
+		return nil
+	}
+
+	if contract.ProviderEnvironment != nil && strings.TrimSpace(*contract.ProviderEnvironment) != "" {
+		env := strings.TrimSpace(*contract.ProviderEnvironment)
+		cfg, err := supabase.GetBillingIntegrationForOfficeAndEnvironmentContext(ctx, contract.AccountingOfficeID, res.Provider, env)
+		if err == nil && cfg != nil {
+			res.Rule = RuleContractEnvironment
+			res.trace("%s %s integration %s", res.Provider, env, cfg.ID)
+			res.Integration = cfg
+			return nil
+		}
+		res.trace("no active %s integration for environment %s, falling back to office default", res.Provider, env)
+	}
+	return resolveOfficeDefault(ctx, contract.AccountingOfficeID, res)
+}
+
+func resolveOfficeDefault(ctx context.Context, officeID string, res *IntegrationResolution) error {
+	res.Rule = RuleOfficeDefault
+	cfg, err := supabase.GetBillingIntegrationForOfficeContext(ctx, officeID, res.Provider)
+	if err != nil || cfg == nil {
+		res.trace("no active %s integration for office %s", res.Provider, officeID)
+		return &IntegrationError{Rule: res.Rule, Trail: res.Trail}
+	}
+	res.trace("office %s default %s integration %s (%s)", officeID, res.Provider, cfg.ID, cfg.Environment)
+	res.Integration = cfg
+	return nil
+}
+
+func (r *IntegrationResolution) trace(format string, args ...any) {
+	r.Trail = append(r.Trail, fmt.Sprintf(format, args...))
+}
+
+func providerOr(name, def string) string {
+	if strings.TrimSpace(name) == "" {
+		return def
+	}
+	return provider.NormalizeName(name)
+}
@@ -0,0 +1,304 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// fakeIAM is a read-only stand-in for the iam tables read by ResolveIntegration:
+// eq and ilike filters, is_default ordering and single-object responses.
+type fakeIAM struct {
+	tables  map[string][]map[string]any
+	failing map[string]bool // tables answering 500
+}
+
+func newFakeIAM(t *testing.T, f *fakeIAM) {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	t.Setenv("SUPABASE_URL", srv.URL)
+	t.Setenv("SUPABASE_KEY", "test-key")
+	supabase.InitClient()
+}
+
+func (f *fakeIAM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	table := strings.TrimPrefix(r.URL.Path, "/rest/v1/")
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if f.failing[table] {
+		http.Error(w, `{"code":"57014","message":"canceling statement due to statement timeout"}`, http.StatusInternalServerError)
+		return
+	}
+	q := r.URL.Query()
+	var rows []map[string]any
+	for _, row := range f.tables[table] {
+		if matchesIAMFilters(row, q) {
+			rows = append(rows, row)
+		}
+	}
+	if strings.Contains(q.Get("order"), "is_default.desc") {
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i]["is_default"] == true && rows[j]["is_default"] != true })
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if strings.Contains(r.Header.Get("Accept"), "vnd.pgrst.object") {
+		if len(rows) != 1 {
+			w.WriteHeader(http.StatusNotAcceptable)
+			_, _ = fmt.Fprintf(w, `{"code":"PGRST116","message":"JSON object requested, multiple (or no) rows returned","details":"%d rows"}`, len(rows))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(rows[0])
+		return
+	}
+	if rows == nil {
+		rows = []map[string]any{}
+	}
+	_ = json.NewEncoder(w).Encode(rows)
+}
+
+func matchesIAMFilters(row map[string]any, q url.Values) bool {
+	for col, values := range q {
+		switch col {
+		case "select", "limit", "order", "offset":
+			continue
+		}
+		for _, v := range values {
+			op, want, _ := strings.Cut(v, ".")
+			got, ok := row[col]
+			if !ok || got == nil {
+				return false
+			}
+			switch op {
+			case "eq":
+				if fmt.Sprint(got) != want {
+					return false
+				}
+			case "ilike":
+				if !strings.EqualFold(fmt.Sprint(got), want) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestResolveIntegration(t *testing.T) {
+	iam := &fakeIAM{tables: map[string][]map[string]any{
+		"billing_integrations": {
+			{"id": "int-hml", "accounting_office_id": "o1", "provider": "asaas", "environment": "SANDBOX", "is_active": true, "is_default": false},
+			{"id": "int-prd", "accounting_office_id": "o1", "provider": "ASAAS", "environment": "PRODUCTION", "is_active": true, "is_default": true},
+			{"id": "int-old", "accounting_office_id": "o1", "provider": "ASAAS", "environment": "PRODUCTION", "is_active": false, "is_default": false},
+			{"id": "int-o2", "accounting_office_id": "o2", "provider": "ASAAS", "environment": "PRODUCTION", "is_active": true, "is_default": true},
+		},
+		"fee_contracts": {
+			{"id": "c-bound", "accounting_office_id": "o1", "company_id": "co1", "billing_integration_id": "int-hml"},
+			{"id": "c-foreign", "accounting_office_id": "o1", "company_id": "co1", "billing_integration_id": "int-o2"},
+			{"id": "c-inactive", "accounting_office_id": "o1", "company_id": "co1", "billing_integration_id": "int-old"},
+			{"id": "c-sandbox", "accounting_office_id": "o1", "company_id": "co1", "provider_environment": "SANDBOX"},
+			{"id": "c-staging", "accounting_office_id": "o1", "company_id": "co1", "provider_environment": "STAGING"},
+			{"id": "c-plain", "accounting_office_id": "o1", "company_id": "co2"},
+			{"id": "c-o3", "accounting_office_id": "o3", "company_id": "co3"},
+		},
+		"charges": {
+			{"provider": "ASAAS", "provider_charge_id": "pay_bound", "accounting_office_id": "o1", "contract_id": "c-bound"},
+			{"provider": "ASAAS", "provider_charge_id": "pay_loose", "accounting_office_id": "o1", "contract_id": ""},
+			{"provider": "ASAAS", "provider_charge_id": "pay_orphan", "accounting_office_id": "o1", "contract_id": "c-deleted"},
+			{"provider": "ASAAS", "provider_charge_id": "pay_i1", "provider_installment_id": "ins_1", "accounting_office_id": "o1", "contract_id": ""},
+			{"provider": "ASAAS", "provider_charge_id": "pay_i2", "provider_installment_id": "ins_1", "accounting_office_id": "o1", "contract_id": "c-sandbox"},
+		},
+		"fee_contract_subscriptions": {
+			{"provider": "ASAAS", "provider_subscription_id": "sub_1", "contract_id": "c-sandbox"},
+			{"provider": "ASAAS", "provider_subscription_id": "sub_unlinked", "contract_id": ""},
+		},
+	}}
+	newFakeIAM(t, iam)
+
+	tests := []struct {
+		name            string
+		req             IntegrationRequest
+		wantIntegration string
+		wantRule        string
+		wantContract    string
+		wantErr         bool // *IntegrationError
+	}{
+		{
+			name:            "contract billing_integration_id",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-bound"},
+			wantIntegration: "int-hml", wantRule: RuleContractIntegration, wantContract: "c-bound",
+		},
+		{
+			name:     "contract integration of another office",
+			req:      IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-foreign"},
+			wantRule: RuleContractIntegration, wantContract: "c-foreign", wantErr: true,
+		},
+		{
+			name:     "inactive contract integration does not fall back",
+			req:      IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-inactive"},
+			wantRule: RuleContractIntegration, wantContract: "c-inactive", wantErr: true,
+		},
+		{
+			name:            "contract provider_environment",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-sandbox"},
+			wantIntegration: "int-hml", wantRule: RuleContractEnvironment, wantContract: "c-sandbox",
+		},
+		{
+			name:            "environment without integration falls back to the office default",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-staging"},
+			wantIntegration: "int-prd", wantRule: RuleOfficeDefault, wantContract: "c-staging",
+		},
+		{
+			name:            "contract without integration or environment",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-plain"},
+			wantIntegration: "int-prd", wantRule: RuleOfficeDefault, wantContract: "c-plain",
+		},
+		{
+			name:    "contract not found",
+			req:     IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-missing", CompanyID: "co2"},
+			wantErr: true,
+		},
+		{
+			name:         "contract of another office",
+			req:          IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-o3"},
+			wantContract: "", wantErr: true,
+		},
+		{
+			name:            "explicit contract wins over the charge",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c-plain", ProviderChargeID: "pay_bound"},
+			wantIntegration: "int-prd", wantRule: RuleOfficeDefault, wantContract: "c-plain",
+		},
+		{
+			name:            "charge → contract wins over the company",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ProviderChargeID: "pay_bound", CompanyID: "co2"},
+			wantIntegration: "int-hml", wantRule: RuleContractIntegration, wantContract: "c-bound",
+		},
+		{
+			name:            "charge looked up without an office",
+			req:             IntegrationRequest{ProviderChargeID: "pay_bound"},
+			wantIntegration: "int-hml", wantRule: RuleContractIntegration, wantContract: "c-bound",
+		},
+		{
+			name:            "charge without contract falls through to the installment",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ProviderChargeID: "pay_loose", ProviderInstallmentID: "ins_1"},
+			wantIntegration: "int-hml", wantRule: RuleContractEnvironment, wantContract: "c-sandbox",
+		},
+		{
+			name:            "charge with a deleted contract falls through to the subscription",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ProviderChargeID: "pay_orphan", ProviderSubscriptionID: "sub_1"},
+			wantIntegration: "int-hml", wantRule: RuleContractEnvironment, wantContract: "c-sandbox",
+		},
+		{
+			name:            "unknown charge falls through to the company",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ProviderChargeID: "pay_missing", CompanyID: "co2"},
+			wantIntegration: "int-prd", wantRule: RuleOfficeDefault, wantContract: "c-plain",
+		},
+		{
+			name:            "installment uses the first row with a contract",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ProviderInstallmentID: "ins_1"},
+			wantIntegration: "int-hml", wantRule: RuleContractEnvironment, wantContract: "c-sandbox",
+		},
+		{
+			name:            "unlinked subscription falls through to the company",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", ProviderSubscriptionID: "sub_unlinked", CompanyID: "co2"},
+			wantIntegration: "int-prd", wantRule: RuleOfficeDefault, wantContract: "c-plain",
+		},
+		{
+			name:            "company without contract uses the office default",
+			req:             IntegrationRequest{AccountingOfficeID: "o1", CompanyID: "co9"},
+			wantIntegration: "int-prd", wantRule: RuleOfficeDefault,
+		},
+		{
+			name:     "office without an active integration",
+			req:      IntegrationRequest{AccountingOfficeID: "o3"},
+			wantRule: RuleOfficeDefault, wantErr: true,
+		},
+		{
+			name:    "nothing to resolve from",
+			req:     IntegrationRequest{CompanyID: "co2"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ResolveIntegration(context.Background(), tt.req)
+			if res == nil {
+				t.Fatal("ResolveIntegration() returned no resolution")
+			}
+			var ie *IntegrationError
+			if tt.wantErr {
+				if !errors.As(err, &ie) {
+					t.Fatalf("ResolveIntegration() error = %v, want *IntegrationError (%s)", err, res.Explain())
+				}
+				if ie.Rule != tt.wantRule {
+					t.Fatalf("IntegrationError.Rule = %q, want %q (%s)", ie.Rule, tt.wantRule, err)
+				}
+			} else if err != nil {
+				t.Fatalf("ResolveIntegration() error = %v", err)
+			}
+
+			gotIntegration := ""
+			if res.Integration != nil {
+				gotIntegration = res.Integration.ID
+			}
+			gotContract := ""
+			if res.Contract != nil {
+				gotContract = res.Contract.ID
+			}
+			if gotIntegration != tt.wantIntegration || gotContract != tt.wantContract || (!tt.wantErr && res.Rule != tt.wantRule) {
+				t.Fatalf("resolved integration %q, contract %q, rule %q; want %q, %q, %q (%s)",
+					gotIntegration, gotContract, res.Rule, tt.wantIntegration, tt.wantContract, tt.wantRule, res.Explain())
+			}
+			if !tt.wantErr && res.Provider != DefaultProviderName {
+				t.Fatalf("Provider = %q, want %q", res.Provider, DefaultProviderName)
+			}
+		})
+	}
+}
+
+func TestResolveIntegrationLookupErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing string
+		req     IntegrationRequest
+	}{
+		// The company rule could pick another account of the office.
+		{"subscription lookup", "fee_contract_subscriptions", IntegrationRequest{AccountingOfficeID: "o1", ProviderSubscriptionID: "sub_1", CompanyID: "co1"}},
+		{"contract lookup", "fee_contracts", IntegrationRequest{AccountingOfficeID: "o1", ContractID: "c1"}},
+		{"installment lookup", "charges", IntegrationRequest{AccountingOfficeID: "o1", ProviderInstallmentID: "ins_1", CompanyID: "co1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeIAM(t, &fakeIAM{
+				tables: map[string][]map[string]any{
+					"fee_contracts": {{"id": "c1", "accounting_office_id": "o1", "company_id": "co1"}},
+					"billing_integrations": {
+						{"id": "int-prd", "accounting_office_id": "o1", "provider": "ASAAS", "environment": "PRODUCTION", "is_active": true, "is_default": true},
+					},
+				},
+				failing: map[string]bool{tt.failing: true},
+			})
+			res, err := ResolveIntegration(context.Background(), tt.req)
+			var ie *IntegrationError
+			if err == nil || errors.As(err, &ie) {
+				t.Fatalf("ResolveIntegration() error = %v, want a plain lookup error", err)
+			}
+			if res.Integration != nil {
+				t.Fatalf("resolved %s after a failed lookup (%s)", res.Integration.ID, res.Explain())
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package billing
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// ChargeLocks serializes read → compare → write of the same charge inside this instance
+// (key "provider:payment_id"): webhooks, API writes and the reconciler all take it.
+var ChargeLocks StripedLock
+
+// StripedLock is a fixed set of mutexes picked by key hash: same key → same mutex,
+// without keeping one entry per key forever. The zero value is ready to use.
+type StripedLock [64]sync.Mutex
+
+// Lock locks the mutex of key and returns its unlock.
+func (l *StripedLock) Lock(key string) (unlock func()) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	mu := &l[h.Sum32()%uint32(len(l))]
+	mu.Lock()
+	return mu.Unlock
+}
@@ -0,0 +1,234 @@
+package billing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// Service item billing types (iam.fee_contract_service_items.billing_type).
+const (
+	ItemRecurring = "RECURRING"
+	ItemOneTime   = "ONE_TIME"
+)
+
+// periodicityCycles maps service item periodicity to the subscription cycle.
+// SPORADIC items have no cycle and are never billed automatically.
+var periodicityCycles = map[string]string{
+	"MONTHLY":    "MONTHLY",
+	"BIMONTHLY":  "BIMONTHLY",
+	"QUARTERLY":  "QUARTERLY",
+	"SEMIANNUAL": "SEMIANNUALLY",
+	"ANNUAL":     "YEARLY",
+}
+
+// Plan is what a contract's service items should generate in the provider.
+// It is pure data: building it never touches Supabase or the provider.
+type Plan struct {
+	Subscriptions []model.BillingRunItem
+	Charges       []model.BillingRunItem
+	Skipped       []model.BillingRunSkipped
+}
+
+// RecurringExternalReference identifies the subscription of a RECURRING group.
+// Keeps the "fee_contract:{uuid}:..." prefix so webhooks can resolve the contract.
+func RecurringExternalReference(contractID, periodicity string, dueDay int32, paymentMethod string) string {
+	return fmt.Sprintf("fee_contract:%s:recurring:%s:%d:%s", contractID, periodicity, dueDay, paymentMethod)
+}
+
+// SubscriptionExternalReference is the default external reference of a subscription created
+// through POST /v1/subscriptions (same "fee_contract:{uuid}:..." prefix).
+func SubscriptionExternalReference(contractID, cycle, paymentMethod string) string {
+	return fmt.Sprintf("fee_contract:%s:subscription:%s:%s", contractID, cycle, paymentMethod)
+}
+
+// OneTimeExternalReference identifies the charge of a ONE_TIME service item.
+func OneTimeExternalReference(contractID, itemID string) string {
+	return fmt.Sprintf("fee_contract:%s:service_item:%s", contractID, itemID)
+}
+
+// BuildPlan groups RECURRING items by (periodicity, due_day, payment_method) into one
+// subscription each and turns every ONE_TIME item into a one-off charge.
+// today is the first acceptable due date.
+func BuildPlan(contract *model.FeeContractRow, items []model.FeeContractServiceItemRow, today time.Time) Plan {
+	today = dateOnly(today)
+	plan := Plan{
+		Subscriptions: []model.BillingRunItem{},
+		Charges:       []model.BillingRunItem{},
+		Skipped:       []model.BillingRunSkipped{},
+	}
+	skip := func(it model.FeeContractServiceItemRow, reason string) {
+		plan.Skipped = append(plan.Skipped, model.BillingRunSkipped{ServiceItemID: it.ID, Name: it.Name, Reason: reason})
+	}
+
+	type group struct {
+		item  model.BillingRunItem
+		names []string
+	}
+	groups := map[string]*group{}
+	var order []string
+
+	for _, it := range items {
+		if it.FinalAmount <= 0 {
+			skip(it, "final_amount must be > 0")
+			continue
+		}
+		method := paymentMethod(it.PaymentMethod)
+		start := startDate(contract, it, today)
+
+		switch strings.ToUpper(strings.TrimSpace(it.BillingType)) {
+		case ItemRecurring:
+			periodicity := ""
+			if it.Periodicity != nil {
+				periodicity = strings.ToUpper(strings.TrimSpace(*it.Periodicity))
+			}
+			cycle, ok := periodicityCycles[periodicity]
+			if !ok {
+				skip(it, fmt.Sprintf("periodicity %q is not billed automatically", periodicity))
+				continue
+			}
+			dueDay := dueDayOf(contract, it)
+			if dueDay == 0 {
+				skip(it, "due_day is required for recurring items (or contract start_date)")
+				continue
+			}
+
+			key := RecurringExternalReference(contract.ID, periodicity, dueDay, method)
+			g, ok := groups[key]
+			if !ok {
+				g = &group{item: model.BillingRunItem{
+					ExternalReference: key,
+					Action:            model.BillingRunActionPlanned,
+					PaymentMethod:     method,
+					Cycle:             cycle,
+					DueDate:           nextDueDate(start, dueDay).Format("2006-01-02"),
+				}}
+				groups[key] = g
+				order = append(order, key)
+			} else if d := nextDueDate(start, dueDay).Format("2006-01-02"); d < g.item.DueDate {
+				g.item.DueDate = d
+			}
+			g.item.Value = roundCents(g.item.Value + it.FinalAmount)
+			g.item.ServiceItemIDs = append(g.item.ServiceItemIDs, it.ID)
+			g.names = append(g.names, it.Name)
+
+		case ItemOneTime:
+			// Future start date wins; otherwise the next due_day (or today when there is none).
+			due := start
+			if !start.After(today) && it.DueDay != nil && *it.DueDay >= 1 && *it.DueDay <= 31 {
+				due = nextDueDate(today, *it.DueDay)
+			}
+			plan.Charges = append(plan.Charges, model.BillingRunItem{
+				ExternalReference: OneTimeExternalReference(contract.ID, it.ID),
+				Action:            model.BillingRunActionPlanned,
+				ServiceItemIDs:    []string{it.ID},
+				PaymentMethod:     method,
+				Value:             roundCents(it.FinalAmount),
+				DueDate:           due.Format("2006-01-02"),
+				Description:       describe(contract, []string{it.Name}),
+			})
+
+		default:
+			skip(it, fmt.Sprintf("unknown billing_type %q", it.BillingType))
+		}
+	}
+
+	sort.Strings(order)
+	for _, key := range order {
+		g := groups[key]
+		g.item.Description = describe(contract, g.names)
+		plan.Subscriptions = append(plan.Subscriptions, g.item)
+	}
+	return plan
+}
+
+func paymentMethod(m *string) string {
+	if m == nil {
+		return string(model.ChargePaymentMethodUndefined)
+	}
+	switch v := model.ChargePaymentMethod(strings.ToUpper(strings.TrimSpace(*m))); v {
+	case model.ChargePaymentMethodBoleto, model.ChargePaymentMethodPix, model.ChargePaymentMethodCreditCard:
+		return string(v)
+	}
+	return string(model.ChargePaymentMethodUndefined)
+}
+
+// dueDayOf returns the item's due_day, falling back to the day of the contract start_date.
+func dueDayOf(contract *model.FeeContractRow, it model.FeeContractServiceItemRow) int32 {
+	if it.DueDay != nil && *it.DueDay >= 1 && *it.DueDay <= 31 {
+		return *it.DueDay
+	}
+	if d, ok := parseDate(contract.StartDate); ok {
+		return int32(d.Day())
+	}
+	return 0
+}
+
+// startDate is the first date an item may be billed: item start_date, else contract
+// start_date, never before today.
+func startDate(contract *model.FeeContractRow, it model.FeeContractServiceItemRow, today time.Time) time.Time {
+	start := today
+	if d, ok := parseDate(it.StartDate); ok {
+		if d.After(start) {
+			start = d
+		}
+	} else if d, ok := parseDate(contract.StartDate); ok && d.After(start) {
+		start = d
+	}
+	return start
+}
+
+// nextDueDate returns the first date >= from that falls on dueDay (clamped to the month length).
+func nextDueDate(from time.Time, dueDay int32) time.Time {
+	from = dateOnly(from)
+	y, m, _ := from.Date()
+	for i := 0; i < 2; i++ {
+		d := time.Date(y, m+time.Month(i), 1, 0, 0, 0, 0, from.Location())
+		last := d.AddDate(0, 1, -1).Day()
+		day := int(dueDay)
+		if day > last {
+			day = last
+		}
+		d = d.AddDate(0, 0, day-1)
+		if !d.Before(from) {
+			return d
+		}
+	}
+	return from
+}
+
+func parseDate(s *string) (time.Time, bool) {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return time.Time{}, false
+	}
+	v := strings.TrimSpace(*s)
+	if len(v) > 10 {
+		v = v[:10]
+	}
+	d, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func describe(contract *model.FeeContractRow, names []string) string {
+	desc := strings.Join(names, ", ")
+	if n := strings.TrimSpace(contract.ContractNumber); n != "" {
+		desc = "Contrato " + n + " - " + desc
+	}
+	return desc
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
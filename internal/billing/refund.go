@@ -0,0 +1,63 @@
+package billing
+
+import (
+	"math"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// refundableStatuses are the provider statuses of a charge that can still be refunded.
+// A partial refund keeps the charge RECEIVED/CONFIRMED, so further partial refunds are allowed.
+var refundableStatuses = map[string]bool{
+	"RECEIVED":  true,
+	"CONFIRMED": true,
+}
+
+// RefundableStatus reports whether a charge with this provider status can be refunded.
+func RefundableStatus(status *string) bool {
+	return status != nil && refundableStatuses[strings.ToUpper(strings.TrimSpace(*status))]
+}
+
+// RefundBalance is how much of a charge can still be refunded.
+type RefundBalance struct {
+	Limit      float64 // net_value when known (the amount actually received), else value
+	Refunded   float64 // refunded_value (sum of iam.charges.refunds)
+	Refundable float64 // Limit - Refunded, never negative
+}
+
+// RefundBalanceOf computes the refund balance of a charge, rounded to cents.
+func RefundBalanceOf(row *model.IamChargeRow) RefundBalance {
+	limit := row.Value
+	if row.NetValue != nil && *row.NetValue > 0 && *row.NetValue < limit {
+		limit = *row.NetValue
+	}
+	refunded := 0.0
+	if row.RefundedValue != nil {
+		refunded = *row.RefundedValue
+	}
+	b := RefundBalance{Limit: roundCents(limit), Refunded: roundCents(refunded)}
+	b.Refundable = math.Max(0, roundCents(b.Limit-b.Refunded))
+	return b
+}
+
+// AppendRefund adds refund to the charge history and updates refunded_value.
+func AppendRefund(row *model.IamChargeRow, refund model.ChargeRefund) {
+	refunded := refund.Value
+	if row.RefundedValue != nil {
+		refunded += *row.RefundedValue
+	}
+	refunded = roundCents(refunded)
+	row.RefundedValue = &refunded
+	row.Refunds = append(row.Refunds, refund)
+}
+
+// RefundedStatus reports whether a charge with this provider status was refunded or has a
+// refund underway.
+func RefundedStatus(status string) bool {
+	switch model.AsaasPaymentStatus(strings.ToUpper(strings.TrimSpace(status))) {
+	case model.AsaasPaymentStatusRefunded, model.AsaasPaymentStatusRefundRequested, model.AsaasPaymentStatusRefundInProgress:
+		return true
+	}
+	return false
+}
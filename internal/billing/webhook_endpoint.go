@@ -0,0 +1,290 @@
+package billing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// ErrIntegrationNotFound is returned when a billing integration does not exist or is inactive.
+var ErrIntegrationNotFound = errors.New("billing integration not found")
+
+// ErrWebhookEmailRequired is returned when a webhook must be created and no notification email was given.
+var ErrWebhookEmailRequired = errors.New("email is required to create the webhook (or set ASAAS_WEBHOOK_EMAIL)")
+
+// WebhookEndpointName is the name of the webhook charges-service registers in the provider.
+const WebhookEndpointName = "charges-service"
+
+// WebhookEvents are the events charges-service subscribes to.
+var WebhookEvents = []string{
+	model.EventPaymentCreated,
+	model.EventPaymentUpdated,
+	model.EventPaymentConfirmed,
+	model.EventPaymentReceived,
+	model.EventPaymentOverdue,
+	model.EventPaymentDeleted,
+	model.EventPaymentRestored,
+	model.EventPaymentRefunded,
+	model.EventPaymentReceivedInCash,
+	model.EventPaymentReceivedInCashUndone,
+	model.EventPaymentChargebackRequested,
+	model.EventPaymentChargebackDispute,
+	model.EventPaymentAwaitingChargeback,
+	model.EventPaymentDunningReceived,
+	model.EventPaymentDunningRequested,
+	model.EventPaymentBankSlipViewed,
+	model.EventPaymentCheckoutViewed,
+	model.EventSubscriptionCreated,
+	model.EventSubscriptionUpdated,
+	model.EventSubscriptionInactivated,
+	model.EventSubscriptionDeleted,
+}
+
+// WebhookPath is the per-integration webhook path served by this service.
+func WebhookPath(integrationID string) string {
+	return "/asaas/feecharges/" + integrationID
+}
+
+// ProvisionWebhook makes the provider webhook of a billing integration match what
+// charges-service expects: URL {base}/asaas/feecharges/{integration_id}, WebhookEvents,
+// sequential delivery, enabled, and the integration's webhook_secret as auth token
+// (generated and stored when missing).
+//
+// The existing webhook is found by iam.billing_integrations.provider_webhook_id, then by
+// the expected URL, then by the shared /asaas/feecharges URL (migrated to the per-integration
+// one). An interrupted queue is only reported unless req.Resume is set.
+// With req.DryRun nothing changes in the provider or in the database.
+func ProvisionWebhook(ctx context.Context, integrationID string, req model.WebhookProvisionRequest) (*model.WebhookProvisionResult, error) {
+	integration, err := supabase.GetBillingIntegrationByIDContext(ctx, integrationID)
+	if err != nil || integration == nil {
+		return nil, ErrIntegrationNotFound
+	}
+	p, err := provider.NewContext(ctx, integration)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimRight(strings.TrimSpace(req.BaseURL), "/")
+	if baseURL == "" {
+		return nil, errors.New("base_url is required")
+	}
+	result := &model.WebhookProvisionResult{
+		IntegrationID: integration.ID,
+		Provider:      p.Name(),
+		DryRun:        req.DryRun,
+		ExpectedURL:   baseURL + WebhookPath(integration.ID),
+	}
+
+	current, err := findWebhookEndpoint(p, integration, baseURL, result.ExpectedURL)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := ""
+	if integration.WebhookSecret != nil {
+		secret = strings.TrimSpace(*integration.WebhookSecret)
+	}
+	newSecret := secret == ""
+	if newSecret {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+		result.Changes = append(result.Changes, "webhook_secret")
+	}
+
+	want := provider.WebhookEndpointInput{
+		Name:      WebhookEndpointName,
+		URL:       result.ExpectedURL,
+		Email:     strings.TrimSpace(req.Email),
+		Enabled:   boolPtr(true),
+		AuthToken: &secret,
+		SendType:  "SEQUENTIALLY",
+		Events:    WebhookEvents,
+	}
+
+	if current == nil {
+		if want.Email == "" {
+			return nil, ErrWebhookEmailRequired
+		}
+		result.Action = model.WebhookProvisionWouldCreate
+		if req.DryRun {
+			return result, nil
+		}
+		created, resp, err := p.CreateWebhookEndpoint(want)
+		if err != nil || !resp.OK() || created == nil {
+			return nil, providerError("create webhook", err, resp)
+		}
+		result.Action = model.WebhookProvisionCreated
+		result.Webhook = webhookStatus(created)
+		log.Printf("[billing] webhook created: integration_id=%s webhook=%s url=%s", integration.ID, created.ID, created.URL)
+		return result, saveWebhookSettings(ctx, integration, secret, newSecret, created.ID)
+	}
+
+	result.Webhook = webhookStatus(current)
+	result.Interrupted = current.Interrupted
+	result.Penalized = current.PenalizedRequests > 0
+	result.Changes = append(result.Changes, webhookDrift(current, want)...)
+	if want.Email == "" {
+		want.Email = current.Email
+	}
+	if result.Interrupted {
+		if req.Resume {
+			want.Interrupted = boolPtr(false)
+			result.Changes = append(result.Changes, "interrupted")
+		} else {
+			result.Warnings = append(result.Warnings, "provider interrupted the webhook queue; fix the cause and call again with resume=true")
+		}
+	}
+	if result.Penalized {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("provider is holding back %d penalized deliveries", current.PenalizedRequests))
+	}
+
+	if len(result.Changes) == 0 && !req.Force {
+		result.Action = model.WebhookProvisionUnchanged
+		if !req.DryRun && !sameString(integration.ProviderWebhookID, current.ID) {
+			return result, saveWebhookSettings(ctx, integration, secret, false, current.ID)
+		}
+		return result, nil
+	}
+
+	result.Action = model.WebhookProvisionWouldUpdate
+	if req.DryRun {
+		return result, nil
+	}
+	updated, resp, err := p.UpdateWebhookEndpoint(current.ID, want)
+	if err != nil || !resp.OK() || updated == nil {
+		return nil, providerError("update webhook", err, resp)
+	}
+	result.Action = model.WebhookProvisionUpdated
+	result.Webhook = webhookStatus(updated)
+	result.Interrupted = updated.Interrupted
+	result.Resumed = current.Interrupted && !updated.Interrupted
+	log.Printf("[billing] webhook updated: integration_id=%s webhook=%s changes=%v resumed=%v",
+		integration.ID, updated.ID, result.Changes, result.Resumed)
+	return result, saveWebhookSettings(ctx, integration, secret, newSecret, updated.ID)
+}
+
+// findWebhookEndpoint returns the provider webhook that belongs to the integration (nil when none).
+func findWebhookEndpoint(p provider.ChargeProvider, integration *model.BillingIntegrationRow, baseURL, expectedURL string) (*provider.WebhookEndpoint, error) {
+	if integration.ProviderWebhookID != nil && strings.TrimSpace(*integration.ProviderWebhookID) != "" {
+		wh, resp, err := p.GetWebhookEndpoint(strings.TrimSpace(*integration.ProviderWebhookID))
+		if err != nil {
+			return nil, providerError("get webhook", err, resp)
+		}
+		if wh != nil {
+			return wh, nil
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			return nil, providerError("get webhook", nil, resp)
+		}
+		// Deleted in the provider: fall back to the URL lookup.
+	}
+
+	list, resp, err := p.ListWebhookEndpoints()
+	if err != nil || !resp.OK() {
+		return nil, providerError("list webhooks", err, resp)
+	}
+	sharedURL := baseURL + "/asaas/feecharges"
+	var shared *provider.WebhookEndpoint
+	for i := range list {
+		switch strings.TrimRight(list[i].URL, "/") {
+		case expectedURL:
+			return &list[i], nil
+		case sharedURL:
+			shared = &list[i]
+		}
+	}
+	return shared, nil
+}
+
+// webhookDrift lists the fields of current that differ from want.
+func webhookDrift(current *provider.WebhookEndpoint, want provider.WebhookEndpointInput) []string {
+	var changes []string
+	if strings.TrimRight(current.URL, "/") != want.URL {
+		changes = append(changes, "url")
+	}
+	if !current.Enabled {
+		changes = append(changes, "enabled")
+	}
+	if !strings.EqualFold(current.SendType, want.SendType) {
+		changes = append(changes, "send_type")
+	}
+	if !current.HasAuthToken {
+		changes = append(changes, "auth_token")
+	}
+	if want.Email != "" && !strings.EqualFold(current.Email, want.Email) {
+		changes = append(changes, "email")
+	}
+	have := make(map[string]bool, len(current.Events))
+	for _, e := range current.Events {
+		have[e] = true
+	}
+	for _, e := range want.Events {
+		if !have[e] {
+			changes = append(changes, "events")
+			break
+		}
+	}
+	return changes
+}
+
+// saveWebhookSettings records the webhook id (and a newly generated secret) on the integration.
+func saveWebhookSettings(ctx context.Context, integration *model.BillingIntegrationRow, secret string, newSecret bool, webhookID string) error {
+	if !newSecret {
+		secret = ""
+	}
+	if err := supabase.UpdateBillingIntegrationWebhookContext(ctx, integration.ID, secret, webhookID); err != nil {
+		// The provider already uses the new configuration; running provisioning again repairs it.
+		return fmt.Errorf("webhook provisioned (id=%s) but saving it on the integration failed, run again: %w", webhookID, err)
+	}
+	return nil
+}
+
+func webhookStatus(wh *provider.WebhookEndpoint) *model.WebhookEndpointStatus {
+	return &model.WebhookEndpointStatus{
+		ID:                wh.ID,
+		Name:              wh.Name,
+		URL:               wh.URL,
+		Email:             wh.Email,
+		Enabled:           wh.Enabled,
+		Interrupted:       wh.Interrupted,
+		PenalizedRequests: wh.PenalizedRequests,
+		HasAuthToken:      wh.HasAuthToken,
+		SendType:          wh.SendType,
+		Events:            wh.Events,
+	}
+}
+
+func providerError(stage string, err error, resp *provider.Response) error {
+	switch {
+	case err != nil:
+		return fmt.Errorf("%s: %w", stage, err)
+	case resp != nil:
+		return fmt.Errorf("%s: %s", stage, resp.ErrorMessage())
+	default:
+		return fmt.Errorf("%s: unexpected empty response", stage)
+	}
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func sameString(p *string, s string) bool {
+	return p != nil && strings.TrimSpace(*p) == s
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -4,7 +4,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +14,77 @@ import (
 type Config struct {
 	Port               string
 	CorsAllowedOrigins []string
+
+	// IdempotencyTTL is how long an Idempotency-Key (and its stored response) is kept.
+	IdempotencyTTL time.Duration
+	// IdempotencyLockTimeout is the lease of an in-progress key: once it expires (crashed
+	// instance) a retry with the same request takes the key over instead of getting 409.
+	IdempotencyLockTimeout time.Duration
+
+	Webhook WebhookConfig
+
+	Reconcile ReconcileConfig
+
+	Asaas AsaasConfig
+
+	Supabase SupabaseConfig
+
+	Auth AuthConfig
+
+	// AdminAPIToken protects /v1/admin/* (header X-Admin-Token). Empty disables the admin API.
+	AdminAPIToken string
+}
+
+// WebhookConfig controls the authentication of the Asaas webhook and the async worker pool (internal/webhook).
+type WebhookConfig struct {
+	Secret       string        // ASAAS_WEBHOOK_SECRET: fallback secret for integrations without webhook_secret
+	Strict       bool          // ASAAS_WEBHOOK_STRICT: reject webhooks when no secret applies
+	Workers      int           // WEBHOOK_WORKERS
+	MaxAttempts  int           // WEBHOOK_MAX_ATTEMPTS: after this the event is dead-lettered
+	PollInterval time.Duration // WEBHOOK_POLL_INTERVAL: how often the inbox is polled for retries
+	RetryBase    time.Duration // WEBHOOK_RETRY_BASE: delay before the 2nd attempt, doubled each time
+	RetryMax     time.Duration // WEBHOOK_RETRY_MAX: backoff cap
+	LockTimeout  time.Duration // WEBHOOK_LOCK_TIMEOUT: claimed events are retried after this (crashed worker)
+}
+
+// ReconcileConfig controls the scheduled reconciliation between the provider and iam.charges (internal/reconcile).
+type ReconcileConfig struct {
+	Enabled         bool          // RECONCILE_ENABLED: run on a schedule (manual runs are always available)
+	Interval        time.Duration // RECONCILE_INTERVAL
+	CreatedLookback time.Duration // RECONCILE_CREATED_LOOKBACK: payments created in this window
+	DueLookback     time.Duration // RECONCILE_DUE_LOOKBACK: payments due from now-lookback …
+	DueLookahead    time.Duration // RECONCILE_DUE_LOOKAHEAD: … to now+lookahead
+}
+
+// AsaasConfig controls timeouts, retries, rate limiting and circuit breaking of the Asaas client (internal/integrations/asaas).
+type AsaasConfig struct {
+	ReadTimeout  time.Duration // ASAAS_READ_TIMEOUT: deadline of each GET attempt
+	WriteTimeout time.Duration // ASAAS_WRITE_TIMEOUT: deadline of each POST/PUT/DELETE attempt
+	MaxRetries   int           // ASAAS_MAX_RETRIES: 0 disables retries
+	RetryBase    time.Duration // ASAAS_RETRY_BASE: backoff before the 1st retry, doubled each time (jittered)
+	RetryMax     time.Duration // ASAAS_RETRY_MAX: backoff cap
+	RateLimit    int           // ASAAS_RATE_LIMIT: max requests per second per Asaas token
+	MinRemaining int           // ASAAS_RATE_LIMIT_MIN_REMAINING: pause the token until Rate-Limit-Reset at this quota
+
+	BreakerFailures       int           // ASAAS_BREAKER_FAILURES: consecutive failures that open an integration's breaker (0 disables)
+	BreakerOpenTimeout    time.Duration // ASAAS_BREAKER_OPEN_TIMEOUT: how long an open breaker fails fast before probing
+	BreakerHalfOpenProbes int           // ASAAS_BREAKER_HALF_OPEN_PROBES: concurrent probe calls while half-open
+}
+
+// SupabaseConfig bounds every call to Supabase (internal/supabase).
+type SupabaseConfig struct {
+	QueryTimeout time.Duration // SUPABASE_QUERY_TIMEOUT: deadline of each table read/write
+	RPCTimeout   time.Duration // SUPABASE_RPC_TIMEOUT: deadline of each RPC call
+}
+
+// AuthConfig controls authentication of the /v1 API (internal/auth). Admin routes keep X-Admin-Token.
+type AuthConfig struct {
+	Disabled    bool              // AUTH_DISABLED: accept every request as a service (local development only)
+	JWTSecret   string            // SUPABASE_JWT_SECRET: verifies HS256 Supabase tokens
+	JWKSFile    string            // SUPABASE_JWKS_FILE: JWKS with the keys of RS256/ES256 Supabase tokens
+	JWTIssuer   string            // SUPABASE_JWT_ISSUER: expected "iss" (empty = not checked)
+	JWTAudience string            // SUPABASE_JWT_AUDIENCE: expected "aud" of user tokens
+	APIKeys     map[string]string // SERVICE_API_KEYS: "name=key,name2=key2" for service-to-service calls (X-API-Key)
 }
 
 func Load() Config {
@@ -28,12 +101,109 @@ func Load() Config {
 	}
 	log.Printf("CORS_ALLOWED_ORIGINS=%q parsed=%v", os.Getenv("CORS_ALLOWED_ORIGINS"), origins)
 
+	idempotencyTTL := envDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+
 	return Config{
-		Port:               port,
-		CorsAllowedOrigins: origins,
+		Port:                   port,
+		CorsAllowedOrigins:     origins,
+		IdempotencyTTL:         idempotencyTTL,
+		IdempotencyLockTimeout: envDuration("IDEMPOTENCY_LOCK_TIMEOUT", 2*time.Minute),
+		AdminAPIToken:          strings.TrimSpace(os.Getenv("ADMIN_API_TOKEN")),
+		Webhook: WebhookConfig{
+			Secret:       strings.TrimSpace(os.Getenv("ASAAS_WEBHOOK_SECRET")),
+			Strict:       envBool("ASAAS_WEBHOOK_STRICT", false),
+			Workers:      envInt("WEBHOOK_WORKERS", 4),
+			MaxAttempts:  envInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			PollInterval: envDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			RetryBase:    envDuration("WEBHOOK_RETRY_BASE", 10*time.Second),
+			RetryMax:     envDuration("WEBHOOK_RETRY_MAX", 30*time.Minute),
+			LockTimeout:  envDuration("WEBHOOK_LOCK_TIMEOUT", 5*time.Minute),
+		},
+		Reconcile: ReconcileConfig{
+			Enabled:         envBool("RECONCILE_ENABLED", true),
+			Interval:        envDuration("RECONCILE_INTERVAL", 6*time.Hour),
+			CreatedLookback: envDuration("RECONCILE_CREATED_LOOKBACK", 7*24*time.Hour),
+			DueLookback:     envDuration("RECONCILE_DUE_LOOKBACK", 30*24*time.Hour),
+			DueLookahead:    envDuration("RECONCILE_DUE_LOOKAHEAD", 30*24*time.Hour),
+		},
+		Asaas: AsaasConfig{
+			ReadTimeout:  envDuration("ASAAS_READ_TIMEOUT", 20*time.Second),
+			WriteTimeout: envDuration("ASAAS_WRITE_TIMEOUT", 30*time.Second),
+			MaxRetries:   envIntMin("ASAAS_MAX_RETRIES", 3, 0),
+			RetryBase:    envDuration("ASAAS_RETRY_BASE", 500*time.Millisecond),
+			RetryMax:     envDuration("ASAAS_RETRY_MAX", 10*time.Second),
+			RateLimit:    envInt("ASAAS_RATE_LIMIT", 10),
+			MinRemaining: envIntMin("ASAAS_RATE_LIMIT_MIN_REMAINING", 5, 0),
+
+			BreakerFailures:       envIntMin("ASAAS_BREAKER_FAILURES", 5, 0),
+			BreakerOpenTimeout:    envDuration("ASAAS_BREAKER_OPEN_TIMEOUT", 30*time.Second),
+			BreakerHalfOpenProbes: envInt("ASAAS_BREAKER_HALF_OPEN_PROBES", 1),
+		},
+		Supabase: SupabaseConfig{
+			QueryTimeout: envDuration("SUPABASE_QUERY_TIMEOUT", 10*time.Second),
+			RPCTimeout:   envDuration("SUPABASE_RPC_TIMEOUT", 15*time.Second),
+		},
+		Auth: AuthConfig{
+			Disabled:    envBool("AUTH_DISABLED", false),
+			JWTSecret:   strings.TrimSpace(os.Getenv("SUPABASE_JWT_SECRET")),
+			JWKSFile:    strings.TrimSpace(os.Getenv("SUPABASE_JWKS_FILE")),
+			JWTIssuer:   strings.TrimSpace(os.Getenv("SUPABASE_JWT_ISSUER")),
+			JWTAudience: envString("SUPABASE_JWT_AUDIENCE", "authenticated"),
+			APIKeys:     parseKeyValues("SERVICE_API_KEYS"),
+		},
 	}
 }
 
+func envInt(key string, def int) int {
+	return envIntMin(key, def, 1)
+}
+
+func envIntMin(key string, def, min int) int {
+	s := strings.TrimSpace(os.Getenv(key))
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min {
+		log.Printf("invalid %s=%q, using %d", key, s, def)
+		return def
+	}
+	return n
+}
+
+func envString(key, def string) string {
+	if s := strings.TrimSpace(os.Getenv(key)); s != "" {
+		return s
+	}
+	return def
+}
+
+func envBool(key string, def bool) bool {
+	s := strings.TrimSpace(os.Getenv(key))
+	if s == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %v", key, s, def)
+		return def
+	}
+	return b
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	s := strings.TrimSpace(os.Getenv(key))
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		log.Printf("invalid %s=%q, using %s", key, s, def)
+		return def
+	}
+	return d
+}
+
 func loadDotEnvBestEffort() {
 	dir, err := os.Getwd()
 	if err != nil {
@@ -62,3 +232,19 @@ func parseCSV(s string) []string {
 	}
 	return out
 }
+
+// parseKeyValues parses "name=value,name2=value2".
+func parseKeyValues(key string) map[string]string {
+	out := map[string]string{}
+	for i, p := range parseCSV(os.Getenv(key)) {
+		name, value, ok := strings.Cut(p, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			// Never log the entry itself: it may be a bare key.
+			log.Printf("invalid %s entry #%d (expected name=key), ignoring", key, i+1)
+			continue
+		}
+		out[name] = value
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// RequireAdminToken protects admin routes with the X-Admin-Token header (env ADMIN_API_TOKEN).
+// When no token is configured the admin API is disabled (403).
+func RequireAdminToken(token string) func(http.Handler) http.Handler {
+	token = strings.TrimSpace(token)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				writeJSON(w, http.StatusForbidden, map[string]any{"error": "admin API disabled (ADMIN_API_TOKEN not configured)"})
+				return
+			}
+			provided := strings.TrimSpace(r.Header.Get("X-Admin-Token"))
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				log.Printf("[admin] unauthorized: method=%s path=%s remote=%s", r.Method, r.URL.Path, r.RemoteAddr)
+				writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -5,98 +5,105 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/seuuser/charges-service/internal/integrations/asaas"
-	"github.com/seuuser/charges-service/internal/supabase"
+	"github.com/seuuser/charges-service/internal/billing"
 )
 
 // GetAsaasChargeDigitableLine godoc
 // @Summary      Linha digitável do boleto (Asaas)
-// @Description  Retorna a linha digitável (identificationField) para uma cobrança no Asaas.
+// @Description  Retorna a linha digitável (identificationField) para uma cobrança no Asaas. A integração é a do contrato da cobrança (iam.charges → contrato).
 // @Tags         asaas
 // @Produce      json
 // @Param        accounting_office_id  query     string  true  "ID do accounting_office (UUID)"
 // @Param        id                   path      string  true  "ID da cobrança no Asaas (payment id)"
 // @Success      200  {object}  map[string]any
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/charges/{id}/digitable-line [get]
 func GetAsaasChargeDigitableLine(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 	paymentID := strings.TrimSpace(chi.URLParam(r, "id"))
 	if paymentID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
 		return
 	}
 
-	cfg, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found for office/provider"})
-		return
-	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, "", billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ProviderChargeID:   paymentID,
+	})
+	if !ok {
 		return
 	}
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-	status, body, callErr := client.GetPaymentIdentificationField(paymentID)
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
+	_, resp, callErr := chargeProvider.GetDigitableLine(paymentID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	writeProviderResponse(w, resp)
 }
 
 // GetAsaasChargePixQrCode godoc
 // @Summary      QRCode Pix (Asaas)
-// @Description  Retorna o QRCode Pix (encodedImage/payload) para uma cobrança no Asaas.
+// @Description  Retorna o QRCode Pix (encodedImage/payload) para uma cobrança no Asaas. A integração é a do contrato da cobrança (iam.charges → contrato).
 // @Tags         asaas
 // @Produce      json
 // @Param        accounting_office_id  query     string  true  "ID do accounting_office (UUID)"
 // @Param        id                   path      string  true  "ID da cobrança no Asaas (payment id)"
 // @Success      200  {object}  map[string]any
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/charges/{id}/pix-qrcode [get]
 func GetAsaasChargePixQrCode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 	paymentID := strings.TrimSpace(chi.URLParam(r, "id"))
 	if paymentID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
 		return
 	}
 
-	cfg, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found for office/provider"})
-		return
-	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, "", billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ProviderChargeID:   paymentID,
+	})
+	if !ok {
 		return
 	}
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-	status, body, callErr := client.GetPaymentPixQrCode(paymentID)
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
+	_, resp, callErr := chargeProvider.GetPixQrCode(paymentID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	writeProviderResponse(w, resp)
 }
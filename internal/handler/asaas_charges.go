@@ -6,10 +6,10 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"net/url"
 	"strings"
 
-	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
 	"github.com/seuuser/charges-service/internal/model"
 	"github.com/seuuser/charges-service/internal/supabase"
 )
@@ -22,7 +22,7 @@ func newRequestID() string {
 
 // CreateAsaasCharge godoc
 // @Summary      Criar cobrança no Asaas
-// @Description  Cria uma cobrança (payment) no Asaas para uma empresa (company_id). O serviço resolve o customer_id no Asaas via mapeamento em company.asaas_integration (RPC em public) e usa a integração ativa (is_active=true) do escritório (accounting_office_id) em iam.billing_integrations.
+// @Description  Cria uma cobrança (payment) no Asaas para uma empresa (company_id). O serviço resolve o customer_id no Asaas via mapeamento em company.asaas_integration (RPC em public) e usa a integração do contrato (billing_integration_id → provider_environment → padrão do escritório) em iam.billing_integrations.
 // @Tags         asaas
 // @Accept       json
 // @Produce      json
@@ -30,18 +30,28 @@ func newRequestID() string {
 // @Param        company_id            query     string  true  "ID da empresa (company_id, UUID)"
 // @Param        contract_id           query     string  true  "ID do contrato (UUID)"
 // @Param        body                  body      model.AsaasCreateChargeRequest  true  "Payload da cobrança"
+// @Param        Idempotency-Key  header  string  false  "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original"
 // @Success      200  {object}  model.AsaasPaymentResponse
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/charges [post]
 func CreateAsaasCharge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	rid := newRequestID()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 
 	companyID := strings.TrimSpace(r.URL.Query().Get("company_id"))
 	if companyID == "" {
@@ -81,184 +91,57 @@ func CreateAsaasCharge(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 
-	// Resolve billing integration via contract (same strategy as CreateAsaasSubscription):
-	// 1) If contract has billing_integration_id → use it directly.
-	// 2) Else if contract has provider_environment → match by office + provider + environment.
-	// 3) Fallback → default/active integration for the office + provider.
-	contract, err := supabase.GetFeeContractByID(contractID)
-	if err != nil {
-		if isDebugEnabled() {
-			log.Printf("[supabase] ERROR loading fee_contract: rid=%s contract_id=%s err=%v", rid, contractID, err)
-		}
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load contract", "request_id": rid})
-		return
-	}
-	if contract == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "contract not found"})
-		return
-	}
-
-	provider := "ASAAS"
-	if contract.Provider != nil && strings.TrimSpace(*contract.Provider) != "" {
-		provider = normalizeProvider(*contract.Provider)
-	}
-
-	var cfg *model.BillingIntegrationRow
-	if contract.BillingIntegrationID != nil && strings.TrimSpace(*contract.BillingIntegrationID) != "" {
-		cfg, err = supabase.GetBillingIntegrationByID(strings.TrimSpace(*contract.BillingIntegrationID))
-	} else if contract.ProviderEnvironment != nil && strings.TrimSpace(*contract.ProviderEnvironment) != "" {
-		cfg, err = supabase.GetBillingIntegrationForOfficeAndEnvironment(contract.AccountingOfficeID, provider, strings.TrimSpace(*contract.ProviderEnvironment))
-		if err != nil {
-			cfg, err = supabase.GetBillingIntegrationForOffice(contract.AccountingOfficeID, provider)
-		}
-	} else {
-		cfg, err = supabase.GetBillingIntegrationForOffice(contract.AccountingOfficeID, provider)
-	}
-
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{
-			"error":       "billing integration not found for contract/office/provider",
-			"contract_id": contractID,
-			"provider":    provider,
-		})
-		return
-	}
-
-	if isDebugEnabled() {
-		log.Printf(
-			"[asaas] using integration cfg for charge: rid=%s contract_id=%s cfg_id=%s provider=%s env=%s base_api=%q token=%s",
-			rid, contractID, cfg.ID, cfg.Provider, cfg.Environment, cfg.BaseAPI, maskToken(cfg.Token),
-		)
-	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+	// Integration of the contract (billing_integration_id → provider_environment → office default).
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ContractID:         contractID,
+	})
+	if !ok {
 		return
 	}
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-
-	// Resolve Asaas customer id. If missing, auto-create customer from company data via RPC and persist mapping.
-	asaasCustomerID, err := supabase.GetCompanyAsaasCustomerID(companyID)
-	if err != nil {
-		if isDebugEnabled() {
-			log.Printf("[asaas] error resolving asaas_customer_id: company_id=%s err=%v", companyID, err)
-		}
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to resolve asaas customer id"})
+	// Resolve provider customer id. If missing, auto-create customer from company data via RPC and persist mapping.
+	customerID, ok := ensureProviderCustomer(ctx, w, rid, chargeProvider, companyID)
+	if !ok {
 		return
 	}
-	if strings.TrimSpace(asaasCustomerID) == "" {
-		if isDebugEnabled() {
-			log.Printf("[asaas] asaas_integration missing; auto-creating customer: rid=%s company_id=%s", rid, companyID)
-		}
-
-		payload, perr := supabase.GetCompanyAsaasCustomerPayload(companyID)
-		if perr != nil {
-			if isDebugEnabled() {
-				log.Printf("[asaas] error loading company payload for customer create: rid=%s company_id=%s err=%v", rid, companyID, perr)
-			}
-			log.Printf("[asaas] ERROR loading company payload for customer create: rid=%s company_id=%s err=%v", rid, companyID, perr)
-			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load company data to create asaas customer"})
-			return
-		}
-		if payload == nil {
-			log.Printf("[asaas] ERROR company not found for customer auto-create: rid=%s company_id=%s", rid, companyID)
-			writeJSON(w, http.StatusNotFound, map[string]any{"error": "company not found to create asaas customer"})
-			return
-		}
-
-		cStatus, cBody, cErr := client.CreateCustomer(asaas.CreateCustomerRequest{
-			Name:                 payload.Name,
-			CpfCnpj:              payload.CpfCnpj,
-			Email:                payload.Email,
-			MobilePhone:          payload.MobilePhone,
-			NotificationDisabled: payload.NotificationDisabled,
-			Company:              payload.Company,
-		})
-		if cErr != nil {
-			writeJSON(w, http.StatusBadGateway, map[string]any{"error": cErr.Error()})
-			return
-		}
-		if cStatus < 200 || cStatus >= 300 {
-			// pass-through Asaas error payload
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(cStatus)
-			_, _ = w.Write(cBody)
-			return
-		}
-
-		var createdCustomer model.AsaasCustomerResponse
-		if err := json.Unmarshal(cBody, &createdCustomer); err != nil || strings.TrimSpace(createdCustomer.ID) == "" {
-			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "invalid asaas response (missing customer id)"})
-			return
-		}
-
-		if err := supabase.UpsertCompanyAsaasIntegration(companyID, createdCustomer.ID); err != nil {
-			// Always log the underlying error server-side (no secrets). Use request_id for correlation.
-			log.Printf("[asaas] ERROR persisting company.asaas_integration: rid=%s company_id=%s asaas_customer_id=%s err=%v",
-				rid, companyID, createdCustomer.ID, err,
-			)
-			if isDebugEnabled() {
-				writeJSON(w, http.StatusBadGateway, map[string]any{
-					"error":      "failed to persist asaas integration",
-					"details":    err.Error(),
-					"request_id": rid,
-				})
-				return
-			}
-			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to persist asaas integration", "request_id": rid})
-			return
-		}
-
-		asaasCustomerID = createdCustomer.ID
-	}
 
-	status, body, callErr := client.CreatePayment(mapCreatePaymentRequest(asaasCustomerID, req))
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
+	in := mapCreatePaymentRequest(customerID, req)
+	created, resp, callErr := chargeProvider.CreatePayment(in)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
 		return
 	}
-
-	if isDebugEnabled() {
-		raw := string(body)
-		if len(raw) > 800 {
-			raw = raw[:800] + "…(truncated)"
-		}
-		log.Printf("[asaas] create charge response: status=%d body=%s", status, raw)
-	}
+	logProviderResponse("create charge", rid, resp)
 
 	// If 2xx, persist charges in our provider-agnostic table (iam.charges).
-	if status >= 200 && status < 300 {
-		var created model.AsaasPaymentResponse
-		_ = json.Unmarshal(body, &created)
-
-		// List charges created. If installment id exists, list by installment; else fallback to externalReference (if any).
-		params := url.Values{}
-		params.Set("customer", asaasCustomerID)
-		params.Set("limit", "100")
-		params.Set("offset", "0")
-
-		if strings.TrimSpace(created.Installment) != "" {
-			params.Set("installment", created.Installment)
+	if resp.OK() && created != nil {
+		// List charges created. If installment id exists, list by installment; else by externalReference (if any).
+		// Without either filter the created payment is the only one to persist.
+		payments := []provider.Payment{*created}
+		filter := provider.PaymentFilter{CustomerID: customerID}
+		if created.InstallmentID != "" {
+			filter.InstallmentID = created.InstallmentID
 		} else if req.ExternalReference != nil && strings.TrimSpace(*req.ExternalReference) != "" {
-			params.Set("externalReference", strings.TrimSpace(*req.ExternalReference))
-		} else if strings.TrimSpace(created.ID) != "" {
-			// last-resort: persist at least the created payment (list may not be filterable)
-			// no extra filter
-		}
-
-		listStatus, listBody, listErr := client.ListPayments(params)
-		if listErr != nil {
-			if isDebugEnabled() {
-				log.Printf("[asaas] ERROR listing charges after create: status=%d err=%v", listStatus, listErr)
+			filter.ExternalReference = strings.TrimSpace(*req.ExternalReference)
+		}
+		if filter.InstallmentID != "" || filter.ExternalReference != "" {
+			// Every page: a 120-installment plan does not fit in one.
+			listed, listErr := provider.Payments(chargeProvider, filter).All()
+			if listErr != nil {
+				if isDebugEnabled() {
+					log.Printf("[asaas] ERROR listing charges after create: rid=%s err=%v", rid, listErr)
+				}
+				writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to list charges after create"})
+				return
+			}
+			// If the list didn't return anything (rare), persist at least the created payment.
+			if len(listed) > 0 {
+				payments = listed
 			}
-			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to list charges after create"})
-			return
 		}
 
-		var list model.AsaasPaymentsListResponse
-		_ = json.Unmarshal(listBody, &list)
-
-		tenantID, terr := supabase.GetCompanyTenantID(companyID)
+		tenantID, terr := supabase.GetCompanyTenantIDContext(ctx, companyID)
 		if terr != nil {
 			if isDebugEnabled() {
 				log.Printf("[supabase] ERROR resolving tenant_id for company_id=%s err=%v", companyID, terr)
@@ -267,109 +150,14 @@ func CreateAsaasCharge(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		rows := make([]model.IamChargeRow, 0, len(list.Data))
-		for _, p := range list.Data {
-			if strings.TrimSpace(p.ID) == "" {
-				continue
-			}
-
-			desc := strings.TrimSpace(p.Description)
-			var descPtr *string
-			if desc != "" {
-				descPtr = &desc
-			}
-			bt := strings.TrimSpace(p.BillingType)
-			var btPtr *string
-			if bt != "" {
-				btPtr = &bt
-			}
-			st := strings.TrimSpace(p.Status)
-			var stPtr *string
-			if st != "" {
-				stPtr = &st
-			}
-			due := strings.TrimSpace(p.DueDate)
-			var duePtr *string
-			if due != "" {
-				duePtr = &due
-			}
-			odue := strings.TrimSpace(p.OriginalDueDate)
-			var oduePtr *string
-			if odue != "" {
-				oduePtr = &odue
-			}
-			iurl := strings.TrimSpace(p.InvoiceURL)
-			var iurlPtr *string
-			if iurl != "" {
-				iurlPtr = &iurl
-			}
-			inum := strings.TrimSpace(p.InvoiceNumber)
-			var inumPtr *string
-			if inum != "" {
-				inumPtr = &inum
-			}
-			eref := strings.TrimSpace(p.ExternalReference)
-			var erefPtr *string
-			if eref != "" {
-				erefPtr = &eref
-			}
-			var netPtr *float64
-			if p.NetValue != 0 {
-				v := p.NetValue
-				netPtr = &v
-			}
-			var instNumPtr *int32
-			if p.InstallmentNumber != 0 {
-				v := p.InstallmentNumber
-				instNumPtr = &v
-			}
-			var instIDPtr *string
-			if strings.TrimSpace(p.Installment) != "" {
-				v := strings.TrimSpace(p.Installment)
-				instIDPtr = &v
-			}
-
-			payload, _ := json.Marshal(p)
-
-			rows = append(rows, model.IamChargeRow{
-				TenantID:              tenantID,
-				AccountingOfficeID:    accountingOfficeID,
-				CompanyID:             companyID,
-				ContractID:            contractID,
-				Provider:              "ASAAS",
-				ProviderChargeID:      p.ID,
-				ProviderInstallmentID: instIDPtr,
-				InstallmentNumber:     instNumPtr,
-				Value:                 p.Value,
-				NetValue:              netPtr,
-				Description:           descPtr,
-				BillingType:           btPtr,
-				Status:                stPtr,
-				DueDate:               duePtr,
-				OriginalDueDate:       oduePtr,
-				InvoiceURL:            iurlPtr,
-				InvoiceNumber:         inumPtr,
-				ExternalReference:     erefPtr,
-				ProviderPayload:       payload,
-			})
-		}
-
-		// If Asaas list didn't return anything (rare), persist at least the created payment.
-		if len(rows) == 0 && strings.TrimSpace(created.ID) != "" {
-			payload, _ := json.Marshal(created)
-			rows = append(rows, model.IamChargeRow{
-				TenantID:           tenantID,
-				AccountingOfficeID: accountingOfficeID,
-				CompanyID:          companyID,
-				ContractID:         contractID,
-				Provider:           "ASAAS",
-				ProviderChargeID:   created.ID,
-				Value:              created.Value,
-				ProviderPayload:    payload,
-			})
-		}
+		rows := billing.ChargeRowsForContract(chargeProvider.Name(), &model.FeeContractRow{
+			ID:                 contractID,
+			TenantID:           tenantID,
+			AccountingOfficeID: accountingOfficeID,
+			CompanyID:          companyID,
+		}, payments)
 
-		if err := supabase.UpsertCharges(rows); err != nil {
+		if err := supabase.UpsertChargesContext(ctx, rows); err != nil {
 			if isDebugEnabled() {
 				log.Printf("[supabase] ERROR upserting iam.charges: err=%v", err)
 				writeJSON(w, http.StatusBadGateway, map[string]any{
@@ -384,82 +172,29 @@ func CreateAsaasCharge(w http.ResponseWriter, r *http.Request) {
 
 		// Sync iam.fee_contract_one_off_charges via RPC:
 		// Link provider_charge_id (first time) and persist provider-side data using external_reference.
-		// Non-fatal: if the RPC fails we log but still return the Asaas payload to the caller.
-		if strings.TrimSpace(created.ID) != "" {
-			eref := strings.TrimSpace(created.ExternalReference)
-			paymentStatus := strings.TrimSpace(created.Status)
-
-			// Build optional extra fields from the Asaas response.
-			var syncExtra *supabase.OneOffSyncFields
-			if created.Value > 0 || strings.TrimSpace(created.DueDate) != "" || strings.TrimSpace(created.BillingType) != "" {
-				syncExtra = &supabase.OneOffSyncFields{}
-				if created.Value > 0 {
-					v := created.Value
-					syncExtra.Value = &v
-				}
-				if d := strings.TrimSpace(created.DueDate); d != "" {
-					syncExtra.DueDate = &d
-				}
-				if bt := strings.TrimSpace(created.BillingType); bt != "" {
-					syncExtra.BillingType = &bt
-				}
-			}
-
-			log.Printf("[supabase] syncing one_off_charge after CREATE: payment_id=%s status=%s external_reference=%q value=%v due=%s billing=%s",
-				created.ID, paymentStatus, eref, created.Value, created.DueDate, created.BillingType)
-			if syncErr := supabase.SyncOneOffChargeFromProvider(created.ID, paymentStatus, eref, syncExtra); syncErr != nil {
-				log.Printf("[supabase] ERROR sync_one_off_charge failed after CREATE: payment_id=%s err=%v", created.ID, syncErr)
-			} else {
-				log.Printf("[supabase] OK fee_contract_one_off_charges synced after CREATE: provider_charge_id=%s status=%s", created.ID, paymentStatus)
-			}
-		}
+		// Non-fatal: if the RPC fails we log but still return the provider payload to the caller.
+		syncOneOffChargeFromPayment(ctx, "CREATE", created)
 	}
 
-	// Pass-through Asaas payload (it matches model.AsaasPaymentResponse on success)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	// Pass-through provider payload (it matches model.AsaasPaymentResponse on success)
+	writeProviderResponse(w, resp)
 }
 
-func mapCreatePaymentRequest(asaasCustomerID string, req model.AsaasCreateChargeRequest) asaas.CreatePaymentRequest {
-	out := asaas.CreatePaymentRequest{
-		Customer:    asaasCustomerID,
+func mapCreatePaymentRequest(customerID string, req model.AsaasCreateChargeRequest) provider.PaymentInput {
+	return provider.PaymentInput{
+		CustomerID:  customerID,
 		BillingType: string(req.BillingType),
 		Value:       req.Value,
 		DueDate:     req.DueDate,
+		Description: req.Description,
+		DaysAfterDueDateToRegistrationCancellation: req.DaysAfterDueDateToRegistrationCancellation,
+		ExternalReference:                          req.ExternalReference,
+		InstallmentCount:                           req.InstallmentCount,
+		TotalValue:                                 req.TotalValue,
+		InstallmentValue:                           req.InstallmentValue,
+		Discount:                                   toProviderDiscount(req.Discount),
+		Interest:                                   toProviderInterest(req.Interest),
+		Fine:                                       toProviderFine(req.Fine),
+		PostalService:                              req.PostalService,
 	}
-
-	out.Description = req.Description
-	out.DaysAfterDueDateToRegistrationCancellation = req.DaysAfterDueDateToRegistrationCancellation
-	out.ExternalReference = req.ExternalReference
-	out.InstallmentCount = req.InstallmentCount
-	out.TotalValue = req.TotalValue
-	out.InstallmentValue = req.InstallmentValue
-	out.PostalService = req.PostalService
-
-	if req.Discount != nil {
-		var t *string
-		if req.Discount.Type != nil {
-			s := string(*req.Discount.Type)
-			t = &s
-		}
-		out.Discount = &asaas.PaymentDiscount{
-			Value:            req.Discount.Value,
-			DueDateLimitDays: req.Discount.DueDateLimitDays,
-			Type:             t,
-		}
-	}
-	if req.Interest != nil {
-		out.Interest = &asaas.PaymentInterest{Value: req.Interest.Value}
-	}
-	if req.Fine != nil {
-		var t *string
-		if req.Fine.Type != nil {
-			s := string(*req.Fine.Type)
-			t = &s
-		}
-		out.Fine = &asaas.PaymentFine{Value: req.Fine.Value, Type: t}
-	}
-
-	return out
 }
@@ -1,84 +1,87 @@
 package handler
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"log"
 	"net/http"
 	"strings"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/billing"
 	"github.com/seuuser/charges-service/internal/supabase"
 )
 
+// deleted_reason of charges deleted through DELETE /v1/asaas/charges/{id} without a reason.
+const deletedReasonAPI = "deleted via API"
+
 // DeleteAsaasCharge godoc
 // @Summary Excluir cobrança do Asaas
-// @Description Exclui uma cobrança (payment) do Asaas e remove do banco de dados iam.charges
+// @Description Exclui uma cobrança (payment) do Asaas e marca a cobrança como excluída em iam.charges (deleted_at, deleted_reason), mantendo o histórico; pode ser restaurada com POST /v1/asaas/charges/{id}/restore. A integração é a do contrato da cobrança (iam.charges → contrato).
 // @Tags asaas
 // @Accept json
 // @Produce json
 // @Param id path string true "ID da cobrança no Asaas (pay_xxx)"
 // @Param accounting_office_id query string true "ID do escritório contábil"
+// @Param reason query string false "Motivo da exclusão (gravado em iam.charges.deleted_reason)"
 // @Success 200 {object} map[string]interface{} "Cobrança excluída com sucesso"
 // @Failure 400 {object} map[string]interface{} "Requisição inválida"
+// @Failure 401 {object} map[string]interface{} "Não autenticado (code: unauthenticated)"
+// @Failure 403 {object} map[string]interface{} "Escritório de outro tenant (code: cross_tenant_access)"
 // @Failure 404 {object} map[string]interface{} "Cobrança não encontrada"
+// @Failure 422 {object} map[string]interface{} "Cobrança recusada pelo provedor (code: provider_validation_error)"
 // @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Failure 502 {object} map[string]interface{} "Falha no provedor (code: provider_unavailable)"
+// @Failure 503 {object} map[string]interface{} "Circuit breaker da integração aberto (code: provider_circuit_open)"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /v1/asaas/charges/{id} [delete]
 func DeleteAsaasCharge(w http.ResponseWriter, r *http.Request) {
-	paymentID := chi.URLParam(r, "id")
-	if paymentID == "" {
-		http.Error(w, `{"error":"payment ID is required"}`, http.StatusBadRequest)
-		return
-	}
-
-	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
-	if accountingOfficeID == "" {
-		http.Error(w, `{"error":"accounting_office_id is required"}`, http.StatusBadRequest)
+	ctx := r.Context()
+	rid := newRequestID()
+	paymentID, accountingOfficeID, ok := chargeOperationParams(w, r)
+	if !ok {
 		return
 	}
 
-	log.Printf("[asaas] DELETE /v1/asaas/charges/%s | office=%s", paymentID, accountingOfficeID)
+	log.Printf("[asaas] delete charge: rid=%s payment_id=%s office=%s", rid, paymentID, accountingOfficeID)
 
-	// Get billing integration for current tenant
-	integration, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil {
-		log.Printf("[asaas] ERROR get billing integration: %v", err)
-		http.Error(w, `{"error":"billing integration not found"}`, http.StatusInternalServerError)
+	// Integration of the charge's contract (falls back to the office default when the
+	// charge is not in iam.charges).
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ProviderChargeID:   paymentID,
+	})
+	if !ok {
 		return
 	}
 
-	// Delete payment from Asaas
-	asaasClient := asaas.NewClient(integration.BaseAPI, integration.Token)
-	statusCode, body, err := asaasClient.DeletePayment(paymentID)
-	if err != nil {
-		log.Printf("[asaas] ERROR delete payment: %v", err)
-		http.Error(w, fmt.Sprintf(`{"error":"failed to delete payment: %s"}`, err.Error()), http.StatusInternalServerError)
+	resp, err := chargeProvider.DeletePayment(paymentID)
+	if err != nil && resp == nil {
+		log.Printf("[asaas] ERROR delete payment: rid=%s payment_id=%s err=%v", rid, paymentID, err)
+		writeProviderCallError(w, rid, err)
 		return
 	}
-
-	if statusCode != http.StatusOK && statusCode != http.StatusNoContent {
-		log.Printf("[asaas] ERROR delete payment returned status %d: %s", statusCode, string(body))
-		http.Error(w, string(body), statusCode)
+	if !resp.OK() {
+		log.Printf("[asaas] ERROR delete payment: rid=%s payment_id=%s err=%s", rid, paymentID, resp.ErrorMessage())
+		writeProviderError(w, rid, chargeProvider.Name(), resp)
 		return
 	}
 
-	// Delete charge from iam.charges
-	if err := supabase.DeleteChargeByProviderID("ASAAS", paymentID); err != nil {
-		log.Printf("[asaas] WARNING failed to delete charge from database: %v", err)
-		// Don't fail the request if we can't delete from database
-		// The charge was already deleted from Asaas
+	// Soft delete in iam.charges: the row keeps its contract context for PAYMENT_RESTORED.
+	// The charge is already deleted in the provider, so a failure here is only logged.
+	reason := strings.TrimSpace(r.URL.Query().Get("reason"))
+	if reason == "" {
+		reason = deletedReasonAPI
+	}
+	if err := supabase.SoftDeleteChargeByProviderIDContext(context.WithoutCancel(ctx), chargeProvider.Name(), paymentID, reason); err != nil {
+		log.Printf("[supabase] ERROR marking charge as deleted: rid=%s payment_id=%s err=%v", rid, paymentID, err)
 	} else {
-		log.Printf("[asaas] ✅ Charge deleted from database: %s", paymentID)
+		log.Printf("[asaas] ✅ charge deleted: rid=%s payment_id=%s", rid, paymentID)
 	}
 
-	log.Printf("[asaas] ✅ Payment deleted successfully from Asaas: %s", paymentID)
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Cobrança excluída com sucesso",
-		"id":      paymentID,
+	writeJSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"message":    "Cobrança excluída com sucesso",
+		"id":         paymentID,
+		"request_id": rid,
 	})
 }
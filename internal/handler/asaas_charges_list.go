@@ -7,7 +7,8 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
 	"github.com/seuuser/charges-service/internal/supabase"
 )
 
@@ -18,6 +19,7 @@ import (
 // @Accept       json
 // @Produce      json
 // @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração; sem ele a integração vem da assinatura (subscription), da empresa (company_id) ou do padrão do escritório"
 // @Param        company_id            query     string  false  "ID da empresa (UUID) - resolve customer automaticamente"
 // @Param        offset                query     int     false  "Elemento inicial da lista"
 // @Param        limit                 query     int     false  "Número de elementos da lista (max: 100)"
@@ -46,15 +48,24 @@ import (
 // @Param        checkoutSession       query     string  false  "Filtrar pelo identificador único da checkout"
 // @Success      200  {object}  model.AsaasPaymentsListResponse
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/charges [get]
 func ListAsaasCharges(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 
 	q := r.URL.Query()
 	params := url.Values{}
@@ -80,7 +91,7 @@ func ListAsaasCharges(w http.ResponseWriter, r *http.Request) {
 	if customer == "" {
 		companyID := strings.TrimSpace(q.Get("company_id"))
 		if companyID != "" {
-			asaasCustomerID, err := supabase.GetCompanyAsaasCustomerID(companyID)
+			asaasCustomerID, err := supabase.GetCompanyAsaasCustomerIDContext(ctx, companyID)
 			if err != nil {
 				if isDebugEnabled() {
 					log.Printf("[asaas] error resolving asaas_customer_id for list: company_id=%s err=%v", companyID, err)
@@ -146,38 +157,34 @@ func ListAsaasCharges(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	cfg, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found for office/provider"})
-		return
-	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+	res, chargeProvider, ok := resolveChargeProvider(ctx, w, "", billing.IntegrationRequest{
+		AccountingOfficeID:     accountingOfficeID,
+		ContractID:             strings.TrimSpace(q.Get("contract_id")),
+		ProviderSubscriptionID: strings.TrimSpace(q.Get("subscription")),
+		CompanyID:              strings.TrimSpace(q.Get("company_id")),
+	})
+	if !ok {
 		return
 	}
 
 	if isDebugEnabled() {
 		log.Printf("[asaas] list charges: accounting_office_id=%s params=%s cfg_base_api=%q token=%s",
-			accountingOfficeID, params.Encode(), cfg.BaseAPI, maskToken(cfg.Token),
+			accountingOfficeID, params.Encode(), res.Integration.BaseAPI, maskToken(res.Integration.Token),
 		)
 	}
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-	status, body, callErr := client.ListPayments(params)
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
-		return
+	// Asaas filter names are forwarded as-is (this route mirrors /v3/payments).
+	filter := provider.PaymentFilter{Extra: make(map[string]string, len(params))}
+	for key := range params {
+		filter.Extra[key] = params.Get(key)
 	}
 
-	if isDebugEnabled() {
-		raw := string(body)
-		if len(raw) > 800 {
-			raw = raw[:800] + "…(truncated)"
-		}
-		log.Printf("[asaas] list charges response: status=%d body=%s", status, raw)
+	_, resp, callErr := chargeProvider.ListPayments(filter)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
+		return
 	}
+	logProviderResponse("list charges", "", resp)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	writeProviderResponse(w, resp)
 }
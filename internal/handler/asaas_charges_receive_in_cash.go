@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// errCodeChargeInvalidStatus is returned when the charge status does not allow the operation.
+const errCodeChargeInvalidStatus = "charge_invalid_status"
+
+// ReceiveAsaasChargeInCash godoc
+// @Summary      Confirmar recebimento em dinheiro no Asaas
+// @Description  Marca uma cobrança PENDING/OVERDUE como recebida fora do Asaas (dinheiro, transferência...), status RECEIVED_IN_CASH. Sem value, usa o valor da cobrança. O resultado é sincronizado em iam.charges e, para cobranças avulsas, em iam.fee_contract_one_off_charges.
+// @Tags         asaas
+// @Accept       json
+// @Produce      json
+// @Param        id                    path      string  true   "ID da cobrança no Asaas (payment_id)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Param        body                  body      model.AsaasReceiveInCashRequest  true  "Data de pagamento, valor e notificação do cliente"
+// @Param        Idempotency-Key  header  string  false  "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original"
+// @Success      200  {object}  model.AsaasPaymentResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      409  {object}  map[string]any  "Cobrança não está aguardando pagamento (code: charge_invalid_status)"
+// @Failure      422  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/charges/{id}/receive-in-cash [post]
+func ReceiveAsaasChargeInCash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	paymentID, accountingOfficeID, ok := chargeOperationParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.AsaasReceiveInCashRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json body"})
+		return
+	}
+	req.PaymentDate = strings.TrimSpace(req.PaymentDate)
+	if req.PaymentDate == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "paymentDate is required"})
+		return
+	}
+	if _, err := time.Parse("2006-01-02", req.PaymentDate); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "paymentDate must be YYYY-MM-DD"})
+		return
+	}
+	if req.Value != nil && *req.Value <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "value must be > 0"})
+		return
+	}
+
+	chargeRow, chargeProvider, ok := storedChargeProvider(ctx, w, rid, accountingOfficeID, paymentID)
+	if !ok {
+		return
+	}
+	if !billing.CanReceiveInCash(chargeRow.Status) {
+		writeChargeInvalidStatus(w, rid, chargeRow, "only pending or overdue charges can be received in cash")
+		return
+	}
+
+	value := chargeRow.Value
+	if req.Value != nil {
+		value = *req.Value
+	}
+
+	log.Printf("[asaas] receive in cash: rid=%s payment_id=%s office=%s date=%s value=%.2f",
+		rid, paymentID, accountingOfficeID, req.PaymentDate, value)
+
+	received, resp, callErr := chargeProvider.ReceiveInCash(paymentID, provider.ReceiveInCashInput{
+		PaymentDate:    req.PaymentDate,
+		Value:          value,
+		NotifyCustomer: req.NotifyCustomer,
+	})
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("receive in cash", rid, resp)
+
+	if resp.OK() && received != nil && received.ID != "" {
+		syncStoredCharge(ctx, rid, "RECEIVE_IN_CASH", chargeProvider.Name(), chargeRow, received)
+	}
+
+	// Pass-through provider payload
+	writeProviderResponse(w, resp)
+}
+
+// UndoAsaasChargeReceivedInCash godoc
+// @Summary      Desfazer recebimento em dinheiro no Asaas
+// @Description  Desfaz a confirmação de recebimento em dinheiro (RECEIVED_IN_CASH): a cobrança volta a aguardar pagamento. O resultado é sincronizado em iam.charges e, para cobranças avulsas, em iam.fee_contract_one_off_charges.
+// @Tags         asaas
+// @Produce      json
+// @Param        id                    path      string  true   "ID da cobrança no Asaas (payment_id)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Success      200  {object}  model.AsaasPaymentResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      409  {object}  map[string]any  "Cobrança não foi recebida em dinheiro (code: charge_invalid_status)"
+// @Failure      422  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/charges/{id}/undo-received-in-cash [post]
+func UndoAsaasChargeReceivedInCash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	paymentID, accountingOfficeID, ok := chargeOperationParams(w, r)
+	if !ok {
+		return
+	}
+
+	chargeRow, chargeProvider, ok := storedChargeProvider(ctx, w, rid, accountingOfficeID, paymentID)
+	if !ok {
+		return
+	}
+	if !billing.CanUndoReceivedInCash(chargeRow.Status) {
+		writeChargeInvalidStatus(w, rid, chargeRow, "only charges received in cash can be undone")
+		return
+	}
+
+	log.Printf("[asaas] undo received in cash: rid=%s payment_id=%s office=%s", rid, paymentID, accountingOfficeID)
+
+	undone, resp, callErr := chargeProvider.UndoReceivedInCash(paymentID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("undo received in cash", rid, resp)
+
+	if resp.OK() && undone != nil && undone.ID != "" {
+		syncStoredCharge(ctx, rid, "UNDO_RECEIVED_IN_CASH", chargeProvider.Name(), chargeRow, undone)
+	}
+
+	// Pass-through provider payload
+	writeProviderResponse(w, resp)
+}
+
+// syncStoredCharge writes the payment returned by the provider back to iam.charges and, for
+// one-off charges, iam.fee_contract_one_off_charges. The operation already happened in the
+// provider, so it runs even if the client went away and failures are only logged.
+//
+// Like refunds and webhooks, the write holds the charge lock and merges into the row re-read
+// under it, so a concurrent update of the same charge is not overwritten with a stale copy.
+func syncStoredCharge(ctx context.Context, rid, stage, providerName string, stored *model.IamChargeRow, p *provider.Payment) {
+	ctx = context.WithoutCancel(ctx)
+	defer billing.ChargeLocks.Lock(providerName + ":" + p.ID)()
+	if current, err := supabase.GetChargeByIDContext(ctx, stored.ID); err != nil || current == nil {
+		log.Printf("[supabase] ERROR reloading charge after %s, merging into the copy read before: rid=%s payment_id=%s err=%v", stage, rid, p.ID, err)
+	} else {
+		stored = current
+	}
+	row := chargeRowFromStored(providerName, stored, *p)
+	if err := supabase.UpsertChargesContext(ctx, []model.IamChargeRow{row}); err != nil {
+		log.Printf("[supabase] ERROR upserting charge after %s: rid=%s payment_id=%s err=%v", stage, rid, p.ID, err)
+	} else {
+		log.Printf("[supabase] iam.charges updated after %s: rid=%s payment_id=%s status=%s", stage, rid, p.ID, p.Status)
+	}
+	if isOneOffCharge(stored) {
+		syncOneOffChargeFromPayment(ctx, stage, p)
+	}
+}
+
+// writeChargeInvalidStatus answers 409 when the stored charge status does not allow the operation.
+func writeChargeInvalidStatus(w http.ResponseWriter, rid string, row *model.IamChargeRow, msg string) {
+	status := ""
+	if row.Status != nil {
+		status = *row.Status
+	}
+	writeJSON(w, http.StatusConflict, map[string]any{
+		"error":      msg,
+		"code":       errCodeChargeInvalidStatus,
+		"status":     status,
+		"request_id": rid,
+	})
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/auth"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// Error codes of refund requests rejected before reaching the provider.
+const (
+	errCodeChargeNotRefundable     = "charge_not_refundable"
+	errCodeRefundExceedsRefundable = "refund_exceeds_refundable"
+)
+
+// RefundAsaasCharge godoc
+// @Summary      Estornar cobrança no Asaas (total ou parcial)
+// @Description  Estorna uma cobrança recebida (RECEIVED/CONFIRMED) no Asaas. Sem value, estorna todo o saldo estornável; com value, estorna parcialmente (vários estornos parciais são permitidos). O saldo estornável é o valor líquido recebido (net_value, ou value quando não informado) menos o já estornado (iam.charges.refunded_value). Cada estorno é registrado no histórico da cobrança (iam.charges.refunds).
+// @Tags         asaas
+// @Accept       json
+// @Produce      json
+// @Param        id                    path      string  true   "ID da cobrança no Asaas (payment_id)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Param        body                  body      model.AsaasRefundChargeRequest  false  "Valor e descrição do estorno (opcional)"
+// @Param        Idempotency-Key  header  string  false  "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original"
+// @Success      200  {object}  model.AsaasPaymentResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      409  {object}  map[string]any  "Cobrança não pode ser estornada no status atual (code: charge_not_refundable)"
+// @Failure      422  {object}  map[string]any  "Valor acima do saldo estornável (code: refund_exceeds_refundable) ou recusado pelo provedor"
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/charges/{id}/refund [post]
+func RefundAsaasCharge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	paymentID, accountingOfficeID, ok := chargeOperationParams(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional: no body refunds everything still refundable.
+	var req model.AsaasRefundChargeRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json body"})
+		return
+	}
+	if req.Value != nil && *req.Value <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "value must be > 0"})
+		return
+	}
+	if req.Description != nil {
+		d := strings.TrimSpace(*req.Description)
+		req.Description = &d
+		if d == "" {
+			req.Description = nil
+		}
+	}
+
+	// The refundable balance and the refund history live in iam.charges. The provider
+	// rejects a refund above what is left, so a refund finishing concurrently cannot be
+	// exceeded even though the balance is read without the charge lock.
+	chargeRow, chargeProvider, ok := storedChargeProvider(ctx, w, rid, accountingOfficeID, paymentID)
+	if !ok {
+		return
+	}
+
+	if !billing.RefundableStatus(chargeRow.Status) {
+		status := ""
+		if chargeRow.Status != nil {
+			status = *chargeRow.Status
+		}
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error":      "only received or confirmed charges can be refunded",
+			"code":       errCodeChargeNotRefundable,
+			"status":     status,
+			"request_id": rid,
+		})
+		return
+	}
+
+	balance := billing.RefundBalanceOf(chargeRow)
+	value := balance.Refundable
+	if req.Value != nil {
+		value = *req.Value
+	}
+	if value <= 0 || value-balance.Refundable > 0.005 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":            "refund value exceeds the refundable amount",
+			"code":             errCodeRefundExceedsRefundable,
+			"value":            value,
+			"refundable_value": balance.Refundable,
+			"refunded_value":   balance.Refunded,
+			"net_value":        chargeRow.NetValue,
+			"charge_value":     chargeRow.Value,
+			"request_id":       rid,
+		})
+		return
+	}
+
+	log.Printf("[asaas] refund charge: rid=%s payment_id=%s office=%s value=%.2f refundable=%.2f",
+		rid, paymentID, accountingOfficeID, value, balance.Refundable)
+
+	// The value is always sent: after a partial refund "no value" would mean the full charge.
+	refunded, resp, callErr := chargeProvider.RefundPayment(paymentID, provider.RefundInput{
+		Value:       &value,
+		Description: req.Description,
+	})
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("refund charge", rid, resp)
+
+	// The refund already happened in the provider: it is recorded even if the client went
+	// away, and a failure to record it is logged, not returned.
+	if resp.OK() && refunded != nil && refunded.ID != "" {
+		ctx := context.WithoutCancel(ctx)
+		refund := model.ChargeRefund{
+			Value:          value,
+			Description:    req.Description,
+			ProviderStatus: refunded.Status,
+			RequestID:      rid,
+		}
+		if p := auth.FromContext(ctx); p != nil {
+			refund.RequestedBy = p.Subject
+		}
+		recordRefund(ctx, rid, chargeProvider.Name(), chargeRow, refunded, refund)
+
+		if isOneOffCharge(chargeRow) {
+			syncOneOffChargeFromPayment(ctx, "REFUND", refunded)
+		}
+	}
+
+	// Pass-through provider payload
+	writeProviderResponse(w, resp)
+}
+
+// recordRefund appends refund to the charge's history in iam.charges. The provider call
+// is made without the charge lock (it can take the whole write timeout plus retries);
+// only the re-read and merge hold it, like syncStoredCharge, so refunds and webhooks of
+// the same charge are not overwritten with a stale copy.
+func recordRefund(ctx context.Context, rid, providerName string, stored *model.IamChargeRow, refunded *provider.Payment, refund model.ChargeRefund) {
+	defer billing.ChargeLocks.Lock(providerName + ":" + refunded.ID)()
+	if current, err := supabase.GetChargeByIDContext(ctx, stored.ID); err != nil || current == nil {
+		log.Printf("[supabase] ERROR reloading charge after refund, merging into the copy read before: rid=%s payment_id=%s err=%v", rid, refunded.ID, err)
+	} else {
+		stored = current
+	}
+
+	row := chargeRowFromStored(providerName, stored, *refunded)
+	refund.CreatedAt = *row.UpdatedAt
+	billing.AppendRefund(&row, refund)
+	if err := supabase.UpsertChargesContext(ctx, []model.IamChargeRow{row}); err != nil {
+		log.Printf("[supabase] ERROR recording refund in iam.charges: rid=%s payment_id=%s value=%.2f err=%v", rid, refunded.ID, refund.Value, err)
+		return
+	}
+	log.Printf("[asaas] ✅ refund recorded: rid=%s payment_id=%s value=%.2f refunded_total=%.2f status=%s",
+		rid, refunded.ID, refund.Value, *row.RefundedValue, refunded.Status)
+}
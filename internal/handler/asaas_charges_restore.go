@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// RestoreAsaasCharge godoc
+// @Summary      Restaurar cobrança excluída no Asaas
+// @Description  Restaura uma cobrança (payment) excluída no Asaas e limpa a exclusão em iam.charges (deleted_at, deleted_reason). A integração é a do contrato da cobrança (iam.charges → contrato); cobranças ausentes de iam.charges são restauradas no provedor e voltam a iam.charges pelo webhook PAYMENT_RESTORED.
+// @Tags         asaas
+// @Produce      json
+// @Param        id                    path      string  true   "ID da cobrança no Asaas (payment_id)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Success      200  {object}  model.AsaasPaymentResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      422  {object}  map[string]any  "Cobrança não está excluída no provedor (code: provider_validation_error)"
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/charges/{id}/restore [post]
+func RestoreAsaasCharge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	paymentID, accountingOfficeID, ok := chargeOperationParams(w, r)
+	if !ok {
+		return
+	}
+
+	// Soft-deleted rows are still found, so the charge's contract selects the integration.
+	res, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ProviderChargeID:   paymentID,
+	})
+	if !ok {
+		return
+	}
+
+	log.Printf("[asaas] restore charge: rid=%s payment_id=%s office=%s in_iam=%t", rid, paymentID, accountingOfficeID, res.Charge != nil)
+
+	restored, resp, callErr := chargeProvider.RestorePayment(paymentID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("restore charge", rid, resp)
+
+	if resp.OK() && restored != nil && restored.ID != "" && res.Charge != nil {
+		syncStoredCharge(ctx, rid, "RESTORE", chargeProvider.Name(), res.Charge, restored)
+		if err := supabase.RestoreChargeByProviderIDContext(context.WithoutCancel(ctx), chargeProvider.Name(), paymentID); err != nil {
+			log.Printf("[supabase] ERROR clearing deleted_at after restore: rid=%s payment_id=%s err=%v", rid, paymentID, err)
+		}
+	}
+
+	// Pass-through provider payload
+	writeProviderResponse(w, resp)
+}
@@ -5,10 +5,9 @@ import (
 	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
 	"github.com/seuuser/charges-service/internal/model"
 	"github.com/seuuser/charges-service/internal/supabase"
 )
@@ -24,10 +23,16 @@ import (
 // @Param        body                  body      model.AsaasUpdateChargeRequest  true  "Payload da atualização"
 // @Success      200  {object}  model.AsaasPaymentResponse
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/charges/{id} [put]
 func UpdateAsaasCharge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	rid := newRequestID()
 	paymentID := strings.TrimSpace(chi.URLParam(r, "id"))
 	if paymentID == "" {
@@ -40,6 +45,9 @@ func UpdateAsaasCharge(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 
 	var req model.AsaasUpdateChargeRequest
 	dec := json.NewDecoder(r.Body)
@@ -61,249 +69,62 @@ func UpdateAsaasCharge(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 
-	// Resolve integration config:
-	// We should NOT guess by office/provider only, because an office may have multiple integrations
-	// and the contract explicitly selects one (billing_integration_id).
-	//
-	// Strategy:
-	// 1) Load charge (iam.charges) by payment_id + accounting_office_id
-	// 2) Load contract (iam.fee_contracts) by charge.contract_id
-	// 3) If contract has billing_integration_id, use it; else fallback to env/default like subscriptions handler
-	chargeRow, chErr := supabase.GetChargeByProviderIDAndOffice("ASAAS", paymentID, accountingOfficeID)
-	if chErr != nil || chargeRow == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{
-			"error":                "charge not found for office/provider",
-			"accounting_office_id": accountingOfficeID,
-			"provider":             "ASAAS",
-			"provider_charge_id":   paymentID,
-		})
-		return
-	}
-
-	contract, cErr := supabase.GetFeeContractByID(chargeRow.ContractID)
-	if cErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load contract", "request_id": rid})
+	// Resolve integration config through the charge: an office may have several
+	// integrations (HML/PRD) and the charge's contract selects one.
+	// iam.charges (payment_id + office) → contract → billing_integration_id / provider_environment / office default
+	chargeRow, chargeProvider, ok := storedChargeProvider(ctx, w, rid, accountingOfficeID, paymentID)
+	if !ok {
 		return
 	}
-	if contract == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "contract not found", "contract_id": chargeRow.ContractID})
-		return
-	}
-
-	provider := "ASAAS"
-	if contract.Provider != nil && strings.TrimSpace(*contract.Provider) != "" {
-		provider = normalizeProvider(*contract.Provider)
-	}
-
-	var cfg *model.BillingIntegrationRow
-	var err error
-	if contract.BillingIntegrationID != nil && strings.TrimSpace(*contract.BillingIntegrationID) != "" {
-		cfg, err = supabase.GetBillingIntegrationByID(strings.TrimSpace(*contract.BillingIntegrationID))
-	} else if contract.ProviderEnvironment != nil && strings.TrimSpace(*contract.ProviderEnvironment) != "" {
-		cfg, err = supabase.GetBillingIntegrationForOfficeAndEnvironment(contract.AccountingOfficeID, provider, strings.TrimSpace(*contract.ProviderEnvironment))
-		if err != nil {
-			cfg, err = supabase.GetBillingIntegrationForOffice(contract.AccountingOfficeID, provider)
-		}
-	} else {
-		cfg, err = supabase.GetBillingIntegrationForOffice(contract.AccountingOfficeID, provider)
-	}
 
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{
-			"error":                "billing integration not found for contract/office/provider",
-			"accounting_office_id": contract.AccountingOfficeID,
-			"provider":             provider,
-			"contract_id":          contract.ID,
-			"billing_integration_id": func() any {
-				if contract.BillingIntegrationID != nil {
-					return strings.TrimSpace(*contract.BillingIntegrationID)
-				}
-				return nil
-			}(),
-			"hint": "Garanta que o contrato tenha billing_integration_id válido e que exista registro ativo em iam.billing_integrations (is_active=true).",
-		})
-		return
-	}
-	if isDebugEnabled() {
-		log.Printf(
-			"[asaas] using integration cfg for charge update: id=%s provider=%s env=%s base_api=%q token=%s",
-			cfg.ID,
-			cfg.Provider,
-			cfg.Environment,
-			cfg.BaseAPI,
-			maskToken(cfg.Token),
-		)
-	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+	updated, resp, callErr := chargeProvider.UpdatePayment(paymentID, mapUpdatePaymentRequest(req))
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
 		return
 	}
+	logProviderResponse("update charge", rid, resp)
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
+	// If 2xx, sync both iam.charges and (if one-off) iam.fee_contract_one_off_charges.
+	// Non-fatal: the charge was already updated in the provider.
+	if resp.OK() && updated != nil && updated.ID != "" {
+		// ── 1. Upsert iam.charges (context fields come from the existing row) ──
+		row := chargeRowFromStored(chargeProvider.Name(), chargeRow, *updated)
 
-	// Build update request
-	updateReq := mapUpdatePaymentRequest(req)
-
-	status, body, callErr := client.UpdatePayment(paymentID, updateReq)
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
-		return
-	}
-
-	if isDebugEnabled() {
-		raw := string(body)
-		if len(raw) > 800 {
-			raw = raw[:800] + "…(truncated)"
+		if upsertErr := supabase.UpsertChargesContext(ctx, []model.IamChargeRow{row}); upsertErr != nil {
+			if isDebugEnabled() {
+				log.Printf("[supabase] ERROR upserting charge after update: payment_id=%s err=%v", updated.ID, upsertErr)
+			}
+		} else if isDebugEnabled() {
+			log.Printf("[supabase] iam.charges updated: payment_id=%s status=%s due=%s value=%.2f",
+				updated.ID, updated.Status, updated.DueDate, updated.Value)
 		}
-		log.Printf("[asaas] update charge response: rid=%s status=%d body=%s", rid, status, raw)
-	}
-
-	// If 2xx, sync both iam.charges and (if one-off) iam.fee_contract_one_off_charges
-	if status >= 200 && status < 300 {
-		var updated model.AsaasPaymentResponse
-		if err := json.Unmarshal(body, &updated); err == nil && strings.TrimSpace(updated.ID) != "" {
-			// Find the existing charge in our database to get required IDs
-			charge, fetchErr := supabase.GetChargeByProviderID("ASAAS", updated.ID)
-			if fetchErr != nil {
-				if isDebugEnabled() {
-					log.Printf("[supabase] ERROR fetching charge for update: payment_id=%s err=%v", updated.ID, fetchErr)
-				}
-				// Non-fatal: we updated in Asaas but couldn't sync to our DB
-			} else if charge != nil {
-				now := time.Now().UTC().Format(time.RFC3339)
-
-				// ── helpers: pointer from non-empty string ──────────────────
-				strPtr := func(s string) *string {
-					s = strings.TrimSpace(s)
-					if s == "" {
-						return nil
-					}
-					return &s
-				}
-
-				var netPtr *float64
-				if updated.NetValue != 0 {
-					v := updated.NetValue
-					netPtr = &v
-				}
-
-				rawPayload, _ := json.Marshal(updated)
 
-				// ── 1. Upsert iam.charges ────────────────────────────────────
-				row := model.IamChargeRow{
-					TenantID:               charge.TenantID,
-					AccountingOfficeID:     charge.AccountingOfficeID,
-					CompanyID:              charge.CompanyID,
-					ContractID:             charge.ContractID,
-					Provider:               "ASAAS",
-					ProviderChargeID:       updated.ID,
-					ProviderInstallmentID:  charge.ProviderInstallmentID,
-					ProviderSubscriptionID: charge.ProviderSubscriptionID,
-					InstallmentNumber:      charge.InstallmentNumber,
-					Value:                  updated.Value,
-					NetValue:               netPtr,
-					Description:            strPtr(updated.Description),
-					BillingType:            strPtr(updated.BillingType),
-					Status:                 strPtr(updated.Status),
-					DueDate:                strPtr(updated.DueDate),
-					OriginalDueDate:        strPtr(updated.OriginalDueDate),
-					InvoiceURL:             strPtr(updated.InvoiceURL),
-					InvoiceNumber:          strPtr(updated.InvoiceNumber),
-					ExternalReference:      strPtr(updated.ExternalReference),
-					UpdatedAt:              &now,
-					ProviderPayload:        rawPayload,
-				}
-
-				if upsertErr := supabase.UpsertCharges([]model.IamChargeRow{row}); upsertErr != nil {
-					if isDebugEnabled() {
-						log.Printf("[supabase] ERROR upserting charge after update: payment_id=%s err=%v", updated.ID, upsertErr)
-					}
-					// Non-fatal
-				} else if isDebugEnabled() {
-					log.Printf("[supabase] iam.charges updated: payment_id=%s status=%s due=%s value=%.2f",
-						updated.ID, updated.Status, updated.DueDate, updated.Value)
-				}
-
-				// ── 2. Sync iam.fee_contract_one_off_charges via RPC ───────────────
-				// For subscription instalments (provider_subscription_id is set) we only
-				// update iam.charges above; the one-off table is not touched.
-				// For one-off charges the RPC updates provider_status using provider_charge_id
-				// (already linked on first create). externalReference is passed as fallback
-				// so the RPC can recover if provider_charge_id was not yet set.
-				isOneOff := charge.ProviderSubscriptionID == nil || strings.TrimSpace(*charge.ProviderSubscriptionID) == ""
-				if isOneOff {
-					eref := strings.TrimSpace(updated.ExternalReference)
-
-					// Build optional extra fields from the Asaas response.
-					var syncExtra *supabase.OneOffSyncFields
-					if updated.Value > 0 || strings.TrimSpace(updated.DueDate) != "" || strings.TrimSpace(updated.BillingType) != "" {
-						syncExtra = &supabase.OneOffSyncFields{}
-						if updated.Value > 0 {
-							v := updated.Value
-							syncExtra.Value = &v
-						}
-						if d := strings.TrimSpace(updated.DueDate); d != "" {
-							syncExtra.DueDate = &d
-						}
-						if bt := strings.TrimSpace(updated.BillingType); bt != "" {
-							syncExtra.BillingType = &bt
-						}
-					}
-
-					log.Printf("[supabase] syncing one_off_charge after UPDATE: payment_id=%s status=%s external_reference=%q value=%v due=%s billing=%s",
-						updated.ID, updated.Status, eref, updated.Value, updated.DueDate, updated.BillingType)
-					if syncErr := supabase.SyncOneOffChargeFromProvider(updated.ID, strings.TrimSpace(updated.Status), eref, syncExtra); syncErr != nil {
-						log.Printf("[supabase] ERROR sync_one_off_charge failed after UPDATE: payment_id=%s err=%v", updated.ID, syncErr)
-						// Non-fatal: iam.charges is already updated; one-off sync is best-effort
-					} else {
-						log.Printf("[supabase] OK fee_contract_one_off_charges synced after UPDATE: provider_charge_id=%s new_status=%s",
-							updated.ID, updated.Status)
-					}
-				}
-			}
+		// ── 2. Sync iam.fee_contract_one_off_charges via RPC ───────────────
+		// For subscription instalments (provider_subscription_id is set) we only
+		// update iam.charges above; the one-off table is not touched.
+		// For one-off charges the RPC updates provider_status using provider_charge_id
+		// (already linked on first create). externalReference is passed as fallback
+		// so the RPC can recover if provider_charge_id was not yet set.
+		if isOneOffCharge(chargeRow) {
+			syncOneOffChargeFromPayment(ctx, "UPDATE", updated)
 		}
 	}
 
-	// Pass-through Asaas payload
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	// Pass-through provider payload
+	writeProviderResponse(w, resp)
 }
 
-func mapUpdatePaymentRequest(req model.AsaasUpdateChargeRequest) asaas.UpdatePaymentRequest {
-	out := asaas.UpdatePaymentRequest{}
-
-	// BillingType: AsaasBillingType → *string (Asaas client expects *string)
+func mapUpdatePaymentRequest(req model.AsaasUpdateChargeRequest) provider.PaymentUpdate {
+	out := provider.PaymentUpdate{
+		Value:    req.Value,
+		DueDate:  req.DueDate,
+		Discount: toProviderDiscount(req.Discount),
+		Interest: toProviderInterest(req.Interest),
+		Fine:     toProviderFine(req.Fine),
+	}
 	if req.BillingType != nil {
 		s := string(*req.BillingType)
 		out.BillingType = &s
 	}
-	out.Value = req.Value
-	out.DueDate = req.DueDate
-
-	if req.Discount != nil {
-		var t *string
-		if req.Discount.Type != nil {
-			s := string(*req.Discount.Type)
-			t = &s
-		}
-		out.Discount = &asaas.PaymentDiscount{
-			Value:            req.Discount.Value,
-			DueDateLimitDays: req.Discount.DueDateLimitDays,
-			Type:             t,
-		}
-	}
-	if req.Interest != nil {
-		out.Interest = &asaas.PaymentInterest{Value: req.Interest.Value}
-	}
-	if req.Fine != nil {
-		var t *string
-		if req.Fine.Type != nil {
-			s := string(*req.Fine.Type)
-			t = &s
-		}
-		out.Fine = &asaas.PaymentFine{Value: req.Fine.Value, Type: t}
-	}
-
 	return out
 }
@@ -7,7 +7,9 @@ import (
 	"os"
 	"strings"
 
+	"github.com/seuuser/charges-service/internal/billing"
 	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
 	"github.com/seuuser/charges-service/internal/model"
 	"github.com/seuuser/charges-service/internal/supabase"
 )
@@ -55,36 +57,40 @@ func itoa(n int) string {
 }
 
 func normalizeProvider(p string) string {
-	p = strings.ToUpper(strings.TrimSpace(p))
-	if strings.HasPrefix(p, "ASSAS") || strings.HasPrefix(p, "ASAAS") {
-		return "ASAAS"
-	}
-	if i := strings.IndexByte(p, '_'); i > 0 {
-		return p[:i]
-	}
-	return p
+	return provider.NormalizeName(p)
 }
 
 // CreateAsaasCustomer godoc
 // @Summary      Criar novo cliente no Asaas
-// @Description  Cria um cliente no Asaas usando a integração ativa (is_active=true) do escritório (accounting_office_id) cadastrada no Supabase (iam.billing_integrations). Quando existir mais de uma integração ativa, o serviço prioriza is_default=true e depois a mais recente.
+// @Description  Cria um cliente no Asaas na integração (iam.billing_integrations) do contrato informado (contract_id) ou do contrato mais recente da empresa no escritório; sem contrato, usa a integração ativa padrão do escritório (is_default=true, depois a mais recente).
 // @Tags         asaas
 // @Accept       json
 // @Produce      json
 // @Param        accounting_office_id  query     string  true  "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório"
 // @Param        company_id            query     string  true  "ID da empresa (company_id, UUID)"
 // @Param        body                  body      model.AsaasCreateCustomerRequest  true  "Payload (campos mínimos)"
+// @Param        Idempotency-Key  header  string  false  "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original"
 // @Success      200  {object}  model.AsaasCustomerResponse
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/customers [post]
 func CreateAsaasCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 
 	companyID := strings.TrimSpace(r.URL.Query().Get("company_id"))
 	if companyID == "" {
@@ -112,7 +118,7 @@ func CreateAsaasCustomer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Avoid duplicate customers in Asaas if integration already exists for this company (scoped by current tenant inside RPC)
-	existingCustomerID, err := supabase.GetCompanyAsaasCustomerID(companyID)
+	existingCustomerID, err := supabase.GetCompanyAsaasCustomerIDContext(ctx, companyID)
 	if err != nil {
 		if isDebugEnabled() {
 			log.Printf("[asaas] warning: error checking existing company.asaas_integration (will continue): %v", err)
@@ -130,66 +136,44 @@ func CreateAsaasCustomer(w http.ResponseWriter, r *http.Request) {
 
 	if isDebugEnabled() {
 		log.Printf("[asaas] no existing asaas_integration found for company_id=%s", companyID)
-		log.Printf("[asaas] loading billing integration config for office_id=%s provider=%s", accountingOfficeID, normalizeProvider("ASAAS"))
+		log.Printf("[asaas] loading billing integration config for office_id=%s provider=%s", accountingOfficeID, normalizeProvider(asaas.ProviderName))
 	}
 
-	cfg, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found for office/provider"})
-		return
-	}
-
-	if isDebugEnabled() {
-		log.Printf(
-			"[asaas] using integration cfg: id=%s provider=%s env=%s is_default=%v base_api=%q token=%s",
-			cfg.ID,
-			cfg.Provider,
-			cfg.Environment,
-			cfg.IsDefault,
-			cfg.BaseAPI,
-			maskToken(cfg.Token),
-		)
-	}
-
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, "", billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ContractID:         strings.TrimSpace(r.URL.Query().Get("contract_id")),
+		CompanyID:          companyID,
+	})
+	if !ok {
 		return
 	}
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-	status, body, callErr := client.CreateCustomer(asaas.CreateCustomerRequest{
+	notificationDisabled := req.NotificationDisabled
+	company := req.Company
+	created, resp, callErr := chargeProvider.CreateCustomer(provider.CustomerInput{
 		Name:                 req.Name,
 		CpfCnpj:              req.CpfCnpj,
 		Email:                req.Email,
 		MobilePhone:          req.MobilePhone,
-		NotificationDisabled: req.NotificationDisabled,
-		Company:              req.Company,
+		NotificationDisabled: &notificationDisabled,
+		Company:              &company,
 	})
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
 		return
 	}
+	logProviderResponse("create customer", "", resp)
 
-	if isDebugEnabled() {
-		// log response status + truncated body (avoid huge logs)
-		raw := string(body)
-		if len(raw) > 800 {
-			raw = raw[:800] + "…(truncated)"
-		}
-		log.Printf("[asaas] create customer response: status=%d body=%s", status, raw)
-	}
-
-	// Parse Asaas response to extract customer id
-	if status >= 200 && status < 300 {
-		var asaasResp model.AsaasCustomerResponse
-		if err := json.Unmarshal(body, &asaasResp); err != nil || strings.TrimSpace(asaasResp.ID) == "" {
+	// Parse provider response to extract customer id
+	if resp.OK() {
+		if created == nil || strings.TrimSpace(created.ID) == "" {
 			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "invalid asaas response (missing id)"})
 			return
 		}
 
 		// Persist mapping in Supabase (company.asaas_integration) via RPC (public schema)
-		if err := supabase.UpsertCompanyAsaasIntegration(companyID, asaasResp.ID); err != nil {
-			log.Printf("[asaas] ERROR persisting company.asaas_integration: company_id=%s asaas_customer_id=%s err=%v", companyID, asaasResp.ID, err)
+		if err := supabase.UpsertCompanyAsaasIntegrationContext(ctx, companyID, created.ID); err != nil {
+			log.Printf("[asaas] ERROR persisting company.asaas_integration: company_id=%s asaas_customer_id=%s err=%v", companyID, created.ID, err)
 			// We must persist this id; otherwise future charges can't be generated reliably.
 			if isDebugEnabled() {
 				writeJSON(w, http.StatusBadGateway, map[string]any{
@@ -201,17 +185,10 @@ func CreateAsaasCustomer(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to persist asaas integration"})
 			return
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(status)
-		_, _ = w.Write(body)
-		return
 	}
 
-	// Non-2xx: pass-through Asaas error payload
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	// Pass-through provider payload (success or error)
+	writeProviderResponse(w, resp)
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload any) {
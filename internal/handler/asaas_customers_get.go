@@ -6,29 +6,39 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/billing"
 	"github.com/seuuser/charges-service/internal/supabase"
 )
 
 // GetAsaasCustomerByID godoc
 // @Summary      Recuperar um único cliente no Asaas
-// @Description  Recupera um cliente no Asaas pelo ID (Asaas customer id), usando a integração ativa (is_active=true) do escritório (accounting_office_id) cadastrada no Supabase (iam.billing_integrations). Referência Asaas: https://docs.asaas.com/reference/recuperar-um-unico-cliente
+// @Description  Recupera um cliente no Asaas pelo ID (Asaas customer id), usando a integração do contrato informado (contract_id) ou, sem ele, a integração ativa padrão do escritório (accounting_office_id) em iam.billing_integrations. Referência Asaas: https://docs.asaas.com/reference/recuperar-um-unico-cliente
 // @Tags         asaas
 // @Accept       json
 // @Produce      json
 // @Param        accounting_office_id  query     string  true  "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório"
 // @Param        id                   path      string  true  "ID do cliente no Asaas"
 // @Success      200  {object}  model.AsaasCustomerResponse
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/customers/{id} [get]
 func GetAsaasCustomerByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 
 	customerID := strings.TrimSpace(chi.URLParam(r, "id"))
 	if customerID == "" {
@@ -40,35 +50,23 @@ func GetAsaasCustomerByID(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[asaas] get customer by id: accounting_office_id=%s customer_id=%s", accountingOfficeID, customerID)
 	}
 
-	cfg, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found for office/provider"})
-		return
-	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, "", billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ContractID:         strings.TrimSpace(r.URL.Query().Get("contract_id")),
+	})
+	if !ok {
 		return
 	}
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-	status, body, callErr := client.GetCustomer(customerID)
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
+	_, resp, callErr := chargeProvider.GetCustomer(customerID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
 		return
 	}
+	logProviderResponse("get customer", "", resp)
 
-	if isDebugEnabled() {
-		raw := string(body)
-		if len(raw) > 800 {
-			raw = raw[:800] + "…(truncated)"
-		}
-		log.Printf("[asaas] get customer response: status=%d body=%s", status, raw)
-	}
-
-	// Pass-through Asaas payload (it matches model.AsaasCustomerResponse on success)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	// Pass-through provider payload (it matches model.AsaasCustomerResponse on success)
+	writeProviderResponse(w, resp)
 }
 
 // GetAsaasCustomerByCompanyID godoc
@@ -78,18 +76,28 @@ func GetAsaasCustomerByID(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        accounting_office_id  query     string  true  "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório"
 // @Param        company_id            query     string  true  "ID da empresa (company_id, UUID)"
 // @Success      200  {object}  model.AsaasCustomerResponse
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/customers/by-company [get]
 func GetAsaasCustomerByCompanyID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 
 	companyID := strings.TrimSpace(r.URL.Query().Get("company_id"))
 	if companyID == "" {
@@ -101,7 +109,7 @@ func GetAsaasCustomerByCompanyID(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[asaas] get customer by company: accounting_office_id=%s company_id=%s", accountingOfficeID, companyID)
 	}
 
-	asaasCustomerID, err := supabase.GetCompanyAsaasCustomerID(companyID)
+	asaasCustomerID, err := supabase.GetCompanyAsaasCustomerIDContext(ctx, companyID)
 	if err != nil {
 		if isDebugEnabled() {
 			log.Printf("[asaas] error loading asaas_customer_id from mapping: company_id=%s err=%v", companyID, err)
@@ -121,32 +129,22 @@ func GetAsaasCustomerByCompanyID(w http.ResponseWriter, r *http.Request) {
 	rctx.URL.RawQuery = q.Encode()
 
 	// We can't easily "call" the other handler with a path param, so just repeat the call.
-	cfg, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found for office/provider"})
-		return
-	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, "", billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ContractID:         strings.TrimSpace(r.URL.Query().Get("contract_id")),
+		CompanyID:          companyID,
+	})
+	if !ok {
 		return
 	}
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-	status, body, callErr := client.GetCustomer(asaasCustomerID)
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
+	_, resp, callErr := chargeProvider.GetCustomer(asaasCustomerID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
 		return
 	}
+	logProviderResponse("get customer (by-company)", "", resp)
 
-	if isDebugEnabled() {
-		raw := string(body)
-		if len(raw) > 800 {
-			raw = raw[:800] + "…(truncated)"
-		}
-		log.Printf("[asaas] get customer (by-company) response: status=%d body=%s", status, raw)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	// Pass-through provider payload (it matches model.AsaasCustomerResponse on success)
+	writeProviderResponse(w, resp)
 }
@@ -7,31 +7,42 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
 	"github.com/seuuser/charges-service/internal/model"
 	"github.com/seuuser/charges-service/internal/supabase"
 )
 
 // UpdateAsaasCustomerByID godoc
 // @Summary      Atualizar cliente existente no Asaas
-// @Description  Atualiza um cliente existente no Asaas pelo ID (Asaas customer id), usando a integração ativa (is_active=true) do escritório (accounting_office_id) cadastrada no Supabase (iam.billing_integrations). Referência Asaas: https://docs.asaas.com/reference/atualizar-cliente-existente
+// @Description  Atualiza um cliente existente no Asaas pelo ID (Asaas customer id), usando a integração do contrato informado (contract_id) ou, sem ele, a integração ativa padrão do escritório (accounting_office_id) em iam.billing_integrations. Referência Asaas: https://docs.asaas.com/reference/atualizar-cliente-existente
 // @Tags         asaas
 // @Accept       json
 // @Produce      json
 // @Param        accounting_office_id  query     string  true  "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório"
 // @Param        id                   path      string  true  "ID do cliente no Asaas"
 // @Param        body                 body      model.AsaasUpdateCustomerRequest  true  "Campos para atualização (parcial)"
 // @Success      200  {object}  model.AsaasCustomerResponse
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/customers/{id} [put]
 func UpdateAsaasCustomerByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 
 	customerID := strings.TrimSpace(chi.URLParam(r, "id"))
 	if customerID == "" {
@@ -89,55 +100,22 @@ func UpdateAsaasCustomerByID(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[asaas] update customer by id: accounting_office_id=%s customer_id=%s", accountingOfficeID, customerID)
 	}
 
-	cfg, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found for office/provider"})
-		return
-	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
-		return
-	}
-
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-	status, body, callErr := client.UpdateCustomer(customerID, asaas.UpdateCustomerRequest{
-		Name:                 req.Name,
-		CpfCnpj:              req.CpfCnpj,
-		Email:                req.Email,
-		Phone:                req.Phone,
-		MobilePhone:          req.MobilePhone,
-		Address:              req.Address,
-		AddressNumber:        req.AddressNumber,
-		Complement:           req.Complement,
-		Province:             req.Province,
-		City:                 req.City,
-		State:                req.State,
-		Country:              req.Country,
-		PostalCode:           req.PostalCode,
-		AdditionalEmails:     req.AdditionalEmails,
-		ExternalReference:    req.ExternalReference,
-		Observations:         req.Observations,
-		PersonType:           string(req.PersonType),
-		NotificationDisabled: req.NotificationDisabled,
-		Company:              req.Company,
-		ForeignCustomer:      req.ForeignCustomer,
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, "", billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ContractID:         strings.TrimSpace(r.URL.Query().Get("contract_id")),
 	})
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
+	if !ok {
 		return
 	}
 
-	if isDebugEnabled() {
-		raw := string(body)
-		if len(raw) > 800 {
-			raw = raw[:800] + "…(truncated)"
-		}
-		log.Printf("[asaas] update customer response: status=%d body=%s", status, raw)
+	_, resp, callErr := chargeProvider.UpdateCustomer(customerID, customerInputFromUpdate(req))
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
+		return
 	}
+	logProviderResponse("update customer", "", resp)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
+	writeProviderResponse(w, resp)
 }
 
 // UpdateAsaasCustomerByCompanyID godoc
@@ -147,19 +125,29 @@ func UpdateAsaasCustomerByID(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        accounting_office_id  query     string  true  "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração (billing_integration_id / provider_environment); sem ele usa o contrato mais recente da empresa ou a integração padrão do escritório"
 // @Param        company_id            query     string  true  "ID da empresa (company_id, UUID)"
 // @Param        body                  body      model.AsaasUpdateCustomerRequest  true  "Campos para atualização (parcial)"
 // @Success      200  {object}  model.AsaasCustomerResponse
 // @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
 // @Failure      404  {object}  map[string]any
 // @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /v1/asaas/customers/by-company [put]
 func UpdateAsaasCustomerByCompanyID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
 	if accountingOfficeID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
 		return
 	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
 
 	companyID := strings.TrimSpace(r.URL.Query().Get("company_id"))
 	if companyID == "" {
@@ -216,7 +204,7 @@ func UpdateAsaasCustomerByCompanyID(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[asaas] update customer by company: accounting_office_id=%s company_id=%s", accountingOfficeID, companyID)
 	}
 
-	asaasCustomerID, err := supabase.GetCompanyAsaasCustomerID(companyID)
+	asaasCustomerID, err := supabase.GetCompanyAsaasCustomerIDContext(ctx, companyID)
 	if err != nil {
 		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to resolve asaas customer id"})
 		return
@@ -226,18 +214,27 @@ func UpdateAsaasCustomerByCompanyID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cfg, err := supabase.GetBillingIntegrationForOffice(accountingOfficeID, normalizeProvider("ASAAS"))
-	if err != nil || cfg == nil {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found for office/provider"})
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, "", billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ContractID:         strings.TrimSpace(r.URL.Query().Get("contract_id")),
+		CompanyID:          companyID,
+	})
+	if !ok {
 		return
 	}
-	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+
+	_, resp, callErr := chargeProvider.UpdateCustomer(asaasCustomerID, customerInputFromUpdate(req))
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, "", callErr)
 		return
 	}
+	logProviderResponse("update customer (by-company)", "", resp)
 
-	client := asaas.NewClient(cfg.BaseAPI, cfg.Token)
-	status, body, callErr := client.UpdateCustomer(asaasCustomerID, asaas.UpdateCustomerRequest{
+	writeProviderResponse(w, resp)
+}
+
+func customerInputFromUpdate(req model.AsaasUpdateCustomerRequest) provider.CustomerInput {
+	return provider.CustomerInput{
 		Name:                 req.Name,
 		CpfCnpj:              req.CpfCnpj,
 		Email:                req.Email,
@@ -258,21 +255,5 @@ func UpdateAsaasCustomerByCompanyID(w http.ResponseWriter, r *http.Request) {
 		NotificationDisabled: req.NotificationDisabled,
 		Company:              req.Company,
 		ForeignCustomer:      req.ForeignCustomer,
-	})
-	if callErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": callErr.Error()})
-		return
 	}
-
-	if isDebugEnabled() {
-		raw := string(body)
-		if len(raw) > 800 {
-			raw = raw[:800] + "…(truncated)"
-		}
-		log.Printf("[asaas] update customer (by-company) response: status=%d body=%s", status, raw)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write(body)
 }
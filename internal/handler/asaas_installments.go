@@ -0,0 +1,363 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/seuuser/charges-service/internal/auth"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// deleted_reason of the installments removed by DELETE /v1/asaas/installments/{id}/payments.
+const deletedReasonInstallmentCancelled = "installment cancelled via API"
+
+// GetAsaasInstallment godoc
+// @Summary      Consultar parcelamento no Asaas
+// @Description  Retorna o parcelamento (installment) do Asaas: valor total, valor de cada parcela e quantidade de parcelas. A integração é a do contrato das parcelas (iam.charges.provider_installment_id → contrato).
+// @Tags         asaas
+// @Produce      json
+// @Param        id                    path      string  true   "ID do parcelamento no Asaas (installment)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Success      200  {object}  model.AsaasInstallmentResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/installments/{id} [get]
+func GetAsaasInstallment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	installmentID, accountingOfficeID, ok := officeResourceParams(w, r, "installment")
+	if !ok {
+		return
+	}
+
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID:    accountingOfficeID,
+		ProviderInstallmentID: installmentID,
+	})
+	if !ok {
+		return
+	}
+
+	_, resp, callErr := chargeProvider.GetInstallment(installmentID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("get installment", rid, resp)
+
+	writeProviderResponse(w, resp)
+}
+
+// ListAsaasInstallmentPayments godoc
+// @Summary      Listar parcelas de um parcelamento no Asaas (paginado)
+// @Description  Lista as cobranças (payments) de um parcelamento do Asaas. A integração é a do contrato das parcelas (iam.charges.provider_installment_id → contrato).
+// @Tags         asaas
+// @Produce      json
+// @Param        id                    path      string  true   "ID do parcelamento no Asaas (installment)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Param        offset                query     int     false  "Elemento inicial da lista"
+// @Param        limit                 query     int     false  "Número de elementos da lista (max: 100)"
+// @Param        status                query     string  false  "Filtrar por status" Enums(PENDING,RECEIVED,CONFIRMED,OVERDUE,REFUNDED,RECEIVED_IN_CASH,REFUND_REQUESTED,REFUND_IN_PROGRESS,CHARGEBACK_REQUESTED,CHARGEBACK_DISPUTE,AWAITING_CHARGEBACK_REVERSAL,DUNNING_REQUESTED,DUNNING_RECEIVED,AWAITING_RISK_ANALYSIS)
+// @Success      200  {object}  model.AsaasPaymentsListResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/installments/{id}/payments [get]
+func ListAsaasInstallmentPayments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	installmentID, accountingOfficeID, ok := officeResourceParams(w, r, "installment")
+	if !ok {
+		return
+	}
+
+	filter, ok := paymentPageFilter(w, r)
+	if !ok {
+		return
+	}
+
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID:    accountingOfficeID,
+		ProviderInstallmentID: installmentID,
+	})
+	if !ok {
+		return
+	}
+
+	_, resp, callErr := chargeProvider.ListInstallmentPayments(installmentID, filter)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("list installment payments", rid, resp)
+
+	writeProviderResponse(w, resp)
+}
+
+// CancelAsaasInstallmentPayments godoc
+// @Summary      Cancelar parcelas pendentes de um parcelamento no Asaas
+// @Description  Exclui no Asaas as parcelas pendentes e vencidas (PENDING/OVERDUE) do parcelamento; as parcelas recebidas são mantidas. As parcelas excluídas são marcadas como excluídas em iam.charges (deleted_at, deleted_reason), mantendo o histórico.
+// @Tags         asaas
+// @Produce      json
+// @Param        id                    path      string  true   "ID do parcelamento no Asaas (installment)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Success      200  {object}  map[string]any
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      422  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/installments/{id}/payments [delete]
+func CancelAsaasInstallmentPayments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	installmentID, accountingOfficeID, ok := officeResourceParams(w, r, "installment")
+	if !ok {
+		return
+	}
+
+	res, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID:    accountingOfficeID,
+		ProviderInstallmentID: installmentID,
+	})
+	if !ok {
+		return
+	}
+
+	log.Printf("[asaas] cancel installment payments: rid=%s installment=%s office=%s in_iam=%d",
+		rid, installmentID, accountingOfficeID, len(res.Charges))
+
+	resp, callErr := chargeProvider.CancelInstallmentPayments(installmentID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("cancel installment payments", rid, resp)
+
+	if resp.OK() && len(res.Charges) > 0 {
+		syncCancelledInstallment(ctx, rid, chargeProvider, installmentID, res.Charges)
+	}
+
+	// Pass-through provider payload
+	writeProviderResponse(w, resp)
+}
+
+// RefundAsaasInstallment godoc
+// @Summary      Estornar parcelamento no Asaas
+// @Description  Estorna todas as parcelas recebidas do parcelamento (cartão de crédito). Em iam.charges, cada parcela estornada recebe o novo status e um registro no histórico de estornos (refunds) com o saldo que ainda era estornável.
+// @Tags         asaas
+// @Produce      json
+// @Param        id                    path      string  true   "ID do parcelamento no Asaas (installment)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Param        Idempotency-Key  header  string  false  "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original"
+// @Success      200  {object}  model.AsaasInstallmentResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      422  {object}  map[string]any  "Parcelamento recusado pelo provedor (code: provider_validation_error)"
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/installments/{id}/refund [post]
+func RefundAsaasInstallment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	installmentID, accountingOfficeID, ok := officeResourceParams(w, r, "installment")
+	if !ok {
+		return
+	}
+
+	res, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID:    accountingOfficeID,
+		ProviderInstallmentID: installmentID,
+	})
+	if !ok {
+		return
+	}
+
+	log.Printf("[asaas] refund installment: rid=%s installment=%s office=%s in_iam=%d",
+		rid, installmentID, accountingOfficeID, len(res.Charges))
+
+	_, resp, callErr := chargeProvider.RefundInstallment(installmentID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("refund installment", rid, resp)
+
+	if resp.OK() && len(res.Charges) > 0 {
+		syncRefundedInstallment(ctx, rid, chargeProvider, installmentID, res.Charges)
+	}
+
+	// Pass-through provider payload
+	writeProviderResponse(w, resp)
+}
+
+// GetAsaasInstallmentPaymentBook godoc
+// @Summary      Carnê do parcelamento (Asaas)
+// @Description  Baixa o carnê (PDF com os boletos de todas as parcelas) de um parcelamento do Asaas.
+// @Tags         asaas
+// @Produce      application/pdf
+// @Param        id                    path      string  true   "ID do parcelamento no Asaas (installment)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      422  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/installments/{id}/payment-book [get]
+func GetAsaasInstallmentPaymentBook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	installmentID, accountingOfficeID, ok := officeResourceParams(w, r, "installment")
+	if !ok {
+		return
+	}
+
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID:    accountingOfficeID,
+		ProviderInstallmentID: installmentID,
+	})
+	if !ok {
+		return
+	}
+
+	book, resp, callErr := chargeProvider.GetInstallmentPaymentBook(installmentID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	if !resp.OK() || book == nil {
+		logProviderResponse("installment payment book", rid, resp)
+		writeProviderResponse(w, resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", book.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="carne-`+installmentID+`.pdf"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(book.Content)
+}
+
+// installmentPaymentsByID lists every payment the provider still has for the installment.
+// ok=false means the list failed (logged): the rows are left to the webhooks.
+func installmentPaymentsByID(ctx context.Context, rid, stage string, p provider.ChargeProvider, installmentID string) (map[string]provider.Payment, bool) {
+	all, err := provider.InstallmentPayments(p.WithContext(ctx), installmentID, provider.PaymentFilter{}).All()
+	if err != nil {
+		log.Printf("[provider] ERROR listing installment payments after %s: rid=%s installment=%s err=%v", stage, rid, installmentID, err)
+		return nil, false
+	}
+	byID := make(map[string]provider.Payment, len(all))
+	for _, pay := range all {
+		if !pay.Deleted {
+			byID[pay.ID] = pay
+		}
+	}
+	return byID, true
+}
+
+// syncCancelledInstallment soft deletes the iam.charges rows whose payment the provider no
+// longer has after the pending installments were cancelled. The cancellation already
+// happened, so it runs even if the client went away and failures are only logged
+// (PAYMENT_DELETED webhooks mark the remaining rows).
+func syncCancelledInstallment(ctx context.Context, rid string, p provider.ChargeProvider, installmentID string, stored []model.IamChargeRow) {
+	ctx = context.WithoutCancel(ctx)
+	remaining, ok := installmentPaymentsByID(ctx, rid, "CANCEL_INSTALLMENT", p, installmentID)
+	if !ok {
+		return
+	}
+
+	deleted := 0
+	for _, row := range stored {
+		if _, found := remaining[row.ProviderChargeID]; found || row.DeletedAt != nil {
+			continue
+		}
+		if err := supabase.SoftDeleteChargeByProviderIDContext(ctx, p.Name(), row.ProviderChargeID, deletedReasonInstallmentCancelled); err != nil {
+			log.Printf("[supabase] ERROR marking cancelled installment as deleted: rid=%s payment_id=%s err=%v", rid, row.ProviderChargeID, err)
+			continue
+		}
+		deleted++
+	}
+	log.Printf("[supabase] iam.charges updated after CANCEL_INSTALLMENT: rid=%s installment=%s deleted=%d kept=%d",
+		rid, installmentID, deleted, len(remaining))
+}
+
+// syncRefundedInstallment writes the refunded payments of an installment back to iam.charges:
+// new status plus, for installments that were refundable before the call, a refund history
+// entry with the balance that was still refundable. Rows are updated one at a time under the
+// charge lock (shared with the webhook and single refunds). Failures are only logged.
+func syncRefundedInstallment(ctx context.Context, rid string, p provider.ChargeProvider, installmentID string, stored []model.IamChargeRow) {
+	ctx = context.WithoutCancel(ctx)
+	current, ok := installmentPaymentsByID(ctx, rid, "REFUND_INSTALLMENT", p, installmentID)
+	if !ok {
+		return
+	}
+
+	requestedBy := ""
+	if principal := auth.FromContext(ctx); principal != nil {
+		requestedBy = principal.Subject
+	}
+
+	for i := range stored {
+		pay, found := current[stored[i].ProviderChargeID]
+		if !found || !billing.RefundedStatus(pay.Status) {
+			continue
+		}
+		// The status before the call tells whether this refund is ours; the row is re-read
+		// under the lock so the balance includes refunds that just finished.
+		wasRefundable := billing.RefundableStatus(stored[i].Status)
+		func() {
+			defer billing.ChargeLocks.Lock(p.Name() + ":" + pay.ID)()
+			chargeRow, err := supabase.GetChargeByIDContext(ctx, stored[i].ID)
+			if err != nil || chargeRow == nil {
+				log.Printf("[supabase] ERROR reloading installment after REFUND_INSTALLMENT: rid=%s payment_id=%s err=%v", rid, pay.ID, err)
+				return
+			}
+
+			row := chargeRowFromStored(p.Name(), chargeRow, pay)
+			if balance := billing.RefundBalanceOf(chargeRow); wasRefundable && balance.Refundable > 0 {
+				billing.AppendRefund(&row, model.ChargeRefund{
+					Value:          balance.Refundable,
+					ProviderStatus: pay.Status,
+					RequestedBy:    requestedBy,
+					RequestID:      rid,
+					CreatedAt:      *row.UpdatedAt,
+				})
+			}
+			if err := supabase.UpsertChargesContext(ctx, []model.IamChargeRow{row}); err != nil {
+				log.Printf("[supabase] ERROR upserting charge after REFUND_INSTALLMENT: rid=%s payment_id=%s err=%v", rid, pay.ID, err)
+			} else {
+				log.Printf("[supabase] iam.charges updated after REFUND_INSTALLMENT: rid=%s payment_id=%s status=%s", rid, pay.ID, pay.Status)
+			}
+			if isOneOffCharge(chargeRow) {
+				syncOneOffChargeFromPayment(ctx, "REFUND_INSTALLMENT", &pay)
+			}
+		}()
+	}
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// deleted_reason of the pending payments cancelled together with their subscription.
+const deletedReasonSubscriptionDeleted = "subscription deleted via API"
+
+// DeleteAsaasSubscription godoc
+// @Summary      Excluir assinatura no Asaas
+// @Description  Exclui uma assinatura (subscription) no Asaas: novas cobranças deixam de ser geradas e iam.fee_contract_subscriptions passa a status DELETED. Com cancel_pending_payments=true, as cobranças pendentes e vencidas (PENDING/OVERDUE) da assinatura também são excluídas no Asaas e marcadas como excluídas em iam.charges (deleted_at, deleted_reason). A integração é a do contrato da assinatura (iam.fee_contract_subscriptions → contrato) ou do contract_id informado.
+// @Tags         asaas
+// @Produce      json
+// @Param        id                       path      string  true   "ID da assinatura no Asaas (sub_...)"
+// @Param        accounting_office_id     query     string  true   "ID do accounting_office (UUID)"
+// @Param        contract_id              query     string  false  "Contrato que seleciona a integração (padrão: contrato vinculado à assinatura)"
+// @Param        cancel_pending_payments  query     bool    false  "Também excluir as cobranças pendentes e vencidas da assinatura"
+// @Success      200  {object}  map[string]any  "Assinatura excluída (cancelled_payments / failed_payments: cobranças pendentes excluídas ou não)"
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      422  {object}  map[string]any  "Assinatura recusada pelo provedor (code: provider_validation_error)"
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/subscriptions/{id} [delete]
+func DeleteAsaasSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	subscriptionID, accountingOfficeID, ok := officeResourceParams(w, r, "subscription")
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	cancelPending := false
+	if s := strings.TrimSpace(q.Get("cancel_pending_payments")); s != "" {
+		if s != "true" && s != "false" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "cancel_pending_payments must be true or false"})
+			return
+		}
+		cancelPending = s == "true"
+	}
+
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID:     accountingOfficeID,
+		ContractID:             strings.TrimSpace(q.Get("contract_id")),
+		ProviderSubscriptionID: subscriptionID,
+	})
+	if !ok {
+		return
+	}
+
+	// The pending payments are listed before the subscription goes away, so a failure here
+	// leaves everything untouched.
+	var pending []provider.Payment
+	if cancelPending {
+		it := provider.SubscriptionPayments(chargeProvider, subscriptionID, provider.PaymentFilter{})
+		for it.Next() {
+			if pay := it.Value(); billing.AwaitingPayment(pay.Status) && !pay.Deleted {
+				pending = append(pending, pay)
+			}
+		}
+		if err := it.Err(); err != nil {
+			if resp := it.Response(); resp != nil && !resp.OK() {
+				writeProviderError(w, rid, chargeProvider.Name(), resp)
+				return
+			}
+			writeProviderCallError(w, rid, err)
+			return
+		}
+	}
+
+	log.Printf("[asaas] delete subscription: rid=%s sub=%s office=%s cancel_pending=%t pending=%d",
+		rid, subscriptionID, accountingOfficeID, cancelPending, len(pending))
+
+	resp, callErr := chargeProvider.DeleteSubscription(subscriptionID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("delete subscription", rid, resp)
+	if !resp.OK() {
+		writeProviderError(w, rid, chargeProvider.Name(), resp)
+		return
+	}
+
+	// The subscription is already gone in the provider: the local updates run even if the
+	// client went away, and their failures are only logged.
+	ctx = context.WithoutCancel(ctx)
+	chargeProvider = chargeProvider.WithContext(ctx)
+	markSubscriptionDeleted(ctx, rid, chargeProvider.Name(), subscriptionID)
+	cancelled, failed := cancelSubscriptionPayments(ctx, rid, chargeProvider, pending)
+
+	log.Printf("[asaas] ✅ subscription deleted: rid=%s sub=%s cancelled=%d failed=%d",
+		rid, subscriptionID, len(cancelled), len(failed))
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"success":            true,
+		"message":            "Assinatura excluída com sucesso",
+		"id":                 subscriptionID,
+		"cancelled_payments": cancelled,
+		"failed_payments":    failed,
+		"request_id":         rid,
+	})
+}
+
+// markSubscriptionDeleted stores DELETED in iam.fee_contract_subscriptions, as the
+// SUBSCRIPTION_DELETED webhook does (and under the same lock). Subscriptions not linked to
+// a contract are left to the webhook.
+func markSubscriptionDeleted(ctx context.Context, rid, providerName, subscriptionID string) {
+	defer billing.ChargeLocks.Lock(providerName + ":" + subscriptionID)()
+
+	existing, err := supabase.GetFeeContractSubscriptionContext(ctx, subscriptionID)
+	if err != nil {
+		log.Printf("[supabase] ERROR loading fee_contract_subscription after delete: rid=%s sub=%s err=%v", rid, subscriptionID, err)
+		return
+	}
+	if existing == nil {
+		log.Printf("[supabase] subscription not linked to a contract, fee_contract_subscriptions unchanged: rid=%s sub=%s", rid, subscriptionID)
+		return
+	}
+
+	status := billing.SubscriptionStatusDeleted
+	row := model.FeeContractSubscriptionRow{
+		ContractID:             existing.ContractID,
+		Provider:               providerName,
+		ProviderSubscriptionID: subscriptionID,
+		Status:                 &status,
+	}
+	if err := supabase.UpsertFeeContractSubscriptionContext(ctx, row); err != nil {
+		log.Printf("[supabase] ERROR marking subscription as deleted: rid=%s sub=%s err=%v", rid, subscriptionID, err)
+		return
+	}
+	log.Printf("[supabase] fee_contract_subscriptions updated after DELETE_SUBSCRIPTION: rid=%s sub=%s contract=%s status=%s",
+		rid, subscriptionID, existing.ContractID, status)
+}
+
+// cancelSubscriptionPayments deletes the pending payments of a deleted subscription and soft
+// deletes their iam.charges rows. A payment the provider no longer has (404) counts as
+// cancelled. Returns the ids cancelled and the ids that could not be deleted.
+func cancelSubscriptionPayments(ctx context.Context, rid string, p provider.ChargeProvider, pending []provider.Payment) (cancelled, failed []string) {
+	cancelled, failed = []string{}, []string{}
+	for _, pay := range pending {
+		resp, err := p.DeletePayment(pay.ID)
+		if resp == nil || (!resp.OK() && resp.StatusCode != http.StatusNotFound) {
+			msg := resp.ErrorMessage()
+			if err != nil {
+				msg = err.Error()
+			}
+			log.Printf("[asaas] ERROR cancelling subscription payment: rid=%s payment_id=%s err=%s", rid, pay.ID, msg)
+			failed = append(failed, pay.ID)
+			continue
+		}
+
+		if err := supabase.SoftDeleteChargeByProviderIDContext(ctx, p.Name(), pay.ID, deletedReasonSubscriptionDeleted); err != nil {
+			log.Printf("[supabase] ERROR marking subscription payment as deleted: rid=%s payment_id=%s err=%v", rid, pay.ID, err)
+		}
+		cancelled = append(cancelled, pay.ID)
+	}
+	return cancelled, failed
+}
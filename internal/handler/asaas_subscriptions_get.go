@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/billing"
+)
+
+// GetAsaasSubscription godoc
+// @Summary      Consultar assinatura no Asaas
+// @Description  Retorna uma assinatura (subscription) do Asaas, inclusive excluídas (deleted=true). A integração é a do contrato da assinatura (iam.fee_contract_subscriptions → contrato) ou do contract_id informado.
+// @Tags         asaas
+// @Produce      json
+// @Param        id                    path      string  true   "ID da assinatura no Asaas (sub_...)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração (padrão: contrato vinculado à assinatura)"
+// @Success      200  {object}  model.AsaasSubscriptionResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/subscriptions/{id} [get]
+func GetAsaasSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	subscriptionID, accountingOfficeID, ok := officeResourceParams(w, r, "subscription")
+	if !ok {
+		return
+	}
+
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID:     accountingOfficeID,
+		ContractID:             strings.TrimSpace(r.URL.Query().Get("contract_id")),
+		ProviderSubscriptionID: subscriptionID,
+	})
+	if !ok {
+		return
+	}
+
+	_, resp, callErr := chargeProvider.GetSubscription(subscriptionID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("get subscription", rid, resp)
+
+	writeProviderResponse(w, resp)
+}
+
+// ListAsaasSubscriptionPayments godoc
+// @Summary      Listar cobranças de uma assinatura no Asaas (paginado)
+// @Description  Lista as cobranças (payments) geradas por uma assinatura do Asaas. A integração é a do contrato da assinatura (iam.fee_contract_subscriptions → contrato) ou do contract_id informado.
+// @Tags         asaas
+// @Produce      json
+// @Param        id                    path      string  true   "ID da assinatura no Asaas (sub_...)"
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração (padrão: contrato vinculado à assinatura)"
+// @Param        offset                query     int     false  "Elemento inicial da lista"
+// @Param        limit                 query     int     false  "Número de elementos da lista (max: 100)"
+// @Param        status                query     string  false  "Filtrar por status" Enums(PENDING,RECEIVED,CONFIRMED,OVERDUE,REFUNDED,RECEIVED_IN_CASH,REFUND_REQUESTED,REFUND_IN_PROGRESS,CHARGEBACK_REQUESTED,CHARGEBACK_DISPUTE,AWAITING_CHARGEBACK_REVERSAL,DUNNING_REQUESTED,DUNNING_RECEIVED,AWAITING_RISK_ANALYSIS)
+// @Success      200  {object}  model.AsaasPaymentsListResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/subscriptions/{id}/payments [get]
+func ListAsaasSubscriptionPayments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	subscriptionID, accountingOfficeID, ok := officeResourceParams(w, r, "subscription")
+	if !ok {
+		return
+	}
+
+	filter, ok := paymentPageFilter(w, r)
+	if !ok {
+		return
+	}
+
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID:     accountingOfficeID,
+		ContractID:             strings.TrimSpace(r.URL.Query().Get("contract_id")),
+		ProviderSubscriptionID: subscriptionID,
+	})
+	if !ok {
+		return
+	}
+
+	_, resp, callErr := chargeProvider.ListSubscriptionPayments(subscriptionID, filter)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("list subscription payments", rid, resp)
+
+	writeProviderResponse(w, resp)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// ListAsaasSubscriptions godoc
+// @Summary      Listar assinaturas no Asaas (paginado)
+// @Description  Lista assinaturas (subscriptions) do Asaas com filtros. Se company_id for informado e customer não, o serviço resolve o customer_id do Asaas via mapeamento (RPC em public) e aplica o filtro customer automaticamente.
+// @Tags         asaas
+// @Produce      json
+// @Param        accounting_office_id  query     string  true   "ID do accounting_office (UUID)"
+// @Param        contract_id           query     string  false  "Contrato que seleciona a integração; sem ele a integração vem da empresa (company_id) ou do padrão do escritório"
+// @Param        company_id            query     string  false  "ID da empresa (UUID) - resolve customer automaticamente"
+// @Param        offset                query     int     false  "Elemento inicial da lista"
+// @Param        limit                 query     int     false  "Número de elementos da lista (max: 100)"
+// @Param        customer              query     string  false  "Filtrar pelo Identificador único do cliente no Asaas"
+// @Param        customerGroupName     query     string  false  "Filtrar pelo nome do grupo de cliente"
+// @Param        billingType           query     string  false  "Filtrar por forma de pagamento" Enums(UNDEFINED,BOLETO,CREDIT_CARD,PIX)
+// @Param        status                query     string  false  "Filtrar por status" Enums(ACTIVE,EXPIRED,INACTIVE)
+// @Param        externalReference     query     string  false  "Filtrar pelo identificador do seu sistema"
+// @Param        includeDeleted        query     bool    false  "Incluir assinaturas excluídas"
+// @Param        deletedOnly           query     bool    false  "Listar apenas assinaturas excluídas"
+// @Success      200  {object}  model.AsaasSubscriptionsListResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/asaas/subscriptions [get]
+func ListAsaasSubscriptions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	accountingOfficeID := strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
+	if accountingOfficeID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
+		return
+	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := provider.SubscriptionFilter{
+		ExternalReference: strings.TrimSpace(q.Get("externalReference")),
+		Status:            strings.TrimSpace(q.Get("status")),
+		Extra:             map[string]string{},
+	}
+
+	// pagination (optional)
+	if s := strings.TrimSpace(q.Get("offset")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "offset must be a non-negative integer"})
+			return
+		}
+		filter.Offset = n
+	}
+	if s := strings.TrimSpace(q.Get("limit")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > provider.MaxPageSize {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "limit must be an integer between 0 and 100"})
+			return
+		}
+		filter.Limit = n
+	}
+
+	// customer filter: prefer explicit customer; else resolve from company_id
+	companyID := strings.TrimSpace(q.Get("company_id"))
+	filter.CustomerID = strings.TrimSpace(q.Get("customer"))
+	if filter.CustomerID == "" && companyID != "" {
+		asaasCustomerID, err := supabase.GetCompanyAsaasCustomerIDContext(ctx, companyID)
+		if err != nil {
+			if isDebugEnabled() {
+				log.Printf("[asaas] error resolving asaas_customer_id for subscription list: rid=%s company_id=%s err=%v", rid, companyID, err)
+			}
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to resolve asaas customer id", "request_id": rid})
+			return
+		}
+		if strings.TrimSpace(asaasCustomerID) == "" {
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": "asaas integration not found for this company (current tenant)"})
+			return
+		}
+		filter.CustomerID = asaasCustomerID
+	}
+
+	// direct pass-through filters (if present)
+	for _, key := range []string{"customerGroupName", "billingType"} {
+		if v := strings.TrimSpace(q.Get(key)); v != "" {
+			filter.Extra[key] = v
+		}
+	}
+	for _, key := range []string{"includeDeleted", "deletedOnly"} {
+		if s := strings.TrimSpace(q.Get(key)); s != "" {
+			if s != "true" && s != "false" {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": key + " must be true or false"})
+				return
+			}
+			filter.Extra[key] = s
+		}
+	}
+
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ContractID:         strings.TrimSpace(q.Get("contract_id")),
+		CompanyID:          companyID,
+	})
+	if !ok {
+		return
+	}
+
+	_, resp, callErr := chargeProvider.ListSubscriptions(filter)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("list subscriptions", rid, resp)
+
+	writeProviderResponse(w, resp)
+}
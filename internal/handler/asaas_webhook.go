@@ -1,64 +1,83 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
 	"github.com/seuuser/charges-service/internal/model"
 	"github.com/seuuser/charges-service/internal/supabase"
+	"github.com/seuuser/charges-service/internal/webhook"
 )
 
 // ReceiveAsaasWebhook godoc
 // @Summary Recebe eventos do Asaas via webhook
-// @Description Endpoint para receber notificações de atualização de status de cobranças do Asaas
+// @Description Endpoint para receber notificações de atualização de status de cobranças do Asaas.
+// @Description O evento é gravado em logs.asaas_webhook_inbox e processado de forma assíncrona pelo worker pool (com retry/backoff).
 // @Tags Webhook
 // @Accept json
 // @Produce json
+// @Description Autenticação: o header asaas-access-token é comparado com o webhook_secret da integração
+// @Description (identificada pelo path ou pelo account.id do evento) ou, na falta dele, com ASAAS_WEBHOOK_SECRET.
 // @Param asaas-access-token header string false "Token de acesso configurado no webhook do Asaas"
 // @Param event body model.AsaasWebhookEvent true "Evento do webhook"
-// @Success 200 {object} map[string]interface{} "Evento recebido com sucesso"
-// @Failure 401 {object} map[string]interface{} "Não autorizado"
+// @Success 200 {object} map[string]interface{} "Evento recebido e enfileirado"
+// @Failure 401 {object} map[string]interface{} "Não autorizado (token inválido, ou evento sem account.id de uma integração)"
+// @Failure 403 {object} map[string]interface{} "Evento sem integração com ASAAS_WEBHOOK_STRICT=true"
 // @Failure 400 {object} map[string]interface{} "JSON inválido"
-// @Failure 500 {object} map[string]interface{} "Erro interno"
+// @Failure 500 {object} map[string]interface{} "Falha ao enfileirar (o Asaas reenvia)"
 // @Router /asaas/feecharges [post]
-func ReceiveAsaasWebhook(w http.ResponseWriter, r *http.Request) {
+func ReceiveAsaasWebhook(auth AsaasWebhookAuth) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		receiveAsaasWebhook(auth, w, r)
+	}
+}
+
+func receiveAsaasWebhook(auth AsaasWebhookAuth, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	log.Printf("🔔 [webhook] ========== WEBHOOK ASAAS INICIADO ==========")
 	log.Printf("🔔 [webhook] Method: %s | Path: %s | RemoteAddr: %s", r.Method, r.URL.Path, r.RemoteAddr)
 	log.Printf("🔔 [webhook] Headers: User-Agent=%s | Content-Type=%s", r.Header.Get("User-Agent"), r.Header.Get("Content-Type"))
 
-	// ── Auth ──────────────────────────────────────────────────────────────────
-	expectedToken := os.Getenv("ASAAS_WEBHOOK_SECRET")
-	if expectedToken != "" {
-		providedToken := r.Header.Get("asaas-access-token")
-		if providedToken != expectedToken {
-			log.Printf("❌ [webhook] Token inválido ou não fornecido")
-			http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
-			return
-		}
-		log.Printf("✅ [webhook] Token validado")
-	} else {
-		log.Printf("⚠️  [webhook] ASAAS_WEBHOOK_SECRET não configurado — webhook sem autenticação")
-	}
+	receivedAt := time.Now()
 
-	// ── Read raw body (needed for logs) ───────────────────────────────────────
+	// ── Read raw body (needed for auth and logs) ──────────────────────────────
 	rawBody, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("❌ [webhook] ERRO ao ler body: %v", err)
 		http.Error(w, `{"error":"Failed to read request body"}`, http.StatusBadRequest)
 		return
 	}
+
+	// ── Auth ──────────────────────────────────────────────────────────────────
+	// The secret depends on the integration (URL or account.id), hence after reading the body.
+	integration, ok := authenticateAsaasWebhook(auth, w, r, rawBody)
+	if !ok {
+		return
+	}
 	rawPayload := json.RawMessage(rawBody)
 
 	// ── Decode JSON ───────────────────────────────────────────────────────────
-	var event model.AsaasWebhookEvent
-	if err := json.Unmarshal(rawBody, &event); err != nil {
+	chargeProvider, err := provider.Lookup(asaas.ProviderName)
+	if err != nil {
+		log.Printf("❌ [webhook] provider %s não registrado: %v", asaas.ProviderName, err)
+		http.Error(w, `{"error":"Provider not available"}`, http.StatusInternalServerError)
+		return
+	}
+	event, err := chargeProvider.ParseWebhookEvent(rawBody)
+	if err != nil {
 		log.Printf("❌ [webhook] ERRO ao decodificar JSON: %v", err)
-		supabase.InsertAsaasWebhookEventLog(model.AsaasWebhookEventLog{
+		webhook.JournalRejected(ctx, chargeProvider.Name(), rawPayload, receivedAt, err)
+		supabase.InsertAsaasWebhookEventLogContext(ctx, model.AsaasWebhookEventLog{
 			ErrorStage:   "decode_payload",
 			ErrorMessage: fmt.Sprintf("falha ao decodificar JSON do webhook: %v", err),
 			RawPayload:   rawPayload,
@@ -67,42 +86,253 @@ func ReceiveAsaasWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("📦 [webhook] Evento recebido: %s | ID=%s | DateCreated=%s", event.Event, event.ID, event.DateCreated)
+	log.Printf("📦 [webhook] Evento recebido: %s | ID=%s | DateCreated=%s | integration=%s", event.Event, event.ID, event.DateCreated, integrationIDOf(integration))
 
 	// Log do payload completo para debug
-	eventJSON, _ := json.MarshalIndent(event, "", "  ")
-	log.Printf("📄 [webhook] Payload completo:\n%s", string(eventJSON))
+	log.Printf("📄 [webhook] Payload completo:\n%s", string(rawBody))
+
+	// ── Enqueue ───────────────────────────────────────────────────────────────
+	// Processing happens in the worker pool (internal/webhook): here we only persist the
+	// raw event, so a slow Supabase never makes Asaas time out and pause the queue.
+	row := model.AsaasWebhookInboxRow{
+		Provider:      chargeProvider.Name(),
+		EventID:       event.ID,
+		EventType:     event.Event,
+		RawPayload:    rawPayload,
+		Status:        model.WebhookInboxPending,
+		NextAttemptAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if integration != nil {
+		row.IntegrationID = integration.ID
+	}
+	if event.Payment != nil {
+		row.PaymentID = event.Payment.ID
+	}
+	inboxID, err := supabase.InsertWebhookInboxContext(ctx, row)
+	if err != nil {
+		// Not persisted: answer 500 so Asaas delivers the event again.
+		log.Printf("❌ [webhook] ERRO ao enfileirar evento: %v", err)
+		http.Error(w, `{"error":"Failed to enqueue event"}`, http.StatusInternalServerError)
+		log.Printf("❌ [webhook] ========== WEBHOOK FINALIZADO COM ERRO ==========\n")
+		return
+	}
+	webhook.Notify()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"received": true,
+		"queued":   true,
+		"event_id": event.ID,
+		"inbox_id": inboxID,
+	})
+	log.Printf("✅ [webhook] ========== WEBHOOK ENFILEIRADO (inbox_id=%s) ==========\n", inboxID)
+}
+
+// ReceiveAsaasWebhookForIntegration godoc
+// @Summary Recebe eventos do Asaas de uma integração específica
+// @Description Igual a POST /asaas/feecharges, mas a integração (iam.billing_integrations) vem do path:
+// @Description o asaas-access-token é comparado com o webhook_secret dela. Use uma URL por conta Asaas.
+// @Description Se a integração tiver provider_account_id, o evento precisa trazer o mesmo account.id.
+// @Description Só cobranças e contratos do escritório da integração são atualizados.
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param integration_id path string true "ID da integração (iam.billing_integrations.id)"
+// @Param asaas-access-token header string false "Token de acesso configurado no webhook do Asaas"
+// @Param event body model.AsaasWebhookEvent true "Evento do webhook"
+// @Success 200 {object} map[string]interface{} "Evento recebido e enfileirado"
+// @Failure 401 {object} map[string]interface{} "Não autorizado (token inválido ou integração desconhecida)"
+// @Failure 400 {object} map[string]interface{} "JSON inválido"
+// @Failure 500 {object} map[string]interface{} "Falha ao enfileirar (o Asaas reenvia)"
+// @Router /asaas/feecharges/{integration_id} [post]
+func ReceiveAsaasWebhookForIntegration(auth AsaasWebhookAuth) http.HandlerFunc {
+	return ReceiveAsaasWebhook(auth)
+}
+
+// deleted_reason of charges deleted in the provider (PAYMENT_DELETED). A charge deleted
+// through the API keeps the reason given there.
+const deletedReasonWebhook = "deleted in provider (PAYMENT_DELETED)"
+
+// statusChangeEvents are the payment events that move a charge to a new status and
+// therefore fire onChargeStatusChanged (once per event id).
+var statusChangeEvents = map[string]bool{
+	model.EventPaymentConfirmed:            true,
+	model.EventPaymentReceived:             true,
+	model.EventPaymentReceivedInCash:       true,
+	model.EventPaymentReceivedInCashUndone: true,
+	model.EventPaymentOverdue:              true,
+	model.EventPaymentRefunded:             true,
+	model.EventPaymentDeleted:              true,
+	model.EventPaymentRestored:             true,
+}
+
+// webhookEventLocks serializes deliveries of the same event id inside this instance;
+// across instances the unique (provider, event_id) in logs.asaas_webhook_processed_events decides.
+var webhookEventLocks billing.StripedLock
+
+// ProcessAsaasWebhookEvent is the webhook.Processor run by the worker pool for each queued event.
+// Errors are retried with backoff; they carry the failing stage (*webhook.StageError).
+//
+// Asaas delivers at-least-once: an event id already in logs.asaas_webhook_processed_events
+// is acknowledged without touching iam.charges, and status-change hooks fire only for the
+// delivery that records the event id.
+//
+// Only charges and contracts of the accounting office of the integration the event was
+// delivered for (integrationID) are updated; see ignoreForeignEvent. Events not tied to
+// an integration (queued before the receiver rejected them) are never applied.
+func ProcessAsaasWebhookEvent(ctx context.Context, providerName, integrationID string, event *provider.WebhookEvent, rawPayload json.RawMessage) (*webhook.Result, error) {
+	eventID := strings.TrimSpace(event.ID)
+	if eventID != "" {
+		defer webhookEventLocks.Lock(providerName + ":" + eventID)()
+
+		processed, err := supabase.GetWebhookProcessedEventContext(ctx, providerName, eventID)
+		if err != nil {
+			return nil, &webhook.StageError{Stage: "dedup_lookup", Err: err}
+		}
+		if processed != nil {
+			processedAt := ""
+			if processed.ProcessedAt != nil {
+				processedAt = *processed.ProcessedAt
+			}
+			log.Printf("🔁 [webhook] Evento duplicado %s (%s) já processado em %s — ignorado", eventID, event.Event, processedAt)
+			return &webhook.Result{Outcome: model.WebhookOutcomeDuplicate}, nil
+		}
+	} else {
+		log.Printf("⚠️  [webhook] Evento %s sem id — processado sem deduplicação", event.Event)
+	}
+
+	if event.Payment == nil && event.Subscription == nil {
+		log.Printf("⚠️  [webhook] Evento %s sem objeto payment/subscription, pulando", event.Event)
+		if _, err := recordProcessedWebhookEvent(ctx, providerName, event); err != nil {
+			return nil, err
+		}
+		return &webhook.Result{Outcome: model.WebhookOutcomeSkipped}, nil
+	}
+
+	officeID, err := webhookIntegrationOffice(ctx, integrationID)
+	if err != nil {
+		return nil, err
+	}
 
-	// ── Skip non-payment events ───────────────────────────────────────────────
 	if event.Payment == nil {
-		log.Printf("⚠️  [webhook] Evento %s sem objeto payment, pulando", event.Event)
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]interface{}{"received": true, "processed": false, "reason": "no_payment_object"})
-		log.Printf("✅ [webhook] ========== WEBHOOK FINALIZADO (SEM PROCESSAMENTO) ==========\n")
-		return
+		result, err := updateSubscriptionFromWebhook(ctx, providerName, officeID, event, rawPayload)
+		if err != nil {
+			return result, err
+		}
+		if _, err := recordProcessedWebhookEvent(ctx, providerName, event); err != nil {
+			return result, err
+		}
+		return result, nil
 	}
 
 	log.Printf("💳 [webhook] Processando payment: ID=%s | Status=%s | Value=%.2f",
 		event.Payment.ID, event.Payment.Status, event.Payment.Value)
 
-	// ── Process ───────────────────────────────────────────────────────────────
-	if err := updateChargeFromWebhook(event, rawPayload); err != nil {
-		log.Printf("❌ [webhook] ERRO CRÍTICO ao processar cobrança: %v", err)
-		http.Error(w, `{"error":"Failed to update charge"}`, http.StatusInternalServerError)
-		log.Printf("❌ [webhook] ========== WEBHOOK FINALIZADO COM ERRO ==========\n")
-		return
+	result, err := updateChargeFromWebhook(ctx, providerName, officeID, event, rawPayload)
+	if err != nil {
+		return result, err
 	}
 
-	log.Printf("✅ [webhook] Payment ID: %s | Novo Status: %s", event.Payment.ID, event.Payment.Status)
+	// Recorded only after iam.charges is updated: a failure above is retried normally.
+	first, err := recordProcessedWebhookEvent(ctx, providerName, event)
+	if err != nil {
+		return result, err
+	}
+	if !first {
+		log.Printf("🔁 [webhook] Evento %s registrado por outra instância — hooks ignorados", eventID)
+		result.Outcome = model.WebhookOutcomeDuplicate
+		return result, nil
+	}
+	if result.Outcome != model.WebhookOutcomeApplied {
+		return result, nil
+	}
+	if statusChangeEvents[event.Event] {
+		onChargeStatusChanged(ctx, event)
+	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"received":   true,
-		"processed":  true,
-		"payment_id": event.Payment.ID,
-		"status":     event.Payment.Status,
-	})
-	log.Printf("✅ [webhook] ========== WEBHOOK FINALIZADO COM SUCESSO ==========\n")
+	log.Printf("✅ [webhook] Payment ID: %s | Status: %s → %s", event.Payment.ID, result.StatusFrom, result.StatusTo)
+	return result, nil
+}
+
+// webhookIntegrationOffice returns the accounting office of the integration an event was
+// delivered for, or "" when the delivery was not tied to an integration (then every
+// charge counts as foreign).
+func webhookIntegrationOffice(ctx context.Context, integrationID string) (string, error) {
+	if strings.TrimSpace(integrationID) == "" {
+		return "", nil
+	}
+	integration, err := supabase.GetBillingIntegrationByIDContext(ctx, integrationID)
+	if err != nil {
+		return "", &webhook.StageError{Stage: "load_integration", Err: fmt.Errorf("integration %s: %w", integrationID, err)}
+	}
+	return integration.AccountingOfficeID, nil
+}
+
+// ignoreForeignEvent reports whether the charge or contract an event touches (officeID)
+// belongs to another accounting office than the integration it was delivered for
+// (integrationOfficeID). Such events are logged with status ignored_foreign and never
+// applied: one Asaas account must not update another office's data. Without an office
+// binding (integrationOfficeID empty) every event is foreign.
+func ignoreForeignEvent(ctx context.Context, event *provider.WebhookEvent, rawPayload json.RawMessage, integrationOfficeID, officeID string) bool {
+	if integrationOfficeID != "" && officeID == integrationOfficeID {
+		return false
+	}
+	msg := fmt.Sprintf("escritório %s não pertence à integração do evento (escritório %s)", officeID, integrationOfficeID)
+	if integrationOfficeID == "" {
+		msg = fmt.Sprintf("evento sem integração vinculada não pode alterar o escritório %s", officeID)
+	}
+	entry := model.AsaasWebhookEventLog{
+		EventID:      event.ID,
+		EventType:    event.Event,
+		ErrorStage:   "integration_mismatch",
+		ErrorMessage: msg,
+		RawPayload:   rawPayload,
+		Status:       model.WebhookLogStatusIgnoredForeign,
+	}
+	if p := event.Payment; p != nil {
+		entry.PaymentID = p.ID
+		entry.SubscriptionID = p.SubscriptionID
+		entry.ExternalReference = p.ExternalReference
+	} else if s := event.Subscription; s != nil {
+		entry.SubscriptionID = s.ID
+		entry.ExternalReference = s.ExternalReference
+	}
+	log.Printf("🚫 [webhook] Evento %s (%s) ignorado: %s", event.ID, event.Event, msg)
+	supabase.InsertAsaasWebhookEventLogContext(ctx, entry)
+	return true
+}
+
+// recordProcessedWebhookEvent stores the event id; first=false means it was already there.
+// Events without id are never recorded and always count as first.
+func recordProcessedWebhookEvent(ctx context.Context, providerName string, event *provider.WebhookEvent) (first bool, err error) {
+	if strings.TrimSpace(event.ID) == "" {
+		return true, nil
+	}
+	row := model.AsaasWebhookProcessedEventRow{
+		Provider:  providerName,
+		EventID:   strings.TrimSpace(event.ID),
+		EventType: event.Event,
+	}
+	if event.Payment != nil {
+		row.PaymentID = event.Payment.ID
+	}
+	first, err = supabase.InsertWebhookProcessedEventContext(ctx, row)
+	if err != nil {
+		return false, &webhook.StageError{Stage: "record_processed_event", Err: err}
+	}
+	return first, nil
+}
+
+// onChargeStatusChanged runs the downstream hooks of a payment status change.
+// Called at most once per event id; hooks are best-effort and never fail the event.
+func onChargeStatusChanged(ctx context.Context, event *provider.WebhookEvent) {
+	p := event.Payment
+	log.Printf("🔔 [webhook] Status alterado por %s: payment=%s status=%s", event.Event, p.ID, p.Status)
+
+	// One-off charges (no subscription) mirror their status into iam.fee_contract_one_off_charges.
+	if strings.TrimSpace(p.SubscriptionID) == "" {
+		syncOneOffChargeFromPayment(ctx, "WEBHOOK "+event.Event, p)
+	}
 }
 
 // updateChargeFromWebhook upserts the charge in iam.charges based on the webhook event.
@@ -111,77 +341,66 @@ func ReceiveAsaasWebhook(w http.ResponseWriter, r *http.Request) {
 // For new charges (e.g. auto-generated installments from a subscription) it resolves
 // the contract context via the subscription ID and inserts them for the first time.
 //
-// rawPayload is the original JSON body and is used exclusively for error logging.
-func updateChargeFromWebhook(event model.AsaasWebhookEvent, rawPayload json.RawMessage) error {
+// Events older than the charge's last_event_at (or, within the same second, with a status of
+// lower precedence) are logged as stale_event and ignored (outcome ignored_stale). Charges or
+// contracts of another office than integrationOfficeID are ignored too (outcome ignored_foreign).
+// The result carries the status transition for logs.asaas_webhook_journal.
+//
+// Failures are returned as *webhook.StageError; the worker pool retries them and writes
+// the dead letter to logs.asaas_webhook_events.
+func updateChargeFromWebhook(ctx context.Context, providerName, integrationOfficeID string, event *provider.WebhookEvent, rawPayload json.RawMessage) (*webhook.Result, error) {
 	log.Printf("🔄 [updateCharge] Iniciando processamento da cobrança...")
 
 	if event.Payment == nil {
-		return nil
+		return &webhook.Result{Outcome: model.WebhookOutcomeSkipped}, nil
 	}
 
 	p := event.Payment
 	log.Printf("🔍 [updateCharge] payment: ID=%s | Status=%s | Value=%.2f | Sub=%s | ExtRef=%s",
-		p.ID, p.Status, p.Value, p.Subscription, p.ExternalReference)
+		p.ID, p.Status, p.Value, p.SubscriptionID, p.ExternalReference)
 
-	// ── Build base charge row ─────────────────────────────────────────────────
-	toPtr := func(s string) *string {
-		if s == "" {
-			return nil
-		}
-		return &s
-	}
+	// Events of the same charge are applied one at a time (read → compare → upsert).
+	defer billing.ChargeLocks.Lock(providerName + ":" + p.ID)()
 
-	charge := model.IamChargeRow{
-		Provider:          "ASAAS",
-		ProviderChargeID:  p.ID,
-		Value:             p.Value,
-		NetValue:          &p.NetValue,
-		Description:       toPtr(p.Description),
-		BillingType:       toPtr(p.BillingType),
-		Status:            toPtr(p.Status),
-		DueDate:           toPtr(p.DueDate),
-		OriginalDueDate:   toPtr(p.OriginalDueDate),
-		InvoiceURL:        toPtr(p.InvoiceURL),
-		InvoiceNumber:     toPtr(p.InvoiceNumber),
-		ExternalReference: toPtr(p.ExternalReference),
-	}
-	if p.Subscription != "" {
-		charge.ProviderSubscriptionID = &p.Subscription
+	// ── Build base charge row ─────────────────────────────────────────────────
+	charge := billing.ChargeRowFromPayment(providerName, *p)
+	eventAt, hasEventAt := billing.ParseEventTime(event.DateCreated)
+	if hasEventAt {
+		ts := eventAt.UTC().Format(time.RFC3339)
+		charge.LastEventAt = &ts
 	}
 
-	payloadBytes, _ := json.Marshal(p)
-	charge.ProviderPayload = payloadBytes
+	result := &webhook.Result{Outcome: model.WebhookOutcomeApplied, StatusTo: p.Status}
 
 	// ── Try to find existing charge ───────────────────────────────────────────
 	log.Printf("🔎 [updateCharge] Buscando cobrança existente (provider_charge_id=%s)...", p.ID)
-	existingCharge, err := supabase.GetChargeByProviderID("ASAAS", p.ID)
+	existingCharge, err := supabase.GetChargeByProviderIDContext(ctx, providerName, p.ID)
 	if err != nil {
 		// Charge not in iam.charges yet — likely PAYMENT_CREATED for a subscription installment.
 		// Resolve the contract context so we can insert it.
 		log.Printf("⚠️  [updateCharge] Cobrança não encontrada no banco (id=%s) — resolvendo contexto...", p.ID)
 
-		contract, resolveErr := resolveContractContextFromPayment(p)
+		contract, resolveErr := billing.ResolveContractContextFromPayment(ctx, p)
 		if resolveErr != nil || contract == nil {
-			msg := fmt.Sprintf("contrato não encontrado para payment=%s sub=%q extRef=%q", p.ID, p.Subscription, p.ExternalReference)
-			log.Printf("⚠️  [updateCharge] %s — persistindo log de erro", msg)
-
-			supabase.InsertAsaasWebhookEventLog(model.AsaasWebhookEventLog{
-				EventType:         event.Event,
-				PaymentID:         p.ID,
-				SubscriptionID:    p.Subscription,
-				ExternalReference: p.ExternalReference,
-				ErrorStage:        "resolve_contract_context",
-				ErrorMessage:      msg,
-				RawPayload:        rawPayload,
-			})
-			// Non-fatal: return nil so Asaas receives 200 and doesn't retry indefinitely.
-			return nil
+			// The subscription link may not be persisted yet (e.g. PAYMENT_CREATED arriving
+			// before the billing run stores it): retried by the worker pool, dead-lettered
+			// in logs.asaas_webhook_events when it never resolves.
+			msg := fmt.Sprintf("contrato não encontrado para payment=%s sub=%q extRef=%q", p.ID, p.SubscriptionID, p.ExternalReference)
+			log.Printf("⚠️  [updateCharge] %s", msg)
+			return nil, &webhook.StageError{Stage: "resolve_contract_context", Err: errors.New(msg)}
+		}
+		if ignoreForeignEvent(ctx, event, rawPayload, integrationOfficeID, contract.AccountingOfficeID) {
+			result.Outcome = model.WebhookOutcomeIgnoredForeign
+			result.StatusTo = ""
+			return result, nil
 		}
 
-		charge.TenantID           = contract.TenantID
+		charge.TenantID = contract.TenantID
 		charge.AccountingOfficeID = contract.AccountingOfficeID
-		charge.CompanyID          = contract.CompanyID
-		charge.ContractID         = contract.ID
+		charge.CompanyID = contract.CompanyID
+		charge.ContractID = contract.ID
+		result.CompanyID = contract.CompanyID
+		result.ContractID = contract.ID
 
 		log.Printf("✅ [updateCharge] Contexto resolvido: contract=%s tenant=%s company=%s",
 			contract.ID, contract.TenantID, contract.CompanyID)
@@ -190,12 +409,59 @@ func updateChargeFromWebhook(event model.AsaasWebhookEvent, rawPayload json.RawM
 		log.Printf("✅ [updateCharge] Cobrança existente encontrada: tenant=%s contract=%s",
 			existingCharge.TenantID, existingCharge.ContractID)
 
-		charge.TenantID               = existingCharge.TenantID
-		charge.AccountingOfficeID     = existingCharge.AccountingOfficeID
-		charge.CompanyID              = existingCharge.CompanyID
-		charge.ContractID             = existingCharge.ContractID
-		charge.InstallmentNumber      = existingCharge.InstallmentNumber
-		charge.ProviderInstallmentID  = existingCharge.ProviderInstallmentID
+		result.CompanyID = existingCharge.CompanyID
+		result.ContractID = existingCharge.ContractID
+		if existingCharge.Status != nil {
+			result.StatusFrom = *existingCharge.Status
+		}
+		if ignoreForeignEvent(ctx, event, rawPayload, integrationOfficeID, existingCharge.AccountingOfficeID) {
+			result.Outcome = model.WebhookOutcomeIgnoredForeign
+			result.StatusTo = result.StatusFrom
+			return result, nil
+		}
+
+		// ── Out-of-order protection ──────────────────────────────────────────
+		// The status is not overwritten by an older event, but deletion and restore still
+		// apply: they only depend on deleted_at (see applyDeletionEvent).
+		stale, reason := billing.StaleEvent(existingCharge, eventAt, hasEventAt, p.Status)
+		if stale && isDeletionEvent(event.Event) {
+			log.Printf("⏭️  [updateCharge] Evento %s fora de ordem: status %s mantido, exclusão/restauração aplicada: payment=%s — %s",
+				event.Event, result.StatusFrom, p.ID, reason)
+			result.StatusTo = result.StatusFrom
+			if err := applyDeletionEvent(ctx, providerName, event); err != nil {
+				return result, err
+			}
+			return result, nil
+		}
+		if stale {
+			log.Printf("⏭️  [updateCharge] Evento %s fora de ordem ignorado: payment=%s status=%s — %s",
+				event.Event, p.ID, p.Status, reason)
+			supabase.InsertAsaasWebhookEventLogContext(ctx, model.AsaasWebhookEventLog{
+				EventID:           event.ID,
+				EventType:         event.Event,
+				PaymentID:         p.ID,
+				SubscriptionID:    p.SubscriptionID,
+				ExternalReference: p.ExternalReference,
+				ErrorStage:        "stale_event",
+				ErrorMessage:      reason,
+				RawPayload:        rawPayload,
+				Status:            model.WebhookLogStatusIgnoredStale,
+			})
+			result.Outcome = model.WebhookOutcomeIgnoredStale
+			result.StatusTo = result.StatusFrom
+			return result, nil
+		}
+
+		charge.TenantID = existingCharge.TenantID
+		charge.AccountingOfficeID = existingCharge.AccountingOfficeID
+		charge.CompanyID = existingCharge.CompanyID
+		charge.ContractID = existingCharge.ContractID
+		if charge.InstallmentNumber == nil {
+			charge.InstallmentNumber = existingCharge.InstallmentNumber
+		}
+		if charge.ProviderInstallmentID == nil {
+			charge.ProviderInstallmentID = existingCharge.ProviderInstallmentID
+		}
 		if charge.ProviderSubscriptionID == nil {
 			charge.ProviderSubscriptionID = existingCharge.ProviderSubscriptionID
 		}
@@ -203,62 +469,42 @@ func updateChargeFromWebhook(event model.AsaasWebhookEvent, rawPayload json.RawM
 
 	// ── Upsert ───────────────────────────────────────────────────────────────
 	log.Printf("💾 [updateCharge] Executando upsert (tenant=%s, charge=%s)...", charge.TenantID, charge.ProviderChargeID)
-	if upsertErr := supabase.UpsertCharges([]model.IamChargeRow{charge}); upsertErr != nil {
-		msg := fmt.Sprintf("erro ao fazer upsert em iam.charges: %v", upsertErr)
-		log.Printf("❌ [updateCharge] %s", msg)
-
-		supabase.InsertAsaasWebhookEventLog(model.AsaasWebhookEventLog{
-			EventType:         event.Event,
-			PaymentID:         p.ID,
-			SubscriptionID:    p.Subscription,
-			ExternalReference: p.ExternalReference,
-			ErrorStage:        "upsert_charge",
-			ErrorMessage:      msg,
-			RawPayload:        rawPayload,
-		})
-		return upsertErr
+	if upsertErr := supabase.UpsertChargesContext(ctx, []model.IamChargeRow{charge}); upsertErr != nil {
+		log.Printf("❌ [updateCharge] erro ao fazer upsert em iam.charges: %v", upsertErr)
+		return result, &webhook.StageError{Stage: "upsert_charge", Err: fmt.Errorf("erro ao fazer upsert em iam.charges: %w", upsertErr)}
 	}
 
 	log.Printf("✅ [updateCharge] Upsert concluído! payment=%s status=%s", p.ID, p.Status)
-	return nil
-}
 
-// resolveContractContextFromPayment attempts to find the fee contract associated with
-// a payment by looking up the subscription ID in iam.fee_contract_subscriptions.
-// Falls back to parsing the external_reference field as "fee_contract:{uuid}:...".
-//
-// Returns (nil, nil) when the context cannot be determined (non-fatal).
-func resolveContractContextFromPayment(p *model.AsaasPaymentResponse) (*model.FeeContractRow, error) {
-	// ── Strategy 1: via subscription ID (most reliable for recurring charges) ──
-	if subID := strings.TrimSpace(p.Subscription); subID != "" {
-		log.Printf("🔗 [resolveContext] Tentando resolver via subscription id=%s", subID)
-		contract, err := supabase.GetFeeContractBySubscriptionProviderID(subID)
-		if err == nil && contract != nil {
-			return contract, nil
-		}
-		log.Printf("⚠️  [resolveContext] Lookup por subscription falhou (sub=%s): %v", subID, err)
-	}
-
-	// ── Strategy 2: via external_reference "fee_contract:{uuid}:..." ─────────
-	if contractID := contractIDFromExtRef(p.ExternalReference); contractID != "" {
-		log.Printf("🔗 [resolveContext] Tentando resolver via external_reference contract_id=%s", contractID)
-		contract, err := supabase.GetFeeContractByID(contractID)
-		if err == nil && contract != nil {
-			return contract, nil
-		}
-		log.Printf("⚠️  [resolveContext] Lookup por external_reference falhou (contract_id=%s): %v", contractID, err)
+	if err := applyDeletionEvent(ctx, providerName, event); err != nil {
+		return result, err
 	}
+	return result, nil
+}
 
-	return nil, fmt.Errorf("não foi possível resolver o contrato para payment=%s sub=%q extRef=%q",
-		p.ID, p.Subscription, p.ExternalReference)
+// isDeletionEvent reports whether event only soft-deletes or restores the charge.
+func isDeletionEvent(event string) bool {
+	return event == model.EventPaymentDeleted || event == model.EventPaymentRestored
 }
 
-// contractIDFromExtRef extracts the contract UUID from the external_reference format
-// "fee_contract:{uuid}:..." used when creating charges/subscriptions.
-func contractIDFromExtRef(extRef string) string {
-	parts := strings.SplitN(strings.TrimSpace(extRef), ":", 3)
-	if len(parts) >= 2 && parts[0] == "fee_contract" {
-		return strings.TrimSpace(parts[1])
+// applyDeletionEvent soft-deletes the charge on PAYMENT_DELETED and restores it on
+// PAYMENT_RESTORED. Deleted charges keep their row (and contract context), so a later
+// PAYMENT_RESTORED only has to clear deleted_at. Each update is conditioned on deleted_at
+// alone (a delete touches only a charge not deleted yet, a restore only a deleted one),
+// never on the status order: a delivery judged stale must still hide the charge.
+func applyDeletionEvent(ctx context.Context, providerName string, event *provider.WebhookEvent) error {
+	p := event.Payment
+	switch event.Event {
+	case model.EventPaymentDeleted:
+		if err := supabase.SoftDeleteChargeByProviderIDContext(ctx, providerName, p.ID, deletedReasonWebhook); err != nil {
+			return &webhook.StageError{Stage: "soft_delete_charge", Err: err}
+		}
+		log.Printf("🗑️  [updateCharge] Cobrança marcada como excluída: payment=%s", p.ID)
+	case model.EventPaymentRestored:
+		if err := supabase.RestoreChargeByProviderIDContext(ctx, providerName, p.ID); err != nil {
+			return &webhook.StageError{Stage: "restore_charge", Err: err}
+		}
+		log.Printf("♻️  [updateCharge] Cobrança restaurada: payment=%s", p.ID)
 	}
-	return ""
+	return nil
 }
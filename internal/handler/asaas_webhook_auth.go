@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// AsaasWebhookAuth is the fallback authentication of the Asaas webhook, loaded once from
+// config.WebhookConfig.
+type AsaasWebhookAuth struct {
+	Secret string // ASAAS_WEBHOOK_SECRET: used when the integration has no webhook_secret
+	Strict bool   // ASAAS_WEBHOOK_STRICT: reject instead of accept (with a warning) when no secret applies
+}
+
+// authenticateAsaasWebhook validates the asaas-access-token header against the secret of
+// the integration the request belongs to. The integration comes from the URL
+// (/asaas/feecharges/{integration_id}) or, on the shared URL, from the event's account.id
+// (iam.billing_integrations.provider_account_id). Without an integration secret the global
+// ASAAS_WEBHOOK_SECRET applies; without any secret the request is accepted with a warning,
+// or rejected in strict mode.
+//
+// Events that cannot be tied to an integration (shared URL without account.id, or an
+// account.id no integration has) are rejected with 401 (403 in strict mode): they would
+// have no office binding, and an unbound event must never write to iam.charges.
+//
+// Writes the error response itself and returns ok=false when the request must stop.
+func authenticateAsaasWebhook(auth AsaasWebhookAuth, w http.ResponseWriter, r *http.Request, rawBody []byte) (integration *model.BillingIntegrationRow, ok bool) {
+	integration, status, reason := webhookIntegration(r, rawBody)
+	if status == 0 && integration == nil {
+		status, reason = http.StatusUnauthorized, "evento não vinculado a nenhuma integração (sem account.id conhecido) — rejeitado"
+		if auth.Strict {
+			status = http.StatusForbidden
+		}
+	}
+	if status != 0 {
+		log.Printf("❌ [webhook] %s", reason)
+		writeJSON(w, status, map[string]any{"error": http.StatusText(status)})
+		return nil, false
+	}
+
+	secret, source := "", ""
+	if integration != nil && integration.WebhookSecret != nil && strings.TrimSpace(*integration.WebhookSecret) != "" {
+		secret, source = strings.TrimSpace(*integration.WebhookSecret), "integration "+integration.ID
+	} else if global := strings.TrimSpace(auth.Secret); global != "" {
+		secret, source = global, "ASAAS_WEBHOOK_SECRET"
+	}
+
+	if secret == "" {
+		if auth.Strict {
+			log.Printf("❌ [webhook] nenhum secret configurado (integration=%s) e ASAAS_WEBHOOK_STRICT=true — rejeitado", integrationIDOf(integration))
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "Unauthorized"})
+			return nil, false
+		}
+		log.Printf("⚠️  [webhook] nenhum secret configurado (integration=%s) — webhook sem autenticação", integrationIDOf(integration))
+		return integration, true
+	}
+
+	if !secretsEqual(r.Header.Get("asaas-access-token"), secret) {
+		log.Printf("❌ [webhook] Token inválido ou não fornecido (secret=%s)", source)
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "Unauthorized"})
+		return nil, false
+	}
+	log.Printf("✅ [webhook] Token validado (secret=%s)", source)
+	return integration, true
+}
+
+// webhookIntegration finds the billing integration of a webhook request. A nil integration
+// with status 0 means "none identified" (shared URL, missing or unknown account). A non-zero
+// status is the HTTP error to answer with.
+func webhookIntegration(r *http.Request, rawBody []byte) (*model.BillingIntegrationRow, int, string) {
+	ctx := r.Context()
+	var probe struct {
+		Account *struct {
+			ID string `json:"id"`
+		} `json:"account"`
+	}
+	_ = json.Unmarshal(rawBody, &probe) // malformed bodies are rejected later, after auth
+	accountID := ""
+	if probe.Account != nil {
+		accountID = strings.TrimSpace(probe.Account.ID)
+	}
+
+	if integrationID := strings.TrimSpace(chi.URLParam(r, "integration_id")); integrationID != "" {
+		integration, err := supabase.GetBillingIntegrationByIDContext(ctx, integrationID)
+		if err != nil || integration == nil {
+			// Same answer as a wrong token: do not reveal which ids exist.
+			return nil, http.StatusUnauthorized, "integração do webhook não encontrada ou inativa: integration_id=" + integrationID
+		}
+		if !strings.EqualFold(integration.Provider, asaas.ProviderName) {
+			return nil, http.StatusUnauthorized, "integração do webhook não é do Asaas: integration_id=" + integrationID
+		}
+		// An integration bound to an Asaas account only accepts events that name that account.
+		if integration.ProviderAccountID != nil && strings.TrimSpace(*integration.ProviderAccountID) != "" {
+			if accountID == "" {
+				return nil, http.StatusUnauthorized, "evento sem account.id para a integração " + integrationID
+			}
+			if strings.TrimSpace(*integration.ProviderAccountID) != accountID {
+				return nil, http.StatusUnauthorized, "account.id do evento (" + accountID + ") não pertence à integração " + integrationID
+			}
+		}
+		return integration, 0, ""
+	}
+
+	if accountID == "" {
+		return nil, 0, ""
+	}
+	integration, err := supabase.GetBillingIntegrationByProviderAccountContext(ctx, asaas.ProviderName, accountID)
+	if err != nil {
+		// Cannot tell which secret applies: answer 5xx so Asaas delivers the event again.
+		return nil, http.StatusServiceUnavailable, "ERRO ao buscar integração do account.id " + accountID + ": " + err.Error()
+	}
+	return integration, 0, ""
+}
+
+// secretsEqual compares two secrets in constant time. Both sides are hashed first so
+// neither the content nor the length of the expected secret leaks through timing.
+func secretsEqual(provided, expected string) bool {
+	p := sha256.Sum256([]byte(strings.TrimSpace(provided)))
+	e := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(p[:], e[:]) == 1
+}
+
+func integrationIDOf(integration *model.BillingIntegrationRow) string {
+	if integration == nil {
+		return "-"
+	}
+	return integration.ID
+}
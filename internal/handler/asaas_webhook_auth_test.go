@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+func TestAuthenticateAsaasWebhook(t *testing.T) {
+	f := newFakeSupabase(t, "billing_integrations")
+	f.rows = []map[string]any{
+		{"id": "int-1", "accounting_office_id": "o1", "provider": "ASAAS", "is_active": true, "provider_account_id": "acc-1", "webhook_secret": "secret-1"},
+		{"id": "int-2", "accounting_office_id": "o2", "provider": "asaas", "is_active": true, "provider_account_id": "acc-2"},
+		{"id": "int-3", "accounting_office_id": "o3", "provider": "ASAAS", "is_active": true, "webhook_secret": "secret-3"},
+		{"id": "int-off", "accounting_office_id": "o1", "provider": "ASAAS", "is_active": false, "provider_account_id": "acc-off", "webhook_secret": "secret-off"},
+		{"id": "int-other", "accounting_office_id": "o1", "provider": "PAGARME", "is_active": true, "webhook_secret": "secret-other"},
+	}
+
+	tests := []struct {
+		name            string
+		auth            AsaasWebhookAuth
+		integrationID   string // URL parameter; "" = shared URL
+		account         string // account.id of the event; "" = absent
+		token           string
+		wantStatus      int // 0 = accepted
+		wantIntegration string
+	}{
+		{name: "shared URL, integration secret", account: "acc-1", token: "secret-1", wantIntegration: "int-1"},
+		{name: "shared URL, wrong token", account: "acc-1", token: "secret-3", wantStatus: http.StatusUnauthorized},
+		{name: "shared URL, no account", token: "secret-1", wantStatus: http.StatusUnauthorized},
+		{name: "shared URL, no account, strict", auth: AsaasWebhookAuth{Strict: true}, token: "secret-1", wantStatus: http.StatusForbidden},
+		{name: "shared URL, unknown account", account: "acc-9", token: "global", auth: AsaasWebhookAuth{Secret: "global"}, wantStatus: http.StatusUnauthorized},
+		{name: "shared URL, inactive integration", account: "acc-off", token: "secret-off", wantStatus: http.StatusUnauthorized},
+		{name: "integration without secret uses the global one", auth: AsaasWebhookAuth{Secret: "global"}, account: "acc-2", token: "global", wantIntegration: "int-2"},
+		{name: "integration secret wins over the global one", auth: AsaasWebhookAuth{Secret: "global"}, account: "acc-1", token: "global", wantStatus: http.StatusUnauthorized},
+		{name: "no secret at all", account: "acc-2", wantIntegration: "int-2"},
+		{name: "no secret at all, strict", auth: AsaasWebhookAuth{Strict: true}, account: "acc-2", wantStatus: http.StatusUnauthorized},
+		{name: "integration URL", integrationID: "int-1", account: "acc-1", token: "secret-1", wantIntegration: "int-1"},
+		{name: "integration URL without account binding", integrationID: "int-3", token: "secret-3", wantIntegration: "int-3"},
+		{name: "integration URL, event of another account", integrationID: "int-1", account: "acc-2", token: "secret-1", wantStatus: http.StatusUnauthorized},
+		{name: "integration URL, event without account", integrationID: "int-1", token: "secret-1", wantStatus: http.StatusUnauthorized},
+		{name: "integration URL, unknown integration", integrationID: "int-9", token: "secret-1", wantStatus: http.StatusUnauthorized},
+		{name: "integration URL, inactive integration", integrationID: "int-off", account: "acc-off", token: "secret-off", wantStatus: http.StatusUnauthorized},
+		{name: "integration URL, not an Asaas integration", integrationID: "int-other", token: "secret-other", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"id":"evt_1","event":"PAYMENT_RECEIVED"}`
+			if tt.account != "" {
+				body = `{"id":"evt_1","event":"PAYMENT_RECEIVED","account":{"id":"` + tt.account + `"}}`
+			}
+			r := httptest.NewRequest(http.MethodPost, "/asaas/feecharges", strings.NewReader(body))
+			if tt.integrationID != "" {
+				rctx := chi.NewRouteContext()
+				rctx.URLParams.Add("integration_id", tt.integrationID)
+				r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			}
+			if tt.token != "" {
+				r.Header.Set("asaas-access-token", tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			integration, ok := authenticateAsaasWebhook(tt.auth, w, r, []byte(body))
+			if tt.wantStatus != 0 {
+				if ok || w.Code != tt.wantStatus {
+					t.Fatalf("authenticateAsaasWebhook() ok=%v status=%d, want rejected with %d", ok, w.Code, tt.wantStatus)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("authenticateAsaasWebhook() rejected with %d: %s", w.Code, w.Body.String())
+			}
+			if integrationIDOf(integration) != tt.wantIntegration {
+				t.Fatalf("integration = %s, want %s", integrationIDOf(integration), tt.wantIntegration)
+			}
+		})
+	}
+}
+
+func TestIgnoreForeignEvent(t *testing.T) {
+	tests := []struct {
+		name              string
+		integrationOffice string
+		chargeOffice      string
+		want              bool
+	}{
+		{"same office", "o1", "o1", false},
+		{"another office", "o1", "o2", true},
+		{"no integration", "", "o1", true},
+		{"no integration, charge without office", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := newFakeSupabase(t, "asaas_webhook_events")
+			event := &provider.WebhookEvent{ID: "evt_1", Event: "PAYMENT_RECEIVED", Payment: &provider.Payment{ID: "pay_1"}}
+			if got := ignoreForeignEvent(context.Background(), event, nil, tt.integrationOffice, tt.chargeOffice); got != tt.want {
+				t.Fatalf("ignoreForeignEvent() = %v, want %v", got, tt.want)
+			}
+			logged := logs.get(map[string]string{"status": model.WebhookLogStatusIgnoredForeign, "payment_id": "pay_1"})
+			if (len(logged) == 1) != tt.want {
+				t.Fatalf("ignored_foreign log rows = %d, want logged=%v", len(logged), tt.want)
+			}
+		})
+	}
+}
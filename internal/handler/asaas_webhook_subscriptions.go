@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+	"github.com/seuuser/charges-service/internal/webhook"
+)
+
+// updateSubscriptionFromWebhook applies a SUBSCRIPTION_* event to iam.fee_contract_subscriptions
+// (status, value, cycle, next_due_date), so contracts reflect subscriptions changed or
+// cancelled directly in the provider panel.
+//
+// Subscriptions not linked yet are attached to the contract of their external reference
+// ("fee_contract:{uuid}:..."); when there is none the event fails at resolve_contract_context
+// and is retried like payment events. Older events than last_event_at are ignored, as are
+// contracts of another office than integrationOfficeID.
+func updateSubscriptionFromWebhook(ctx context.Context, providerName, integrationOfficeID string, event *provider.WebhookEvent, rawPayload json.RawMessage) (*webhook.Result, error) {
+	s := event.Subscription
+	status := subscriptionStatusFromEvent(event.Event, s)
+	log.Printf("🔍 [updateSubscription] subscription: ID=%s | Event=%s | Status=%s | Value=%.2f | Cycle=%s | NextDueDate=%s",
+		s.ID, event.Event, status, s.Value, s.Cycle, s.NextDueDate)
+
+	defer billing.ChargeLocks.Lock(providerName + ":" + s.ID)()
+
+	result := &webhook.Result{Outcome: model.WebhookOutcomeApplied, StatusTo: status}
+	eventAt, hasEventAt := billing.ParseEventTime(event.DateCreated)
+
+	existing, err := supabase.GetFeeContractSubscriptionContext(ctx, s.ID)
+	if err != nil {
+		return nil, &webhook.StageError{Stage: "load_subscription", Err: err}
+	}
+
+	row := model.FeeContractSubscriptionRow{
+		Provider:               providerName,
+		ProviderSubscriptionID: s.ID,
+	}
+	if existing != nil {
+		row.ContractID = existing.ContractID
+		if existing.Status != nil {
+			result.StatusFrom = *existing.Status
+		}
+		if stale, reason := billing.StaleSubscriptionEvent(existing, eventAt, hasEventAt, status); stale {
+			log.Printf("⏭️  [updateSubscription] Evento %s fora de ordem ignorado: sub=%s status=%s — %s",
+				event.Event, s.ID, status, reason)
+			result.Outcome = model.WebhookOutcomeIgnoredStale
+			result.StatusTo = result.StatusFrom
+			result.ContractID = row.ContractID
+			return result, nil
+		}
+	} else {
+		contractID := billing.ContractIDFromExtRef(s.ExternalReference)
+		if contractID == "" {
+			msg := fmt.Sprintf("assinatura sub=%s não vinculada a contrato e sem external_reference fee_contract (extRef=%q)", s.ID, s.ExternalReference)
+			log.Printf("⚠️  [updateSubscription] %s", msg)
+			return nil, &webhook.StageError{Stage: "resolve_contract_context", Err: errors.New(msg)}
+		}
+		row.ContractID = contractID
+	}
+
+	contract, err := supabase.GetFeeContractByIDContext(ctx, row.ContractID)
+	if err != nil || contract == nil {
+		msg := fmt.Sprintf("contrato %s não encontrado para sub=%s: %v", row.ContractID, s.ID, err)
+		log.Printf("⚠️  [updateSubscription] %s", msg)
+		return nil, &webhook.StageError{Stage: "resolve_contract_context", Err: errors.New(msg)}
+	}
+	result.ContractID = contract.ID
+	result.CompanyID = contract.CompanyID
+	if ignoreForeignEvent(ctx, event, rawPayload, integrationOfficeID, contract.AccountingOfficeID) {
+		result.Outcome = model.WebhookOutcomeIgnoredForeign
+		result.StatusTo = result.StatusFrom
+		return result, nil
+	}
+
+	if status != "" {
+		row.Status = &status
+	}
+	if s.Value > 0 {
+		v := s.Value
+		row.Value = &v
+	}
+	if c := strings.TrimSpace(s.Cycle); c != "" {
+		row.Cycle = &c
+	}
+	if d := strings.TrimSpace(s.NextDueDate); d != "" {
+		row.NextDueDate = &d
+	}
+	if ref := strings.TrimSpace(s.ExternalReference); ref != "" {
+		row.ExternalReference = &ref
+	}
+	if hasEventAt {
+		ts := eventAt.UTC().Format(time.RFC3339)
+		row.LastEventAt = &ts
+	}
+
+	if err := supabase.UpsertFeeContractSubscriptionContext(ctx, row); err != nil {
+		log.Printf("❌ [updateSubscription] erro ao atualizar iam.fee_contract_subscriptions: %v", err)
+		return result, &webhook.StageError{Stage: "upsert_subscription", Err: err}
+	}
+
+	log.Printf("✅ [updateSubscription] Assinatura atualizada: sub=%s contract=%s status=%s → %s",
+		s.ID, contract.ID, result.StatusFrom, status)
+	return result, nil
+}
+
+// subscriptionStatusFromEvent is the status stored for the subscription after the event.
+// Asaas keeps status=ACTIVE on deleted subscriptions, so deletions are stored as DELETED.
+func subscriptionStatusFromEvent(eventType string, s *provider.Subscription) string {
+	status := strings.ToUpper(strings.TrimSpace(s.Status))
+	switch {
+	case s.Deleted || eventType == model.EventSubscriptionDeleted:
+		return billing.SubscriptionStatusDeleted
+	case eventType == model.EventSubscriptionInactivated && (status == "" || status == "ACTIVE"):
+		return "INACTIVE"
+	}
+	return status
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+func TestIsDeletionEvent(t *testing.T) {
+	tests := []struct {
+		event string
+		want  bool
+	}{
+		{model.EventPaymentDeleted, true},
+		{model.EventPaymentRestored, true},
+		{"PAYMENT_RECEIVED", false},
+		{"PAYMENT_REFUNDED", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isDeletionEvent(tt.event); got != tt.want {
+			t.Errorf("isDeletionEvent(%q) = %v, want %v", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestApplyDeletionEvent(t *testing.T) {
+	const apiReason = "deleted through the API"
+	tests := []struct {
+		name        string
+		deletedAt   any // seeded deleted_at; nil = not deleted
+		reason      any
+		event       string
+		wantDeleted bool
+		wantReason  any
+	}{
+		{"delete", nil, nil, model.EventPaymentDeleted, true, deletedReasonWebhook},
+		{"delete keeps the first reason", "2026-03-01T10:00:00Z", apiReason, model.EventPaymentDeleted, true, apiReason},
+		{"restore", "2026-03-01T10:00:00Z", apiReason, model.EventPaymentRestored, false, nil},
+		{"restore of a live charge", nil, nil, model.EventPaymentRestored, false, nil},
+		{"other events are left alone", "2026-03-01T10:00:00Z", apiReason, "PAYMENT_RECEIVED", true, apiReason},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeSupabase(t, "charges")
+			f.rows = []map[string]any{
+				{"provider": "ASAAS", "provider_charge_id": "pay_1", "status": "PENDING", "deleted_at": tt.deletedAt, "deleted_reason": tt.reason},
+				{"provider": "ASAAS", "provider_charge_id": "pay_2", "status": "PENDING", "deleted_at": nil, "deleted_reason": nil},
+			}
+
+			event := &provider.WebhookEvent{Event: tt.event, Payment: &provider.Payment{ID: "pay_1"}}
+			if err := applyDeletionEvent(context.Background(), "ASAAS", event); err != nil {
+				t.Fatalf("applyDeletionEvent() error = %v", err)
+			}
+
+			row := f.get(map[string]string{"provider_charge_id": "pay_1"})[0]
+			if deleted := row["deleted_at"] != nil; deleted != tt.wantDeleted {
+				t.Fatalf("deleted_at = %v, want deleted=%v", row["deleted_at"], tt.wantDeleted)
+			}
+			if row["deleted_reason"] != tt.wantReason {
+				t.Fatalf("deleted_reason = %v, want %v", row["deleted_reason"], tt.wantReason)
+			}
+			if other := f.get(map[string]string{"provider_charge_id": "pay_2"})[0]; other["deleted_at"] != nil {
+				t.Fatalf("another charge was deleted: %v", other)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/auth"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// Error codes of authentication/authorization failures.
+const (
+	errCodeUnauthenticated = "unauthenticated"
+	errCodeCrossTenant     = "cross_tenant_access"
+)
+
+// RequireAuth authenticates /v1 callers (Supabase JWT or X-API-Key) and stores the
+// principal in the request context. Handlers then check tenant access with authorize*.
+func RequireAuth(a *auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, err := a.Authenticate(r)
+			if err != nil {
+				log.Printf("[auth] unauthenticated: method=%s path=%s remote=%s err=%v", r.Method, r.URL.Path, r.RemoteAddr, err)
+				writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "code": errCodeUnauthenticated, "details": err.Error()})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// authorize answers 403 and returns false when the caller may not access a resource
+// of tenantID / accountingOfficeID. Without a principal (route not behind RequireAuth)
+// access is denied.
+func authorize(ctx context.Context, w http.ResponseWriter, tenantID, accountingOfficeID string) bool {
+	p := auth.FromContext(ctx)
+	if p.CanAccess(strings.TrimSpace(tenantID), strings.TrimSpace(accountingOfficeID)) {
+		return true
+	}
+	subject := ""
+	if p != nil {
+		subject = p.Subject
+	}
+	log.Printf("[auth] cross-tenant access denied: subject=%q tenant_id=%s accounting_office_id=%s", subject, tenantID, accountingOfficeID)
+	writeJSON(w, http.StatusForbidden, map[string]any{
+		"error": "forbidden: resource belongs to another tenant or accounting office",
+		"code":  errCodeCrossTenant,
+	})
+	return false
+}
+
+// authorizeOffice checks routes scoped by the accounting_office_id parameter.
+func authorizeOffice(ctx context.Context, w http.ResponseWriter, accountingOfficeID string) bool {
+	return authorize(ctx, w, "", accountingOfficeID)
+}
+
+// authorizeContract checks the tenant and office of a fee contract.
+func authorizeContract(ctx context.Context, w http.ResponseWriter, contract *model.FeeContractRow) bool {
+	return authorize(ctx, w, contract.TenantID, contract.AccountingOfficeID)
+}
+
+// authorizeCharge checks the tenant and office of an iam.charges row.
+func authorizeCharge(ctx context.Context, w http.ResponseWriter, row *model.IamChargeRow) bool {
+	return authorize(ctx, w, row.TenantID, row.AccountingOfficeID)
+}
+
+// scopeChargeFilter restricts a charge listing to the caller's tenants and offices
+// (no-op for services).
+func scopeChargeFilter(ctx context.Context, f *supabase.ChargeListFilter) {
+	p := auth.FromContext(ctx)
+	if p.IsService() {
+		return
+	}
+	f.Scoped = true
+	if p != nil {
+		f.TenantIDs = p.TenantIDs
+		f.AccountingOfficeIDs = p.AccountingOfficeIDs
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// GenerateContractBilling godoc
+// @Summary      Gerar cobranças a partir dos itens de serviço do contrato
+// @Description  Lê iam.fee_contract_service_items do contrato e cria no provedor as assinaturas (itens RECURRING agrupados por periodicity + due_day + payment_method) e as cobranças avulsas (itens ONE_TIME). Idempotente: cada assinatura/cobrança recebe um externalReference determinístico (fee_contract:{id}:recurring:... / fee_contract:{id}:service_item:{item_id}) e é procurada antes de ser criada, então reexecutar nunca duplica. Use dry_run=true para apenas simular.
+// @Tags         billing
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true   "ID do contrato (UUID)"
+// @Param        dry_run  query     bool    false  "Apenas simula (não cria nada no provedor)"
+// @Success      200  {object}  model.BillingRunResult
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/contracts/{id}/billing/generate [post]
+func GenerateContractBilling(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+
+	contractID := strings.TrimSpace(chi.URLParam(r, "id"))
+	if contractID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
+		return
+	}
+
+	dryRun := false
+	if s := strings.TrimSpace(r.URL.Query().Get("dry_run")); s != "" {
+		if s != "true" && s != "false" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "dry_run must be true or false"})
+			return
+		}
+		dryRun = s == "true"
+	}
+
+	contract, err := supabase.GetFeeContractByIDContext(ctx, contractID)
+	if err != nil {
+		log.Printf("[supabase] ERROR loading fee_contract: rid=%s contract_id=%s err=%v", rid, contractID, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load contract", "request_id": rid})
+		return
+	}
+	if contract == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "contract not found"})
+		return
+	}
+	if !authorizeContract(ctx, w, contract) {
+		return
+	}
+
+	result, err := billing.GenerateContractCharges(ctx, contractID, dryRun)
+	if errors.Is(err, billing.ErrContractNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "contract not found"})
+		return
+	}
+	if errors.Is(err, provider.ErrCircuitOpen) {
+		writeProviderCallError(w, rid, err)
+		return
+	}
+	if err != nil {
+		log.Printf("[billing] ERROR generating charges: rid=%s contract_id=%s err=%v", rid, contractID, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "request_id": rid})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
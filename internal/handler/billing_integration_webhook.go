@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// GetBillingIntegrationWebhook godoc
+// @Summary      Verificar o webhook de uma integração no provedor
+// @Description  Compara o webhook cadastrado no provedor (Asaas /v3/webhooks) com a configuração esperada (URL /asaas/feecharges/{integration_id}, eventos, envio sequencial, auth token) sem alterar nada. Informa se a fila foi interrompida (interrupted) ou está penalizada. Requer o header X-Admin-Token.
+// @Tags         admin
+// @Produce      json
+// @Param        X-Admin-Token  header  string  true   "Token de administração (ADMIN_API_TOKEN)"
+// @Param        id             path    string  true   "ID da integração (iam.billing_integrations.id)"
+// @Param        base_url       query   string  false  "URL pública do serviço (default WEBHOOK_PUBLIC_URL)"
+// @Success      200  {object}  model.WebhookProvisionResult
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Router       /v1/admin/billing-integrations/{id}/webhook [get]
+func GetBillingIntegrationWebhook(w http.ResponseWriter, r *http.Request) {
+	provisionWebhook(w, r, model.WebhookProvisionRequest{
+		BaseURL: r.URL.Query().Get("base_url"),
+		DryRun:  true,
+	})
+}
+
+// ProvisionBillingIntegrationWebhook godoc
+// @Summary      Criar ou reparar o webhook de uma integração no provedor
+// @Description  Cria o webhook do charges-service na conta do provedor da integração ou corrige o existente (URL, eventos, envio sequencial, habilitado, auth token). O auth token é o webhook_secret da integração; se ela não tiver, um secret é gerado e gravado. Com resume=true retoma uma fila interrompida pelo Asaas. Use dry_run=true para apenas simular. Requer o header X-Admin-Token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        X-Admin-Token  header  string                         true   "Token de administração (ADMIN_API_TOKEN)"
+// @Param        id             path    string                         true   "ID da integração (iam.billing_integrations.id)"
+// @Param        body           body    model.WebhookProvisionRequest  false  "Opções"
+// @Success      200  {object}  model.WebhookProvisionResult
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Router       /v1/admin/billing-integrations/{id}/webhook [post]
+func ProvisionBillingIntegrationWebhook(w http.ResponseWriter, r *http.Request) {
+	var req model.WebhookProvisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body"})
+		return
+	}
+	provisionWebhook(w, r, req)
+}
+
+func provisionWebhook(w http.ResponseWriter, r *http.Request, req model.WebhookProvisionRequest) {
+	ctx := r.Context()
+	rid := newRequestID()
+
+	integrationID := strings.TrimSpace(chi.URLParam(r, "id"))
+	if integrationID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
+		return
+	}
+	if strings.TrimSpace(req.BaseURL) == "" {
+		req.BaseURL = strings.TrimSpace(os.Getenv("WEBHOOK_PUBLIC_URL"))
+	}
+	if req.BaseURL == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "base_url is required (or set WEBHOOK_PUBLIC_URL)"})
+		return
+	}
+	if !strings.HasPrefix(req.BaseURL, "https://") && !strings.HasPrefix(req.BaseURL, "http://") {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "base_url must start with https:// or http://"})
+		return
+	}
+	if strings.TrimSpace(req.Email) == "" {
+		req.Email = strings.TrimSpace(os.Getenv("ASAAS_WEBHOOK_EMAIL"))
+	}
+
+	log.Printf("[admin] webhook provision: rid=%s integration_id=%s resume=%v force=%v dry_run=%v",
+		rid, integrationID, req.Resume, req.Force, req.DryRun)
+
+	result, err := billing.ProvisionWebhook(ctx, integrationID, req)
+	switch {
+	case errors.Is(err, billing.ErrIntegrationNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found"})
+		return
+	case errors.Is(err, billing.ErrWebhookEmailRequired):
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	case errors.Is(err, provider.ErrCircuitOpen):
+		writeProviderCallError(w, rid, err)
+		return
+	case err != nil:
+		log.Printf("[admin] ERROR webhook provision: rid=%s integration_id=%s err=%v", rid, integrationID, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "request_id": rid})
+		return
+	}
+	if result.Interrupted {
+		log.Printf("⚠️  [admin] webhook queue interrupted by provider: integration_id=%s webhook=%s", integrationID, result.Webhook.ID)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
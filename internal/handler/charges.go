@@ -0,0 +1,358 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/seuuser/charges-service/internal/auth"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// ListCharges godoc
+// @Summary      Listar cobranças (neutro)
+// @Description  Lista as cobranças persistidas em iam.charges de um contrato (contract_id) ou de uma empresa (company_id), no formato normalizado do serviço (independente do provedor).
+// @Tags         charges
+// @Accept       json
+// @Produce      json
+// @Param        contract_id      query     string  false  "ID do contrato (UUID)"
+// @Param        company_id       query     string  false  "ID da empresa (UUID)"
+// @Param        provider_status  query     string  false  "Filtrar pelo status original do provedor (ex.: PENDING, RECEIVED)"
+// @Param        include_deleted  query     bool    false  "Incluir cobranças excluídas (deleted_at preenchido); default false"
+// @Param        offset           query     int     false  "Elemento inicial da lista"
+// @Param        limit            query     int     false  "Número de elementos da lista (max: 100, default: 20)"
+// @Success      200  {object}  model.ChargeListResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/charges [get]
+func ListCharges(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	filter := supabase.ChargeListFilter{
+		ContractID: strings.TrimSpace(q.Get("contract_id")),
+		CompanyID:  strings.TrimSpace(q.Get("company_id")),
+		Status:     strings.TrimSpace(q.Get("provider_status")),
+	}
+	if filter.ContractID == "" && filter.CompanyID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "contract_id or company_id is required"})
+		return
+	}
+	switch strings.TrimSpace(q.Get("include_deleted")) {
+	case "", "false":
+	case "true":
+		filter.IncludeDeleted = true
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "include_deleted must be true or false"})
+		return
+	}
+
+	if filter.ContractID != "" && !auth.FromContext(ctx).IsService() {
+		contract, err := supabase.GetFeeContractByIDContext(ctx, filter.ContractID)
+		if err != nil {
+			if isDebugEnabled() {
+				log.Printf("[supabase] ERROR loading fee_contract: contract_id=%s err=%v", filter.ContractID, err)
+			}
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load contract"})
+			return
+		}
+		if contract != nil && !authorizeContract(ctx, w, contract) {
+			return
+		}
+	}
+
+	var ok bool
+	filter.Offset, filter.Limit, ok = parseChargePagination(w, r)
+	if !ok {
+		return
+	}
+
+	writeChargePage(ctx, w, filter)
+}
+
+// GetCharge godoc
+// @Summary      Recuperar cobrança (neutro)
+// @Description  Recupera uma cobrança de iam.charges pelo ID interno (UUID), no formato normalizado do serviço.
+// @Tags         charges
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID da cobrança em iam.charges (UUID)"
+// @Success      200  {object}  model.Charge
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/charges/{id} [get]
+func GetCharge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
+		return
+	}
+
+	row, err := supabase.GetChargeByIDContext(ctx, id)
+	if err != nil {
+		if isDebugEnabled() {
+			log.Printf("[supabase] ERROR loading iam.charges: id=%s err=%v", id, err)
+		}
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load charge"})
+		return
+	}
+	if row == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "charge not found"})
+		return
+	}
+	if !authorizeCharge(ctx, w, row) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, chargeFromRow(*row))
+}
+
+// CreateCharge godoc
+// @Summary      Criar cobrança (neutro)
+// @Description  Cria uma cobrança (ou parcelamento) para um contrato no provedor configurado no contrato (billing_integration_id / provider_environment / integração padrão do escritório). O customer é resolvido (ou auto-criado) a partir da empresa do contrato. Retorna as cobranças persistidas em iam.charges no formato normalizado.
+// @Tags         charges
+// @Accept       json
+// @Produce      json
+// @Param        body  body      model.CreateChargeRequest  true  "Payload da cobrança"
+// @Param        Idempotency-Key  header  string  false  "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original"
+// @Success      201  {object}  model.ChargeListResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      422  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/charges [post]
+func CreateCharge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+
+	var req model.CreateChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json body"})
+		return
+	}
+
+	req.ContractID = strings.TrimSpace(req.ContractID)
+	req.DueDate = strings.TrimSpace(req.DueDate)
+	if req.ContractID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "contract_id is required"})
+		return
+	}
+	if !validPaymentMethod(req.PaymentMethod) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "payment_method must be one of BOLETO, PIX, CREDIT_CARD, UNDEFINED"})
+		return
+	}
+	if req.Value <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "value must be > 0"})
+		return
+	}
+	if req.DueDate == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "due_date is required"})
+		return
+	}
+
+	contract, chargeProvider, ok := contractChargeProvider(ctx, w, rid, req.ContractID)
+	if !ok {
+		return
+	}
+
+	customerID, ok := ensureProviderCustomer(ctx, w, rid, chargeProvider, contract.CompanyID)
+	if !ok {
+		return
+	}
+
+	in := provider.PaymentInput{
+		CustomerID:        customerID,
+		BillingType:       string(req.PaymentMethod),
+		Value:             req.Value,
+		DueDate:           req.DueDate,
+		Description:       req.Description,
+		ExternalReference: req.ExternalReference,
+		InstallmentCount:  req.InstallmentCount,
+		InstallmentValue:  req.InstallmentValue,
+		TotalValue:        req.TotalValue,
+	}
+	billing.FinancialTermsFromContract(contract, &in.Discount, &in.Interest, &in.Fine)
+
+	created, resp, callErr := chargeProvider.CreatePayment(in)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("create charge", rid, resp)
+	if !resp.OK() || created == nil {
+		writeProviderError(w, rid, chargeProvider.Name(), resp)
+		return
+	}
+
+	// Installment plans generate several payments (up to hundreds): persist all of them.
+	payments := []provider.Payment{*created}
+	if created.InstallmentID != "" {
+		all, listErr := provider.Payments(chargeProvider, provider.PaymentFilter{
+			CustomerID:    customerID,
+			InstallmentID: created.InstallmentID,
+		}).All()
+		if listErr != nil {
+			log.Printf("[provider] WARN listing installments after create: rid=%s installment=%s listed=%d err=%v", rid, created.InstallmentID, len(all), listErr)
+		}
+		if len(all) > 0 {
+			payments = all
+		}
+	}
+
+	stored, err := supabase.UpsertChargesReturningContext(ctx, billing.ChargeRowsForContract(chargeProvider.Name(), contract, payments))
+	if err != nil {
+		log.Printf("[supabase] ERROR upserting iam.charges: rid=%s contract_id=%s err=%v", rid, contract.ID, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to persist charges", "request_id": rid})
+		return
+	}
+
+	syncOneOffChargeFromPayment(ctx, "CREATE", created)
+
+	charges := chargesFromRows(stored)
+	writeJSON(w, http.StatusCreated, model.ChargeListResponse{
+		Data:       charges,
+		TotalCount: int64(len(charges)),
+		Limit:      len(charges),
+	})
+}
+
+// parseChargePagination reads offset/limit from the query string (limit defaults to 20, max 100).
+// ok=false means an error response was already written.
+func parseChargePagination(w http.ResponseWriter, r *http.Request) (offset int, limit int, ok bool) {
+	limit = 20
+	q := r.URL.Query()
+	if s := strings.TrimSpace(q.Get("offset")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "offset must be a non-negative integer"})
+			return 0, 0, false
+		}
+		offset = n
+	}
+	if s := strings.TrimSpace(q.Get("limit")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 100 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "limit must be an integer between 1 and 100"})
+			return 0, 0, false
+		}
+		limit = n
+	}
+	return offset, limit, true
+}
+
+// writeChargePage lists iam.charges and writes a model.ChargeListResponse.
+// Rows outside the caller's tenants and offices are never listed.
+func writeChargePage(ctx context.Context, w http.ResponseWriter, filter supabase.ChargeListFilter) {
+	scopeChargeFilter(ctx, &filter)
+	rows, total, err := supabase.ListChargesContext(ctx, filter)
+	if err != nil {
+		if isDebugEnabled() {
+			log.Printf("[supabase] ERROR listing iam.charges: contract_id=%s company_id=%s subscription=%s err=%v",
+				filter.ContractID, filter.CompanyID, filter.ProviderSubscriptionID, err)
+		}
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to list charges"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, model.ChargeListResponse{
+		Data:       chargesFromRows(rows),
+		TotalCount: total,
+		Offset:     filter.Offset,
+		Limit:      filter.Limit,
+		HasMore:    int64(filter.Offset+len(rows)) < total,
+	})
+}
+
+func validPaymentMethod(m model.ChargePaymentMethod) bool {
+	switch m {
+	case model.ChargePaymentMethodBoleto, model.ChargePaymentMethodPix, model.ChargePaymentMethodCreditCard, model.ChargePaymentMethodUndefined:
+		return true
+	}
+	return false
+}
+
+func chargesFromRows(rows []model.IamChargeRow) []model.Charge {
+	out := make([]model.Charge, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, chargeFromRow(row))
+	}
+	return out
+}
+
+// chargeFromRow maps an iam.charges row to the neutral representation.
+func chargeFromRow(row model.IamChargeRow) model.Charge {
+	c := model.Charge{
+		ID:                row.ID,
+		ContractID:        row.ContractID,
+		CompanyID:         row.CompanyID,
+		Provider:          row.Provider,
+		ProviderChargeID:  row.ProviderChargeID,
+		InstallmentID:     row.ProviderInstallmentID,
+		SubscriptionID:    row.ProviderSubscriptionID,
+		InstallmentNumber: row.InstallmentNumber,
+		Value:             row.Value,
+		NetValue:          row.NetValue,
+		Description:       row.Description,
+		Status:            chargeStatusFromProvider(row.Status),
+		ProviderStatus:    row.Status,
+		DueDate:           row.DueDate,
+		OriginalDueDate:   row.OriginalDueDate,
+		InvoiceURL:        row.InvoiceURL,
+		InvoiceNumber:     row.InvoiceNumber,
+		ExternalReference: row.ExternalReference,
+		DeletedAt:         row.DeletedAt,
+		DeletedReason:     row.DeletedReason,
+		RefundedValue:     row.RefundedValue,
+		Refunds:           row.Refunds,
+		UpdatedAt:         row.UpdatedAt,
+	}
+	if row.BillingType != nil {
+		c.PaymentMethod = model.ChargePaymentMethod(strings.ToUpper(strings.TrimSpace(*row.BillingType)))
+	}
+	return c
+}
+
+// chargeStatusFromProvider collapses provider statuses into model.ChargeStatus.
+// Provider vocabularies are mapped here so the UI only knows the neutral values.
+func chargeStatusFromProvider(status *string) model.ChargeStatus {
+	if status == nil {
+		return model.ChargeStatusUnknown
+	}
+	switch strings.ToUpper(strings.TrimSpace(*status)) {
+	case "PENDING":
+		return model.ChargeStatusPending
+	case "RECEIVED", "CONFIRMED", "RECEIVED_IN_CASH", "DUNNING_RECEIVED":
+		return model.ChargeStatusPaid
+	case "OVERDUE", "DUNNING_REQUESTED":
+		return model.ChargeStatusOverdue
+	case "REFUNDED":
+		return model.ChargeStatusRefunded
+	case "AWAITING_RISK_ANALYSIS", "REFUND_REQUESTED", "REFUND_IN_PROGRESS",
+		"CHARGEBACK_REQUESTED", "CHARGEBACK_DISPUTE", "AWAITING_CHARGEBACK_REVERSAL":
+		return model.ChargeStatusProcessing
+	case "DELETED", "CANCELLED", "CANCELED":
+		return model.ChargeStatusCancelled
+	}
+	return model.ChargeStatusUnknown
+}
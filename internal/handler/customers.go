@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// GetCustomer godoc
+// @Summary      Recuperar customer da empresa (neutro)
+// @Description  Recupera o customer da empresa no provedor de cobrança, no formato normalizado. Informe contract_id (resolve empresa e integração pelo contrato) ou company_id + accounting_office_id (integração do contrato mais recente da empresa no escritório, ou a padrão do escritório).
+// @Tags         customers
+// @Accept       json
+// @Produce      json
+// @Param        contract_id           query     string  false  "ID do contrato (UUID)"
+// @Param        company_id            query     string  false  "ID da empresa (UUID)"
+// @Param        accounting_office_id  query     string  false  "ID do accounting_office (UUID) - obrigatório com company_id"
+// @Success      200  {object}  model.Customer
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/customers [get]
+func GetCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	q := r.URL.Query()
+
+	companyID, chargeProvider, ok := customerContext(ctx, w, rid,
+		strings.TrimSpace(q.Get("contract_id")),
+		strings.TrimSpace(q.Get("company_id")),
+		strings.TrimSpace(q.Get("accounting_office_id")),
+	)
+	if !ok {
+		return
+	}
+
+	customerID, err := supabase.GetCompanyAsaasCustomerIDContext(ctx, companyID)
+	if err != nil {
+		if isDebugEnabled() {
+			log.Printf("[supabase] error resolving customer id: rid=%s company_id=%s err=%v", rid, companyID, err)
+		}
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to resolve customer id", "request_id": rid})
+		return
+	}
+	if strings.TrimSpace(customerID) == "" {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "customer not found for this company"})
+		return
+	}
+
+	writeCustomer(w, rid, chargeProvider, companyID, customerID)
+}
+
+// EnsureCustomer godoc
+// @Summary      Garantir customer da empresa (neutro)
+// @Description  Garante que a empresa possui customer no provedor de cobrança: reutiliza o mapeamento existente ou cria o customer a partir dos dados da empresa. Informe contract_id ou company_id + accounting_office_id.
+// @Tags         customers
+// @Accept       json
+// @Produce      json
+// @Param        body  body      model.EnsureCustomerRequest  true  "contract_id ou company_id + accounting_office_id"
+// @Param        Idempotency-Key  header  string  false  "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original"
+// @Success      200  {object}  model.Customer
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/customers [post]
+func EnsureCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+
+	var req model.EnsureCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json body"})
+		return
+	}
+
+	companyID, chargeProvider, ok := customerContext(ctx, w, rid,
+		strings.TrimSpace(req.ContractID),
+		strings.TrimSpace(req.CompanyID),
+		strings.TrimSpace(req.AccountingOfficeID),
+	)
+	if !ok {
+		return
+	}
+
+	customerID, ok := ensureProviderCustomer(ctx, w, rid, chargeProvider, companyID)
+	if !ok {
+		return
+	}
+
+	writeCustomer(w, rid, chargeProvider, companyID, customerID)
+}
+
+// customerContext resolves the company and provider for the customer endpoints,
+// either through the contract or through company_id + accounting_office_id.
+// ok=false means an error response was already written.
+func customerContext(ctx context.Context, w http.ResponseWriter, rid, contractID, companyID, accountingOfficeID string) (string, provider.ChargeProvider, bool) {
+	if contractID != "" {
+		contract, chargeProvider, ok := contractChargeProvider(ctx, w, rid, contractID)
+		if !ok {
+			return "", nil, false
+		}
+		return contract.CompanyID, chargeProvider, true
+	}
+
+	if companyID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "contract_id or company_id is required"})
+		return "", nil, false
+	}
+	if accountingOfficeID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required with company_id"})
+		return "", nil, false
+	}
+	if !authorizeOffice(ctx, w, accountingOfficeID) {
+		return "", nil, false
+	}
+
+	_, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		CompanyID:          companyID,
+	})
+	if !ok {
+		return "", nil, false
+	}
+	return companyID, chargeProvider, true
+}
+
+// writeCustomer fetches the provider customer and writes it as model.Customer.
+func writeCustomer(w http.ResponseWriter, rid string, chargeProvider provider.ChargeProvider, companyID, customerID string) {
+	customer, resp, callErr := chargeProvider.GetCustomer(customerID)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("get customer", rid, resp)
+	if !resp.OK() || customer == nil {
+		writeProviderError(w, rid, chargeProvider.Name(), resp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, model.Customer{
+		ID:                customer.ID,
+		CompanyID:         companyID,
+		Provider:          chargeProvider.Name(),
+		Name:              customer.Name,
+		CpfCnpj:           customer.CpfCnpj,
+		Email:             customer.Email,
+		MobilePhone:       customer.MobilePhone,
+		ExternalReference: customer.ExternalReference,
+		Deleted:           customer.Deleted,
+	})
+}
@@ -4,21 +4,59 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/model"
 )
 
 type HealthResponse struct {
-	Status string    `json:"status"`
+	Status string    `json:"status"` // ok | degraded (some circuit breaker is not closed)
 	Time   time.Time `json:"time"`
+
+	// CircuitBreakers counts the breakers of the billing integrations called since startup
+	// by state. Details (integration, last error) are on GET /v1/admin/circuit-breakers.
+	CircuitBreakers model.CircuitBreakerCounts `json:"circuit_breakers"`
 }
 
 // Health godoc
 // @Summary      Health check
-// @Description  Check the health of the service
+// @Description  Check the health of the service. Counts the circuit breakers of the billing integrations by state (closed, open, half_open); status is "degraded" while any of them is not closed. Always answers 200.
 // @Tags         status
 // @Success      200  {object}  HealthResponse
 // @Router       /health [get]
 func Health(w http.ResponseWriter, r *http.Request) {
 	resp := HealthResponse{Status: "ok", Time: time.Now()}
+	for _, b := range asaas.Breakers() {
+		switch b.State {
+		case model.CircuitOpen:
+			resp.CircuitBreakers.Open++
+		case model.CircuitHalfOpen:
+			resp.CircuitBreakers.HalfOpen++
+		default:
+			resp.CircuitBreakers.Closed++
+		}
+	}
+	if resp.CircuitBreakers.Open > 0 || resp.CircuitBreakers.HalfOpen > 0 {
+		resp.Status = "degraded"
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+// ListCircuitBreakers godoc
+// @Summary      Circuit breakers das integrações
+// @Description  Lista o circuit breaker de cada integração de cobrança chamada desde o início do processo: estado, falhas seguidas, próxima tentativa e último erro do provedor. Requer o header X-Admin-Token.
+// @Tags         admin
+// @Produce      json
+// @Param        X-Admin-Token  header  string  true  "Token de administração (ADMIN_API_TOKEN)"
+// @Success      200  {array}   model.CircuitBreakerStatus
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Router       /v1/admin/circuit-breakers [get]
+func ListCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	breakers := asaas.Breakers()
+	if breakers == nil {
+		breakers = []model.CircuitBreakerStatus{}
+	}
+	writeJSON(w, http.StatusOK, breakers)
+}
@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/auth"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+const (
+	idempotencyHeader         = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
+// Idempotent wraps a create endpoint with Idempotency-Key support.
+//
+// The first request with a key runs normally and its response is stored in
+// iam.idempotency_keys for ttl. A retry with the same key and the same request
+// (method, path, query and body) gets the stored response back without running
+// the handler again; reusing the key for a different request returns 409.
+// Requests without the header are not affected.
+//
+// A response is stored, errors included, once the handler sent a write to the provider:
+// a 502 after the provider already created the charge must not be retried blindly (that
+// is how clients got billed twice). When no write was sent (validation errors, Supabase
+// failures, an open circuit breaker) the key is released instead, so a retry with the
+// same key runs the request again rather than replaying that error until the key expires.
+//
+// While the first request runs, the key holds a lease of lockTimeout (locked_until) under a
+// random owner token. Retries get 409 during the lease; once it expires without a stored
+// response (the instance crashed), the next retry of the same request takes the key over
+// and runs. A live owner keeps renewing its lease while the handler runs; if it cannot
+// (the key was taken over, or the renewals failed until the lease ran out) the handler's
+// context is cancelled, so no provider call is started once another request may run the
+// same create. Storing the response also requires the owner token, so a late original
+// request never overwrites the response of the request that took over.
+func Idempotent(ttl, lockTimeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		key := strings.TrimSpace(r.Header.Get(idempotencyHeader))
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "Idempotency-Key must have at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "failed to read request body"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are per caller: a replay never hands one tenant's response to another.
+		scope := r.Method + " " + r.URL.Path
+		if p := auth.FromContext(ctx); p != nil {
+			scope += " " + p.Subject
+		}
+		hash := requestHash(r, body)
+
+		existing, err := supabase.GetIdempotencyKeyContext(ctx, key, scope)
+		if err != nil {
+			log.Printf("[idempotency] ERROR loading key: scope=%q err=%v", scope, err)
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to check Idempotency-Key"})
+			return
+		}
+		if existing != nil && idempotencyExpired(existing, time.Now()) {
+			if err := supabase.DeleteIdempotencyKeyContext(ctx, key, scope); err != nil {
+				log.Printf("[idempotency] ERROR releasing expired key: scope=%q err=%v", scope, err)
+			}
+			existing = nil
+		}
+		now := time.Now()
+		owner := newLeaseOwner()
+		if existing != nil && !leaseExpired(existing, now) {
+			replayIdempotent(w, existing, hash)
+			return
+		}
+
+		if existing != nil {
+			if existing.RequestHash != hash {
+				writeJSON(w, http.StatusConflict, map[string]any{"error": "Idempotency-Key was already used with a different request"})
+				return
+			}
+			took, err := supabase.TakeOverIdempotencyKeyContext(ctx, key, scope, owner, now, now.Add(lockTimeout))
+			if err != nil {
+				log.Printf("[idempotency] ERROR taking over key: scope=%q err=%v", scope, err)
+				writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to register Idempotency-Key"})
+				return
+			}
+			if !took {
+				// Another retry renewed the lease first, or the owner completed meanwhile.
+				writeJSON(w, http.StatusConflict, map[string]any{"error": "a request with this Idempotency-Key is already in progress"})
+				return
+			}
+			log.Printf("[idempotency] lease expired, key taken over: scope=%q", scope)
+		} else {
+			lockedUntil := now.Add(lockTimeout).UTC().Format(time.RFC3339)
+			err = supabase.InsertIdempotencyKeyContext(ctx, model.IdempotencyKeyRow{
+				IdempotencyKey: key,
+				Scope:          scope,
+				RequestHash:    hash,
+				Status:         model.IdempotencyStatusInProgress,
+				LockedUntil:    &lockedUntil,
+				LockOwner:      &owner,
+				ExpiresAt:      now.Add(ttl).UTC().Format(time.RFC3339),
+			})
+			if errors.Is(err, supabase.ErrIdempotencyKeyExists) {
+				// Lost the race against a concurrent request with the same key.
+				writeJSON(w, http.StatusConflict, map[string]any{"error": "a request with this Idempotency-Key is already in progress"})
+				return
+			}
+			if err != nil {
+				log.Printf("[idempotency] ERROR claiming key: scope=%q err=%v", scope, err)
+				writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to register Idempotency-Key"})
+				return
+			}
+		}
+
+		leaseCtx, stopLease := keepIdempotencyLease(ctx, key, scope, owner, now.Add(lockTimeout), lockTimeout)
+		defer stopLease()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			if !completed {
+				// Handler panicked: release the key so the client can retry.
+				_ = supabase.ReleaseIdempotencyKeyContext(context.WithoutCancel(ctx), key, scope, owner)
+			}
+		}()
+		trackedCtx, writeSent := provider.TrackWrites(leaseCtx)
+		next(rec, r.WithContext(trackedCtx))
+		completed = true
+		stopLease()
+
+		if !writeSent() {
+			// Nothing reached the provider: the request can safely run again.
+			if err := supabase.ReleaseIdempotencyKeyContext(context.WithoutCancel(ctx), key, scope, owner); err != nil {
+				log.Printf("[idempotency] ERROR releasing key: scope=%q status=%d err=%v", scope, rec.status, err)
+			}
+			return
+		}
+
+		// Stored even if the client went away: the retry must replay this response.
+		stored, err := supabase.CompleteIdempotencyKeyContext(context.WithoutCancel(ctx), key, scope, owner, rec.status, rec.Header().Get("Content-Type"), rec.body.String())
+		if err != nil {
+			log.Printf("[idempotency] ERROR storing response: scope=%q status=%d err=%v", scope, rec.status, err)
+		} else if !stored {
+			log.Printf("[idempotency] WARN lease lost before completion, response not stored: scope=%q status=%d", scope, rec.status)
+		}
+	}
+}
+
+// keepIdempotencyLease renews the owner's lease every third of lockTimeout while the handler
+// runs. The returned context is cancelled when the key is taken over or when the lease
+// (leaseEnd) runs out without a successful renewal; stop ends the renewals.
+func keepIdempotencyLease(ctx context.Context, key, scope, owner string, leaseEnd time.Time, lockTimeout time.Duration) (context.Context, context.CancelFunc) {
+	leaseCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(lockTimeout / 3)
+		defer ticker.Stop()
+		expiry := time.NewTimer(time.Until(leaseEnd))
+		defer expiry.Stop()
+		for {
+			select {
+			case <-leaseCtx.Done():
+				return
+			case <-expiry.C:
+				log.Printf("[idempotency] WARN lease ran out without renewal, cancelling request: scope=%q", scope)
+				cancel()
+				return
+			case <-ticker.C:
+				until := time.Now().Add(lockTimeout)
+				ok, err := supabase.RenewIdempotencyKeyContext(leaseCtx, key, scope, owner, until)
+				if err != nil {
+					log.Printf("[idempotency] ERROR renewing lease: scope=%q err=%v", scope, err)
+					continue
+				}
+				if !ok {
+					log.Printf("[idempotency] WARN key taken over, cancelling request: scope=%q", scope)
+					cancel()
+					return
+				}
+				if !expiry.Stop() {
+					select {
+					case <-expiry.C:
+					default:
+					}
+				}
+				expiry.Reset(time.Until(until))
+			}
+		}
+	}()
+	return leaseCtx, cancel
+}
+
+func newLeaseOwner() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// replayIdempotent answers a retry from the stored row.
+func replayIdempotent(w http.ResponseWriter, row *model.IdempotencyKeyRow, hash string) {
+	if row.RequestHash != hash {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+	if row.Status != model.IdempotencyStatusCompleted || row.ResponseStatus == nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "a request with this Idempotency-Key is already in progress"})
+		return
+	}
+
+	if row.ResponseContentType != nil && *row.ResponseContentType != "" {
+		w.Header().Set("Content-Type", *row.ResponseContentType)
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(*row.ResponseStatus)
+	if row.ResponseBody != nil {
+		_, _ = io.WriteString(w, *row.ResponseBody)
+	}
+}
+
+// requestHash fingerprints what makes two requests "the same": method, path, query and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.Query().Encode()+"\n")
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func idempotencyExpired(row *model.IdempotencyKeyRow, now time.Time) bool {
+	exp, err := time.Parse(time.RFC3339, row.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return now.After(exp)
+}
+
+// leaseExpired reports whether an in_progress row's owner gave up (locked_until passed).
+// Completed rows never expire here: they are replayed until expires_at.
+func leaseExpired(row *model.IdempotencyKeyRow, now time.Time) bool {
+	if row.Status != model.IdempotencyStatusInProgress {
+		return false
+	}
+	if row.LockedUntil == nil {
+		return true // claimed before leases existed
+	}
+	until, err := time.Parse(time.RFC3339, *row.LockedUntil)
+	if err != nil {
+		return false
+	}
+	return now.After(until)
+}
+
+// responseRecorder passes the response through while keeping a copy of status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,554 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// fakePostgREST is an in-memory stand-in for the PostgREST API used by the supabase
+// package: select/insert/update/delete on one table with eq, ilike, is.null, not and lt filters.
+type fakePostgREST struct {
+	mu     sync.Mutex
+	table  string
+	unique []string // columns of the unique constraint checked on insert
+	rows   []map[string]any
+}
+
+// newFakeSupabase points the supabase package at a fakePostgREST serving table.
+func newFakeSupabase(t *testing.T, table string, unique ...string) *fakePostgREST {
+	t.Helper()
+	f := &fakePostgREST{table: table, unique: unique}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	t.Setenv("SUPABASE_URL", srv.URL)
+	t.Setenv("SUPABASE_KEY", "test-key")
+	supabase.InitClient()
+	return f
+}
+
+func (f *fakePostgREST) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path != "/rest/v1/"+f.table {
+		http.Error(w, `{"code":"42P01","message":"unknown table"}`, http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	switch r.Method {
+	case http.MethodGet:
+		writeFakeRows(w, f.matching(q))
+	case http.MethodPost:
+		var row map[string]any
+		if err := json.Unmarshal(body, &row); err != nil {
+			http.Error(w, `{"code":"PGRST102","message":"bad json"}`, http.StatusBadRequest)
+			return
+		}
+		for _, existing := range f.rows {
+			if f.sameKey(existing, row) {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = io.WriteString(w, `{"code":"23505","message":"duplicate key value violates unique constraint"}`)
+				return
+			}
+		}
+		f.rows = append(f.rows, row)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPatch:
+		var patch map[string]any
+		if err := json.Unmarshal(body, &patch); err != nil {
+			http.Error(w, `{"code":"PGRST102","message":"bad json"}`, http.StatusBadRequest)
+			return
+		}
+		updated := f.matching(q)
+		for _, row := range updated {
+			for k, v := range patch {
+				row[k] = v
+			}
+		}
+		writeFakeRows(w, updated)
+	case http.MethodDelete:
+		kept := f.rows[:0]
+		for _, row := range f.rows {
+			if !matchesFilters(row, q) {
+				kept = append(kept, row)
+			}
+		}
+		f.rows = kept
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakePostgREST) matching(q url.Values) []map[string]any {
+	var out []map[string]any
+	for _, row := range f.rows {
+		if matchesFilters(row, q) {
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+func (f *fakePostgREST) sameKey(a, b map[string]any) bool {
+	for _, col := range f.unique {
+		if fmt.Sprint(a[col]) != fmt.Sprint(b[col]) {
+			return false
+		}
+	}
+	return len(f.unique) > 0
+}
+
+// get returns a copy of the rows matching the column values in eq.
+func (f *fakePostgREST) get(eq map[string]string) []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []map[string]any
+	for _, row := range f.rows {
+		ok := true
+		for col, v := range eq {
+			if fmt.Sprint(row[col]) != v {
+				ok = false
+			}
+		}
+		if ok {
+			cp := map[string]any{}
+			for k, v := range row {
+				cp[k] = v
+			}
+			out = append(out, cp)
+		}
+	}
+	return out
+}
+
+// set overwrites col on every row matching eq.
+func (f *fakePostgREST) set(eq map[string]string, col string, v any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, row := range f.rows {
+		ok := true
+		for c, want := range eq {
+			if fmt.Sprint(row[c]) != want {
+				ok = false
+			}
+		}
+		if ok {
+			row[col] = v
+		}
+	}
+}
+
+func writeFakeRows(w http.ResponseWriter, rows []map[string]any) {
+	if rows == nil {
+		rows = []map[string]any{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rows)
+}
+
+func matchesFilters(row map[string]any, q url.Values) bool {
+	for col, values := range q {
+		switch col {
+		case "select", "limit", "order", "offset":
+			continue
+		case "or":
+			for _, v := range values {
+				if !matchesAny(row, strings.TrimSuffix(strings.TrimPrefix(v, "("), ")")) {
+					return false
+				}
+			}
+		default:
+			for _, v := range values {
+				if !matchesOp(row, col, v) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func matchesAny(row map[string]any, clauses string) bool {
+	for _, c := range strings.Split(clauses, ",") {
+		col, cond, _ := strings.Cut(c, ".")
+		if matchesOp(row, col, cond) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesOp(row map[string]any, col, cond string) bool {
+	op, want, _ := strings.Cut(cond, ".")
+	v, present := row[col]
+	switch op {
+	case "eq":
+		return present && v != nil && fmt.Sprint(v) == want
+	case "is":
+		return want == "null" && (!present || v == nil)
+	case "not":
+		return !matchesOp(row, col, want)
+	case "ilike":
+		return present && v != nil && strings.EqualFold(fmt.Sprint(v), want)
+	case "lt":
+		s, ok := v.(string)
+		return ok && s < want
+	}
+	return false
+}
+
+const idempotencyTestPath = "/v1/charges"
+
+func idempotencyRequest(key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, idempotencyTestPath, strings.NewReader(body))
+	r.Header.Set(idempotencyHeader, key)
+	return r
+}
+
+func idempotencyHashOf(body string) string {
+	return requestHash(httptest.NewRequest(http.MethodPost, idempotencyTestPath, nil), []byte(body))
+}
+
+func rfc3339(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+
+func TestIdempotent(t *testing.T) {
+	const (
+		key   = "key-1"
+		body  = `{"value":10}`
+		scope = http.MethodPost + " " + idempotencyTestPath
+	)
+	created := func(w http.ResponseWriter, r *http.Request) {
+		provider.MarkWriteSent(r.Context())
+		writeJSON(w, http.StatusCreated, map[string]any{"id": "pay_1"})
+	}
+	failedBeforeProvider := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "circuit open"})
+	}
+	failedAfterProvider := func(w http.ResponseWriter, r *http.Request) {
+		provider.MarkWriteSent(r.Context())
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "provider timeout"})
+	}
+	inProgress := func(lockedUntil time.Time, owner string) map[string]any {
+		return map[string]any{
+			"idempotency_key": key,
+			"scope":           scope,
+			"request_hash":    idempotencyHashOf(body),
+			"status":          model.IdempotencyStatusInProgress,
+			"locked_until":    rfc3339(lockedUntil),
+			"lock_owner":      owner,
+			"expires_at":      rfc3339(time.Now().Add(time.Hour)),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		seed    []map[string]any
+		handler http.HandlerFunc
+		// requests are sent in order with these bodies; wantStatus is the status of each.
+		bodies     []string
+		wantStatus []int
+		wantCalls  int
+		wantRow    string // "completed", "in_progress", "" (no row)
+		replayed   bool   // the last response came from the stored row
+	}{
+		{
+			name:       "stores the response once a write reached the provider",
+			handler:    created,
+			bodies:     []string{body, body},
+			wantStatus: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:  1,
+			wantRow:    model.IdempotencyStatusCompleted,
+			replayed:   true,
+		},
+		{
+			name:       "stores a provider error after the write was sent",
+			handler:    failedAfterProvider,
+			bodies:     []string{body, body},
+			wantStatus: []int{http.StatusBadGateway, http.StatusBadGateway},
+			wantCalls:  1,
+			wantRow:    model.IdempotencyStatusCompleted,
+			replayed:   true,
+		},
+		{
+			name:       "releases the key when nothing reached the provider",
+			handler:    failedBeforeProvider,
+			bodies:     []string{body, body},
+			wantStatus: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			wantCalls:  2,
+			wantRow:    "",
+		},
+		{
+			name:       "same key with another body is a conflict",
+			handler:    created,
+			bodies:     []string{body, `{"value":20}`},
+			wantStatus: []int{http.StatusCreated, http.StatusConflict},
+			wantCalls:  1,
+			wantRow:    model.IdempotencyStatusCompleted,
+		},
+		{
+			name:       "live lease answers 409 without running",
+			seed:       []map[string]any{inProgress(time.Now().Add(time.Minute), "other")},
+			handler:    created,
+			bodies:     []string{body},
+			wantStatus: []int{http.StatusConflict},
+			wantCalls:  0,
+			wantRow:    model.IdempotencyStatusInProgress,
+		},
+		{
+			name:       "expired lease is taken over and run",
+			seed:       []map[string]any{inProgress(time.Now().Add(-time.Minute), "crashed")},
+			handler:    created,
+			bodies:     []string{body, body},
+			wantStatus: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:  1,
+			wantRow:    model.IdempotencyStatusCompleted,
+			replayed:   true,
+		},
+		{
+			name: "expired key runs again",
+			seed: []map[string]any{{
+				"idempotency_key": key,
+				"scope":           scope,
+				"request_hash":    idempotencyHashOf(`{"value":99}`),
+				"status":          model.IdempotencyStatusCompleted,
+				"response_status": http.StatusCreated,
+				"expires_at":      rfc3339(time.Now().Add(-time.Minute)),
+			}},
+			handler:    created,
+			bodies:     []string{body},
+			wantStatus: []int{http.StatusCreated},
+			wantCalls:  1,
+			wantRow:    model.IdempotencyStatusCompleted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeSupabase(t, "idempotency_keys", "idempotency_key", "scope")
+			store.rows = append(store.rows, tt.seed...)
+
+			calls := 0
+			h := Idempotent(time.Hour, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				tt.handler(w, r)
+			})
+
+			var last *httptest.ResponseRecorder
+			for i, b := range tt.bodies {
+				last = httptest.NewRecorder()
+				h(last, idempotencyRequest(key, b))
+				if last.Code != tt.wantStatus[i] {
+					t.Fatalf("request %d: status = %d, want %d (body %s)", i+1, last.Code, tt.wantStatus[i], last.Body)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+			if got := last.Header().Get(idempotencyReplayedHeader) == "true"; got != tt.replayed {
+				t.Fatalf("replayed = %v, want %v", got, tt.replayed)
+			}
+
+			rows := store.get(map[string]string{"idempotency_key": key, "scope": scope})
+			switch {
+			case tt.wantRow == "" && len(rows) != 0:
+				t.Fatalf("key not released: %v", rows)
+			case tt.wantRow != "" && (len(rows) != 1 || rows[0]["status"] != tt.wantRow):
+				t.Fatalf("rows = %v, want one %s row", rows, tt.wantRow)
+			}
+		})
+	}
+}
+
+func TestIdempotentLostLease(t *testing.T) {
+	const (
+		key   = "key-1"
+		body  = `{"value":10}`
+		scope = http.MethodPost + " " + idempotencyTestPath
+	)
+	filter := map[string]string{"idempotency_key": key, "scope": scope}
+
+	t.Run("late completion does not overwrite the new owner", func(t *testing.T) {
+		store := newFakeSupabase(t, "idempotency_keys", "idempotency_key", "scope")
+		h := Idempotent(time.Hour, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+			store.set(filter, "lock_owner", "took-over")
+			provider.MarkWriteSent(r.Context())
+			writeJSON(w, http.StatusCreated, map[string]any{"id": "pay_1"})
+		})
+		h(httptest.NewRecorder(), idempotencyRequest(key, body))
+
+		rows := store.get(filter)
+		if len(rows) != 1 || rows[0]["status"] != model.IdempotencyStatusInProgress || rows[0]["lock_owner"] != "took-over" {
+			t.Fatalf("rows = %v, want the new owner's in_progress row untouched", rows)
+		}
+	})
+
+	t.Run("takeover cancels the running request", func(t *testing.T) {
+		store := newFakeSupabase(t, "idempotency_keys", "idempotency_key", "scope")
+		cancelled := make(chan bool, 1)
+		h := Idempotent(time.Hour, 60*time.Millisecond, func(w http.ResponseWriter, r *http.Request) {
+			store.set(filter, "lock_owner", "took-over")
+			select {
+			case <-r.Context().Done():
+				cancelled <- true
+			case <-time.After(2 * time.Second):
+				cancelled <- false
+			}
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "cancelled"})
+		})
+		h(httptest.NewRecorder(), idempotencyRequest(key, body))
+
+		if !<-cancelled {
+			t.Fatal("handler context not cancelled after the key was taken over")
+		}
+		if rows := store.get(filter); len(rows) != 1 || rows[0]["lock_owner"] != "took-over" {
+			t.Fatalf("rows = %v, want the new owner's row kept", rows)
+		}
+	})
+
+	t.Run("panic releases the key", func(t *testing.T) {
+		store := newFakeSupabase(t, "idempotency_keys", "idempotency_key", "scope")
+		h := Idempotent(time.Hour, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+		func() {
+			defer func() { _ = recover() }()
+			h(httptest.NewRecorder(), idempotencyRequest(key, body))
+		}()
+		if rows := store.get(filter); len(rows) != 0 {
+			t.Fatalf("key not released after panic: %v", rows)
+		}
+	})
+}
+
+func TestIdempotentWithoutKey(t *testing.T) {
+	calls := 0
+	h := Idempotent(time.Hour, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, idempotencyTestPath, strings.NewReader(`{}`)))
+	if rec.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("status = %d, calls = %d; want 201 and 1", rec.Code, calls)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, idempotencyRequest(strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`))
+	if rec.Code != http.StatusBadRequest || calls != 1 {
+		t.Fatalf("long key: status = %d, calls = %d; want 400 and 1", rec.Code, calls)
+	}
+}
+
+func TestLeaseExpired(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	past, future, bad := rfc3339(now.Add(-time.Second)), rfc3339(now.Add(time.Second)), "tomorrow"
+
+	tests := []struct {
+		name string
+		row  model.IdempotencyKeyRow
+		want bool
+	}{
+		{"completed never expires", model.IdempotencyKeyRow{Status: model.IdempotencyStatusCompleted, LockedUntil: &past}, false},
+		{"in progress without lease", model.IdempotencyKeyRow{Status: model.IdempotencyStatusInProgress}, true},
+		{"lease passed", model.IdempotencyKeyRow{Status: model.IdempotencyStatusInProgress, LockedUntil: &past}, true},
+		{"lease running", model.IdempotencyKeyRow{Status: model.IdempotencyStatusInProgress, LockedUntil: &future}, false},
+		{"unparseable lease", model.IdempotencyKeyRow{Status: model.IdempotencyStatusInProgress, LockedUntil: &bad}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := leaseExpired(&tt.row, now); got != tt.want {
+				t.Fatalf("leaseExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdempotencyExpired(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expiresAt string
+		want      bool
+	}{
+		{rfc3339(now.Add(-time.Second)), true},
+		{rfc3339(now.Add(time.Second)), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := idempotencyExpired(&model.IdempotencyKeyRow{ExpiresAt: tt.expiresAt}, now); got != tt.want {
+			t.Errorf("idempotencyExpired(%q) = %v, want %v", tt.expiresAt, got, tt.want)
+		}
+	}
+}
+
+func TestReplayIdempotent(t *testing.T) {
+	status, ct, stored := http.StatusCreated, "application/json", `{"id":"pay_1"}`
+	completed := model.IdempotencyKeyRow{
+		RequestHash:         "h1",
+		Status:              model.IdempotencyStatusCompleted,
+		ResponseStatus:      &status,
+		ResponseContentType: &ct,
+		ResponseBody:        &stored,
+	}
+
+	tests := []struct {
+		name       string
+		row        model.IdempotencyKeyRow
+		hash       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"replays the stored response", completed, "h1", http.StatusCreated, stored},
+		{"different request", completed, "h2", http.StatusConflict, ""},
+		{"still in progress", model.IdempotencyKeyRow{RequestHash: "h1", Status: model.IdempotencyStatusInProgress}, "h1", http.StatusConflict, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			replayIdempotent(rec, &tt.row, tt.hash)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if rec.Body.String() != tt.wantBody || rec.Header().Get(idempotencyReplayedHeader) != "true" {
+					t.Fatalf("body = %q replayed=%q, want %q replayed", rec.Body, rec.Header().Get(idempotencyReplayedHeader), tt.wantBody)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestHash(t *testing.T) {
+	base := requestHash(httptest.NewRequest(http.MethodPost, "/v1/charges?a=1&b=2", nil), []byte(`{}`))
+	tests := []struct {
+		name string
+		r    *http.Request
+		body string
+		same bool
+	}{
+		{"query order ignored", httptest.NewRequest(http.MethodPost, "/v1/charges?b=2&a=1", nil), `{}`, true},
+		{"other body", httptest.NewRequest(http.MethodPost, "/v1/charges?a=1&b=2", nil), `{"x":1}`, false},
+		{"other path", httptest.NewRequest(http.MethodPost, "/v1/customers?a=1&b=2", nil), `{}`, false},
+		{"other method", httptest.NewRequest(http.MethodPut, "/v1/charges?a=1&b=2", bytes.NewReader(nil)), `{}`, false},
+	}
+	for _, tt := range tests {
+		if got := requestHash(tt.r, []byte(tt.body)) == base; got != tt.same {
+			t.Errorf("%s: same hash = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+}
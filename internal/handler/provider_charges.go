@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// defaultProviderName is used for contracts created before multi-provider support (provider IS NULL).
+const defaultProviderName = billing.DefaultProviderName
+
+// errCodeIntegrationNotFound is returned when no billing integration matches the request.
+const errCodeIntegrationNotFound = "integration_not_found"
+
+// newChargeProvider builds the ChargeProvider for an integration row, writing the
+// HTTP error itself when the row is unusable. ok=false means the response was already sent.
+func newChargeProvider(ctx context.Context, w http.ResponseWriter, cfg *model.BillingIntegrationRow) (provider.ChargeProvider, bool) {
+	if strings.TrimSpace(cfg.BaseAPI) == "" || strings.TrimSpace(cfg.Token) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "integration config missing base_api or token"})
+		return nil, false
+	}
+	p, err := provider.NewContext(ctx, cfg)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error(), "provider": cfg.Provider})
+		return nil, false
+	}
+	return p, true
+}
+
+// contractChargeProvider loads a contract and builds the ChargeProvider of its integration.
+// ok=false means an error response was already written.
+func contractChargeProvider(ctx context.Context, w http.ResponseWriter, rid string, contractID string) (*model.FeeContractRow, provider.ChargeProvider, bool) {
+	contract, err := supabase.GetFeeContractByIDContext(ctx, contractID)
+	if err != nil {
+		if isDebugEnabled() {
+			log.Printf("[supabase] ERROR loading fee_contract: rid=%s contract_id=%s err=%v", rid, contractID, err)
+		}
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load contract", "request_id": rid})
+		return nil, nil, false
+	}
+	if contract == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "contract not found"})
+		return nil, nil, false
+	}
+	if !authorizeContract(ctx, w, contract) {
+		return nil, nil, false
+	}
+
+	res, err := billing.ResolveIntegrationForContract(ctx, contract)
+	if err != nil {
+		writeIntegrationError(w, rid, res, err)
+		return nil, nil, false
+	}
+	logIntegrationResolution(rid, res)
+
+	chargeProvider, ok := newChargeProvider(ctx, w, res.Integration)
+	if !ok {
+		return nil, nil, false
+	}
+	return contract, chargeProvider, true
+}
+
+// resolveChargeProvider resolves the integration of a request (charge → contract →
+// integration, see billing.ResolveIntegration), checks the caller may access the
+// contract it went through and builds the ChargeProvider. ok=false means an error
+// response was already written.
+func resolveChargeProvider(ctx context.Context, w http.ResponseWriter, rid string, req billing.IntegrationRequest) (*billing.IntegrationResolution, provider.ChargeProvider, bool) {
+	res, err := billing.ResolveIntegration(ctx, req)
+	if err != nil {
+		writeIntegrationError(w, rid, res, err)
+		return nil, nil, false
+	}
+	if res.Contract != nil && !authorizeContract(ctx, w, res.Contract) {
+		return nil, nil, false
+	}
+	logIntegrationResolution(rid, res)
+
+	chargeProvider, ok := newChargeProvider(ctx, w, res.Integration)
+	if !ok {
+		return nil, nil, false
+	}
+	return res, chargeProvider, true
+}
+
+// storedChargeProvider is resolveChargeProvider for operations on a charge that must be in
+// iam.charges (its row supplies tenant/company/contract when syncing the result back).
+// ok=false means an error response was already written.
+func storedChargeProvider(ctx context.Context, w http.ResponseWriter, rid, accountingOfficeID, paymentID string) (*model.IamChargeRow, provider.ChargeProvider, bool) {
+	res, chargeProvider, ok := resolveChargeProvider(ctx, w, rid, billing.IntegrationRequest{
+		AccountingOfficeID: accountingOfficeID,
+		ProviderChargeID:   paymentID,
+	})
+	if !ok {
+		return nil, nil, false
+	}
+	if res.Charge == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{
+			"error":                "charge not found for office/provider",
+			"accounting_office_id": accountingOfficeID,
+			"provider":             res.Provider,
+			"provider_charge_id":   paymentID,
+		})
+		return nil, nil, false
+	}
+	return res.Charge, chargeProvider, true
+}
+
+// chargeOperationParams reads the {id} path param and the accounting_office_id query of
+// /v1/asaas/charges/{id}/... and checks the caller may access the office.
+// ok=false means an error response was already written.
+func chargeOperationParams(w http.ResponseWriter, r *http.Request) (paymentID, accountingOfficeID string, ok bool) {
+	return officeResourceParams(w, r, "payment")
+}
+
+// officeResourceParams reads the {id} path param (resource names it in the error) and the
+// accounting_office_id query of /v1/asaas/{resources}/{id}/... and checks the caller may
+// access the office. ok=false means an error response was already written.
+func officeResourceParams(w http.ResponseWriter, r *http.Request, resource string) (id, accountingOfficeID string, ok bool) {
+	id = strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": resource + " id is required"})
+		return "", "", false
+	}
+	accountingOfficeID = strings.TrimSpace(r.URL.Query().Get("accounting_office_id"))
+	if accountingOfficeID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "accounting_office_id is required"})
+		return "", "", false
+	}
+	if !authorizeOffice(r.Context(), w, accountingOfficeID) {
+		return "", "", false
+	}
+	return id, accountingOfficeID, true
+}
+
+// paymentPageFilter reads the offset, limit and status query params of the routes that list
+// the payments of one installment plan or subscription.
+// ok=false means an error response was already written.
+func paymentPageFilter(w http.ResponseWriter, r *http.Request) (provider.PaymentFilter, bool) {
+	q := r.URL.Query()
+	filter := provider.PaymentFilter{Status: strings.TrimSpace(q.Get("status"))}
+	if s := strings.TrimSpace(q.Get("offset")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "offset must be a non-negative integer"})
+			return filter, false
+		}
+		filter.Offset = n
+	}
+	if s := strings.TrimSpace(q.Get("limit")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > provider.MaxPageSize {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "limit must be an integer between 0 and 100"})
+			return filter, false
+		}
+		filter.Limit = n
+	}
+	return filter, true
+}
+
+// writeIntegrationError answers 404 when no integration matched, telling which rule
+// was applied, and 502 when the lookups themselves failed.
+func writeIntegrationError(w http.ResponseWriter, rid string, res *billing.IntegrationResolution, err error) {
+	var notFound *billing.IntegrationError
+	if errors.As(err, &notFound) {
+		body := map[string]any{
+			"error":      "billing integration not found",
+			"code":       errCodeIntegrationNotFound,
+			"resolution": notFound.Trail,
+			"request_id": rid,
+		}
+		if notFound.Rule != "" {
+			body["rule"] = notFound.Rule
+		}
+		if res != nil && res.Provider != "" {
+			body["provider"] = res.Provider
+		}
+		writeJSON(w, http.StatusNotFound, body)
+		return
+	}
+	log.Printf("[provider] ERROR resolving billing integration: rid=%s err=%v", rid, err)
+	writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to resolve billing integration", "request_id": rid})
+}
+
+func logIntegrationResolution(rid string, res *billing.IntegrationResolution) {
+	if isDebugEnabled() {
+		cfg := res.Integration
+		log.Printf("[provider] using integration cfg: rid=%s cfg_id=%s provider=%s env=%s base_api=%q token=%s rule=%s",
+			rid, cfg.ID, cfg.Provider, cfg.Environment, cfg.BaseAPI, maskToken(cfg.Token), res.Explain(),
+		)
+	}
+}
+
+// writeProviderResponse passes a successful provider payload through unchanged;
+// provider errors are translated by writeProviderError.
+func writeProviderResponse(w http.ResponseWriter, resp *provider.Response) {
+	if !resp.OK() {
+		writeProviderError(w, "", "", resp)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(resp.Body)
+}
+
+// logProviderResponse logs a truncated provider response when CHARGES_DEBUG is on.
+func logProviderResponse(label string, rid string, resp *provider.Response) {
+	if !isDebugEnabled() || resp == nil {
+		return
+	}
+	raw := string(resp.Body)
+	if len(raw) > 800 {
+		raw = raw[:800] + "…(truncated)"
+	}
+	log.Printf("[provider] %s response: rid=%s status=%d body=%s", label, rid, resp.StatusCode, raw)
+}
+
+// chargeRowFromStored maps a payment returned by the provider after an operation on a known
+// charge; the context fields (tenant, company, contract, installment, subscription) and the
+// refund history come from the stored iam.charges row.
+func chargeRowFromStored(providerName string, stored *model.IamChargeRow, p provider.Payment) model.IamChargeRow {
+	now := time.Now().UTC().Format(time.RFC3339)
+	row := billing.ChargeRowFromPayment(providerName, p)
+	row.TenantID = stored.TenantID
+	row.AccountingOfficeID = stored.AccountingOfficeID
+	row.CompanyID = stored.CompanyID
+	row.ContractID = stored.ContractID
+	row.ProviderInstallmentID = stored.ProviderInstallmentID
+	row.ProviderSubscriptionID = stored.ProviderSubscriptionID
+	row.InstallmentNumber = stored.InstallmentNumber
+	row.RefundedValue = stored.RefundedValue
+	row.Refunds = stored.Refunds
+	row.UpdatedAt = &now
+	return row
+}
+
+// isOneOffCharge reports whether a stored charge is a one-off charge (no subscription),
+// mirrored in iam.fee_contract_one_off_charges.
+func isOneOffCharge(row *model.IamChargeRow) bool {
+	return row.ProviderSubscriptionID == nil || strings.TrimSpace(*row.ProviderSubscriptionID) == ""
+}
+
+// syncOneOffChargeFromPayment mirrors provider-side fields into iam.fee_contract_one_off_charges.
+// Best-effort: failures are logged and never returned.
+func syncOneOffChargeFromPayment(ctx context.Context, stage string, p *provider.Payment) {
+	if p == nil || strings.TrimSpace(p.ID) == "" {
+		return
+	}
+
+	var syncExtra *supabase.OneOffSyncFields
+	if p.Value > 0 || p.DueDate != "" || p.BillingType != "" {
+		syncExtra = &supabase.OneOffSyncFields{}
+		if p.Value > 0 {
+			v := p.Value
+			syncExtra.Value = &v
+		}
+		if d := p.DueDate; d != "" {
+			syncExtra.DueDate = &d
+		}
+		if bt := p.BillingType; bt != "" {
+			syncExtra.BillingType = &bt
+		}
+	}
+
+	log.Printf("[supabase] syncing one_off_charge after %s: payment_id=%s status=%s external_reference=%q value=%v due=%s billing=%s",
+		stage, p.ID, p.Status, p.ExternalReference, p.Value, p.DueDate, p.BillingType)
+	if err := supabase.SyncOneOffChargeFromProviderContext(ctx, p.ID, p.Status, p.ExternalReference, syncExtra); err != nil {
+		log.Printf("[supabase] ERROR sync_one_off_charge failed after %s: payment_id=%s err=%v", stage, p.ID, err)
+		return
+	}
+	log.Printf("[supabase] OK fee_contract_one_off_charges synced after %s: provider_charge_id=%s status=%s", stage, p.ID, p.Status)
+}
+
+// ensureProviderCustomer resolves (or creates) the provider customer of a company with
+// billing.EnsureCustomer and maps its failures to an HTTP response.
+// ok=false means an error response was already written.
+func ensureProviderCustomer(ctx context.Context, w http.ResponseWriter, rid string, p provider.ChargeProvider, companyID string) (string, bool) {
+	customerID, err := billing.EnsureCustomer(ctx, p, companyID)
+	if err == nil {
+		return customerID, true
+	}
+
+	var custErr *billing.CustomerError
+	stage := ""
+	if errors.As(err, &custErr) {
+		stage = custErr.Stage
+	}
+	log.Printf("[asaas] ERROR ensuring customer: rid=%s company_id=%s stage=%s err=%v", rid, companyID, stage, err)
+
+	switch {
+	case errors.Is(err, billing.ErrCompanyNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "company not found to create asaas customer"})
+	case stage == billing.CustomerStageCreate && custErr.Resp == nil:
+		writeProviderCallError(w, rid, err)
+	case stage == billing.CustomerStageCreate && !custErr.Resp.OK():
+		writeProviderError(w, rid, p.Name(), custErr.Resp)
+	case stage == billing.CustomerStageCreate:
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "invalid asaas response (missing customer id)", "request_id": rid})
+	case stage == billing.CustomerStageLoad:
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load company data to create asaas customer", "request_id": rid})
+	case stage == billing.CustomerStagePersist:
+		body := map[string]any{"error": "failed to persist asaas integration", "request_id": rid}
+		if isDebugEnabled() {
+			body["details"] = err.Error()
+		}
+		writeJSON(w, http.StatusBadGateway, body)
+	default:
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to resolve asaas customer id", "request_id": rid})
+	}
+	return "", false
+}
+
+func toProviderDiscount(d *model.AsaasChargeDiscount) *provider.Discount {
+	if d == nil {
+		return nil
+	}
+	var t *string
+	if d.Type != nil {
+		s := string(*d.Type)
+		t = &s
+	}
+	return &provider.Discount{Value: d.Value, DueDateLimitDays: d.DueDateLimitDays, Type: t}
+}
+
+func toProviderInterest(i *model.AsaasChargeInterest) *provider.Interest {
+	if i == nil {
+		return nil
+	}
+	return &provider.Interest{Value: i.Value}
+}
+
+func toProviderFine(f *model.AsaasChargeFine) *provider.Fine {
+	if f == nil {
+		return nil
+	}
+	var t *string
+	if f.Type != nil {
+		s := string(*f.Type)
+		t = &s
+	}
+	return &provider.Fine{Value: f.Value, Type: t}
+}
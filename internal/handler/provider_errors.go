@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+)
+
+// Error codes returned when the provider call fails. They are stable across providers;
+// the provider's own codes are kept in provider_errors.
+const (
+	errCodeProviderValidation  = "provider_validation_error"
+	errCodeProviderNotFound    = "provider_not_found"
+	errCodeProviderAuth        = "provider_auth_failed"
+	errCodeProviderRateLimited = "provider_rate_limited"
+	errCodeProviderUnavailable = "provider_unavailable"
+	errCodeProviderRejected    = "provider_rejected"
+	errCodeProviderCircuitOpen = "provider_circuit_open"
+)
+
+// providerErrorStatus translates a provider status into our HTTP status, error code and message.
+func providerErrorStatus(providerStatus int) (int, string, string) {
+	switch {
+	case providerStatus == http.StatusBadRequest || providerStatus == http.StatusUnprocessableEntity:
+		return http.StatusUnprocessableEntity, errCodeProviderValidation, "provider rejected the request data"
+	case providerStatus == http.StatusNotFound:
+		return http.StatusNotFound, errCodeProviderNotFound, "resource not found in the provider"
+	case providerStatus == http.StatusUnauthorized || providerStatus == http.StatusForbidden:
+		// Our credentials were refused: a billing integration problem, not the caller's.
+		return http.StatusBadGateway, errCodeProviderAuth, "provider refused the billing integration credentials"
+	case providerStatus == http.StatusTooManyRequests:
+		return http.StatusTooManyRequests, errCodeProviderRateLimited, "provider rate limit reached, try again later"
+	case providerStatus >= 400 && providerStatus < 500:
+		return http.StatusUnprocessableEntity, errCodeProviderRejected, "provider rejected the request"
+	default:
+		return http.StatusBadGateway, errCodeProviderUnavailable, "provider unavailable"
+	}
+}
+
+// writeProviderError answers a provider rejection with a stable error code instead of the
+// provider payload: {"error","code","provider_status","provider_errors":[{"code","description"}]}.
+func writeProviderError(w http.ResponseWriter, rid string, providerName string, resp *provider.Response) {
+	providerStatus := 0
+	if resp != nil {
+		providerStatus = resp.StatusCode
+	}
+	status, code, msg := providerErrorStatus(providerStatus)
+	body := map[string]any{
+		"error":           msg,
+		"code":            code,
+		"provider_status": providerStatus,
+	}
+	if providerName != "" {
+		body["provider"] = providerName
+	}
+	if resp != nil && len(resp.Errors) > 0 {
+		body["provider_errors"] = resp.Errors
+	}
+	if rid != "" {
+		body["request_id"] = rid
+	}
+	writeJSON(w, status, body)
+}
+
+// writeProviderCallError answers a provider call that got no response at all (network, timeout...).
+// A call refused by the integration's open circuit breaker is answered 503 with Retry-After.
+func writeProviderCallError(w http.ResponseWriter, rid string, err error) {
+	var circuitErr *provider.CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		body := map[string]any{
+			"error":       "provider temporarily unavailable for this billing integration, try again later",
+			"code":        errCodeProviderCircuitOpen,
+			"details":     err.Error(),
+			"retry_after": int(math.Ceil(circuitErr.RetryAfter.Seconds())),
+		}
+		if rid != "" {
+			body["request_id"] = rid
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(circuitErr.RetryAfter.Seconds()))))
+		writeJSON(w, http.StatusServiceUnavailable, body)
+		return
+	}
+
+	body := map[string]any{
+		"error":   "provider request failed",
+		"code":    errCodeProviderUnavailable,
+		"details": err.Error(),
+	}
+	if rid != "" {
+		body["request_id"] = rid
+	}
+	writeJSON(w, http.StatusBadGateway, body)
+}
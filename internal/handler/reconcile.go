@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/reconcile"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// RunReconciliation godoc
+// @Summary      Reconciliar iam.charges com o provedor
+// @Description  Executa agora a reconciliação (a mesma do agendamento RECONCILE_INTERVAL): pagina as cobranças do provedor de cada integração ativa (janelas de dateCreated e dueDate), compara com iam.charges por provider_charge_id, corrige status/valor/vencimento divergentes e insere cobranças ausentes cujo contrato pode ser resolvido. Retorna o relatório de divergências, também gravado em logs.charges_reconcile_runs. Use dry_run=true para apenas relatar. Requer o header X-Admin-Token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        X-Admin-Token  header  string                  true   "Token de administração (ADMIN_API_TOKEN)"
+// @Param        body           body    model.ReconcileRequest  false  "Opções"
+// @Success      200  {object}  model.ReconcileReport
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      409  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Router       /v1/admin/reconcile [post]
+func RunReconciliation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+
+	var req model.ReconcileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body"})
+		return
+	}
+
+	log.Printf("[admin] reconcile: rid=%s integration_id=%q dry_run=%v", rid, req.IntegrationID, req.DryRun)
+
+	report, err := reconcile.Run(ctx, reconcile.Options{
+		IntegrationID: strings.TrimSpace(req.IntegrationID),
+		DryRun:        req.DryRun,
+		Trigger:       reconcile.TriggerManual,
+	})
+	switch {
+	case errors.Is(err, reconcile.ErrRunning):
+		writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+		return
+	case errors.Is(err, billing.ErrIntegrationNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "billing integration not found"})
+		return
+	case err != nil:
+		log.Printf("[admin] ERROR reconcile: rid=%s err=%v", rid, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "request_id": rid})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// ListReconciliationRuns godoc
+// @Summary      Relatórios de reconciliação
+// @Description  Lista os relatórios de divergência das últimas execuções da reconciliação (logs.charges_reconcile_runs), mais recentes primeiro. Requer o header X-Admin-Token.
+// @Tags         admin
+// @Produce      json
+// @Param        X-Admin-Token  header  string  true   "Token de administração (ADMIN_API_TOKEN)"
+// @Param        offset         query   int     false  "Offset (default 0)"
+// @Param        limit          query   int     false  "Limite 1-100 (default 20)"
+// @Success      200  {object}  model.ReconcileRunPage
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Router       /v1/admin/reconcile/runs [get]
+func ListReconciliationRuns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+
+	offset, limit, ok := parseChargePagination(w, r)
+	if !ok {
+		return
+	}
+	rows, total, err := supabase.ListReconcileRunsContext(ctx, offset, limit)
+	if err != nil {
+		log.Printf("[admin] ERROR listing reconcile runs: rid=%s err=%v", rid, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to list reconcile runs", "request_id": rid})
+		return
+	}
+	if rows == nil {
+		rows = []model.ReconcileReport{}
+	}
+
+	writeJSON(w, http.StatusOK, model.ReconcileRunPage{
+		Data:       rows,
+		TotalCount: total,
+		Offset:     offset,
+		Limit:      limit,
+		HasMore:    int64(offset+len(rows)) < total,
+	})
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// CreateSubscription godoc
+// @Summary      Criar assinatura (neutro)
+// @Description  Cria uma assinatura recorrente para um contrato no provedor configurado no contrato. Juros/multa/desconto são herdados do contrato. A assinatura é vinculada ao contrato em iam.fee_contract_subscriptions; sem external_reference, usa fee_contract:{contract_id}:subscription:{cycle}:{payment_method}. As cobranças geradas são persistidas em iam.charges e retornadas no formato normalizado.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        body  body      model.CreateSubscriptionRequest  true  "Payload da assinatura"
+// @Param        Idempotency-Key  header  string  false  "Chave de idempotência: reenvios com a mesma chave devolvem a resposta original"
+// @Success      201  {object}  model.Subscription
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      422  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Failure      503  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/subscriptions [post]
+func CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+
+	var req model.CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json body"})
+		return
+	}
+
+	req.ContractID = strings.TrimSpace(req.ContractID)
+	req.NextDueDate = strings.TrimSpace(req.NextDueDate)
+	req.Cycle = strings.ToUpper(strings.TrimSpace(req.Cycle))
+	if req.ContractID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "contract_id is required"})
+		return
+	}
+	if !validPaymentMethod(req.PaymentMethod) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "payment_method must be one of BOLETO, PIX, CREDIT_CARD, UNDEFINED"})
+		return
+	}
+	if req.Value <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "value must be > 0"})
+		return
+	}
+	if req.NextDueDate == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "next_due_date is required"})
+		return
+	}
+	if req.Cycle == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "cycle is required"})
+		return
+	}
+
+	contract, chargeProvider, ok := contractChargeProvider(ctx, w, rid, req.ContractID)
+	if !ok {
+		return
+	}
+
+	customerID, ok := ensureProviderCustomer(ctx, w, rid, chargeProvider, contract.CompanyID)
+	if !ok {
+		return
+	}
+
+	// The fee_contract prefix lets webhooks resolve the contract even before the link below exists.
+	extRef := billing.SubscriptionExternalReference(contract.ID, req.Cycle, string(req.PaymentMethod))
+	if req.ExternalReference != nil && strings.TrimSpace(*req.ExternalReference) != "" {
+		extRef = strings.TrimSpace(*req.ExternalReference)
+	}
+
+	in := provider.SubscriptionInput{
+		CustomerID:        customerID,
+		BillingType:       string(req.PaymentMethod),
+		Value:             req.Value,
+		NextDueDate:       req.NextDueDate,
+		Cycle:             req.Cycle,
+		Description:       req.Description,
+		EndDate:           req.EndDate,
+		MaxPayments:       req.MaxPayments,
+		ExternalReference: &extRef,
+	}
+	billing.FinancialTermsFromContract(contract, &in.Discount, &in.Interest, &in.Fine)
+
+	created, resp, callErr := chargeProvider.CreateSubscription(in)
+	if callErr != nil && resp == nil {
+		writeProviderCallError(w, rid, callErr)
+		return
+	}
+	logProviderResponse("create subscription", rid, resp)
+	if !resp.OK() || created == nil || created.ID == "" {
+		writeProviderError(w, rid, chargeProvider.Name(), resp)
+		return
+	}
+
+	// Same link as the billing engine: best-effort, the SUBSCRIPTION_* webhooks refresh it.
+	if created.ExternalReference == "" {
+		created.ExternalReference = extRef
+	}
+	if err := billing.LinkSubscription(ctx, chargeProvider.Name(), contract.ID, created); err != nil {
+		log.Printf("[supabase] ERROR linking subscription to contract: rid=%s contract_id=%s sub=%s err=%v", rid, contract.ID, created.ID, err)
+	}
+
+	out := model.Subscription{
+		ID:                created.ID,
+		ContractID:        contract.ID,
+		CompanyID:         contract.CompanyID,
+		Provider:          chargeProvider.Name(),
+		PaymentMethod:     model.ChargePaymentMethod(created.BillingType),
+		Value:             created.Value,
+		Cycle:             created.Cycle,
+		NextDueDate:       created.NextDueDate,
+		Description:       req.Description,
+		EndDate:           req.EndDate,
+		Status:            created.Status,
+		ExternalReference: &extRef,
+		Charges:           []model.Charge{},
+	}
+
+	// Persist only actual payments (never a header row for the subscription itself), same as CreateAsaasSubscription.
+	payments, listErr := provider.Payments(chargeProvider, provider.PaymentFilter{
+		CustomerID:     customerID,
+		SubscriptionID: created.ID,
+	}).All()
+	if listErr != nil {
+		log.Printf("[provider] WARN listing subscription payments after create: rid=%s sub=%s listed=%d err=%v", rid, created.ID, len(payments), listErr)
+		if len(payments) == 0 {
+			writeJSON(w, http.StatusCreated, out)
+			return
+		}
+	}
+	for i := range payments {
+		payments[i].SubscriptionID = created.ID
+	}
+	stored, err := supabase.UpsertChargesReturningContext(ctx, billing.ChargeRowsForContract(chargeProvider.Name(), contract, payments))
+	if err != nil {
+		log.Printf("[supabase] ERROR upserting subscription charges: rid=%s sub=%s err=%v", rid, created.ID, err)
+	}
+	out.Charges = chargesFromRows(stored)
+
+	writeJSON(w, http.StatusCreated, out)
+}
+
+// GetSubscription godoc
+// @Summary      Consultar assinatura (neutro)
+// @Description  Retorna uma assinatura vinculada a um contrato (iam.fee_contract_subscriptions) no formato normalizado, com as cobranças persistidas em iam.charges (até 100; use /v1/subscriptions/{id}/charges para paginar).
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id   path      string  true  "ID da assinatura no provedor"
+// @Success      200  {object}  model.Subscription
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      404  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/subscriptions/{id} [get]
+func GetSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriptionID := strings.TrimSpace(chi.URLParam(r, "id"))
+	if subscriptionID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
+		return
+	}
+
+	link, err := supabase.GetFeeContractSubscriptionContext(ctx, subscriptionID)
+	if err != nil {
+		if isDebugEnabled() {
+			log.Printf("[supabase] ERROR loading fee_contract_subscription: sub=%s err=%v", subscriptionID, err)
+		}
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load subscription"})
+		return
+	}
+	if link == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "subscription not found"})
+		return
+	}
+
+	contract, err := supabase.GetFeeContractByIDContext(ctx, link.ContractID)
+	if err != nil {
+		if isDebugEnabled() {
+			log.Printf("[supabase] ERROR loading fee_contract: sub=%s contract_id=%s err=%v", subscriptionID, link.ContractID, err)
+		}
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to load contract"})
+		return
+	}
+	if contract == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "subscription not found"})
+		return
+	}
+	if !authorizeContract(ctx, w, contract) {
+		return
+	}
+
+	rows, _, err := supabase.ListChargesContext(ctx, supabase.ChargeListFilter{
+		ProviderSubscriptionID: subscriptionID,
+		Limit:                  provider.MaxPageSize,
+	})
+	if err != nil {
+		if isDebugEnabled() {
+			log.Printf("[supabase] ERROR listing iam.charges: subscription=%s err=%v", subscriptionID, err)
+		}
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to list charges"})
+		return
+	}
+
+	out := model.Subscription{
+		ID:                link.ProviderSubscriptionID,
+		ContractID:        contract.ID,
+		CompanyID:         contract.CompanyID,
+		Provider:          link.Provider,
+		ExternalReference: link.ExternalReference,
+		Charges:           chargesFromRows(rows),
+	}
+	if link.Value != nil {
+		out.Value = *link.Value
+	}
+	if link.Cycle != nil {
+		out.Cycle = *link.Cycle
+	}
+	if link.NextDueDate != nil {
+		out.NextDueDate = *link.NextDueDate
+	}
+	if link.Status != nil {
+		out.Status = *link.Status
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// ListSubscriptionCharges godoc
+// @Summary      Listar cobranças de uma assinatura (neutro)
+// @Description  Lista as cobranças persistidas em iam.charges vinculadas a uma assinatura (provider_subscription_id), no formato normalizado.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string  true   "ID da assinatura no provedor"
+// @Param        offset  query     int     false  "Elemento inicial da lista"
+// @Param        limit   query     int     false  "Número de elementos da lista (max: 100, default: 20)"
+// @Success      200  {object}  model.ChargeListResponse
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /v1/subscriptions/{id}/charges [get]
+func ListSubscriptionCharges(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriptionID := strings.TrimSpace(chi.URLParam(r, "id"))
+	if subscriptionID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "id is required"})
+		return
+	}
+
+	offset, limit, ok := parseChargePagination(w, r)
+	if !ok {
+		return
+	}
+
+	writeChargePage(ctx, w, supabase.ChargeListFilter{
+		ProviderSubscriptionID: subscriptionID,
+		Offset:                 offset,
+		Limit:                  limit,
+	})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// ListWebhookJournal godoc
+// @Summary      Histórico de eventos de webhook
+// @Description  Lista logs.asaas_webhook_journal: todo evento recebido do provedor com o resultado do processamento (applied, duplicate, ignored_stale, ignored_foreign, retrying, dead_letter...), latência e a transição de status da cobrança. Ordenado por received_at. Requer ao menos um filtro e o header X-Admin-Token.
+// @Tags         admin
+// @Produce      json
+// @Param        X-Admin-Token    header  string  true   "Token de administração (ADMIN_API_TOKEN)"
+// @Param        payment_id       query   string  false  "ID da cobrança no provedor (pay_...)"
+// @Param        subscription_id  query   string  false  "ID da assinatura no provedor (sub_...)"
+// @Param        company_id       query   string  false  "ID da empresa (UUID)"
+// @Param        from             query   string  false  "received_at >= (YYYY-MM-DD ou RFC 3339; data = início do dia em UTC)"
+// @Param        to               query   string  false  "received_at <= (YYYY-MM-DD ou RFC 3339; data = inclui o dia inteiro em UTC)"
+// @Param        offset           query   int     false  "Offset (default 0)"
+// @Param        limit            query   int     false  "Limite 1-100 (default 20)"
+// @Success      200  {object}  model.WebhookJournalPage
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Router       /v1/admin/webhooks/events [get]
+func ListWebhookJournal(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+	q := r.URL.Query()
+
+	f := supabase.WebhookJournalFilter{
+		PaymentID:      strings.TrimSpace(q.Get("payment_id")),
+		SubscriptionID: strings.TrimSpace(q.Get("subscription_id")),
+		CompanyID:      strings.TrimSpace(q.Get("company_id")),
+	}
+	var err error
+	if f.From, f.To, f.ToExclusive, err = ParseTimeRange(q.Get("from"), q.Get("to")); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if f.PaymentID == "" && f.SubscriptionID == "" && f.CompanyID == "" && f.From.IsZero() && f.To.IsZero() {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "at least one filter is required (payment_id, subscription_id, company_id, from, to)"})
+		return
+	}
+	offset, limit, ok := parseChargePagination(w, r)
+	if !ok {
+		return
+	}
+	f.Offset, f.Limit = offset, limit
+
+	rows, total, err := supabase.ListWebhookJournalContext(ctx, f)
+	if err != nil {
+		log.Printf("[admin] ERROR listing webhook journal: rid=%s err=%v", rid, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to list webhook events", "request_id": rid})
+		return
+	}
+	if rows == nil {
+		rows = []model.WebhookJournalEntry{}
+	}
+
+	writeJSON(w, http.StatusOK, model.WebhookJournalPage{
+		Data:       rows,
+		TotalCount: total,
+		Offset:     offset,
+		Limit:      limit,
+		HasMore:    int64(offset+len(rows)) < total,
+	})
+}
+
+// ParseTimeRange parses optional from/to bounds: a date (YYYY-MM-DD, midnight UTC) or an
+// RFC 3339 timestamp. A date-only to covers the whole day, so it becomes the next midnight
+// with toExclusive set. Empty values give zero times (unbounded). The error text is meant
+// for the caller (400 body or CLI message). Shared by the journal, the replay endpoint and
+// the replay-webhooks command.
+func ParseTimeRange(fromValue, toValue string) (from, to time.Time, toExclusive bool, err error) {
+	if s := strings.TrimSpace(fromValue); s != "" {
+		if from, _, err = parseTimeBound(s); err != nil {
+			return time.Time{}, time.Time{}, false, errors.New("from must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+		}
+	}
+	if s := strings.TrimSpace(toValue); s != "" {
+		var dateOnly bool
+		if to, dateOnly, err = parseTimeBound(s); err != nil {
+			return time.Time{}, time.Time{}, false, errors.New("to must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+		}
+		if dateOnly {
+			// The whole day: everything before the next midnight.
+			to, toExclusive = to.AddDate(0, 0, 1), true
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && (from.After(to) || (toExclusive && from.Equal(to))) {
+		return time.Time{}, time.Time{}, false, errors.New("from must not be after to")
+	}
+	return from, to, toExclusive, nil
+}
+
+// parseTimeBound parses one from/to value. dateOnly tells the caller to widen a "to" date
+// to the whole day.
+func parseTimeBound(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse("2006-01-02", s); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	return t, false, err
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+	"github.com/seuuser/charges-service/internal/webhook"
+)
+
+// Limits of one replay (HTTP and the replay-webhooks command): events processed when the
+// request does not say, and the most it may ask for.
+const (
+	DefaultWebhookReplayLimit = 50
+	MaxWebhookReplayLimit     = 500
+)
+
+// ReplayWebhookEvents godoc
+// @Summary      Reprocessar eventos de webhook com falha
+// @Description  Seleciona eventos em logs.asaas_webhook_events (por ids, estágio do erro, payment_id e/ou período de created_at) e os executa novamente no pipeline do webhook. O resultado fica registrado na linha do log (resolved, replay_attempts, last_replay_error). Por padrão ignora eventos já resolvidos, eventos fora de ordem (ignored_stale) e de outro escritório (ignored_foreign). Requer o header X-Admin-Token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        X-Admin-Token  header  string                      true  "Token de administração (ADMIN_API_TOKEN)"
+// @Param        body           body    model.WebhookReplayRequest  true  "Filtros"
+// @Success      200  {object}  model.WebhookReplayResult
+// @Failure      400  {object}  map[string]any
+// @Failure      401  {object}  map[string]any
+// @Failure      403  {object}  map[string]any
+// @Failure      502  {object}  map[string]any
+// @Router       /v1/admin/webhooks/replay [post]
+func ReplayWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rid := newRequestID()
+
+	var req model.WebhookReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body"})
+		return
+	}
+	if len(req.IDs) == 0 && req.Stage == "" && req.PaymentID == "" && req.From == "" && req.To == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "at least one filter is required (ids, stage, payment_id, from, to)"})
+		return
+	}
+	from, to, toExclusive, err := ParseTimeRange(req.From, req.To)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	limit := DefaultWebhookReplayLimit
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+	if limit < 1 || limit > MaxWebhookReplayLimit {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": fmt.Sprintf("limit must be between 1 and %d", MaxWebhookReplayLimit)})
+		return
+	}
+
+	log.Printf("[admin] webhook replay: rid=%s ids=%d stage=%q payment_id=%q from=%q to=%q limit=%d dry_run=%v",
+		rid, len(req.IDs), req.Stage, req.PaymentID, req.From, req.To, limit, req.DryRun)
+
+	result, err := webhook.Replay(ctx, asaas.ProviderName, supabase.WebhookEventLogFilter{
+		IDs:             req.IDs,
+		Stage:           req.Stage,
+		PaymentID:       req.PaymentID,
+		From:            from,
+		To:              to,
+		ToExclusive:     toExclusive,
+		IncludeResolved: req.IncludeResolved,
+		Limit:           limit,
+	}, req.DryRun, ProcessAsaasWebhookEvent)
+	if err != nil {
+		log.Printf("[admin] ERROR webhook replay: rid=%s err=%v", rid, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "request_id": rid})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seuuser/charges-service/internal/integrations/asaas"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+func TestReplayWebhookEventsLimit(t *testing.T) {
+	var gotLimit string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLimit = r.URL.Query().Get("limit")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	t.Setenv("SUPABASE_URL", srv.URL)
+	t.Setenv("SUPABASE_KEY", "test-key")
+	supabase.InitClient()
+	if !provider.Registered(asaas.ProviderName) {
+		provider.Register(asaas.ProviderName, asaas.NewChargeProvider)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantLimit  string // sent to asaas_webhook_events; "" = no query
+	}{
+		{"default", `{"stage":"update_charge"}`, http.StatusOK, "50"},
+		{"explicit", `{"stage":"update_charge","limit":10}`, http.StatusOK, "10"},
+		{"maximum", `{"stage":"update_charge","limit":500}`, http.StatusOK, "500"},
+		{"zero", `{"stage":"update_charge","limit":0}`, http.StatusBadRequest, ""},
+		{"negative", `{"stage":"update_charge","limit":-1}`, http.StatusBadRequest, ""},
+		{"above maximum", `{"stage":"update_charge","limit":501}`, http.StatusBadRequest, ""},
+		{"no filter", `{"limit":10}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotLimit = ""
+			w := httptest.NewRecorder()
+			ReplayWebhookEvents(w, httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks/replay", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if gotLimit != tt.wantLimit {
+				t.Fatalf("limit sent to Supabase = %q, want %q", gotLimit, tt.wantLimit)
+			}
+		})
+	}
+}
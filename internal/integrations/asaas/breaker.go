@@ -0,0 +1,206 @@
+package asaas
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// breaker is the circuit breaker of one billing integration (base_api + token).
+// After BreakerFailures consecutive failures (network error, timeout, 5xx) it opens and
+// refuses calls for BreakerOpenTimeout; then it lets up to BreakerHalfOpenProbes calls
+// through: a success closes it, a failure opens it again.
+type breaker struct {
+	mu            sync.Mutex
+	integrationID string
+	baseURL       string
+	fingerprint   string // base_api + token: new credentials start with a fresh breaker
+	state         string
+	failures      int
+	openedAt      time.Time
+	probes        int // probes in flight (half-open)
+	lastErr       string
+}
+
+// outcome of a call, as seen by the breaker.
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeIgnored // the caller gave up (context canceled): says nothing about Asaas
+)
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*breaker{}
+)
+
+// breaker returns the breaker of c's billing integration, or nil when breakers are disabled.
+func (c *Client) breaker(o Options) *breaker {
+	if o.BreakerFailures <= 0 {
+		return nil
+	}
+	key := c.IntegrationID
+	if key == "" {
+		key = c.BaseURL
+	}
+	sum := sha256.Sum256([]byte(c.BaseURL + "\x00" + c.Token))
+	fp := hex.EncodeToString(sum[:8])
+
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[key]
+	if !ok || b.fingerprint != fp {
+		b = &breaker{integrationID: c.IntegrationID, baseURL: c.BaseURL, fingerprint: fp, state: model.CircuitClosed}
+		breakers[key] = b
+	}
+	return b
+}
+
+// allow reports whether a call may be sent. probe is true when the call is one of the
+// half-open probes; it must be reported back with done.
+func (b *breaker) allow(o Options) (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.state == model.CircuitOpen {
+		if wait := b.openedAt.Add(o.BreakerOpenTimeout).Sub(now); wait > 0 {
+			return false, b.openError(wait)
+		}
+		b.state, b.probes = model.CircuitHalfOpen, 0
+		log.Printf("[asaas] circuit half-open for integration %s, probing", b.name())
+	}
+	if b.state == model.CircuitHalfOpen {
+		if b.probes >= max(o.BreakerHalfOpenProbes, 1) {
+			return false, b.openError(time.Second)
+		}
+		b.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+// done records the outcome of a call let through by allow.
+func (b *breaker) done(o Options, probe bool, res outcome, reason string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe && b.probes > 0 {
+		b.probes--
+	}
+	switch res {
+	case outcomeSuccess:
+		if b.state == model.CircuitHalfOpen && probe {
+			log.Printf("[asaas] circuit closed for integration %s", b.name())
+			b.state = model.CircuitClosed
+		}
+		if b.state == model.CircuitClosed {
+			b.failures = 0
+		}
+	case outcomeFailure:
+		b.lastErr = reason
+		switch {
+		case b.state == model.CircuitHalfOpen && probe:
+			b.open("probe failed: " + reason)
+		case b.state == model.CircuitClosed:
+			b.failures++
+			if b.failures >= o.BreakerFailures {
+				b.open(fmt.Sprintf("%d consecutive failures, last: %s", b.failures, reason))
+			}
+		}
+	}
+}
+
+func (b *breaker) open(why string) {
+	b.state = model.CircuitOpen
+	b.openedAt = time.Now()
+	b.probes = 0
+	log.Printf("[asaas] circuit OPEN for integration %s: %s", b.name(), why)
+}
+
+func (b *breaker) openError(wait time.Duration) error {
+	return &provider.CircuitOpenError{Integration: b.name(), RetryAfter: wait}
+}
+
+func (b *breaker) name() string {
+	if b.integrationID != "" {
+		return b.integrationID
+	}
+	return b.baseURL
+}
+
+// callOutcome classifies one attempt: network errors, timeouts and 5xx count as failures,
+// unless ctx (the caller's context) was canceled.
+func callOutcome(ctx context.Context, status int, err error) outcome {
+	if err != nil {
+		if ctx.Err() != nil {
+			return outcomeIgnored
+		}
+		return outcomeFailure
+	}
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return outcomeFailure
+	}
+	return outcomeSuccess
+}
+
+// Breakers returns the state of every circuit breaker, for the health endpoint.
+func Breakers() []model.CircuitBreakerStatus {
+	o := currentOptions()
+	breakersMu.Lock()
+	list := make([]*breaker, 0, len(breakers))
+	for _, b := range breakers {
+		list = append(list, b)
+	}
+	breakersMu.Unlock()
+
+	out := make([]model.CircuitBreakerStatus, 0, len(list))
+	for _, b := range list {
+		b.mu.Lock()
+		st := model.CircuitBreakerStatus{
+			IntegrationID:       b.integrationID,
+			BaseAPI:             b.baseURL,
+			State:               b.state,
+			ConsecutiveFailures: b.failures,
+		}
+		if b.state != model.CircuitClosed {
+			opened := b.openedAt.UTC().Format(time.RFC3339)
+			st.OpenedAt = &opened
+		}
+		if b.state == model.CircuitOpen {
+			retry := b.openedAt.Add(o.BreakerOpenTimeout).UTC().Format(time.RFC3339)
+			st.RetryAt = &retry
+		}
+		if b.lastErr != "" {
+			lastErr := b.lastErr
+			st.LastError = &lastErr
+		}
+		b.mu.Unlock()
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].IntegrationID != out[j].IntegrationID {
+			return out[i].IntegrationID < out[j].IntegrationID
+		}
+		return out[i].BaseAPI < out[j].BaseAPI
+	})
+	return out
+}
@@ -0,0 +1,217 @@
+package asaas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// useOptions installs o for the test (resetting breakers and limiters) and restores
+// the previous options afterwards.
+func useOptions(t *testing.T, o Options) {
+	t.Helper()
+	prev := currentOptions()
+	Configure(o)
+	t.Cleanup(func() { Configure(prev) })
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	o := Options{BreakerFailures: 3, BreakerOpenTimeout: 30 * time.Millisecond, BreakerHalfOpenProbes: 1}
+
+	type step struct {
+		wait      time.Duration // before the call
+		res       outcome       // reported when the call is let through
+		rejected  bool          // allow refuses the call
+		wantState string        // after the step
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"opens after consecutive failures", []step{
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+			{res: outcomeFailure, wantState: model.CircuitOpen},
+			{rejected: true, wantState: model.CircuitOpen},
+		}},
+		{"a success resets the count", []step{
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+			{res: outcomeSuccess, wantState: model.CircuitClosed},
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+		}},
+		{"canceled calls are not counted", []step{
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+			{res: outcomeIgnored, wantState: model.CircuitClosed},
+			{res: outcomeFailure, wantState: model.CircuitOpen},
+		}},
+		{"successful probe closes", []step{
+			{res: outcomeFailure}, {res: outcomeFailure}, {res: outcomeFailure, wantState: model.CircuitOpen},
+			{wait: 40 * time.Millisecond, res: outcomeSuccess, wantState: model.CircuitClosed},
+			{res: outcomeFailure, wantState: model.CircuitClosed},
+		}},
+		{"failed probe opens again", []step{
+			{res: outcomeFailure}, {res: outcomeFailure}, {res: outcomeFailure, wantState: model.CircuitOpen},
+			{wait: 40 * time.Millisecond, res: outcomeFailure, wantState: model.CircuitOpen},
+			{rejected: true, wantState: model.CircuitOpen},
+		}},
+		{"ignored probe stays half-open", []step{
+			{res: outcomeFailure}, {res: outcomeFailure}, {res: outcomeFailure, wantState: model.CircuitOpen},
+			{wait: 40 * time.Millisecond, res: outcomeIgnored, wantState: model.CircuitHalfOpen},
+			{res: outcomeSuccess, wantState: model.CircuitClosed},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &breaker{integrationID: "int-1", state: model.CircuitClosed}
+			for i, s := range tt.steps {
+				time.Sleep(s.wait)
+				probe, err := b.allow(o)
+				if rejected := err != nil; rejected != s.rejected {
+					t.Fatalf("step %d: allow() error = %v, want rejected=%v", i+1, err, s.rejected)
+				}
+				if err != nil && !errors.Is(err, provider.ErrCircuitOpen) {
+					t.Fatalf("step %d: allow() error = %v, want ErrCircuitOpen", i+1, err)
+				}
+				if err == nil {
+					b.done(o, probe, s.res, "test")
+				}
+				if s.wantState != "" && b.state != s.wantState {
+					t.Fatalf("step %d: state = %s, want %s", i+1, b.state, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerHalfOpenProbeLimit(t *testing.T) {
+	o := Options{BreakerFailures: 1, BreakerOpenTimeout: time.Millisecond, BreakerHalfOpenProbes: 2}
+	b := &breaker{state: model.CircuitClosed}
+	probe, _ := b.allow(o)
+	b.done(o, probe, outcomeFailure, "down")
+	time.Sleep(5 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		if probe, err := b.allow(o); err != nil || !probe {
+			t.Fatalf("probe %d: allow() = %v, %v; want a probe", i+1, probe, err)
+		}
+	}
+	if _, err := b.allow(o); !errors.Is(err, provider.ErrCircuitOpen) {
+		t.Fatalf("third call while two probes are in flight: err = %v, want ErrCircuitOpen", err)
+	}
+	b.done(o, true, outcomeSuccess, "")
+	if b.state != model.CircuitClosed {
+		t.Fatalf("state = %s after a successful probe, want closed", b.state)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	c := &Client{BaseURL: "http://asaas.test", Token: "t"}
+	b := c.breaker(Options{BreakerFailures: 0})
+	if b != nil {
+		t.Fatalf("breaker() = %v, want nil when BreakerFailures is 0", b)
+	}
+	if probe, err := b.allow(Options{}); probe || err != nil {
+		t.Fatalf("nil breaker allow() = %v, %v", probe, err)
+	}
+	b.done(Options{}, false, outcomeFailure, "") // must not panic
+}
+
+func TestBreakerPerIntegration(t *testing.T) {
+	useOptions(t, Options{BreakerFailures: 1, BreakerOpenTimeout: time.Minute, BreakerHalfOpenProbes: 1})
+	o := currentOptions()
+
+	a := &Client{BaseURL: "http://asaas.test", Token: "a", IntegrationID: "int-a"}
+	b := &Client{BaseURL: "http://asaas.test", Token: "b", IntegrationID: "int-b"}
+
+	brk := a.breaker(o)
+	probe, _ := brk.allow(o)
+	brk.done(o, probe, outcomeFailure, "down")
+	if _, err := a.breaker(o).allow(o); err == nil {
+		t.Fatal("integration a: breaker should be open")
+	}
+	if _, err := b.breaker(o).allow(o); err != nil {
+		t.Fatalf("integration b shares a's breaker: %v", err)
+	}
+
+	// New credentials for the same integration start with a fresh breaker.
+	a.Token = "rotated"
+	if _, err := a.breaker(o).allow(o); err != nil {
+		t.Fatalf("rotated token still refused: %v", err)
+	}
+}
+
+func TestCallOutcome(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+		err    error
+		want   outcome
+	}{
+		{"2xx", context.Background(), http.StatusOK, nil, outcomeSuccess},
+		{"4xx is Asaas answering", context.Background(), http.StatusBadRequest, nil, outcomeSuccess},
+		{"429", context.Background(), http.StatusTooManyRequests, nil, outcomeSuccess},
+		{"500", context.Background(), http.StatusInternalServerError, nil, outcomeFailure},
+		{"502", context.Background(), http.StatusBadGateway, nil, outcomeFailure},
+		{"503", context.Background(), http.StatusServiceUnavailable, nil, outcomeFailure},
+		{"504", context.Background(), http.StatusGatewayTimeout, nil, outcomeFailure},
+		{"network error", context.Background(), 0, errors.New("connection refused"), outcomeFailure},
+		{"caller canceled", canceled, 0, context.Canceled, outcomeIgnored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callOutcome(tt.ctx, tt.status, tt.err); got != tt.want {
+				t.Fatalf("callOutcome() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendFailsFastWhenOpen(t *testing.T) {
+	useOptions(t, Options{BreakerFailures: 2, BreakerOpenTimeout: time.Minute, BreakerHalfOpenProbes: 1})
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "token")
+	c.IntegrationID = "int-open"
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.send(context.Background(), http.MethodGet, "/v3/payments", nil); !IsServerError(err) {
+			t.Fatalf("call %d: err = %v, want a 5xx APIError", i+1, err)
+		}
+	}
+	_, _, err := c.send(context.Background(), http.MethodGet, "/v3/payments", nil)
+	if !errors.Is(err, provider.ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("Asaas received %d requests, want 2 (the third must fail fast)", got)
+	}
+
+	var open int
+	for _, st := range Breakers() {
+		if st.IntegrationID == "int-open" && st.State == model.CircuitOpen && st.RetryAt != nil {
+			open++
+		}
+	}
+	if open != 1 {
+		t.Fatalf("Breakers() = %+v, want int-open listed as open", Breakers())
+	}
+}
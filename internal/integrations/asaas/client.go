@@ -2,18 +2,28 @@ package asaas
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
 )
 
 type Client struct {
 	BaseURL string
 	Token   string
 	HTTP    *http.Client
+
+	// IntegrationID keys the circuit breaker (iam.billing_integrations.id);
+	// when empty the breaker is shared by every Client of BaseURL.
+	IntegrationID string
 }
 
 func NewClient(baseURL, token string) *Client {
@@ -25,9 +35,8 @@ func NewClient(baseURL, token string) *Client {
 	return &Client{
 		BaseURL: baseURL,
 		Token:   strings.TrimSpace(token),
-		HTTP: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		// No client-wide timeout: each request carries its own deadline (see Options).
+		HTTP: &http.Client{},
 	}
 }
 
@@ -40,7 +49,45 @@ type CreateCustomerRequest struct {
 	Company              bool   `json:"company"`
 }
 
-func (c *Client) CreateCustomer(req CreateCustomerRequest) (int, []byte, error) {
+// CreateCustomer calls POST /v3/customers.
+func (c *Client) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*model.AsaasCustomerResponse, error) {
+	return decode[model.AsaasCustomerResponse](c.createCustomer(ctx, req))
+}
+
+func (c *Client) createCustomer(ctx context.Context, req CreateCustomerRequest) (int, []byte, error) {
+	return c.send(ctx, http.MethodPost, "/v3/customers", req)
+}
+
+// List is the paginated list envelope returned by Asaas list endpoints.
+type List[T any] struct {
+	Object     string `json:"object"` // "list"
+	HasMore    bool   `json:"hasMore"`
+	TotalCount int    `json:"totalCount"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Data       []T    `json:"data"`
+}
+
+// DeleteResponse is the answer of Asaas DELETE endpoints.
+type DeleteResponse struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// send performs one call to the Asaas API; payload is JSON-encoded when not nil.
+// Calls go through the integration's circuit breaker and the token's rate limiter and
+// are retried with jittered backoff (see Options). Each attempt is bounded by the read
+// (GET) or write timeout; canceling ctx stops the call, including waits for the limiter
+// and between retries. An open breaker fails fast with *provider.CircuitOpenError.
+// A non-2xx answer is returned as *APIError, together with its status and raw body
+// (the provider adapter keeps the body for pass-through).
+func (c *Client) send(ctx context.Context, method, path string, payload any) (int, []byte, error) {
+	return c.sendAccept(ctx, method, path, payload, "application/json")
+}
+
+// sendAccept is send for endpoints that answer with something other than JSON (e.g. PDF
+// documents); accept is sent as the Accept header. Error answers are still JSON.
+func (c *Client) sendAccept(ctx context.Context, method, path string, payload any, accept string) (int, []byte, error) {
 	if c.BaseURL == "" {
 		return 0, nil, fmt.Errorf("asaas baseURL is empty")
 	}
@@ -48,30 +95,111 @@ func (c *Client) CreateCustomer(req CreateCustomerRequest) (int, []byte, error)
 		return 0, nil, fmt.Errorf("asaas token is empty")
 	}
 
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return 0, nil, fmt.Errorf("marshal request: %w", err)
+	var reqBody []byte
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, fmt.Errorf("marshal request: %w", err)
+		}
+		reqBody = b
+	}
+
+	opts := currentOptions()
+	lim := limiterFor(c.Token, opts)
+	brk := c.breaker(opts)
+	for attempt := 0; ; attempt++ {
+		probe, err := brk.allow(opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := lim.wait(ctx); err != nil {
+			brk.done(opts, probe, outcomeIgnored, "")
+			return 0, nil, err
+		}
+		if method != http.MethodGet {
+			provider.MarkWriteSent(ctx)
+		}
+		status, body, header, err := c.do(ctx, opts.timeout(method), method, path, reqBody, accept)
+		lim.observe(status, header)
+		brk.done(opts, probe, callOutcome(ctx, status, err), fmt.Sprintf("%s %s: status=%d err=%v", method, path, status, err))
+
+		ok := err == nil && status >= 200 && status < 300
+		if !ok && ctx.Err() == nil && attempt < opts.MaxRetries && shouldRetry(method, status, err) {
+			delay := retryDelay(opts, attempt, header)
+			log.Printf("[asaas] %s %s failed (status=%d err=%v), retry %d/%d in %s",
+				method, path, status, err, attempt+1, opts.MaxRetries, delay)
+			if err := sleep(ctx, delay); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		switch {
+		case err != nil:
+			return 0, nil, err
+		case !ok:
+			return status, body, newAPIError(status, body)
+		default:
+			return status, body, nil
+		}
+	}
+}
+
+// do sends a single HTTP request, giving up after timeout (0 = only ctx).
+func (c *Client) do(ctx context.Context, timeout time.Duration, method, path string, reqBody []byte, accept string) (int, []byte, http.Header, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	url := c.BaseURL + "/v3/customers"
-	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	var body io.Reader
+	if reqBody != nil {
+		body = bytes.NewReader(reqBody)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
 	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
+		return 0, nil, nil, fmt.Errorf("new request: %w", err)
 	}
 
 	// Asaas auth header (per docs)
 	httpReq.Header.Set("access_token", c.Token)
 	// Also set Authorization for compatibility with proxies/tools and potential Asaas variants.
 	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
+	if reqBody != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", accept)
 
 	resp, err := c.HTTP.Do(httpReq)
 	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
+		return 0, nil, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("read response: %w", err)
+	}
+	return resp.StatusCode, respBody, resp.Header, nil
+}
+
+// decode turns the answer of send into a typed result.
+func decode[T any](_ int, body []byte, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	var out T
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("invalid asaas response: %w", err)
+	}
+	return &out, nil
+}
+
+// withQuery appends params to path.
+func withQuery(path string, params url.Values) string {
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	return path
 }
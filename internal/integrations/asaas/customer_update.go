@@ -1,12 +1,12 @@
 package asaas
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
 )
 
 type UpdateCustomerRequest struct {
@@ -39,41 +39,15 @@ type UpdateCustomerRequest struct {
 	ForeignCustomer      *bool  `json:"foreignCustomer,omitempty"`
 }
 
-func (c *Client) UpdateCustomer(customerID string, req UpdateCustomerRequest) (int, []byte, error) {
+// UpdateCustomer calls PUT /v3/customers/{id}.
+func (c *Client) UpdateCustomer(ctx context.Context, customerID string, req UpdateCustomerRequest) (*model.AsaasCustomerResponse, error) {
+	return decode[model.AsaasCustomerResponse](c.updateCustomer(ctx, customerID, req))
+}
+
+func (c *Client) updateCustomer(ctx context.Context, customerID string, req UpdateCustomerRequest) (int, []byte, error) {
 	customerID = strings.TrimSpace(customerID)
 	if customerID == "" {
 		return 0, nil, fmt.Errorf("asaas customerID is empty")
 	}
-	if c.BaseURL == "" {
-		return 0, nil, fmt.Errorf("asaas baseURL is empty")
-	}
-	if c.Token == "" {
-		return 0, nil, fmt.Errorf("asaas token is empty")
-	}
-
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return 0, nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	url := c.BaseURL + "/v3/customers/" + customerID
-	httpReq, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(payload))
-	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
-	}
-
-	// Asaas auth header (per docs)
-	httpReq.Header.Set("access_token", c.Token)
-	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+	return c.send(ctx, http.MethodPut, "/v3/customers/"+customerID, req)
 }
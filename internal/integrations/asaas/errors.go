@@ -0,0 +1,99 @@
+package asaas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorDetail is one entry of an Asaas error body.
+type ErrorDetail struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// APIError is a non-2xx answer of the Asaas API.
+// Asaas reports errors as {"errors":[{"code":"invalid_value","description":"..."}]};
+// Errors is empty when the body has another shape (e.g. an HTML 502 from a proxy).
+type APIError struct {
+	StatusCode int
+	Errors     []ErrorDetail
+	Body       []byte // raw body, for logs
+}
+
+func newAPIError(status int, body []byte) *APIError {
+	e := &APIError{StatusCode: status, Body: body}
+	var parsed struct {
+		Errors []ErrorDetail `json:"errors"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		e.Errors = parsed.Errors
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("asaas: status %d", e.StatusCode)
+	}
+	parts := make([]string, 0, len(e.Errors))
+	for _, d := range e.Errors {
+		if d.Code != "" {
+			parts = append(parts, d.Code+": "+d.Description)
+		} else {
+			parts = append(parts, d.Description)
+		}
+	}
+	return fmt.Sprintf("asaas: status %d: %s", e.StatusCode, strings.Join(parts, "; "))
+}
+
+// HasCode reports whether Asaas returned the given error code (e.g. "invalid_customer").
+func (e *APIError) HasCode(code string) bool {
+	for _, d := range e.Errors {
+		if d.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// AsAPIError returns the *APIError wrapped in err, if any.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound reports whether Asaas answered 404 (unknown or deleted resource).
+func IsNotFound(err error) bool {
+	return hasStatus(err, func(s int) bool { return s == http.StatusNotFound })
+}
+
+// IsValidation reports whether Asaas rejected the payload (400 or 422).
+func IsValidation(err error) bool {
+	return hasStatus(err, func(s int) bool { return s == http.StatusBadRequest || s == http.StatusUnprocessableEntity })
+}
+
+// IsUnauthorized reports whether Asaas rejected the token (401 or 403).
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, func(s int) bool { return s == http.StatusUnauthorized || s == http.StatusForbidden })
+}
+
+// IsRateLimited reports whether Asaas answered 429.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, func(s int) bool { return s == http.StatusTooManyRequests })
+}
+
+// IsServerError reports whether Asaas failed on its side (5xx).
+func IsServerError(err error) bool {
+	return hasStatus(err, func(s int) bool { return s >= 500 })
+}
+
+func hasStatus(err error, match func(int) bool) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && match(apiErr.StatusCode)
+}
@@ -0,0 +1,113 @@
+package asaas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorHelpers(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		notFound     bool
+		validation   bool
+		unauthorized bool
+		rateLimited  bool
+		serverError  bool
+	}{
+		{name: "400", err: newAPIError(http.StatusBadRequest, nil), validation: true},
+		{name: "422", err: newAPIError(http.StatusUnprocessableEntity, nil), validation: true},
+		{name: "401", err: newAPIError(http.StatusUnauthorized, nil), unauthorized: true},
+		{name: "403", err: newAPIError(http.StatusForbidden, nil), unauthorized: true},
+		{name: "404", err: newAPIError(http.StatusNotFound, nil), notFound: true},
+		{name: "429", err: newAPIError(http.StatusTooManyRequests, nil), rateLimited: true},
+		{name: "500", err: newAPIError(http.StatusInternalServerError, nil), serverError: true},
+		{name: "wrapped 404", err: fmt.Errorf("get payment: %w", newAPIError(http.StatusNotFound, nil)), notFound: true},
+		{name: "network error", err: errors.New("connection refused")},
+		{name: "nil", err: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []bool{IsNotFound(tt.err), IsValidation(tt.err), IsUnauthorized(tt.err), IsRateLimited(tt.err), IsServerError(tt.err)}
+			want := []bool{tt.notFound, tt.validation, tt.unauthorized, tt.rateLimited, tt.serverError}
+			for i, name := range []string{"IsNotFound", "IsValidation", "IsUnauthorized", "IsRateLimited", "IsServerError"} {
+				if got[i] != want[i] {
+					t.Errorf("%s() = %v, want %v", name, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		codes   []string
+		message string
+	}{
+		{
+			name:    "asaas error body",
+			body:    `{"errors":[{"code":"invalid_customer","description":"Cliente inválido"},{"code":"invalid_value","description":"Valor inválido"}]}`,
+			codes:   []string{"invalid_customer", "invalid_value"},
+			message: "asaas: status 400: invalid_customer: Cliente inválido; invalid_value: Valor inválido",
+		},
+		{
+			name:    "html from a proxy",
+			body:    `<html>Bad Gateway</html>`,
+			message: "asaas: status 400",
+		},
+		{
+			name:    "empty body",
+			message: "asaas: status 400",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newAPIError(http.StatusBadRequest, []byte(tt.body))
+			if e.Error() != tt.message {
+				t.Fatalf("Error() = %q, want %q", e.Error(), tt.message)
+			}
+			for _, code := range tt.codes {
+				if !e.HasCode(code) {
+					t.Errorf("HasCode(%q) = false", code)
+				}
+			}
+			if e.HasCode("other_code") {
+				t.Errorf("HasCode(other_code) = true")
+			}
+		})
+	}
+}
+
+func TestTypedClient(t *testing.T) {
+	useOptions(t, Options{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/payments/pay_1/refund":
+			_, _ = w.Write([]byte(`{"id":"pay_1","status":"REFUNDED","value":100}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"invalid_action","description":"Cobrança não encontrada"}]}`))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "token")
+
+	pay, err := c.RefundPayment(context.Background(), "pay_1", RefundPaymentRequest{})
+	if err != nil || pay == nil || pay.ID != "pay_1" || pay.Status != "REFUNDED" {
+		t.Fatalf("RefundPayment() = %+v, %v", pay, err)
+	}
+
+	_, err = c.GetSubscription(context.Background(), "sub_missing")
+	if !IsNotFound(err) {
+		t.Fatalf("GetSubscription() error = %v, want not found", err)
+	}
+	if apiErr, ok := AsAPIError(err); !ok || !apiErr.HasCode("invalid_action") {
+		t.Fatalf("AsAPIError() = %v, %v; want invalid_action", apiErr, ok)
+	}
+}
@@ -0,0 +1,82 @@
+package asaas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// GetInstallment fetches an installment (the group of payments created with installmentCount).
+// Reference: https://docs.asaas.com/reference/recuperar-um-unico-parcelamento
+func (c *Client) GetInstallment(ctx context.Context, installmentID string) (*model.AsaasInstallmentResponse, error) {
+	return decode[model.AsaasInstallmentResponse](c.getInstallment(ctx, installmentID))
+}
+
+func (c *Client) getInstallment(ctx context.Context, installmentID string) (int, []byte, error) {
+	if strings.TrimSpace(installmentID) == "" {
+		return 0, nil, fmt.Errorf("installmentID is required")
+	}
+	return c.send(ctx, http.MethodGet, "/v3/installments/"+installmentID, nil)
+}
+
+// ListInstallmentPayments lists the payments of an installment (offset, limit and status filters).
+// Reference: https://docs.asaas.com/reference/listar-cobrancas-de-um-parcelamento
+func (c *Client) ListInstallmentPayments(ctx context.Context, installmentID string, params url.Values) (*List[model.AsaasPaymentResponse], error) {
+	return decode[List[model.AsaasPaymentResponse]](c.listInstallmentPayments(ctx, installmentID, params))
+}
+
+func (c *Client) listInstallmentPayments(ctx context.Context, installmentID string, params url.Values) (int, []byte, error) {
+	if strings.TrimSpace(installmentID) == "" {
+		return 0, nil, fmt.Errorf("installmentID is required")
+	}
+	return c.send(ctx, http.MethodGet, withQuery("/v3/installments/"+installmentID+"/payments", params), nil)
+}
+
+// CancelInstallmentPayments deletes the pending and overdue payments of an installment;
+// received payments are kept.
+// Reference: https://docs.asaas.com/reference/cancelar-cobrancas-pendentes-ou-vencidas-de-um-parcelamento
+func (c *Client) CancelInstallmentPayments(ctx context.Context, installmentID string) error {
+	_, _, err := c.cancelInstallmentPayments(ctx, installmentID)
+	return err
+}
+
+func (c *Client) cancelInstallmentPayments(ctx context.Context, installmentID string) (int, []byte, error) {
+	if strings.TrimSpace(installmentID) == "" {
+		return 0, nil, fmt.Errorf("installmentID is required")
+	}
+	return c.send(ctx, http.MethodDelete, "/v3/installments/"+installmentID+"/payments", nil)
+}
+
+// RefundInstallment refunds every received payment of an installment (credit card).
+// Reference: https://docs.asaas.com/reference/estornar-parcelamento
+func (c *Client) RefundInstallment(ctx context.Context, installmentID string) (*model.AsaasInstallmentResponse, error) {
+	return decode[model.AsaasInstallmentResponse](c.refundInstallment(ctx, installmentID))
+}
+
+func (c *Client) refundInstallment(ctx context.Context, installmentID string) (int, []byte, error) {
+	if strings.TrimSpace(installmentID) == "" {
+		return 0, nil, fmt.Errorf("installmentID is required")
+	}
+	return c.send(ctx, http.MethodPost, "/v3/installments/"+installmentID+"/refund", nil)
+}
+
+// GetInstallmentPaymentBook downloads the payment book (carnê) of an installment as a PDF.
+// Reference: https://docs.asaas.com/reference/gerar-carne-de-parcelamento
+func (c *Client) GetInstallmentPaymentBook(ctx context.Context, installmentID string) ([]byte, error) {
+	_, body, err := c.getInstallmentPaymentBook(ctx, installmentID)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (c *Client) getInstallmentPaymentBook(ctx context.Context, installmentID string) (int, []byte, error) {
+	if strings.TrimSpace(installmentID) == "" {
+		return 0, nil, fmt.Errorf("installmentID is required")
+	}
+	return c.sendAccept(ctx, http.MethodGet, "/v3/installments/"+installmentID+"/paymentBook", nil, "application/pdf")
+}
@@ -1,11 +1,11 @@
 package asaas
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
+
+	"github.com/seuuser/charges-service/internal/model"
 )
 
 // PaymentDiscount mirrors Asaas discount object.
@@ -48,13 +48,11 @@ type CreatePaymentRequest struct {
 }
 
 // CreatePayment calls the Asaas API to create a new charge (payment).
-func (c *Client) CreatePayment(req CreatePaymentRequest) (int, []byte, error) {
-	if c.BaseURL == "" {
-		return 0, nil, fmt.Errorf("asaas baseURL is empty")
-	}
-	if c.Token == "" {
-		return 0, nil, fmt.Errorf("asaas token is empty")
-	}
+func (c *Client) CreatePayment(ctx context.Context, req CreatePaymentRequest) (*model.AsaasPaymentResponse, error) {
+	return decode[model.AsaasPaymentResponse](c.createPayment(ctx, req))
+}
+
+func (c *Client) createPayment(ctx context.Context, req CreatePaymentRequest) (int, []byte, error) {
 	if req.Customer == "" {
 		return 0, nil, fmt.Errorf("customer is required")
 	}
@@ -67,29 +65,5 @@ func (c *Client) CreatePayment(req CreatePaymentRequest) (int, []byte, error) {
 	if req.DueDate == "" {
 		return 0, nil, fmt.Errorf("dueDate is required")
 	}
-
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return 0, nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	url := c.BaseURL + "/v3/payments"
-	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
-	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
-	}
-
-	httpReq.Header.Set("access_token", c.Token)
-	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+	return c.send(ctx, http.MethodPost, "/v3/payments", req)
 }
@@ -1,40 +1,27 @@
 package asaas
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 )
 
+// IdentificationFieldResponse is the boleto data returned by /identificationField.
+type IdentificationFieldResponse struct {
+	IdentificationField string `json:"identificationField"`
+	NossoNumero         string `json:"nossoNumero"`
+	BarCode             string `json:"barCode"`
+}
+
 // GetPaymentIdentificationField fetches the boleto "linha digitável" for a payment.
 // Asaas endpoint: GET /v3/payments/{id}/identificationField
-func (c *Client) GetPaymentIdentificationField(paymentID string) (int, []byte, error) {
-	if c.BaseURL == "" {
-		return 0, nil, fmt.Errorf("asaas baseURL is empty")
-	}
-	if c.Token == "" {
-		return 0, nil, fmt.Errorf("asaas token is empty")
-	}
+func (c *Client) GetPaymentIdentificationField(ctx context.Context, paymentID string) (*IdentificationFieldResponse, error) {
+	return decode[IdentificationFieldResponse](c.getPaymentIdentificationField(ctx, paymentID))
+}
+
+func (c *Client) getPaymentIdentificationField(ctx context.Context, paymentID string) (int, []byte, error) {
 	if paymentID == "" {
 		return 0, nil, fmt.Errorf("payment id is empty")
 	}
-
-	endpoint := c.BaseURL + "/v3/payments/" + paymentID + "/identificationField"
-	httpReq, err := http.NewRequest(http.MethodGet, endpoint, nil)
-	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
-	}
-
-	httpReq.Header.Set("access_token", c.Token)
-	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Accept", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+	return c.send(ctx, http.MethodGet, "/v3/payments/"+paymentID+"/identificationField", nil)
 }
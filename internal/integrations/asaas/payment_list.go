@@ -1,42 +1,19 @@
 package asaas
 
 import (
-	"fmt"
-	"io"
+	"context"
 	"net/http"
 	"net/url"
+
+	"github.com/seuuser/charges-service/internal/model"
 )
 
 // ListPayments calls the Asaas API to list payments (charges) with optional filters.
 // https://docs.asaas.com/reference/listar-cobrancas
-func (c *Client) ListPayments(params url.Values) (int, []byte, error) {
-	if c.BaseURL == "" {
-		return 0, nil, fmt.Errorf("asaas baseURL is empty")
-	}
-	if c.Token == "" {
-		return 0, nil, fmt.Errorf("asaas token is empty")
-	}
-
-	endpoint := c.BaseURL + "/v3/payments"
-	if params != nil && len(params) > 0 {
-		endpoint += "?" + params.Encode()
-	}
-
-	httpReq, err := http.NewRequest(http.MethodGet, endpoint, nil)
-	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
-	}
-
-	httpReq.Header.Set("access_token", c.Token)
-	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Accept", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+func (c *Client) ListPayments(ctx context.Context, params url.Values) (*List[model.AsaasPaymentResponse], error) {
+	return decode[List[model.AsaasPaymentResponse]](c.listPayments(ctx, params))
+}
 
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+func (c *Client) listPayments(ctx context.Context, params url.Values) (int, []byte, error) {
+	return c.send(ctx, http.MethodGet, withQuery("/v3/payments", params), nil)
 }
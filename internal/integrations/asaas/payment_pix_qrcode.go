@@ -1,40 +1,27 @@
 package asaas
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 )
 
+// PixQrCodeResponse is the Pix data returned by /pixQrCode.
+type PixQrCodeResponse struct {
+	EncodedImage   string `json:"encodedImage"` // base64 PNG
+	Payload        string `json:"payload"`      // Pix copia e cola
+	ExpirationDate string `json:"expirationDate"`
+}
+
 // GetPaymentPixQrCode fetches Pix QR code data for a payment.
 // Asaas endpoint: GET /v3/payments/{id}/pixQrCode
-func (c *Client) GetPaymentPixQrCode(paymentID string) (int, []byte, error) {
-	if c.BaseURL == "" {
-		return 0, nil, fmt.Errorf("asaas baseURL is empty")
-	}
-	if c.Token == "" {
-		return 0, nil, fmt.Errorf("asaas token is empty")
-	}
+func (c *Client) GetPaymentPixQrCode(ctx context.Context, paymentID string) (*PixQrCodeResponse, error) {
+	return decode[PixQrCodeResponse](c.getPaymentPixQrCode(ctx, paymentID))
+}
+
+func (c *Client) getPaymentPixQrCode(ctx context.Context, paymentID string) (int, []byte, error) {
 	if paymentID == "" {
 		return 0, nil, fmt.Errorf("payment id is empty")
 	}
-
-	endpoint := c.BaseURL + "/v3/payments/" + paymentID + "/pixQrCode"
-	httpReq, err := http.NewRequest(http.MethodGet, endpoint, nil)
-	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
-	}
-
-	httpReq.Header.Set("access_token", c.Token)
-	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Accept", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+	return c.send(ctx, http.MethodGet, "/v3/payments/"+paymentID+"/pixQrCode", nil)
 }
@@ -0,0 +1,45 @@
+package asaas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// ReceiveInCashRequest is the payload for confirming a charge paid outside Asaas
+// (cash, bank transfer...).
+type ReceiveInCashRequest struct {
+	PaymentDate    string  `json:"paymentDate"` // YYYY-MM-DD
+	Value          float64 `json:"value"`
+	NotifyCustomer *bool   `json:"notifyCustomer,omitempty"`
+}
+
+// ReceivePaymentInCash confirms the receipt of a charge in cash (status RECEIVED_IN_CASH).
+// Reference: https://docs.asaas.com/reference/confirmar-recebimento-em-dinheiro
+func (c *Client) ReceivePaymentInCash(ctx context.Context, paymentID string, req ReceiveInCashRequest) (*model.AsaasPaymentResponse, error) {
+	return decode[model.AsaasPaymentResponse](c.receivePaymentInCash(ctx, paymentID, req))
+}
+
+// UndoPaymentReceivedInCash reverts a receipt confirmed with ReceivePaymentInCash;
+// the charge goes back to PENDING (or OVERDUE).
+// Reference: https://docs.asaas.com/reference/desfazer-confirmacao-de-recebimento-em-dinheiro
+func (c *Client) UndoPaymentReceivedInCash(ctx context.Context, paymentID string) (*model.AsaasPaymentResponse, error) {
+	return decode[model.AsaasPaymentResponse](c.undoPaymentReceivedInCash(ctx, paymentID))
+}
+
+func (c *Client) receivePaymentInCash(ctx context.Context, paymentID string, req ReceiveInCashRequest) (int, []byte, error) {
+	if strings.TrimSpace(paymentID) == "" {
+		return 0, nil, fmt.Errorf("paymentID is required")
+	}
+	return c.send(ctx, http.MethodPost, "/v3/payments/"+paymentID+"/receiveInCash", req)
+}
+
+func (c *Client) undoPaymentReceivedInCash(ctx context.Context, paymentID string) (int, []byte, error) {
+	if strings.TrimSpace(paymentID) == "" {
+		return 0, nil, fmt.Errorf("paymentID is required")
+	}
+	return c.send(ctx, http.MethodPost, "/v3/payments/"+paymentID+"/undoReceivedInCash", nil)
+}
@@ -0,0 +1,31 @@
+package asaas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// RefundPaymentRequest is the payload for refunding a received charge.
+// Value nil refunds the full amount; Description is shown in the refund receipt.
+type RefundPaymentRequest struct {
+	Value       *float64 `json:"value,omitempty"`
+	Description *string  `json:"description,omitempty"`
+}
+
+// RefundPayment refunds a received charge (payment), fully or partially.
+// Several partial refunds are allowed until the charge value is reached.
+// Reference: https://docs.asaas.com/reference/estornar-cobranca
+func (c *Client) RefundPayment(ctx context.Context, paymentID string, req RefundPaymentRequest) (*model.AsaasPaymentResponse, error) {
+	return decode[model.AsaasPaymentResponse](c.refundPayment(ctx, paymentID, req))
+}
+
+func (c *Client) refundPayment(ctx context.Context, paymentID string, req RefundPaymentRequest) (int, []byte, error) {
+	if strings.TrimSpace(paymentID) == "" {
+		return 0, nil, fmt.Errorf("paymentID is required")
+	}
+	return c.send(ctx, http.MethodPost, "/v3/payments/"+paymentID+"/refund", req)
+}
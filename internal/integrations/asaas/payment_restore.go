@@ -0,0 +1,23 @@
+package asaas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// RestorePayment restores a deleted charge (payment) in Asaas.
+// Reference: https://docs.asaas.com/reference/restaurar-cobranca-removida
+func (c *Client) RestorePayment(ctx context.Context, paymentID string) (*model.AsaasPaymentResponse, error) {
+	return decode[model.AsaasPaymentResponse](c.restorePayment(ctx, paymentID))
+}
+
+func (c *Client) restorePayment(ctx context.Context, paymentID string) (int, []byte, error) {
+	if strings.TrimSpace(paymentID) == "" {
+		return 0, nil, fmt.Errorf("paymentID is required")
+	}
+	return c.send(ctx, http.MethodPost, "/v3/payments/"+paymentID+"/restore", nil)
+}
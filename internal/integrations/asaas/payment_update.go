@@ -1,12 +1,12 @@
 package asaas
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
 )
 
 // PaymentSplit represents a split configuration for updating a charge.
@@ -44,39 +44,13 @@ type UpdatePaymentRequest struct {
 // paymentID is the Asaas payment ID (e.g. "pay_080225913252").
 // Note: Only charges awaiting payment or overdue can be updated.
 // The customer cannot be changed once the charge is created.
-func (c *Client) UpdatePayment(paymentID string, req UpdatePaymentRequest) (int, []byte, error) {
-	if c.BaseURL == "" {
-		return 0, nil, fmt.Errorf("asaas baseURL is empty")
-	}
-	if c.Token == "" {
-		return 0, nil, fmt.Errorf("asaas token is empty")
-	}
+func (c *Client) UpdatePayment(ctx context.Context, paymentID string, req UpdatePaymentRequest) (*model.AsaasPaymentResponse, error) {
+	return decode[model.AsaasPaymentResponse](c.updatePayment(ctx, paymentID, req))
+}
+
+func (c *Client) updatePayment(ctx context.Context, paymentID string, req UpdatePaymentRequest) (int, []byte, error) {
 	if strings.TrimSpace(paymentID) == "" {
 		return 0, nil, fmt.Errorf("paymentID is required")
 	}
-
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return 0, nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/v3/payments/%s", c.BaseURL, paymentID)
-	httpReq, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(payload))
-	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
-	}
-
-	httpReq.Header.Set("access_token", c.Token)
-	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+	return c.send(ctx, http.MethodPut, "/v3/payments/"+paymentID, req)
 }
@@ -0,0 +1,698 @@
+package asaas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// ProviderName is the registry key of the Asaas implementation.
+const ProviderName = "ASAAS"
+
+// chargeProvider adapts Client to provider.ChargeProvider.
+type chargeProvider struct {
+	client *Client
+	ctx    context.Context
+}
+
+// NewChargeProvider is the provider.Factory for Asaas.
+func NewChargeProvider(cfg *model.BillingIntegrationRow) (provider.ChargeProvider, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("billing integration is nil")
+	}
+	client := NewClient(cfg.BaseAPI, cfg.Token)
+	client.IntegrationID = cfg.ID
+	return &chargeProvider{client: client, ctx: context.Background()}, nil
+}
+
+func (p *chargeProvider) Name() string { return ProviderName }
+
+func (p *chargeProvider) WithContext(ctx context.Context) provider.ChargeProvider {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cp := *p
+	cp.ctx = ctx
+	return &cp
+}
+
+// ── Customers ────────────────────────────────────────────────────────────────
+
+func (p *chargeProvider) CreateCustomer(in provider.CustomerInput) (*provider.Customer, *provider.Response, error) {
+	req := CreateCustomerRequest{
+		Name:        in.Name,
+		CpfCnpj:     in.CpfCnpj,
+		Email:       in.Email,
+		MobilePhone: in.MobilePhone,
+	}
+	if in.NotificationDisabled != nil {
+		req.NotificationDisabled = *in.NotificationDisabled
+	}
+	if in.Company != nil {
+		req.Company = *in.Company
+	}
+
+	status, body, err := p.client.createCustomer(p.ctx, req)
+	return decodeCustomer(status, body, err)
+}
+
+func (p *chargeProvider) GetCustomer(customerID string) (*provider.Customer, *provider.Response, error) {
+	status, body, err := p.client.getCustomer(p.ctx, customerID)
+	return decodeCustomer(status, body, err)
+}
+
+func (p *chargeProvider) UpdateCustomer(customerID string, in provider.CustomerInput) (*provider.Customer, *provider.Response, error) {
+	status, body, err := p.client.updateCustomer(p.ctx, customerID, UpdateCustomerRequest{
+		Name:                 in.Name,
+		CpfCnpj:              in.CpfCnpj,
+		Email:                in.Email,
+		Phone:                in.Phone,
+		MobilePhone:          in.MobilePhone,
+		Address:              in.Address,
+		AddressNumber:        in.AddressNumber,
+		Complement:           in.Complement,
+		Province:             in.Province,
+		City:                 in.City,
+		State:                in.State,
+		Country:              in.Country,
+		PostalCode:           in.PostalCode,
+		AdditionalEmails:     in.AdditionalEmails,
+		ExternalReference:    in.ExternalReference,
+		Observations:         in.Observations,
+		PersonType:           in.PersonType,
+		NotificationDisabled: in.NotificationDisabled,
+		Company:              in.Company,
+		ForeignCustomer:      in.ForeignCustomer,
+	})
+	return decodeCustomer(status, body, err)
+}
+
+// ── Payments ─────────────────────────────────────────────────────────────────
+
+func (p *chargeProvider) CreatePayment(in provider.PaymentInput) (*provider.Payment, *provider.Response, error) {
+	status, body, err := p.client.createPayment(p.ctx, CreatePaymentRequest{
+		Customer:    in.CustomerID,
+		BillingType: in.BillingType,
+		Value:       in.Value,
+		DueDate:     in.DueDate,
+		Description: in.Description,
+		DaysAfterDueDateToRegistrationCancellation: in.DaysAfterDueDateToRegistrationCancellation,
+		ExternalReference:                          in.ExternalReference,
+		InstallmentCount:                           in.InstallmentCount,
+		TotalValue:                                 in.TotalValue,
+		InstallmentValue:                           in.InstallmentValue,
+		Discount:                                   toPaymentDiscount(in.Discount),
+		Interest:                                   toPaymentInterest(in.Interest),
+		Fine:                                       toPaymentFine(in.Fine),
+		PostalService:                              in.PostalService,
+	})
+	return decodePayment(status, body, err)
+}
+
+func (p *chargeProvider) UpdatePayment(paymentID string, in provider.PaymentUpdate) (*provider.Payment, *provider.Response, error) {
+	status, body, err := p.client.updatePayment(p.ctx, paymentID, UpdatePaymentRequest{
+		BillingType:       in.BillingType,
+		Value:             in.Value,
+		DueDate:           in.DueDate,
+		Description:       in.Description,
+		ExternalReference: in.ExternalReference,
+		Discount:          toPaymentDiscount(in.Discount),
+		Interest:          toPaymentInterest(in.Interest),
+		Fine:              toPaymentFine(in.Fine),
+	})
+	return decodePayment(status, body, err)
+}
+
+func (p *chargeProvider) DeletePayment(paymentID string) (*provider.Response, error) {
+	status, body, err := p.client.deletePayment(p.ctx, paymentID)
+	return response(status, body, err)
+}
+
+func (p *chargeProvider) RestorePayment(paymentID string) (*provider.Payment, *provider.Response, error) {
+	status, body, err := p.client.restorePayment(p.ctx, paymentID)
+	return decodePayment(status, body, err)
+}
+
+func (p *chargeProvider) RefundPayment(paymentID string, in provider.RefundInput) (*provider.Payment, *provider.Response, error) {
+	status, body, err := p.client.refundPayment(p.ctx, paymentID, RefundPaymentRequest{
+		Value:       in.Value,
+		Description: in.Description,
+	})
+	return decodePayment(status, body, err)
+}
+
+func (p *chargeProvider) ReceiveInCash(paymentID string, in provider.ReceiveInCashInput) (*provider.Payment, *provider.Response, error) {
+	status, body, err := p.client.receivePaymentInCash(p.ctx, paymentID, ReceiveInCashRequest{
+		PaymentDate:    in.PaymentDate,
+		Value:          in.Value,
+		NotifyCustomer: in.NotifyCustomer,
+	})
+	return decodePayment(status, body, err)
+}
+
+func (p *chargeProvider) UndoReceivedInCash(paymentID string) (*provider.Payment, *provider.Response, error) {
+	status, body, err := p.client.undoPaymentReceivedInCash(p.ctx, paymentID)
+	return decodePayment(status, body, err)
+}
+
+func (p *chargeProvider) ListPayments(filter provider.PaymentFilter) (*provider.PaymentPage, *provider.Response, error) {
+	return decodePaymentPage(p.client.listPayments(p.ctx, paymentFilterParams(filter)))
+}
+
+// ── Installments ─────────────────────────────────────────────────────────────
+
+func (p *chargeProvider) GetInstallment(installmentID string) (*provider.Installment, *provider.Response, error) {
+	status, body, err := p.client.getInstallment(p.ctx, installmentID)
+	return decodeInstallment(status, body, err)
+}
+
+func (p *chargeProvider) ListInstallmentPayments(installmentID string, filter provider.PaymentFilter) (*provider.PaymentPage, *provider.Response, error) {
+	filter.InstallmentID = ""
+	return decodePaymentPage(p.client.listInstallmentPayments(p.ctx, installmentID, paymentFilterParams(filter)))
+}
+
+func (p *chargeProvider) CancelInstallmentPayments(installmentID string) (*provider.Response, error) {
+	status, body, err := p.client.cancelInstallmentPayments(p.ctx, installmentID)
+	return response(status, body, err)
+}
+
+func (p *chargeProvider) RefundInstallment(installmentID string) (*provider.Installment, *provider.Response, error) {
+	status, body, err := p.client.refundInstallment(p.ctx, installmentID)
+	return decodeInstallment(status, body, err)
+}
+
+func (p *chargeProvider) GetInstallmentPaymentBook(installmentID string) (*provider.PaymentBook, *provider.Response, error) {
+	status, body, err := p.client.getInstallmentPaymentBook(p.ctx, installmentID)
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+	return &provider.PaymentBook{ContentType: "application/pdf", Content: body}, resp, nil
+}
+
+// ── Subscriptions ────────────────────────────────────────────────────────────
+
+func (p *chargeProvider) CreateSubscription(in provider.SubscriptionInput) (*provider.Subscription, *provider.Response, error) {
+	status, body, err := p.client.createSubscription(p.ctx, CreateSubscriptionRequest{
+		Customer:          in.CustomerID,
+		BillingType:       in.BillingType,
+		Value:             in.Value,
+		NextDueDate:       in.NextDueDate,
+		Cycle:             in.Cycle,
+		Description:       in.Description,
+		EndDate:           in.EndDate,
+		MaxPayments:       in.MaxPayments,
+		ExternalReference: in.ExternalReference,
+		Discount:          toPaymentDiscount(in.Discount),
+		Interest:          toPaymentInterest(in.Interest),
+		Fine:              toPaymentFine(in.Fine),
+	})
+	return decodeSubscription(status, body, err)
+}
+
+func (p *chargeProvider) UpdateSubscription(subscriptionID string, in provider.SubscriptionUpdate) (*provider.Subscription, *provider.Response, error) {
+	status, body, err := p.client.updateSubscription(p.ctx, subscriptionID, UpdateSubscriptionRequest{
+		BillingType:           in.BillingType,
+		Status:                in.Status,
+		Value:                 in.Value,
+		NextDueDate:           in.NextDueDate,
+		Cycle:                 in.Cycle,
+		Description:           in.Description,
+		EndDate:               in.EndDate,
+		UpdatePendingPayments: in.UpdatePendingPayments,
+		ExternalReference:     in.ExternalReference,
+		Discount:              toPaymentDiscount(in.Discount),
+		Interest:              toPaymentInterest(in.Interest),
+		Fine:                  toPaymentFine(in.Fine),
+	})
+	return decodeSubscription(status, body, err)
+}
+
+func (p *chargeProvider) GetSubscription(subscriptionID string) (*provider.Subscription, *provider.Response, error) {
+	status, body, err := p.client.getSubscription(p.ctx, subscriptionID)
+	return decodeSubscription(status, body, err)
+}
+
+func (p *chargeProvider) DeleteSubscription(subscriptionID string) (*provider.Response, error) {
+	status, body, err := p.client.deleteSubscription(p.ctx, subscriptionID)
+	return response(status, body, err)
+}
+
+func (p *chargeProvider) ListSubscriptionPayments(subscriptionID string, filter provider.PaymentFilter) (*provider.PaymentPage, *provider.Response, error) {
+	filter.SubscriptionID = ""
+	return decodePaymentPage(p.client.listSubscriptionPayments(p.ctx, subscriptionID, paymentFilterParams(filter)))
+}
+
+func (p *chargeProvider) ListSubscriptions(filter provider.SubscriptionFilter) (*provider.SubscriptionPage, *provider.Response, error) {
+	params := url.Values{}
+	for k, v := range filter.Extra {
+		if strings.TrimSpace(v) != "" {
+			params.Set(k, v)
+		}
+	}
+	set := func(key, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			params.Set(key, value)
+		}
+	}
+	set("customer", filter.CustomerID)
+	set("externalReference", filter.ExternalReference)
+	set("status", filter.Status)
+	if filter.Offset > 0 {
+		params.Set("offset", strconv.Itoa(filter.Offset))
+	}
+	if filter.Limit > 0 {
+		params.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	status, body, err := p.client.listSubscriptions(p.ctx, params)
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	var list List[json.RawMessage]
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, resp, fmt.Errorf("invalid asaas subscription list: %w", err)
+	}
+
+	page := &provider.SubscriptionPage{
+		Data:       make([]provider.Subscription, 0, len(list.Data)),
+		HasMore:    list.HasMore,
+		TotalCount: list.TotalCount,
+		Offset:     list.Offset,
+		Limit:      list.Limit,
+	}
+	for _, raw := range list.Data {
+		var s model.AsaasSubscriptionResponse
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, resp, fmt.Errorf("invalid asaas subscription: %w", err)
+		}
+		page.Data = append(page.Data, *subscriptionFromModel(s, append(json.RawMessage(nil), raw...)))
+	}
+	return page, resp, nil
+}
+
+// ── Assets ───────────────────────────────────────────────────────────────────
+
+func (p *chargeProvider) GetDigitableLine(paymentID string) (*provider.DigitableLine, *provider.Response, error) {
+	status, body, err := p.client.getPaymentIdentificationField(p.ctx, paymentID)
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	var out IdentificationFieldResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, resp, fmt.Errorf("invalid asaas identificationField response: %w", err)
+	}
+	return &provider.DigitableLine{
+		IdentificationField: out.IdentificationField,
+		NossoNumero:         out.NossoNumero,
+		BarCode:             out.BarCode,
+	}, resp, nil
+}
+
+func (p *chargeProvider) GetPixQrCode(paymentID string) (*provider.PixQrCode, *provider.Response, error) {
+	status, body, err := p.client.getPaymentPixQrCode(p.ctx, paymentID)
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	var out PixQrCodeResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, resp, fmt.Errorf("invalid asaas pixQrCode response: %w", err)
+	}
+	return &provider.PixQrCode{
+		EncodedImage:   out.EncodedImage,
+		Payload:        out.Payload,
+		ExpirationDate: out.ExpirationDate,
+	}, resp, nil
+}
+
+// ── Webhooks ─────────────────────────────────────────────────────────────────
+
+func (p *chargeProvider) ParseWebhookEvent(body []byte) (*provider.WebhookEvent, error) {
+	var event model.AsaasWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+
+	out := &provider.WebhookEvent{
+		ID:          event.ID,
+		Event:       event.Event,
+		DateCreated: event.DateCreated,
+	}
+	if event.Account != nil {
+		out.AccountID = event.Account.ID
+	}
+
+	// Re-read the payment/subscription as raw JSON so the stored payload keeps every field Asaas sent.
+	var raw struct {
+		Payment      json.RawMessage `json:"payment"`
+		Subscription json.RawMessage `json:"subscription"`
+	}
+	_ = json.Unmarshal(body, &raw)
+	if event.Payment != nil {
+		pay := paymentFromModel(*event.Payment)
+		if len(raw.Payment) > 0 && string(raw.Payment) != "null" {
+			pay.Raw = raw.Payment
+		}
+		out.Payment = &pay
+	}
+	if event.Subscription != nil {
+		out.Subscription = subscriptionFromModel(*event.Subscription, raw.Subscription)
+	}
+	return out, nil
+}
+
+func (p *chargeProvider) CreateWebhookEndpoint(in provider.WebhookEndpointInput) (*provider.WebhookEndpoint, *provider.Response, error) {
+	req := toWebhookRequest(in)
+	req.APIVersion = 3
+	status, body, err := p.client.createWebhook(p.ctx, req)
+	return decodeWebhookEndpoint(status, body, err)
+}
+
+func (p *chargeProvider) GetWebhookEndpoint(endpointID string) (*provider.WebhookEndpoint, *provider.Response, error) {
+	status, body, err := p.client.getWebhook(p.ctx, endpointID)
+	return decodeWebhookEndpoint(status, body, err)
+}
+
+func (p *chargeProvider) UpdateWebhookEndpoint(endpointID string, in provider.WebhookEndpointInput) (*provider.WebhookEndpoint, *provider.Response, error) {
+	status, body, err := p.client.updateWebhook(p.ctx, endpointID, toWebhookRequest(in))
+	return decodeWebhookEndpoint(status, body, err)
+}
+
+func (p *chargeProvider) DeleteWebhookEndpoint(endpointID string) (*provider.Response, error) {
+	status, body, err := p.client.deleteWebhook(p.ctx, endpointID)
+	return response(status, body, err)
+}
+
+// ListWebhookEndpoints returns every webhook of the account (Asaas allows only a few per account,
+// but the list is paginated, so all pages are read).
+func (p *chargeProvider) ListWebhookEndpoints() ([]provider.WebhookEndpoint, *provider.Response, error) {
+	const pageSize = 100
+	var out []provider.WebhookEndpoint
+	for offset := 0; ; offset += pageSize {
+		params := url.Values{}
+		params.Set("offset", strconv.Itoa(offset))
+		params.Set("limit", strconv.Itoa(pageSize))
+
+		status, body, err := p.client.listWebhooks(p.ctx, params)
+		resp, err := response(status, body, err)
+		if err != nil || !resp.OK() {
+			return nil, resp, err
+		}
+
+		var list List[json.RawMessage]
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, resp, fmt.Errorf("invalid asaas webhook list: %w", err)
+		}
+		for _, raw := range list.Data {
+			var wh WebhookResponse
+			if err := json.Unmarshal(raw, &wh); err != nil {
+				return nil, resp, fmt.Errorf("invalid asaas webhook: %w", err)
+			}
+			out = append(out, webhookEndpointFromResponse(wh, append(json.RawMessage(nil), raw...)))
+		}
+		if !list.HasMore || len(list.Data) == 0 {
+			return out, resp, nil
+		}
+	}
+}
+
+// ── Mapping helpers ──────────────────────────────────────────────────────────
+
+func toPaymentDiscount(d *provider.Discount) *PaymentDiscount {
+	if d == nil {
+		return nil
+	}
+	return &PaymentDiscount{Value: d.Value, DueDateLimitDays: d.DueDateLimitDays, Type: d.Type}
+}
+
+func toPaymentInterest(i *provider.Interest) *PaymentInterest {
+	if i == nil {
+		return nil
+	}
+	return &PaymentInterest{Value: i.Value}
+}
+
+func toPaymentFine(f *provider.Fine) *PaymentFine {
+	if f == nil {
+		return nil
+	}
+	return &PaymentFine{Value: f.Value, Type: f.Type}
+}
+
+func paymentFilterParams(f provider.PaymentFilter) url.Values {
+	params := url.Values{}
+	for k, v := range f.Extra {
+		if strings.TrimSpace(v) != "" {
+			params.Set(k, v)
+		}
+	}
+	set := func(key, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			params.Set(key, value)
+		}
+	}
+	set("customer", f.CustomerID)
+	set("subscription", f.SubscriptionID)
+	set("installment", f.InstallmentID)
+	set("externalReference", f.ExternalReference)
+	set("status", f.Status)
+	if f.Offset > 0 {
+		params.Set("offset", strconv.Itoa(f.Offset))
+	}
+	if f.Limit > 0 {
+		params.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return params
+}
+
+// response adapts a client answer to the ChargeProvider contract: an *APIError becomes
+// a non-2xx Response with the parsed provider errors, transport errors are returned as is.
+func response(status int, body []byte, err error) (*provider.Response, error) {
+	if err == nil {
+		return &provider.Response{StatusCode: status, Body: body}, nil
+	}
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return nil, err
+	}
+	resp := &provider.Response{StatusCode: apiErr.StatusCode, Body: apiErr.Body}
+	for _, d := range apiErr.Errors {
+		resp.Errors = append(resp.Errors, provider.ErrorDetail{Code: d.Code, Description: d.Description})
+	}
+	return resp, nil
+}
+
+func decodeCustomer(status int, body []byte, err error) (*provider.Customer, *provider.Response, error) {
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	var c model.AsaasCustomerResponse
+	if err := json.Unmarshal(body, &c); err != nil {
+		return nil, resp, fmt.Errorf("invalid asaas customer response: %w", err)
+	}
+	return &provider.Customer{
+		ID:                c.ID,
+		Name:              c.Name,
+		CpfCnpj:           c.CpfCnpj,
+		Email:             c.Email,
+		MobilePhone:       c.MobilePhone,
+		ExternalReference: c.ExternalReference,
+		Deleted:           c.Deleted,
+		Raw:               body,
+	}, resp, nil
+}
+
+func decodePayment(status int, body []byte, err error) (*provider.Payment, *provider.Response, error) {
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	pay, perr := paymentFromRaw(body)
+	if perr != nil {
+		return nil, resp, perr
+	}
+	return pay, resp, nil
+}
+
+func decodePaymentPage(status int, body []byte, err error) (*provider.PaymentPage, *provider.Response, error) {
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	var list List[json.RawMessage]
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, resp, fmt.Errorf("invalid asaas payment list: %w", err)
+	}
+
+	page := &provider.PaymentPage{
+		Data:       make([]provider.Payment, 0, len(list.Data)),
+		HasMore:    list.HasMore,
+		TotalCount: list.TotalCount,
+		Offset:     list.Offset,
+		Limit:      list.Limit,
+	}
+	for _, raw := range list.Data {
+		pay, err := paymentFromRaw(raw)
+		if err != nil {
+			return nil, resp, err
+		}
+		page.Data = append(page.Data, *pay)
+	}
+	return page, resp, nil
+}
+
+func decodeInstallment(status int, body []byte, err error) (*provider.Installment, *provider.Response, error) {
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	var in model.AsaasInstallmentResponse
+	if err := json.Unmarshal(body, &in); err != nil {
+		return nil, resp, fmt.Errorf("invalid asaas installment response: %w", err)
+	}
+	out := &provider.Installment{
+		ID:               strings.TrimSpace(in.ID),
+		DateCreated:      strings.TrimSpace(in.DateCreated),
+		CustomerID:       strings.TrimSpace(in.Customer),
+		BillingType:      strings.TrimSpace(in.BillingType),
+		Value:            in.Value,
+		NetValue:         in.NetValue,
+		PaymentValue:     in.PaymentValue,
+		InstallmentCount: in.InstallmentCount,
+		Deleted:          in.Deleted,
+		Raw:              body,
+	}
+	if in.Description != nil {
+		out.Description = strings.TrimSpace(*in.Description)
+	}
+	return out, resp, nil
+}
+
+func decodeSubscription(status int, body []byte, err error) (*provider.Subscription, *provider.Response, error) {
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	var s model.AsaasSubscriptionResponse
+	if err := json.Unmarshal(body, &s); err != nil {
+		return nil, resp, fmt.Errorf("invalid asaas subscription response: %w", err)
+	}
+	return subscriptionFromModel(s, body), resp, nil
+}
+
+func paymentFromRaw(raw []byte) (*provider.Payment, error) {
+	var p model.AsaasPaymentResponse
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("invalid asaas payment: %w", err)
+	}
+	out := paymentFromModel(p)
+	out.Raw = append(json.RawMessage(nil), raw...)
+	return &out, nil
+}
+
+func paymentFromModel(p model.AsaasPaymentResponse) provider.Payment {
+	raw, _ := json.Marshal(p)
+	return provider.Payment{
+		ID:                strings.TrimSpace(p.ID),
+		DateCreated:       strings.TrimSpace(p.DateCreated),
+		CustomerID:        strings.TrimSpace(p.Customer),
+		SubscriptionID:    strings.TrimSpace(p.Subscription),
+		InstallmentID:     strings.TrimSpace(p.Installment),
+		InstallmentNumber: p.InstallmentNumber,
+		Value:             p.Value,
+		NetValue:          p.NetValue,
+		Description:       strings.TrimSpace(p.Description),
+		BillingType:       strings.TrimSpace(p.BillingType),
+		Status:            strings.TrimSpace(p.Status),
+		DueDate:           strings.TrimSpace(p.DueDate),
+		OriginalDueDate:   strings.TrimSpace(p.OriginalDueDate),
+		PaymentDate:       strings.TrimSpace(p.PaymentDate),
+		InvoiceURL:        strings.TrimSpace(p.InvoiceURL),
+		InvoiceNumber:     strings.TrimSpace(p.InvoiceNumber),
+		ExternalReference: strings.TrimSpace(p.ExternalReference),
+		Deleted:           p.Deleted,
+		Raw:               raw,
+	}
+}
+
+func subscriptionFromModel(s model.AsaasSubscriptionResponse, raw []byte) *provider.Subscription {
+	out := &provider.Subscription{
+		ID:          strings.TrimSpace(s.ID),
+		DateCreated: strings.TrimSpace(s.DateCreated),
+		CustomerID:  strings.TrimSpace(s.Customer),
+		BillingType: strings.TrimSpace(s.BillingType),
+		Cycle:       strings.TrimSpace(s.Cycle),
+		Value:       s.Value,
+		NextDueDate: strings.TrimSpace(s.NextDueDate),
+		EndDate:     strings.TrimSpace(s.EndDate),
+		Description: strings.TrimSpace(s.Description),
+		Status:      strings.TrimSpace(s.Status),
+		Deleted:     s.Deleted,
+		Raw:         raw,
+	}
+	if s.ExternalReference != nil {
+		out.ExternalReference = strings.TrimSpace(*s.ExternalReference)
+	}
+	return out
+}
+
+func toWebhookRequest(in provider.WebhookEndpointInput) WebhookRequest {
+	return WebhookRequest{
+		Name:        in.Name,
+		URL:         in.URL,
+		Email:       in.Email,
+		Enabled:     in.Enabled,
+		Interrupted: in.Interrupted,
+		AuthToken:   in.AuthToken,
+		SendType:    in.SendType,
+		Events:      in.Events,
+	}
+}
+
+func decodeWebhookEndpoint(status int, body []byte, err error) (*provider.WebhookEndpoint, *provider.Response, error) {
+	resp, err := response(status, body, err)
+	if err != nil || !resp.OK() {
+		return nil, resp, err
+	}
+
+	var wh WebhookResponse
+	if err := json.Unmarshal(body, &wh); err != nil {
+		return nil, resp, fmt.Errorf("invalid asaas webhook response: %w", err)
+	}
+	out := webhookEndpointFromResponse(wh, body)
+	return &out, resp, nil
+}
+
+func webhookEndpointFromResponse(wh WebhookResponse, raw []byte) provider.WebhookEndpoint {
+	return provider.WebhookEndpoint{
+		ID:                strings.TrimSpace(wh.ID),
+		Name:              wh.Name,
+		URL:               strings.TrimSpace(wh.URL),
+		Email:             wh.Email,
+		Enabled:           wh.Enabled,
+		Interrupted:       wh.Interrupted,
+		PenalizedRequests: wh.PenalizedRequestsCount,
+		HasAuthToken:      wh.HasAuthToken,
+		SendType:          wh.SendType,
+		Events:            wh.Events,
+		Raw:               raw,
+	}
+}
@@ -0,0 +1,230 @@
+package asaas
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options controls timeouts, retries, rate limiting and circuit breaking of every Client (see Configure).
+type Options struct {
+	// ReadTimeout bounds each GET attempt, WriteTimeout each POST/PUT/DELETE attempt
+	// (0 = no deadline besides the caller's context).
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// MaxRetries is how many times a failed call is retried: network errors and 5xx on
+	// idempotent methods (GET, PUT, DELETE), 429 on any method (Asaas did not process it).
+	MaxRetries int
+	// RetryBase is the backoff before the first retry, doubled on each attempt up to RetryMax.
+	// The actual wait is jittered between half and the full value.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+
+	// RateLimit is the max number of requests per second sent with the same token
+	// (shared by every Client of an account, so bulk jobs don't exhaust its quota).
+	RateLimit int
+	// MinRemaining pauses the token until Rate-Limit-Reset when Asaas reports
+	// Rate-Limit-Remaining at or below this value.
+	MinRemaining int
+
+	// BreakerFailures consecutive failures (network error, timeout, 5xx) open the circuit
+	// breaker of a billing integration (0 disables breakers). While open, calls fail fast
+	// for BreakerOpenTimeout; then BreakerHalfOpenProbes calls are let through to probe Asaas.
+	BreakerFailures       int
+	BreakerOpenTimeout    time.Duration
+	BreakerHalfOpenProbes int
+}
+
+var (
+	optionsMu sync.RWMutex
+	options   = Options{
+		ReadTimeout:  20 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		MaxRetries:   3,
+		RetryBase:    500 * time.Millisecond,
+		RetryMax:     10 * time.Second,
+		RateLimit:    10,
+		MinRemaining: 5,
+
+		BreakerFailures:       5,
+		BreakerOpenTimeout:    30 * time.Second,
+		BreakerHalfOpenProbes: 1,
+	}
+)
+
+// Configure replaces the default Options. It is meant to be called once at startup (see cmd/api).
+func Configure(o Options) {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	options = o
+
+	limitersMu.Lock()
+	limiters = map[string]*limiter{}
+	limitersMu.Unlock()
+
+	breakersMu.Lock()
+	breakers = map[string]*breaker{}
+	breakersMu.Unlock()
+}
+
+func currentOptions() Options {
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+	return options
+}
+
+// timeout is the deadline of one attempt of method.
+func (o Options) timeout(method string) time.Duration {
+	if method == http.MethodGet {
+		return o.ReadTimeout
+	}
+	return o.WriteTimeout
+}
+
+// sleep waits d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limiter spaces the requests of one token and holds them while Asaas says the quota is exhausted.
+type limiter struct {
+	mu           sync.Mutex
+	interval     time.Duration // 1s / RateLimit
+	minRemaining int
+	next         time.Time // earliest start of the next request
+	pausedUntil  time.Time // set from Rate-Limit-Reset / Retry-After
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[string]*limiter{}
+)
+
+// limiterFor returns the limiter shared by every Client using token.
+func limiterFor(token string, o Options) *limiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	l, ok := limiters[token]
+	if !ok {
+		l = &limiter{minRemaining: o.MinRemaining}
+		if o.RateLimit > 0 {
+			l.interval = time.Second / time.Duration(o.RateLimit)
+		}
+		limiters[token] = l
+	}
+	return l
+}
+
+// wait blocks until the token may send another request and reserves the slot.
+// It returns ctx.Err() when ctx is done first (the slot is not given back).
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	start := now
+	if l.next.After(start) {
+		start = l.next
+	}
+	if l.pausedUntil.After(start) {
+		start = l.pausedUntil
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	if d := start.Sub(now); d > 0 {
+		return sleep(ctx, d)
+	}
+	return ctx.Err()
+}
+
+// observe reads the Asaas rate-limit headers of a response and pauses the token when
+// the quota is (almost) exhausted or the request was throttled.
+func (l *limiter) observe(status int, h http.Header) {
+	if h == nil {
+		return
+	}
+	reset := resetDelay(h)
+	remaining, hasRemaining := headerInt(h, "Rate-Limit-Remaining", "RateLimit-Remaining")
+	throttled := status == http.StatusTooManyRequests
+	if !throttled && (!hasRemaining || remaining > l.minRemaining) {
+		return
+	}
+	if reset <= 0 {
+		return
+	}
+
+	until := time.Now().Add(reset)
+	l.mu.Lock()
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+		log.Printf("[asaas] rate limit: status=%d remaining=%d, pausing token for %s", status, remaining, reset)
+	}
+	l.mu.Unlock()
+}
+
+// resetDelay is how long until the quota resets, from Rate-Limit-Reset (seconds) or Retry-After.
+func resetDelay(h http.Header) time.Duration {
+	if secs, ok := headerInt(h, "Rate-Limit-Reset", "RateLimit-Reset", "Retry-After"); ok && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
+
+func headerInt(h http.Header, keys ...string) (int, bool) {
+	for _, k := range keys {
+		if v := strings.TrimSpace(h.Get(k)); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// shouldRetry reports whether a call that failed with err/status can be sent again.
+// POST is only retried on 429: after a network error or a 5xx the payment may exist already.
+func shouldRetry(method string, status int, err error) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	idempotent := method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+	if !idempotent {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay is the jittered exponential backoff before retry number attempt+1,
+// never shorter than the reset announced by Asaas.
+func retryDelay(o Options, attempt int, h http.Header) time.Duration {
+	d := o.RetryBase << attempt
+	if d <= 0 || d > o.RetryMax {
+		d = o.RetryMax
+	}
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	if h != nil {
+		if reset := resetDelay(h); reset > d {
+			d = reset
+		}
+	}
+	return d
+}
@@ -0,0 +1,157 @@
+package asaas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	netErr := errors.New("connection reset")
+	tests := []struct {
+		method string
+		status int
+		err    error
+		want   bool
+	}{
+		{http.MethodGet, 0, netErr, true},
+		{http.MethodPut, 0, netErr, true},
+		{http.MethodDelete, 0, netErr, true},
+		{http.MethodPost, 0, netErr, false}, // the payment may exist already
+		{http.MethodGet, http.StatusInternalServerError, nil, true},
+		{http.MethodGet, http.StatusBadGateway, nil, true},
+		{http.MethodGet, http.StatusServiceUnavailable, nil, true},
+		{http.MethodGet, http.StatusGatewayTimeout, nil, true},
+		{http.MethodPost, http.StatusServiceUnavailable, nil, false},
+		{http.MethodGet, http.StatusNotImplemented, nil, false},
+		{http.MethodGet, http.StatusTooManyRequests, nil, true},
+		{http.MethodPost, http.StatusTooManyRequests, nil, true}, // Asaas did not process it
+		{http.MethodGet, http.StatusBadRequest, nil, false},
+		{http.MethodGet, http.StatusNotFound, nil, false},
+		{http.MethodPost, http.StatusUnauthorized, nil, false},
+	}
+	for _, tt := range tests {
+		if got := shouldRetry(tt.method, tt.status, tt.err); got != tt.want {
+			t.Errorf("shouldRetry(%s, %d, %v) = %v, want %v", tt.method, tt.status, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	o := Options{RetryBase: 100 * time.Millisecond, RetryMax: time.Second}
+	tests := []struct {
+		name    string
+		attempt int
+		header  http.Header
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"first retry", 0, nil, 50 * time.Millisecond, 100 * time.Millisecond},
+		{"doubles", 2, nil, 200 * time.Millisecond, 400 * time.Millisecond},
+		{"capped", 6, nil, 500 * time.Millisecond, time.Second},
+		{"shift overflow is capped", 70, nil, 500 * time.Millisecond, time.Second},
+		{"waits for the announced reset", 0, http.Header{"Rate-Limit-Reset": {"3"}}, 3 * time.Second, 3 * time.Second},
+		{"retry-after", 0, http.Header{"Retry-After": {"2"}}, 2 * time.Second, 2 * time.Second},
+		{"reset shorter than backoff", 6, http.Header{"Retry-After": {"0"}}, 500 * time.Millisecond, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				if d := retryDelay(o, tt.attempt, tt.header); d < tt.min || d > tt.max {
+					t.Fatalf("retryDelay() = %s, want between %s and %s", d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterObserve(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		paused bool
+	}{
+		{"quota left", http.StatusOK, http.Header{"Rate-Limit-Remaining": {"50"}, "Rate-Limit-Reset": {"10"}}, false},
+		{"quota almost exhausted", http.StatusOK, http.Header{"Rate-Limit-Remaining": {"5"}, "Rate-Limit-Reset": {"10"}}, true},
+		{"throttled", http.StatusTooManyRequests, http.Header{"Retry-After": {"10"}}, true},
+		{"throttled without reset", http.StatusTooManyRequests, http.Header{}, false},
+		{"no headers", http.StatusOK, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &limiter{minRemaining: 5}
+			l.observe(tt.status, tt.header)
+			if paused := l.pausedUntil.After(time.Now()); paused != tt.paused {
+				t.Fatalf("paused = %v, want %v", paused, tt.paused)
+			}
+		})
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		responses []int // status of each attempt; the last one repeats
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"GET retried until it succeeds", http.MethodGet, []int{503, 502, 200}, 3, false},
+		{"GET gives up after MaxRetries", http.MethodGet, []int{500}, 3, true},
+		{"POST not retried on 5xx", http.MethodPost, []int{503, 200}, 1, true},
+		{"POST retried on 429", http.MethodPost, []int{429, 200}, 2, false},
+		{"PUT retried on 5xx", http.MethodPut, []int{504, 200}, 2, false},
+		{"4xx not retried", http.MethodGet, []int{400, 200}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useOptions(t, Options{MaxRetries: 2, RetryBase: time.Millisecond, RetryMax: 5 * time.Millisecond})
+
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(calls.Add(1))
+				w.WriteHeader(tt.responses[min(n, len(tt.responses))-1])
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			_, _, err := NewClient(srv.URL, "token").send(context.Background(), tt.method, "/v3/payments", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("Asaas received %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSendStopsRetryingWhenCanceled(t *testing.T) {
+	useOptions(t, Options{MaxRetries: 5, RetryBase: time.Second, RetryMax: time.Second})
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err := NewClient(srv.URL, "token").send(ctx, http.MethodGet, "/v3/payments", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("send() error = %v, want the context error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		t.Fatalf("send() took %s, the backoff should stop with the context", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("Asaas received %d requests, want 1", got)
+	}
+}
@@ -1,11 +1,11 @@
 package asaas
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
+
+	"github.com/seuuser/charges-service/internal/model"
 )
 
 // CreateSubscriptionRequest is the payload for creating a new subscription in Asaas.
@@ -28,13 +28,11 @@ type CreateSubscriptionRequest struct {
 }
 
 // CreateSubscription calls the Asaas API to create a subscription.
-func (c *Client) CreateSubscription(req CreateSubscriptionRequest) (int, []byte, error) {
-	if c.BaseURL == "" {
-		return 0, nil, fmt.Errorf("asaas baseURL is empty")
-	}
-	if c.Token == "" {
-		return 0, nil, fmt.Errorf("asaas token is empty")
-	}
+func (c *Client) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*model.AsaasSubscriptionResponse, error) {
+	return decode[model.AsaasSubscriptionResponse](c.createSubscription(ctx, req))
+}
+
+func (c *Client) createSubscription(ctx context.Context, req CreateSubscriptionRequest) (int, []byte, error) {
 	if req.Customer == "" {
 		return 0, nil, fmt.Errorf("customer is required")
 	}
@@ -50,30 +48,5 @@ func (c *Client) CreateSubscription(req CreateSubscriptionRequest) (int, []byte,
 	if req.Cycle == "" {
 		return 0, nil, fmt.Errorf("cycle is required")
 	}
-
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return 0, nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	url := c.BaseURL + "/v3/subscriptions"
-	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
-	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
-	}
-
-	httpReq.Header.Set("access_token", c.Token)
-	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+	return c.send(ctx, http.MethodPost, "/v3/subscriptions", req)
 }
-
@@ -0,0 +1,21 @@
+package asaas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DeleteSubscription deletes a subscription from Asaas; no new payments are generated.
+// Reference: https://docs.asaas.com/reference/remover-assinatura
+func (c *Client) DeleteSubscription(ctx context.Context, subscriptionID string) (*DeleteResponse, error) {
+	return decode[DeleteResponse](c.deleteSubscription(ctx, subscriptionID))
+}
+
+func (c *Client) deleteSubscription(ctx context.Context, subscriptionID string) (int, []byte, error) {
+	if strings.TrimSpace(subscriptionID) == "" {
+		return 0, nil, fmt.Errorf("subscriptionID is required")
+	}
+	return c.send(ctx, http.MethodDelete, "/v3/subscriptions/"+subscriptionID, nil)
+}
@@ -0,0 +1,23 @@
+package asaas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// GetSubscription fetches a subscription from Asaas (deleted subscriptions included, with deleted=true).
+// Reference: https://docs.asaas.com/reference/recuperar-uma-unica-assinatura
+func (c *Client) GetSubscription(ctx context.Context, subscriptionID string) (*model.AsaasSubscriptionResponse, error) {
+	return decode[model.AsaasSubscriptionResponse](c.getSubscription(ctx, subscriptionID))
+}
+
+func (c *Client) getSubscription(ctx context.Context, subscriptionID string) (int, []byte, error) {
+	if strings.TrimSpace(subscriptionID) == "" {
+		return 0, nil, fmt.Errorf("subscriptionID is required")
+	}
+	return c.send(ctx, http.MethodGet, "/v3/subscriptions/"+subscriptionID, nil)
+}
@@ -0,0 +1,20 @@
+package asaas
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// ListSubscriptions calls the Asaas API to list subscriptions with optional filters
+// (customer, externalReference, status, offset, limit...).
+// https://docs.asaas.com/reference/listar-assinaturas
+func (c *Client) ListSubscriptions(ctx context.Context, params url.Values) (*List[model.AsaasSubscriptionResponse], error) {
+	return decode[List[model.AsaasSubscriptionResponse]](c.listSubscriptions(ctx, params))
+}
+
+func (c *Client) listSubscriptions(ctx context.Context, params url.Values) (int, []byte, error) {
+	return c.send(ctx, http.MethodGet, withQuery("/v3/subscriptions", params), nil)
+}
@@ -0,0 +1,24 @@
+package asaas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// ListSubscriptionPayments lists the payments generated by a subscription (offset, limit and status filters).
+// Reference: https://docs.asaas.com/reference/listar-cobrancas-de-uma-assinatura
+func (c *Client) ListSubscriptionPayments(ctx context.Context, subscriptionID string, params url.Values) (*List[model.AsaasPaymentResponse], error) {
+	return decode[List[model.AsaasPaymentResponse]](c.listSubscriptionPayments(ctx, subscriptionID, params))
+}
+
+func (c *Client) listSubscriptionPayments(ctx context.Context, subscriptionID string, params url.Values) (int, []byte, error) {
+	if strings.TrimSpace(subscriptionID) == "" {
+		return 0, nil, fmt.Errorf("subscriptionID is required")
+	}
+	return c.send(ctx, http.MethodGet, withQuery("/v3/subscriptions/"+subscriptionID+"/payments", params), nil)
+}
@@ -1,11 +1,11 @@
 package asaas
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
+
+	"github.com/seuuser/charges-service/internal/model"
 )
 
 // UpdateSubscriptionRequest is the payload for updating an existing subscription in Asaas.
@@ -31,39 +31,13 @@ type UpdateSubscriptionRequest struct {
 }
 
 // UpdateSubscription calls PUT /v3/subscriptions/{id} on the Asaas API.
-func (c *Client) UpdateSubscription(subscriptionID string, req UpdateSubscriptionRequest) (int, []byte, error) {
-	if c.BaseURL == "" {
-		return 0, nil, fmt.Errorf("asaas baseURL is empty")
-	}
-	if c.Token == "" {
-		return 0, nil, fmt.Errorf("asaas token is empty")
-	}
+func (c *Client) UpdateSubscription(ctx context.Context, subscriptionID string, req UpdateSubscriptionRequest) (*model.AsaasSubscriptionResponse, error) {
+	return decode[model.AsaasSubscriptionResponse](c.updateSubscription(ctx, subscriptionID, req))
+}
+
+func (c *Client) updateSubscription(ctx context.Context, subscriptionID string, req UpdateSubscriptionRequest) (int, []byte, error) {
 	if subscriptionID == "" {
 		return 0, nil, fmt.Errorf("subscriptionID is required")
 	}
-
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return 0, nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	url := c.BaseURL + "/v3/subscriptions/" + subscriptionID
-	httpReq, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(payload))
-	if err != nil {
-		return 0, nil, fmt.Errorf("new request: %w", err)
-	}
-
-	httpReq.Header.Set("access_token", c.Token)
-	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return 0, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	return resp.StatusCode, body, nil
+	return c.send(ctx, http.MethodPut, "/v3/subscriptions/"+subscriptionID, req)
 }
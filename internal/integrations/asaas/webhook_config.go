@@ -0,0 +1,98 @@
+package asaas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WebhookRequest is the payload to create or update a webhook in Asaas (/v3/webhooks).
+// https://docs.asaas.com/reference/criar-novo-webhook
+// On update every field is optional.
+type WebhookRequest struct {
+	Name        string   `json:"name,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Enabled     *bool    `json:"enabled,omitempty"`
+	Interrupted *bool    `json:"interrupted,omitempty"` // false resumes a queue interrupted by Asaas
+	APIVersion  int      `json:"apiVersion,omitempty"`
+	AuthToken   *string  `json:"authToken,omitempty"`
+	SendType    string   `json:"sendType,omitempty"` // SEQUENTIALLY | NON_SEQUENTIALLY
+	Events      []string `json:"events,omitempty"`
+}
+
+// WebhookResponse is a webhook as returned by Asaas.
+// Asaas never returns the auth token, only whether one is set.
+type WebhookResponse struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Email        string   `json:"email"`
+	Enabled      bool     `json:"enabled"`
+	Interrupted  bool     `json:"interrupted"`
+	APIVersion   int      `json:"apiVersion"`
+	HasAuthToken bool     `json:"hasAuthToken"`
+	SendType     string   `json:"sendType"`
+	Events       []string `json:"events"`
+
+	// PenalizedRequestsCount is the number of deliveries Asaas is holding back after
+	// failures (the queue is "penalized" before being interrupted).
+	PenalizedRequestsCount int `json:"penalizedRequestsCount"`
+}
+
+// CreateWebhook calls POST /v3/webhooks.
+func (c *Client) CreateWebhook(ctx context.Context, req WebhookRequest) (*WebhookResponse, error) {
+	return decode[WebhookResponse](c.createWebhook(ctx, req))
+}
+
+// ListWebhooks calls GET /v3/webhooks (offset, limit).
+// https://docs.asaas.com/reference/listar-webhooks
+func (c *Client) ListWebhooks(ctx context.Context, params url.Values) (*List[WebhookResponse], error) {
+	return decode[List[WebhookResponse]](c.listWebhooks(ctx, params))
+}
+
+// GetWebhook calls GET /v3/webhooks/{id}.
+func (c *Client) GetWebhook(ctx context.Context, webhookID string) (*WebhookResponse, error) {
+	return decode[WebhookResponse](c.getWebhook(ctx, webhookID))
+}
+
+// UpdateWebhook calls PUT /v3/webhooks/{id}.
+// https://docs.asaas.com/reference/atualizar-webhook-existente
+func (c *Client) UpdateWebhook(ctx context.Context, webhookID string, req WebhookRequest) (*WebhookResponse, error) {
+	return decode[WebhookResponse](c.updateWebhook(ctx, webhookID, req))
+}
+
+// DeleteWebhook calls DELETE /v3/webhooks/{id}.
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) (*DeleteResponse, error) {
+	return decode[DeleteResponse](c.deleteWebhook(ctx, webhookID))
+}
+
+func (c *Client) createWebhook(ctx context.Context, req WebhookRequest) (int, []byte, error) {
+	return c.send(ctx, http.MethodPost, "/v3/webhooks", req)
+}
+
+func (c *Client) listWebhooks(ctx context.Context, params url.Values) (int, []byte, error) {
+	return c.send(ctx, http.MethodGet, withQuery("/v3/webhooks", params), nil)
+}
+
+func (c *Client) getWebhook(ctx context.Context, webhookID string) (int, []byte, error) {
+	if webhookID == "" {
+		return 0, nil, fmt.Errorf("webhookID is required")
+	}
+	return c.send(ctx, http.MethodGet, "/v3/webhooks/"+url.PathEscape(webhookID), nil)
+}
+
+func (c *Client) updateWebhook(ctx context.Context, webhookID string, req WebhookRequest) (int, []byte, error) {
+	if webhookID == "" {
+		return 0, nil, fmt.Errorf("webhookID is required")
+	}
+	return c.send(ctx, http.MethodPut, "/v3/webhooks/"+url.PathEscape(webhookID), req)
+}
+
+func (c *Client) deleteWebhook(ctx context.Context, webhookID string) (int, []byte, error) {
+	if webhookID == "" {
+		return 0, nil, fmt.Errorf("webhookID is required")
+	}
+	return c.send(ctx, http.MethodDelete, "/v3/webhooks/"+url.PathEscape(webhookID), nil)
+}
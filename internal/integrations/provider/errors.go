@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCircuitOpen is matched (errors.Is) by the error returned when a provider call is
+// refused because the circuit breaker of its billing integration is open.
+var ErrCircuitOpen = errors.New("provider circuit open")
+
+// CircuitOpenError reports a call refused without reaching the provider.
+type CircuitOpenError struct {
+	Integration string        // billing integration id (or base URL when there is none)
+	RetryAfter  time.Duration // until the breaker lets a probe through
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("provider circuit open for integration %s, retry in %s", e.Integration, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *CircuitOpenError) Is(target error) bool { return target == ErrCircuitOpen }
@@ -0,0 +1,152 @@
+package provider
+
+import "fmt"
+
+// MaxPageSize is the largest page the iterators request (Asaas caps limit at 100).
+const MaxPageSize = 100
+
+// pageFunc fetches one page of a list endpoint.
+type pageFunc[T any] func(offset, limit int) (items []T, hasMore bool, resp *Response, err error)
+
+// Iterator streams every item of a paginated list, fetching the next page (offset/limit,
+// following hasMore) only when the current one is consumed. Retries (429 included) are up
+// to the provider client: a failed page ends the iteration. Use it like bufio.Scanner:
+//
+//	it := provider.Payments(p, provider.PaymentFilter{InstallmentID: id})
+//	for it.Next() {
+//		pay := it.Value()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	fetch   pageFunc[T]
+	offset  int
+	limit   int
+	page    []T
+	pos     int
+	hasMore bool
+	cur     T
+	resp    *Response
+	err     error
+}
+
+func newIterator[T any](offset, limit int, fetch pageFunc[T]) *Iterator[T] {
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return &Iterator[T]{fetch: fetch, offset: offset, limit: limit, hasMore: true}
+}
+
+// Next advances to the next item, fetching a page when needed.
+// It returns false at the end of the list or on error (see Err).
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	for it.pos >= len(it.page) {
+		if !it.hasMore {
+			return false
+		}
+		if !it.fetchPage() {
+			return false
+		}
+	}
+	it.cur = it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// Value returns the current item.
+func (it *Iterator[T]) Value() T { return it.cur }
+
+// Err returns the error that stopped the iteration (nil at the normal end of the list).
+func (it *Iterator[T]) Err() error { return it.err }
+
+// Response returns the last provider response (the failing one when the provider
+// answered with a non-2xx status), so handlers can pass provider errors through.
+func (it *Iterator[T]) Response() *Response { return it.resp }
+
+func (it *Iterator[T]) fetchPage() bool {
+	items, hasMore, resp, err := it.fetch(it.offset, it.limit)
+	it.resp = resp
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if !resp.OK() {
+		it.err = fmt.Errorf("list page offset=%d: %s", it.offset, resp.ErrorMessage())
+		return false
+	}
+
+	it.page, it.pos = items, 0
+	it.offset += len(items)
+	// An empty page with hasMore would loop forever.
+	it.hasMore = hasMore && len(items) > 0
+	return true
+}
+
+// All drains the iterator into a slice.
+func (it *Iterator[T]) All() ([]T, error) {
+	var out []T
+	for it.Next() {
+		out = append(out, it.Value())
+	}
+	return out, it.Err()
+}
+
+// Payments iterates over every payment matching filter (filter.Offset is the starting
+// offset, filter.Limit the page size).
+func Payments(p ChargeProvider, filter PaymentFilter) *Iterator[Payment] {
+	return newIterator(filter.Offset, filter.Limit, func(offset, limit int) ([]Payment, bool, *Response, error) {
+		f := filter
+		f.Offset, f.Limit = offset, limit
+		page, resp, err := p.ListPayments(f)
+		if page == nil {
+			return nil, false, resp, err
+		}
+		return page.Data, page.HasMore, resp, err
+	})
+}
+
+// InstallmentPayments iterates over every payment of an installment plan matching filter
+// (InstallmentID is ignored: the plan is installmentID).
+func InstallmentPayments(p ChargeProvider, installmentID string, filter PaymentFilter) *Iterator[Payment] {
+	return newIterator(filter.Offset, filter.Limit, func(offset, limit int) ([]Payment, bool, *Response, error) {
+		f := filter
+		f.Offset, f.Limit = offset, limit
+		page, resp, err := p.ListInstallmentPayments(installmentID, f)
+		if page == nil {
+			return nil, false, resp, err
+		}
+		return page.Data, page.HasMore, resp, err
+	})
+}
+
+// Subscriptions iterates over every subscription matching filter.
+func Subscriptions(p ChargeProvider, filter SubscriptionFilter) *Iterator[Subscription] {
+	return newIterator(filter.Offset, filter.Limit, func(offset, limit int) ([]Subscription, bool, *Response, error) {
+		f := filter
+		f.Offset, f.Limit = offset, limit
+		page, resp, err := p.ListSubscriptions(f)
+		if page == nil {
+			return nil, false, resp, err
+		}
+		return page.Data, page.HasMore, resp, err
+	})
+}
+
+// SubscriptionPayments iterates over every payment generated by a subscription matching
+// filter (SubscriptionID is ignored: the subscription is subscriptionID).
+func SubscriptionPayments(p ChargeProvider, subscriptionID string, filter PaymentFilter) *Iterator[Payment] {
+	return newIterator(filter.Offset, filter.Limit, func(offset, limit int) ([]Payment, bool, *Response, error) {
+		f := filter
+		f.Offset, f.Limit = offset, limit
+		page, resp, err := p.ListSubscriptionPayments(subscriptionID, f)
+		if page == nil {
+			return nil, false, resp, err
+		}
+		return page.Data, page.HasMore, resp, err
+	})
+}
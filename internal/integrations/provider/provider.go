@@ -0,0 +1,146 @@
+// Package provider defines the contract every billing provider (bank/PSP) must
+// implement so handlers can create and manage charges without knowing which
+// provider backs a given billing integration.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// ChargeProvider is the provider-agnostic surface used by handlers.
+//
+// Every remote call returns the decoded object (nil when the provider answered
+// with a non-2xx status) plus the raw Response, so /v1/asaas-style endpoints can
+// keep passing the provider payload through unchanged.
+type ChargeProvider interface {
+	// Name returns the normalized provider key (e.g. "ASAAS").
+	Name() string
+
+	// WithContext returns a copy whose calls are bound to ctx: canceling it (e.g. the
+	// client disconnected) aborts in-flight requests. The default context is Background.
+	WithContext(ctx context.Context) ChargeProvider
+
+	// Customers
+	CreateCustomer(in CustomerInput) (*Customer, *Response, error)
+	GetCustomer(customerID string) (*Customer, *Response, error)
+	UpdateCustomer(customerID string, in CustomerInput) (*Customer, *Response, error)
+
+	// Payments (charges)
+	CreatePayment(in PaymentInput) (*Payment, *Response, error)
+	UpdatePayment(paymentID string, in PaymentUpdate) (*Payment, *Response, error)
+	DeletePayment(paymentID string) (*Response, error)
+	RestorePayment(paymentID string) (*Payment, *Response, error)
+	RefundPayment(paymentID string, in RefundInput) (*Payment, *Response, error)
+	ReceiveInCash(paymentID string, in ReceiveInCashInput) (*Payment, *Response, error)
+	UndoReceivedInCash(paymentID string) (*Payment, *Response, error)
+	ListPayments(filter PaymentFilter) (*PaymentPage, *Response, error)
+
+	// Installments (payment groups created with PaymentInput.InstallmentCount)
+	GetInstallment(installmentID string) (*Installment, *Response, error)
+	ListInstallmentPayments(installmentID string, filter PaymentFilter) (*PaymentPage, *Response, error)
+	CancelInstallmentPayments(installmentID string) (*Response, error) // deletes pending/overdue payments
+	RefundInstallment(installmentID string) (*Installment, *Response, error)
+	GetInstallmentPaymentBook(installmentID string) (*PaymentBook, *Response, error)
+
+	// Subscriptions
+	CreateSubscription(in SubscriptionInput) (*Subscription, *Response, error)
+	GetSubscription(subscriptionID string) (*Subscription, *Response, error)
+	UpdateSubscription(subscriptionID string, in SubscriptionUpdate) (*Subscription, *Response, error)
+	DeleteSubscription(subscriptionID string) (*Response, error)
+	ListSubscriptions(filter SubscriptionFilter) (*SubscriptionPage, *Response, error)
+	ListSubscriptionPayments(subscriptionID string, filter PaymentFilter) (*PaymentPage, *Response, error)
+
+	// Assets
+	GetDigitableLine(paymentID string) (*DigitableLine, *Response, error)
+	GetPixQrCode(paymentID string) (*PixQrCode, *Response, error)
+
+	// Webhooks
+	ParseWebhookEvent(body []byte) (*WebhookEvent, error)
+	CreateWebhookEndpoint(in WebhookEndpointInput) (*WebhookEndpoint, *Response, error)
+	GetWebhookEndpoint(endpointID string) (*WebhookEndpoint, *Response, error)
+	UpdateWebhookEndpoint(endpointID string, in WebhookEndpointInput) (*WebhookEndpoint, *Response, error)
+	DeleteWebhookEndpoint(endpointID string) (*Response, error)
+	ListWebhookEndpoints() ([]WebhookEndpoint, *Response, error)
+}
+
+// Factory builds a ChargeProvider bound to one billing integration (base_api + token).
+type Factory func(cfg *model.BillingIntegrationRow) (ChargeProvider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a provider implementation available under name.
+// It is meant to be called once per provider during startup (see cmd/api).
+func Register(name string, f Factory) {
+	name = NormalizeName(name)
+	if name == "" || f == nil {
+		panic("provider: Register requires a name and a factory")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[name]; dup {
+		panic("provider: Register called twice for " + name)
+	}
+	registry[name] = f
+}
+
+// Registered reports whether a provider implementation exists for name.
+func Registered(name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[NormalizeName(name)]
+	return ok
+}
+
+// New builds the ChargeProvider for a billing integration, picking the
+// implementation by cfg.Provider.
+func New(cfg *model.BillingIntegrationRow) (ChargeProvider, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("billing integration is nil")
+	}
+	name := NormalizeName(cfg.Provider)
+
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("provider %q is not supported", cfg.Provider)
+	}
+	return f(cfg)
+}
+
+// NewContext is New with the provider bound to ctx (see ChargeProvider.WithContext).
+func NewContext(ctx context.Context, cfg *model.BillingIntegrationRow) (ChargeProvider, error) {
+	p, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p.WithContext(ctx), nil
+}
+
+// Lookup builds a ChargeProvider without integration credentials. It is only
+// useful for stateless operations such as ParseWebhookEvent.
+func Lookup(name string) (ChargeProvider, error) {
+	return New(&model.BillingIntegrationRow{Provider: name})
+}
+
+// NormalizeName maps the provider values found in iam.fee_contracts and
+// iam.billing_integrations (e.g. "asaas", "ASSAS_PRD") to the registry key.
+func NormalizeName(p string) string {
+	p = strings.ToUpper(strings.TrimSpace(p))
+	if strings.HasPrefix(p, "ASSAS") || strings.HasPrefix(p, "ASAAS") {
+		return "ASAAS"
+	}
+	if i := strings.IndexByte(p, '_'); i > 0 {
+		return p[:i]
+	}
+	return p
+}
@@ -0,0 +1,25 @@
+package provider
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type writeTrackerKey struct{}
+
+// TrackWrites returns a context that records whether a request that may change provider
+// state (anything but a read) was sent through it, and a func reporting it. Idempotent
+// create endpoints use it to tell "failed before reaching the provider" (safe to run
+// again) from "the provider may have acted" (the answer must be kept).
+func TrackWrites(ctx context.Context) (context.Context, func() bool) {
+	sent := new(atomic.Bool)
+	return context.WithValue(ctx, writeTrackerKey{}, sent), sent.Load
+}
+
+// MarkWriteSent is called by implementations right before sending a write request.
+// No-op when ctx is not tracked.
+func MarkWriteSent(ctx context.Context) {
+	if sent, ok := ctx.Value(writeTrackerKey{}).(*atomic.Bool); ok {
+		sent.Store(true)
+	}
+}
@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrackWrites(t *testing.T) {
+	tests := []struct {
+		name string
+		mark func(tracked context.Context)
+		want bool
+	}{
+		{"nothing sent", func(context.Context) {}, false},
+		{"write sent", func(ctx context.Context) { MarkWriteSent(ctx) }, true},
+		{"write sent through a derived context", func(ctx context.Context) {
+			derived, cancel := context.WithCancel(ctx)
+			defer cancel()
+			MarkWriteSent(derived)
+		}, true},
+		{"write sent on another context", func(context.Context) { MarkWriteSent(context.Background()) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, sent := TrackWrites(context.Background())
+			tt.mark(ctx)
+			if got := sent(); got != tt.want {
+				t.Fatalf("sent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Response is the raw answer returned by the provider API.
+type Response struct {
+	StatusCode int
+	Body       []byte
+
+	// Errors are the errors parsed from a non-2xx Body (empty when the body has no known shape).
+	Errors []ErrorDetail
+}
+
+// ErrorDetail is one error reported by the provider.
+type ErrorDetail struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// OK reports whether the provider answered with a 2xx status.
+func (r *Response) OK() bool {
+	return r != nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// ErrorMessage summarizes a non-2xx answer: the provider error descriptions,
+// or the (truncated) body when none could be parsed.
+func (r *Response) ErrorMessage() string {
+	if r == nil {
+		return "no response"
+	}
+	if len(r.Errors) > 0 {
+		parts := make([]string, 0, len(r.Errors))
+		for _, e := range r.Errors {
+			if e.Code != "" {
+				parts = append(parts, e.Code+": "+e.Description)
+			} else {
+				parts = append(parts, e.Description)
+			}
+		}
+		return fmt.Sprintf("provider status %d: %s", r.StatusCode, strings.Join(parts, "; "))
+	}
+	body := string(r.Body)
+	if len(body) > 300 {
+		body = body[:300] + "…"
+	}
+	return fmt.Sprintf("provider status %d: %s", r.StatusCode, body)
+}
+
+// Discount, Interest and Fine are the financial settings shared by payments and subscriptions.
+type Discount struct {
+	Value            *float64
+	DueDateLimitDays *int32
+	Type             *string // FIXED | PERCENTAGE
+}
+
+type Interest struct {
+	Value *float64
+}
+
+type Fine struct {
+	Value *float64
+	Type  *string // FIXED | PERCENTAGE
+}
+
+// CustomerInput is used both to create and to (partially) update a customer.
+// Empty strings and nil pointers are not sent to the provider.
+type CustomerInput struct {
+	Name        string
+	CpfCnpj     string
+	Email       string
+	Phone       string
+	MobilePhone string
+
+	Address       string
+	AddressNumber string
+	Complement    string
+	Province      string
+	City          *int32
+	State         string
+	Country       string
+	PostalCode    string
+
+	AdditionalEmails  string
+	ExternalReference string
+	Observations      string
+
+	PersonType           string
+	NotificationDisabled *bool
+	Company              *bool
+	ForeignCustomer      *bool
+}
+
+// PaymentInput creates a single charge or an installment plan.
+type PaymentInput struct {
+	CustomerID                                 string
+	BillingType                                string // BOLETO | CREDIT_CARD | PIX | UNDEFINED
+	Value                                      float64
+	DueDate                                    string // YYYY-MM-DD
+	Description                                *string
+	DaysAfterDueDateToRegistrationCancellation *int32
+	ExternalReference                          *string
+
+	InstallmentCount *int32
+	TotalValue       *float64
+	InstallmentValue *float64
+
+	Discount      *Discount
+	Interest      *Interest
+	Fine          *Fine
+	PostalService *bool
+}
+
+// PaymentUpdate holds the mutable fields of an existing charge. Nil fields are left untouched.
+type PaymentUpdate struct {
+	BillingType       *string
+	Value             *float64
+	DueDate           *string
+	Description       *string
+	ExternalReference *string
+
+	Discount *Discount
+	Interest *Interest
+	Fine     *Fine
+}
+
+// RefundInput refunds a received payment. Value nil refunds the full amount.
+type RefundInput struct {
+	Value       *float64
+	Description *string
+}
+
+// ReceiveInCashInput confirms a payment received outside the provider (cash, bank transfer...).
+type ReceiveInCashInput struct {
+	PaymentDate    string // YYYY-MM-DD
+	Value          float64
+	NotifyCustomer *bool
+}
+
+// PaymentFilter selects payments in ListPayments.
+// Extra carries provider-specific filters that are forwarded as-is.
+type PaymentFilter struct {
+	CustomerID        string
+	SubscriptionID    string
+	InstallmentID     string
+	ExternalReference string
+	Status            string
+	Offset            int
+	Limit             int
+	Extra             map[string]string
+}
+
+// SubscriptionInput creates a recurring charge.
+type SubscriptionInput struct {
+	CustomerID        string
+	BillingType       string
+	Value             float64
+	NextDueDate       string // YYYY-MM-DD
+	Cycle             string // WEEKLY | BIWEEKLY | MONTHLY | BIMONTHLY | QUARTERLY | SEMIANNUALLY | YEARLY
+	Description       *string
+	EndDate           *string
+	MaxPayments       *int32
+	ExternalReference *string
+
+	Discount *Discount
+	Interest *Interest
+	Fine     *Fine
+}
+
+// SubscriptionUpdate holds the mutable fields of a subscription. Nil fields are left untouched.
+type SubscriptionUpdate struct {
+	BillingType           *string
+	Status                *string // ACTIVE | INACTIVE
+	Value                 *float64
+	NextDueDate           *string
+	Cycle                 *string
+	Description           *string
+	EndDate               *string
+	UpdatePendingPayments *bool
+	ExternalReference     *string
+
+	Discount *Discount
+	Interest *Interest
+	Fine     *Fine
+}
+
+// SubscriptionFilter selects subscriptions in ListSubscriptions.
+// Extra carries provider-specific filters that are forwarded as-is.
+type SubscriptionFilter struct {
+	CustomerID        string
+	ExternalReference string
+	Status            string
+	Offset            int
+	Limit             int
+	Extra             map[string]string
+}
+
+// Customer is the normalized customer returned by a provider.
+type Customer struct {
+	ID                string
+	Name              string
+	CpfCnpj           string
+	Email             string
+	MobilePhone       string
+	ExternalReference string
+	Deleted           bool
+	Raw               json.RawMessage
+}
+
+// Payment is the normalized charge returned by a provider.
+// Raw keeps the original provider object and is persisted as iam.charges.provider_payload.
+type Payment struct {
+	ID                string
+	DateCreated       string
+	CustomerID        string
+	SubscriptionID    string
+	InstallmentID     string
+	InstallmentNumber int32
+	Value             float64
+	NetValue          float64
+	Description       string
+	BillingType       string
+	Status            string
+	DueDate           string
+	OriginalDueDate   string
+	PaymentDate       string
+	InvoiceURL        string
+	InvoiceNumber     string
+	ExternalReference string
+	Deleted           bool
+	Raw               json.RawMessage
+}
+
+// PaymentPage is one page of ListPayments.
+type PaymentPage struct {
+	Data       []Payment
+	HasMore    bool
+	TotalCount int
+	Offset     int
+	Limit      int
+}
+
+// Subscription is the normalized subscription returned by a provider.
+type Subscription struct {
+	ID                string
+	DateCreated       string
+	CustomerID        string
+	BillingType       string
+	Cycle             string
+	Value             float64
+	NextDueDate       string
+	EndDate           string
+	Description       string
+	Status            string
+	ExternalReference string
+	Deleted           bool
+	Raw               json.RawMessage
+}
+
+// SubscriptionPage is one page of ListSubscriptions.
+type SubscriptionPage struct {
+	Data       []Subscription
+	HasMore    bool
+	TotalCount int
+	Offset     int
+	Limit      int
+}
+
+// Installment is the normalized installment plan (the group of payments created with
+// PaymentInput.InstallmentCount) returned by a provider.
+type Installment struct {
+	ID               string
+	DateCreated      string
+	CustomerID       string
+	BillingType      string
+	Value            float64 // total of the plan
+	NetValue         float64
+	PaymentValue     float64 // value of each installment
+	InstallmentCount int32
+	Description      string
+	Deleted          bool
+	Raw              json.RawMessage
+}
+
+// PaymentBook is the printable payment book (carnê) of an installment plan.
+type PaymentBook struct {
+	ContentType string
+	Content     []byte
+}
+
+// DigitableLine is the boleto "linha digitável" of a payment.
+type DigitableLine struct {
+	IdentificationField string
+	NossoNumero         string
+	BarCode             string
+}
+
+// PixQrCode is the Pix copy-and-paste payload and QR code image of a payment.
+type PixQrCode struct {
+	EncodedImage   string
+	Payload        string
+	ExpirationDate string
+}
+
+// WebhookEvent is the normalized webhook notification.
+// Payment is nil for events that are not about a charge; Subscription is set for
+// subscription events (SUBSCRIPTION_*).
+type WebhookEvent struct {
+	ID           string
+	Event        string
+	DateCreated  string
+	AccountID    string
+	Payment      *Payment
+	Subscription *Subscription
+}
+
+// WebhookEndpoint is a webhook registered in the provider account (where and which
+// events the provider delivers). Interrupted means the provider stopped delivering
+// after repeated failures; PenalizedRequests counts deliveries it is holding back.
+type WebhookEndpoint struct {
+	ID                string
+	Name              string
+	URL               string
+	Email             string
+	Enabled           bool
+	Interrupted       bool
+	PenalizedRequests int
+	HasAuthToken      bool
+	SendType          string
+	Events            []string
+	Raw               json.RawMessage
+}
+
+// WebhookEndpointInput creates or (partially) updates a webhook endpoint.
+// Empty strings, nil pointers and nil slices are not sent to the provider.
+type WebhookEndpointInput struct {
+	Name        string
+	URL         string
+	Email       string
+	Enabled     *bool
+	Interrupted *bool // false resumes an interrupted delivery queue
+	AuthToken   *string
+	SendType    string
+	Events      []string
+}
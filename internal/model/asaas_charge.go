@@ -68,7 +68,6 @@ type AsaasPaymentResponse struct {
 
 	InvoiceURL    string `json:"invoiceUrl"`
 	BankSlipURL   string `json:"bankSlipUrl"`
-	PaymentLink2  string `json:"paymentLink"` // duplicated field name workaround
 	InvoiceNumber string `json:"invoiceNumber"`
 
 	Deleted       bool `json:"deleted"`
@@ -0,0 +1,21 @@
+package model
+
+// AsaasInstallmentResponse models the installment (parcelamento) object returned by Asaas
+// /v3/installments: the group of payments created with installmentCount.
+type AsaasInstallmentResponse struct {
+	Object                string  `json:"object"` // "installment"
+	ID                    string  `json:"id"`
+	Value                 float64 `json:"value"`
+	NetValue              float64 `json:"netValue"`
+	PaymentValue          float64 `json:"paymentValue"`
+	InstallmentCount      int32   `json:"installmentCount"`
+	BillingType           string  `json:"billingType"`
+	PaymentDate           *string `json:"paymentDate,omitempty"`
+	Description           *string `json:"description,omitempty"`
+	ExpirationDay         int32   `json:"expirationDay"`
+	DateCreated           string  `json:"dateCreated"`
+	Customer              string  `json:"customer"`
+	PaymentLink           *string `json:"paymentLink,omitempty"`
+	TransactionReceiptURL *string `json:"transactionReceiptUrl,omitempty"`
+	Deleted               bool    `json:"deleted"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// AsaasReceiveInCashRequest is the payload to confirm that a charge was paid outside Asaas
+// (cash, bank transfer...). Value omitted uses the charge value.
+//
+// https://docs.asaas.com/reference/confirmar-recebimento-em-dinheiro
+type AsaasReceiveInCashRequest struct {
+	PaymentDate    string   `json:"paymentDate" example:"2026-01-10"` // YYYY-MM-DD
+	Value          *float64 `json:"value,omitempty" example:"129.9"`
+	NotifyCustomer *bool    `json:"notifyCustomer,omitempty"`
+}
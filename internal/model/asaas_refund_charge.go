@@ -0,0 +1,10 @@
+package model
+
+// AsaasRefundChargeRequest is the payload to refund a received charge (payment) in Asaas.
+// Value omitted refunds everything still refundable; several partial refunds are allowed.
+//
+// https://docs.asaas.com/reference/estornar-cobranca
+type AsaasRefundChargeRequest struct {
+	Value       *float64 `json:"value,omitempty" example:"50"`
+	Description *string  `json:"description,omitempty" example:"Pagamento em duplicidade"`
+}
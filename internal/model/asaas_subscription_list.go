@@ -0,0 +1,11 @@
+package model
+
+// AsaasSubscriptionsListResponse models the paginated list response from Asaas /v3/subscriptions.
+type AsaasSubscriptionsListResponse struct {
+	Object     string                      `json:"object"` // "list"
+	HasMore    bool                        `json:"hasMore"`
+	TotalCount int32                       `json:"totalCount"`
+	Limit      int32                       `json:"limit"`
+	Offset     int32                       `json:"offset"`
+	Data       []AsaasSubscriptionResponse `json:"data"`
+}
@@ -8,6 +8,7 @@ type AsaasWebhookEvent struct {
 	DateCreated string                `json:"dateCreated"` // Format: "2026-01-24 16:13:02"
 	Account     *AsaasWebhookAccount  `json:"account,omitempty"`
 	Payment     *AsaasPaymentResponse `json:"payment,omitempty"` // The payment object (when event is payment-related)
+	Subscription *AsaasSubscriptionResponse `json:"subscription,omitempty"` // The subscription object (SUBSCRIPTION_* events)
 }
 
 // AsaasWebhookAccount represents the account object in webhook events
@@ -28,6 +29,7 @@ const (
 	EventPaymentRestored          = "PAYMENT_RESTORED"
 	EventPaymentRefunded          = "PAYMENT_REFUNDED"
 	EventPaymentReceivedInCash    = "PAYMENT_RECEIVED_IN_CASH"
+	EventPaymentReceivedInCashUndone = "PAYMENT_RECEIVED_IN_CASH_UNDONE"
 	EventPaymentChargebackRequested = "PAYMENT_CHARGEBACK_REQUESTED"
 	EventPaymentChargebackDispute   = "PAYMENT_CHARGEBACK_DISPUTE"
 	EventPaymentAwaitingChargeback  = "PAYMENT_AWAITING_CHARGEBACK_REVERSAL"
@@ -35,4 +37,9 @@ const (
 	EventPaymentDunningRequested    = "PAYMENT_DUNNING_REQUESTED"
 	EventPaymentBankSlipViewed      = "PAYMENT_BANK_SLIP_VIEWED"
 	EventPaymentCheckoutViewed      = "PAYMENT_CHECKOUT_VIEWED"
+
+	EventSubscriptionCreated     = "SUBSCRIPTION_CREATED"
+	EventSubscriptionUpdated     = "SUBSCRIPTION_UPDATED"
+	EventSubscriptionInactivated = "SUBSCRIPTION_INACTIVATED"
+	EventSubscriptionDeleted     = "SUBSCRIPTION_DELETED"
 )
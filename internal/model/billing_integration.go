@@ -11,6 +11,17 @@ type BillingIntegrationRow struct {
 	Token              string `json:"token"`
 	IsActive           bool   `json:"is_active"`
 	IsDefault          bool   `json:"is_default"`
-	UpdatedAt          string `json:"updated_at"`
-	CreatedAt          string `json:"created_at"`
+
+	// WebhookSecret is the asaas-access-token expected on webhooks of this integration
+	// (falls back to ASAAS_WEBHOOK_SECRET when empty). Secret, like Token.
+	WebhookSecret *string `json:"webhook_secret,omitempty"`
+	// ProviderAccountID is the provider account behind the token (Asaas "account.id" in
+	// webhook events); used to find the integration of an incoming event.
+	ProviderAccountID *string `json:"provider_account_id,omitempty"`
+	// ProviderWebhookID is the webhook provisioned for this integration in the provider
+	// (see billing.ProvisionWebhook).
+	ProviderWebhookID *string `json:"provider_webhook_id,omitempty"`
+
+	UpdatedAt string `json:"updated_at"`
+	CreatedAt string `json:"created_at"`
 }
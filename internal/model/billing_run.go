@@ -0,0 +1,41 @@
+package model
+
+// Billing run actions reported per planned subscription/charge.
+const (
+	BillingRunActionPlanned  = "planned"  // dry run: would be created
+	BillingRunActionCreated  = "created"  // created in the provider during this run
+	BillingRunActionExisting = "existing" // already existed (same external reference): nothing created
+	BillingRunActionDeleted  = "deleted"  // one-off charge deliberately deleted: not issued again
+	BillingRunActionFailed   = "failed"
+)
+
+// BillingRunResult is the report of a billing run over a contract's service items.
+type BillingRunResult struct {
+	ContractID    string              `json:"contract_id"`
+	Provider      string              `json:"provider,omitempty" example:"ASAAS"`
+	DryRun        bool                `json:"dry_run"`
+	Subscriptions []BillingRunItem    `json:"subscriptions"`
+	Charges       []BillingRunItem    `json:"charges"`
+	Skipped       []BillingRunSkipped `json:"skipped"`
+}
+
+// BillingRunItem is one subscription (RECURRING group) or one-off charge (ONE_TIME item).
+type BillingRunItem struct {
+	ExternalReference string   `json:"external_reference" example:"fee_contract:2f8c7a1e-1111-2222-3333-444455556666:recurring:MONTHLY:10:BOLETO"`
+	Action            string   `json:"action" example:"created"`
+	ProviderID        string   `json:"provider_id,omitempty" example:"sub_VXJBYgP2u0eO"`
+	ServiceItemIDs    []string `json:"service_item_ids"`
+	PaymentMethod     string   `json:"payment_method" example:"BOLETO"`
+	Value             float64  `json:"value" example:"450"`
+	Cycle             string   `json:"cycle,omitempty" example:"MONTHLY"`
+	DueDate           string   `json:"due_date" example:"2026-01-10"` // nextDueDate for subscriptions
+	Description       string   `json:"description"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// BillingRunSkipped is a service item the engine could not bill automatically.
+type BillingRunSkipped struct {
+	ServiceItemID string `json:"service_item_id"`
+	Name          string `json:"name"`
+	Reason        string `json:"reason"`
+}
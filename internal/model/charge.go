@@ -0,0 +1,130 @@
+package model
+
+// ChargePaymentMethod is the provider-neutral payment method exposed by /v1/charges.
+type ChargePaymentMethod string
+
+const (
+	ChargePaymentMethodBoleto     ChargePaymentMethod = "BOLETO"
+	ChargePaymentMethodPix        ChargePaymentMethod = "PIX"
+	ChargePaymentMethodCreditCard ChargePaymentMethod = "CREDIT_CARD"
+	ChargePaymentMethodUndefined  ChargePaymentMethod = "UNDEFINED"
+)
+
+// ChargeStatus is the provider-neutral status exposed by /v1/charges.
+// The original provider status is kept in Charge.ProviderStatus.
+type ChargeStatus string
+
+const (
+	ChargeStatusPending    ChargeStatus = "PENDING"
+	ChargeStatusPaid       ChargeStatus = "PAID"
+	ChargeStatusOverdue    ChargeStatus = "OVERDUE"
+	ChargeStatusRefunded   ChargeStatus = "REFUNDED"
+	ChargeStatusProcessing ChargeStatus = "PROCESSING"
+	ChargeStatusCancelled  ChargeStatus = "CANCELLED"
+	ChargeStatusUnknown    ChargeStatus = "UNKNOWN"
+)
+
+// Charge is the normalized charge returned by /v1/charges (built from iam.charges).
+type Charge struct {
+	ID         string `json:"id,omitempty" example:"7b0c2c7e-9a3e-4f51-9a55-3c2b8c1d0e11"`
+	ContractID string `json:"contract_id"`
+	CompanyID  string `json:"company_id"`
+
+	Provider         string  `json:"provider" example:"ASAAS"`
+	ProviderChargeID string  `json:"provider_charge_id" example:"pay_080225913252"`
+	InstallmentID    *string `json:"installment_id,omitempty"`
+	SubscriptionID   *string `json:"subscription_id,omitempty"`
+
+	InstallmentNumber *int32              `json:"installment_number,omitempty"`
+	Value             float64             `json:"value" example:"129.9"`
+	NetValue          *float64            `json:"net_value,omitempty"`
+	Description       *string             `json:"description,omitempty"`
+	PaymentMethod     ChargePaymentMethod `json:"payment_method,omitempty" example:"BOLETO"`
+	Status            ChargeStatus        `json:"status" example:"PENDING"`
+	ProviderStatus    *string             `json:"provider_status,omitempty" example:"PENDING"`
+	DueDate           *string             `json:"due_date,omitempty" example:"2026-01-10"`
+	OriginalDueDate   *string             `json:"original_due_date,omitempty"`
+	InvoiceURL        *string             `json:"invoice_url,omitempty"`
+	InvoiceNumber     *string             `json:"invoice_number,omitempty"`
+	ExternalReference *string             `json:"external_reference,omitempty"`
+	DeletedAt         *string             `json:"deleted_at,omitempty"`
+	DeletedReason     *string             `json:"deleted_reason,omitempty"`
+	RefundedValue     *float64            `json:"refunded_value,omitempty"`
+	Refunds           []ChargeRefund      `json:"refunds,omitempty"`
+	UpdatedAt         *string             `json:"updated_at,omitempty"`
+}
+
+// ChargeListResponse is the paginated response of GET /v1/charges.
+type ChargeListResponse struct {
+	Data       []Charge `json:"data"`
+	TotalCount int64    `json:"total_count"`
+	Offset     int      `json:"offset"`
+	Limit      int      `json:"limit"`
+	HasMore    bool     `json:"has_more"`
+}
+
+// CreateChargeRequest is the body of POST /v1/charges.
+type CreateChargeRequest struct {
+	ContractID        string              `json:"contract_id" example:"2f8c7a1e-1111-2222-3333-444455556666"`
+	PaymentMethod     ChargePaymentMethod `json:"payment_method" example:"BOLETO"`
+	Value             float64             `json:"value" example:"129.9"`
+	DueDate           string              `json:"due_date" example:"2026-01-10"`
+	Description       *string             `json:"description,omitempty"`
+	ExternalReference *string             `json:"external_reference,omitempty"`
+
+	// Parcelamento (opcional)
+	InstallmentCount *int32   `json:"installment_count,omitempty"`
+	InstallmentValue *float64 `json:"installment_value,omitempty"`
+	TotalValue       *float64 `json:"total_value,omitempty"`
+}
+
+// Subscription is the normalized subscription returned by /v1/subscriptions.
+type Subscription struct {
+	ID                string              `json:"id" example:"sub_VXJBYgP2u0eO"`
+	ContractID        string              `json:"contract_id"`
+	CompanyID         string              `json:"company_id"`
+	Provider          string              `json:"provider" example:"ASAAS"`
+	PaymentMethod     ChargePaymentMethod `json:"payment_method,omitempty" example:"BOLETO"`
+	Value             float64             `json:"value" example:"129.9"`
+	Cycle             string              `json:"cycle" example:"MONTHLY"`
+	NextDueDate       string              `json:"next_due_date,omitempty" example:"2026-01-10"`
+	EndDate           *string             `json:"end_date,omitempty"`
+	Description       *string             `json:"description,omitempty"`
+	Status            string              `json:"status,omitempty" example:"ACTIVE"`
+	ExternalReference *string             `json:"external_reference,omitempty"`
+	Charges           []Charge            `json:"charges"`
+}
+
+// CreateSubscriptionRequest is the body of POST /v1/subscriptions.
+type CreateSubscriptionRequest struct {
+	ContractID        string              `json:"contract_id" example:"2f8c7a1e-1111-2222-3333-444455556666"`
+	PaymentMethod     ChargePaymentMethod `json:"payment_method" example:"BOLETO"`
+	Value             float64             `json:"value" example:"129.9"`
+	NextDueDate       string              `json:"next_due_date" example:"2026-01-10"`
+	Cycle             string              `json:"cycle" example:"MONTHLY"` // WEEKLY | BIWEEKLY | MONTHLY | BIMONTHLY | QUARTERLY | SEMIANNUALLY | YEARLY
+	Description       *string             `json:"description,omitempty"`
+	EndDate           *string             `json:"end_date,omitempty"`
+	MaxPayments       *int32              `json:"max_payments,omitempty"`
+	ExternalReference *string             `json:"external_reference,omitempty"` // default: fee_contract:{contract_id}:subscription:{cycle}:{payment_method}
+}
+
+// Customer is the normalized customer returned by /v1/customers.
+type Customer struct {
+	ID                string `json:"id" example:"cus_000005219613"`
+	CompanyID         string `json:"company_id"`
+	Provider          string `json:"provider" example:"ASAAS"`
+	Name              string `json:"name"`
+	CpfCnpj           string `json:"cpf_cnpj,omitempty"`
+	Email             string `json:"email,omitempty"`
+	MobilePhone       string `json:"mobile_phone,omitempty"`
+	ExternalReference string `json:"external_reference,omitempty"`
+	Deleted           bool   `json:"deleted"`
+}
+
+// EnsureCustomerRequest is the body of POST /v1/customers.
+// Either contract_id or company_id + accounting_office_id must be informed.
+type EnsureCustomerRequest struct {
+	ContractID         string `json:"contract_id,omitempty"`
+	CompanyID          string `json:"company_id,omitempty"`
+	AccountingOfficeID string `json:"accounting_office_id,omitempty"`
+}
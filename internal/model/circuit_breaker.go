@@ -0,0 +1,27 @@
+package model
+
+// Circuit breaker states (see internal/integrations/asaas/breaker.go).
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitBreakerCounts is the number of breakers in each state, shown on the public /health.
+type CircuitBreakerCounts struct {
+	Closed   int `json:"closed"`
+	Open     int `json:"open"`
+	HalfOpen int `json:"half_open"`
+}
+
+// CircuitBreakerStatus is the state of the breaker of one billing integration, listed on
+// GET /v1/admin/circuit-breakers.
+type CircuitBreakerStatus struct {
+	IntegrationID       string  `json:"integration_id,omitempty"`
+	BaseAPI             string  `json:"base_api"`
+	State               string  `json:"state"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	OpenedAt            *string `json:"opened_at,omitempty"` // RFC3339
+	RetryAt             *string `json:"retry_at,omitempty"`  // when the next probe is allowed (open)
+	LastError           *string `json:"last_error,omitempty"`
+}
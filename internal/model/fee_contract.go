@@ -40,3 +40,18 @@ type FeeContractServiceItemRow struct {
 	PaymentMethod *string  `json:"payment_method"`
 }
 
+
+// FeeContractSubscriptionRow links a provider subscription to a contract in iam.fee_contract_subscriptions.
+type FeeContractSubscriptionRow struct {
+	ContractID             string   `json:"contract_id"`
+	Provider               string   `json:"provider"`
+	ProviderSubscriptionID string   `json:"provider_subscription_id"`
+	ExternalReference      *string  `json:"external_reference,omitempty"`
+	Status                 *string  `json:"status,omitempty"`
+	Value                  *float64 `json:"value,omitempty"`
+	Cycle                  *string  `json:"cycle,omitempty"`
+	NextDueDate            *string  `json:"next_due_date,omitempty"` // YYYY-MM-DD
+
+	// LastEventAt is the dateCreated of the last SUBSCRIPTION_* webhook applied (ISO 8601).
+	LastEventAt *string `json:"last_event_at,omitempty"`
+}
@@ -4,6 +4,7 @@ import "encoding/json"
 
 // IamChargeRow is the row we persist in iam.charges (provider-agnostic).
 type IamChargeRow struct {
+	ID                 string `json:"id,omitempty"` // generated by the database
 	TenantID           string `json:"tenant_id"`
 	AccountingOfficeID string `json:"accounting_office_id"`
 	CompanyID          string `json:"company_id"`
@@ -26,7 +27,33 @@ type IamChargeRow struct {
 	InvoiceNumber     *string  `json:"invoice_number,omitempty"`
 	ExternalReference *string  `json:"external_reference,omitempty"`
 
+	CreatedAt *string `json:"created_at,omitempty"` // ISO 8601 timestamp
 	UpdatedAt *string `json:"updated_at,omitempty"` // ISO 8601 timestamp
 
+	// LastEventAt is the dateCreated of the last webhook event applied to the charge.
+	// Only webhooks set it; older events are ignored (see billing.StaleEvent).
+	LastEventAt *string `json:"last_event_at,omitempty"` // ISO 8601 timestamp
+
+	// DeletedAt is set when the charge is deleted in the provider (soft delete: the row keeps
+	// its contract context so PAYMENT_RESTORED can bring it back). Upserts never clear it;
+	// see supabase.RestoreChargeByProviderIDContext.
+	DeletedAt     *string `json:"deleted_at,omitempty"` // ISO 8601 timestamp
+	DeletedReason *string `json:"deleted_reason,omitempty"`
+
+	// RefundedValue and Refunds are the refund history (POST /v1/asaas/charges/{id}/refund).
+	// Webhooks and syncs leave them nil, so an upsert never erases the history.
+	RefundedValue *float64       `json:"refunded_value,omitempty"`
+	Refunds       []ChargeRefund `json:"refunds,omitempty"` // jsonb
+
 	ProviderPayload json.RawMessage `json:"provider_payload,omitempty"`
 }
+
+// ChargeRefund is one refund requested for a charge (an item of iam.charges.refunds).
+type ChargeRefund struct {
+	Value          float64 `json:"value" example:"50"`
+	Description    *string `json:"description,omitempty"`
+	ProviderStatus string  `json:"provider_status,omitempty" example:"REFUND_REQUESTED"` // payment status answered by the provider
+	RequestedBy    string  `json:"requested_by,omitempty"`                               // authenticated subject (user id or service name)
+	RequestID      string  `json:"request_id,omitempty"`
+	CreatedAt      string  `json:"created_at" example:"2026-01-10T12:00:00Z"` // ISO 8601 timestamp
+}
@@ -0,0 +1,30 @@
+package model
+
+// Idempotency key states in iam.idempotency_keys.
+const (
+	IdempotencyStatusInProgress = "in_progress"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyKeyRow represents a row in iam.idempotency_keys: one Idempotency-Key
+// per scope (method + path) with the hash of the original request and its stored response.
+type IdempotencyKeyRow struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	Scope          string `json:"scope"` // e.g. "POST /v1/asaas/charges"
+	RequestHash    string `json:"request_hash"`
+	Status         string `json:"status"` // in_progress | completed
+
+	// LockedUntil is the lease of an in_progress row (ISO 8601): after it, a retry of the
+	// same request may take the key over (the owner crashed).
+	LockedUntil *string `json:"locked_until,omitempty"`
+	// LockOwner is the random token of the request holding the lease; renewing, completing
+	// and releasing the key only apply while it still matches.
+	LockOwner *string `json:"lock_owner,omitempty"`
+
+	ResponseStatus      *int    `json:"response_status,omitempty"`
+	ResponseBody        *string `json:"response_body,omitempty"`
+	ResponseContentType *string `json:"response_content_type,omitempty"`
+
+	CreatedAt *string `json:"created_at,omitempty"` // ISO 8601 timestamp
+	ExpiresAt string  `json:"expires_at"`           // ISO 8601 timestamp
+}
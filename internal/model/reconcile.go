@@ -0,0 +1,79 @@
+package model
+
+// Drift kinds found by the reconciler (iam.charges vs provider).
+const (
+	DriftMissing = "missing" // payment exists in the provider but not in iam.charges
+	DriftStatus  = "status"
+	DriftValue   = "value"
+	DriftDueDate = "due_date"
+	DriftDeleted = "deleted" // soft-deleted in iam.charges but active in the provider
+)
+
+// Actions taken on a drifted charge.
+const (
+	DriftActionFixed      = "fixed"
+	DriftActionInserted   = "inserted"
+	DriftActionUnresolved = "unresolved" // missing locally and no contract could be resolved
+	DriftActionFailed     = "failed"
+	DriftActionPlanned    = "planned" // dry run
+)
+
+// ReconcileDriftItem is one charge that differs between iam.charges and the provider.
+type ReconcileDriftItem struct {
+	PaymentID string   `json:"payment_id"`
+	Kinds     []string `json:"kinds"`
+	Local     *string  `json:"local,omitempty"` // "status=PENDING value=100.00 due_date=2026-01-10"
+	Remote    string   `json:"remote"`
+	Action    string   `json:"action"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ReconcileIntegrationReport is the reconciliation of one billing integration.
+type ReconcileIntegrationReport struct {
+	IntegrationID      string               `json:"integration_id"`
+	AccountingOfficeID string               `json:"accounting_office_id"`
+	Provider           string               `json:"provider"`
+	Scanned            int                  `json:"scanned"`
+	Drifted            int                  `json:"drifted"`
+	Fixed              int                  `json:"fixed"`
+	Inserted           int                  `json:"inserted"`
+	Unresolved         int                  `json:"unresolved"`
+	Failed             int                  `json:"failed"`
+	Error              string               `json:"error,omitempty"`
+	Items              []ReconcileDriftItem `json:"items"`
+}
+
+// ReconcileReport is the drift report of one reconciliation run,
+// stored in logs.charges_reconcile_runs.
+type ReconcileReport struct {
+	ID           string                       `json:"id,omitempty"` // generated by the database
+	Trigger      string                       `json:"trigger"`      // schedule | manual
+	DryRun       bool                         `json:"dry_run"`
+	StartedAt    string                       `json:"started_at"`
+	FinishedAt   string                       `json:"finished_at"`
+	CreatedFrom  string                       `json:"created_from"` // dateCreated window (YYYY-MM-DD)
+	DueFrom      string                       `json:"due_from"`     // dueDate window (YYYY-MM-DD)
+	DueTo        string                       `json:"due_to"`
+	Scanned      int                          `json:"scanned"`
+	Drifted      int                          `json:"drifted"`
+	Fixed        int                          `json:"fixed"`
+	Inserted     int                          `json:"inserted"`
+	Unresolved   int                          `json:"unresolved"`
+	Failed       int                          `json:"failed"`
+	Integrations []ReconcileIntegrationReport `json:"integrations"`
+}
+
+// ReconcileRequest is the body of POST /v1/admin/reconcile.
+type ReconcileRequest struct {
+	IntegrationID string `json:"integration_id,omitempty"` // default: every active integration
+	DryRun        bool   `json:"dry_run"`
+}
+
+// ReconcileRunPage is a page of drift reports, newest first.
+type ReconcileRunPage struct {
+	Data       []ReconcileReport `json:"data"`
+	TotalCount int64             `json:"total_count"`
+	Offset     int               `json:"offset"`
+	Limit      int               `json:"limit"`
+	HasMore    bool              `json:"has_more"`
+}
@@ -2,10 +2,32 @@ package model
 
 import "encoding/json"
 
+// Webhook event log statuses (logs.asaas_webhook_events.status).
+const (
+	// WebhookLogStatusDeadLetter marks an event the worker pool gave up on after
+	// WEBHOOK_MAX_ATTEMPTS attempts; it needs manual resolution.
+	WebhookLogStatusDeadLetter = "dead_letter"
+
+	// WebhookLogStatusIgnoredStale marks an event older than the state already stored for
+	// the charge (out-of-order delivery); it was acknowledged and not applied.
+	WebhookLogStatusIgnoredStale = "ignored_stale"
+
+	// WebhookLogStatusIgnoredForeign marks an event whose charge or contract belongs to
+	// another accounting office than the integration it was delivered for; it was
+	// acknowledged and not applied.
+	WebhookLogStatusIgnoredForeign = "ignored_foreign"
+)
+
 // AsaasWebhookEventLog represents a row in logs.asaas_webhook_events.
 // It is written whenever an Asaas webhook event cannot be processed successfully,
 // providing full context for debugging and manual resolution.
 type AsaasWebhookEventLog struct {
+	// ID is generated by the database (used to replay the event).
+	ID string `json:"id,omitempty"`
+
+	// EventID is the Asaas event identifier ("evt_..."), when known.
+	EventID string `json:"event_id,omitempty"`
+
 	// EventType is the event name sent by Asaas (e.g. "PAYMENT_CREATED").
 	EventType string `json:"event_type,omitempty"`
 
@@ -18,8 +40,12 @@ type AsaasWebhookEventLog struct {
 	// ExternalReference is the external_reference field from the payment object.
 	ExternalReference string `json:"external_reference,omitempty"`
 
+	// IntegrationID is the billing integration the event was delivered for (see
+	// AsaasWebhookInboxRow.IntegrationID); kept so a replay is bound to the same office.
+	IntegrationID string `json:"integration_id,omitempty"`
+
 	// ErrorStage indicates where in the processing pipeline the failure occurred.
-	// Expected values: "decode_payload", "resolve_contract_context", "upsert_charge".
+	// Expected values: "decode_payload", "resolve_contract_context", "upsert_charge", "stale_event", "integration_mismatch".
 	ErrorStage string `json:"error_stage,omitempty"`
 
 	// ErrorMessage is the human-readable description of what went wrong.
@@ -28,4 +54,23 @@ type AsaasWebhookEventLog struct {
 	// RawPayload is the full JSON body received from the Asaas webhook.
 	// Stored as JSONB for reprocessing and forensic analysis.
 	RawPayload json.RawMessage `json:"raw_payload,omitempty"`
+
+	// Status is set for events that left the async queue (e.g. "dead_letter", "ignored_stale").
+	// Empty for failures logged straight from the HTTP request (e.g. decode_payload).
+	Status string `json:"status,omitempty"`
+
+	// Attempts is how many times the worker pool tried to process the event.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Resolved is set once a replay (POST /v1/admin/webhooks/replay or the
+	// replay-webhooks command) processes the event successfully.
+	Resolved   bool    `json:"resolved,omitempty"`
+	ResolvedAt *string `json:"resolved_at,omitempty"` // ISO 8601 timestamp
+
+	// ReplayAttempts counts replays of this row; LastReplayError is the error of the last one.
+	ReplayAttempts  int     `json:"replay_attempts,omitempty"`
+	LastReplayAt    *string `json:"last_replay_at,omitempty"` // ISO 8601 timestamp
+	LastReplayError *string `json:"last_replay_error,omitempty"`
+
+	CreatedAt *string `json:"created_at,omitempty"` // ISO 8601 timestamp, set by the database
 }
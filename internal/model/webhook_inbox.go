@@ -0,0 +1,37 @@
+package model
+
+import "encoding/json"
+
+// Webhook inbox states (logs.asaas_webhook_inbox.status).
+const (
+	WebhookInboxPending    = "pending"    // waiting for a worker (first try or retry after backoff)
+	WebhookInboxProcessing = "processing" // claimed by a worker until locked_until
+	WebhookInboxDone       = "done"
+	WebhookInboxDead       = "dead" // exhausted its attempts; copied to logs.asaas_webhook_events
+)
+
+// AsaasWebhookInboxRow represents a row in logs.asaas_webhook_inbox: the durable queue
+// the webhook endpoint writes to before answering Asaas.
+type AsaasWebhookInboxRow struct {
+	ID        string `json:"id,omitempty"` // generated by the database
+	Provider  string `json:"provider"`
+	EventID   string `json:"event_id,omitempty"`
+	EventType string `json:"event_type,omitempty"`
+	PaymentID string `json:"payment_id,omitempty"`
+
+	// IntegrationID is the billing integration that authenticated the delivery (URL or
+	// account.id); empty when none was identified. Processing only touches its office.
+	IntegrationID string `json:"integration_id,omitempty"`
+
+	RawPayload json.RawMessage `json:"raw_payload"`
+
+	Status        string  `json:"status"`
+	Attempts      int     `json:"attempts"`
+	NextAttemptAt string  `json:"next_attempt_at"`        // ISO 8601 timestamp
+	LockedUntil   *string `json:"locked_until,omitempty"` // ISO 8601 timestamp (status=processing)
+	LastError     *string `json:"last_error,omitempty"`
+	LastStage     *string `json:"last_stage,omitempty"`
+
+	CreatedAt *string `json:"created_at,omitempty"`
+	UpdatedAt *string `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import "encoding/json"
+
+// Webhook journal outcomes (logs.asaas_webhook_journal.outcome).
+const (
+	WebhookOutcomeApplied        = "applied"         // charge updated in iam.charges
+	WebhookOutcomeDuplicate      = "duplicate"       // event id already processed: no side effects
+	WebhookOutcomeIgnoredStale   = "ignored_stale"   // older than the stored charge state
+	WebhookOutcomeIgnoredForeign = "ignored_foreign" // charge/contract of another office than the integration
+	WebhookOutcomeSkipped        = "skipped"         // nothing to apply (e.g. event without payment)
+	WebhookOutcomeRetrying       = "retrying"        // failed, another attempt is scheduled
+	WebhookOutcomeDeadLetter     = "dead_letter"     // failed on its last attempt
+	WebhookOutcomeFailed         = "failed"          // replay failed
+	WebhookOutcomeRejected       = "rejected"        // invalid payload, answered 400
+)
+
+// Webhook journal sources.
+const (
+	WebhookSourceDelivery = "webhook" // received on /asaas/feecharges
+	WebhookSourceReplay   = "replay"  // POST /v1/admin/webhooks/replay or replay-webhooks
+)
+
+// WebhookJournalEntry represents a row in logs.asaas_webhook_journal: every received
+// event with its processing outcome, latency and the charge status transition it caused.
+// Rows of queued deliveries are keyed by inbox_id and updated after each attempt.
+type WebhookJournalEntry struct {
+	ID      string  `json:"id,omitempty"`       // generated by the database
+	InboxID *string `json:"inbox_id,omitempty"` // logs.asaas_webhook_inbox.id (deliveries)
+
+	Provider          string  `json:"provider" example:"ASAAS"`
+	Source            string  `json:"source" example:"webhook"`
+	EventID           string  `json:"event_id,omitempty" example:"evt_05b708f961d739ea7eba7e4db318f621"`
+	EventType         string  `json:"event_type,omitempty" example:"PAYMENT_RECEIVED"`
+	PaymentID         string  `json:"payment_id,omitempty" example:"pay_080225913252"`
+	SubscriptionID    string  `json:"subscription_id,omitempty"`
+	ExternalReference string  `json:"external_reference,omitempty"`
+	CompanyID         *string `json:"company_id,omitempty"`
+	ContractID        *string `json:"contract_id,omitempty"`
+
+	Outcome    string  `json:"outcome" example:"applied"`
+	Attempts   int     `json:"attempts" example:"1"`
+	StatusFrom *string `json:"status_from,omitempty" example:"PENDING"`
+	StatusTo   *string `json:"status_to,omitempty" example:"RECEIVED"`
+
+	ErrorStage   *string `json:"error_stage,omitempty"`
+	ErrorMessage *string `json:"error_message,omitempty"`
+
+	EventCreatedAt *string `json:"event_created_at,omitempty"` // provider dateCreated (ISO 8601)
+	ReceivedAt     *string `json:"received_at,omitempty"`      // ISO 8601 timestamp
+	ProcessedAt    *string `json:"processed_at,omitempty"`     // ISO 8601 timestamp (last attempt)
+	LatencyMs      *int64  `json:"latency_ms,omitempty"`       // received → processed
+	ProcessingMs   *int64  `json:"processing_ms,omitempty"`    // duration of the last attempt
+
+	RawPayload json.RawMessage `json:"raw_payload,omitempty" swaggertype:"object"`
+
+	CreatedAt *string `json:"created_at,omitempty"` // ISO 8601 timestamp
+}
+
+// WebhookJournalPage is a page of journal entries, oldest first.
+type WebhookJournalPage struct {
+	Data       []WebhookJournalEntry `json:"data"`
+	TotalCount int64                 `json:"total_count"`
+	Offset     int                   `json:"offset"`
+	Limit      int                   `json:"limit"`
+	HasMore    bool                  `json:"has_more"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// AsaasWebhookProcessedEventRow represents a row in logs.asaas_webhook_processed_events:
+// one row per (provider, event_id) successfully applied. Asaas delivers at-least-once,
+// so a later delivery of the same event id is acknowledged without side effects.
+type AsaasWebhookProcessedEventRow struct {
+	Provider  string `json:"provider"`
+	EventID   string `json:"event_id"` // Asaas event id ("evt_...")
+	EventType string `json:"event_type,omitempty"`
+	PaymentID string `json:"payment_id,omitempty"`
+
+	ProcessedAt *string `json:"processed_at,omitempty"` // ISO 8601 timestamp, set by the database
+}
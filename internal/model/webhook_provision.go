@@ -0,0 +1,52 @@
+package model
+
+// Actions of WebhookProvisionResult.
+const (
+	WebhookProvisionCreated     = "created"
+	WebhookProvisionUpdated     = "updated"
+	WebhookProvisionUnchanged   = "unchanged"
+	WebhookProvisionWouldCreate = "would_create" // dry run
+	WebhookProvisionWouldUpdate = "would_update" // dry run
+)
+
+// WebhookProvisionRequest is the body of POST /v1/admin/billing-integrations/{id}/webhook.
+type WebhookProvisionRequest struct {
+	// BaseURL is the public URL of this service (default WEBHOOK_PUBLIC_URL).
+	BaseURL string `json:"base_url,omitempty" example:"https://api-charges.carteiracontabil.com"`
+	// Email receives the provider's failure notifications (default ASAAS_WEBHOOK_EMAIL or the current one).
+	Email string `json:"email,omitempty" example:"financeiro@empresa.com"`
+	// Resume restarts a delivery queue interrupted by the provider.
+	Resume bool `json:"resume"`
+	// Force re-sends the whole configuration (including the auth token) even without drift.
+	Force  bool `json:"force"`
+	DryRun bool `json:"dry_run"`
+}
+
+// WebhookEndpointStatus is the webhook as registered in the provider.
+type WebhookEndpointStatus struct {
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	URL               string   `json:"url"`
+	Email             string   `json:"email"`
+	Enabled           bool     `json:"enabled"`
+	Interrupted       bool     `json:"interrupted"`
+	PenalizedRequests int      `json:"penalized_requests"`
+	HasAuthToken      bool     `json:"has_auth_token"`
+	SendType          string   `json:"send_type"`
+	Events            []string `json:"events"`
+}
+
+// WebhookProvisionResult reports the state of an integration's webhook and what provisioning did.
+type WebhookProvisionResult struct {
+	IntegrationID string                 `json:"integration_id"`
+	Provider      string                 `json:"provider"`
+	DryRun        bool                   `json:"dry_run"`
+	Action        string                 `json:"action"`
+	ExpectedURL   string                 `json:"expected_url"`
+	Changes       []string               `json:"changes,omitempty"` // fields that differ from the expected configuration
+	Interrupted   bool                   `json:"interrupted"`       // provider stopped delivering (see resume)
+	Penalized     bool                   `json:"penalized"`         // provider is holding back deliveries after failures
+	Resumed       bool                   `json:"resumed"`
+	Warnings      []string               `json:"warnings,omitempty"`
+	Webhook       *WebhookEndpointStatus `json:"webhook,omitempty"`
+}
@@ -0,0 +1,43 @@
+package model
+
+// Webhook replay outcomes reported per row of logs.asaas_webhook_events.
+const (
+	WebhookReplayResolved = "resolved"
+	WebhookReplayFailed   = "failed"
+	WebhookReplayPlanned  = "planned" // dry run: would be replayed
+)
+
+// WebhookReplayRequest selects logged events to run through the webhook pipeline again.
+// All filters are optional and combined with AND.
+type WebhookReplayRequest struct {
+	IDs             []string `json:"ids,omitempty"`                                      // logs.asaas_webhook_events.id
+	Stage           string   `json:"stage,omitempty" example:"resolve_contract_context"` // error_stage
+	PaymentID       string   `json:"payment_id,omitempty" example:"pay_080225913252"`
+	From            string   `json:"from,omitempty" example:"2026-01-01"`         // created_at >= (YYYY-MM-DD or RFC 3339)
+	To              string   `json:"to,omitempty" example:"2026-01-31T23:59:59Z"` // created_at <= (YYYY-MM-DD = the whole day, or RFC 3339)
+	IncludeResolved bool     `json:"include_resolved,omitempty"`
+	Limit           *int     `json:"limit,omitempty" example:"50"` // 1..500, default 50
+	DryRun          bool     `json:"dry_run,omitempty"`
+}
+
+// WebhookReplayResult is the report of a replay run.
+type WebhookReplayResult struct {
+	DryRun   bool                `json:"dry_run"`
+	Selected int                 `json:"selected"`
+	Resolved int                 `json:"resolved"`
+	Failed   int                 `json:"failed"`
+	Items    []WebhookReplayItem `json:"items"`
+}
+
+// WebhookReplayItem is the outcome of replaying one logged event.
+type WebhookReplayItem struct {
+	LogID          string `json:"log_id"`
+	EventID        string `json:"event_id,omitempty"`
+	EventType      string `json:"event_type,omitempty" example:"PAYMENT_CREATED"`
+	PaymentID      string `json:"payment_id,omitempty"`
+	ErrorStage     string `json:"error_stage,omitempty"` // stage of the original failure
+	Result         string `json:"result" example:"resolved"`
+	Stage          string `json:"stage,omitempty"` // stage where the replay failed
+	Error          string `json:"error,omitempty"`
+	ReplayAttempts int    `json:"replay_attempts"`
+}
@@ -0,0 +1,417 @@
+// Package reconcile compares iam.charges with the provider and fixes the drift
+// left by lost webhooks.
+//
+// For each active billing integration the reconciler pages through the provider's
+// payments created recently (dateCreated window) and due around today (dueDate window),
+// diffs them against iam.charges by provider_charge_id and fixes status, value and due
+// date. Payments missing locally are inserted when their contract can be resolved
+// (billing.ResolveContractContextFromPayment). Every run produces a drift report,
+// stored in logs.charges_reconcile_runs.
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/billing"
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// ErrRunning is returned by Run when another reconciliation is in progress in this instance.
+var ErrRunning = errors.New("reconciliation already running")
+
+// Triggers of a run (model.ReconcileReport.Trigger).
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Config controls the reconciler (see config.ReconcileConfig for the env vars).
+type Config struct {
+	Enabled         bool
+	Interval        time.Duration
+	CreatedLookback time.Duration // payments created in the last CreatedLookback
+	DueLookback     time.Duration // payments due between now-DueLookback …
+	DueLookahead    time.Duration // … and now+DueLookahead
+}
+
+// Options selects what one run reconciles.
+type Options struct {
+	IntegrationID string // empty = every active integration
+	DryRun        bool   // report only, change nothing
+	Trigger       string
+}
+
+// lookupBatch is the number of provider ids per iam.charges query.
+const lookupBatch = 100
+
+var (
+	cfgMu      sync.RWMutex
+	defaultCfg = Config{CreatedLookback: 7 * 24 * time.Hour, DueLookback: 30 * 24 * time.Hour, DueLookahead: 30 * 24 * time.Hour}
+
+	running sync.Mutex
+)
+
+// Scheduler runs the reconciler every Config.Interval.
+type Scheduler struct {
+	wg sync.WaitGroup
+}
+
+// Start stores cfg for Run and, when cfg.Enabled, runs the reconciler every cfg.Interval
+// (first run one minute after startup) until ctx is cancelled.
+func Start(ctx context.Context, cfg Config) *Scheduler {
+	cfgMu.Lock()
+	defaultCfg = cfg
+	cfgMu.Unlock()
+
+	s := &Scheduler{}
+	if !cfg.Enabled {
+		log.Printf("[reconcile] scheduler disabled (RECONCILE_ENABLED=false)")
+		return s
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		timer := time.NewTimer(time.Minute)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if _, err := Run(ctx, Options{Trigger: TriggerSchedule}); err != nil {
+				log.Printf("[reconcile] ERROR scheduled run: %v", err)
+			}
+			timer.Reset(cfg.Interval)
+		}
+	}()
+	log.Printf("[reconcile] scheduler started: interval=%s created_lookback=%s due_window=-%s/+%s",
+		cfg.Interval, cfg.CreatedLookback, cfg.DueLookback, cfg.DueLookahead)
+	return s
+}
+
+// Wait blocks until a scheduled run in progress has finished.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// Run reconciles the selected integrations once and returns (and stores) the drift report.
+// Only one run at a time per instance: concurrent calls get ErrRunning.
+func Run(ctx context.Context, opts Options) (*model.ReconcileReport, error) {
+	if !running.TryLock() {
+		return nil, ErrRunning
+	}
+	defer running.Unlock()
+
+	cfgMu.RLock()
+	cfg := defaultCfg
+	cfgMu.RUnlock()
+
+	var integrations []model.BillingIntegrationRow
+	if opts.IntegrationID != "" {
+		integration, err := supabase.GetBillingIntegrationByIDContext(ctx, opts.IntegrationID)
+		if err != nil || integration == nil {
+			return nil, billing.ErrIntegrationNotFound
+		}
+		integrations = append(integrations, *integration)
+	} else {
+		var err error
+		if integrations, err = supabase.ListActiveBillingIntegrationsContext(ctx, ""); err != nil {
+			return nil, fmt.Errorf("list billing integrations: %w", err)
+		}
+	}
+
+	now := time.Now()
+	report := &model.ReconcileReport{
+		Trigger:      opts.Trigger,
+		DryRun:       opts.DryRun,
+		StartedAt:    now.UTC().Format(time.RFC3339),
+		CreatedFrom:  now.Add(-cfg.CreatedLookback).Format("2006-01-02"),
+		DueFrom:      now.Add(-cfg.DueLookback).Format("2006-01-02"),
+		DueTo:        now.Add(cfg.DueLookahead).Format("2006-01-02"),
+		Integrations: make([]model.ReconcileIntegrationReport, 0, len(integrations)),
+	}
+	if report.Trigger == "" {
+		report.Trigger = TriggerManual
+	}
+
+	for i := range integrations {
+		ir := reconcileIntegration(ctx, &integrations[i], report, opts.DryRun)
+		report.Scanned += ir.Scanned
+		report.Drifted += ir.Drifted
+		report.Fixed += ir.Fixed
+		report.Inserted += ir.Inserted
+		report.Unresolved += ir.Unresolved
+		report.Failed += ir.Failed
+		report.Integrations = append(report.Integrations, ir)
+	}
+	report.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+
+	log.Printf("[reconcile] run finished: trigger=%s dry_run=%v integrations=%d scanned=%d drifted=%d fixed=%d inserted=%d unresolved=%d failed=%d (%s)",
+		report.Trigger, report.DryRun, len(report.Integrations), report.Scanned, report.Drifted, report.Fixed,
+		report.Inserted, report.Unresolved, report.Failed, time.Since(now).Round(time.Millisecond))
+
+	if id, err := supabase.InsertReconcileRunContext(ctx, *report); err != nil {
+		log.Printf("[reconcile] ERROR storing drift report: %v", err)
+	} else {
+		report.ID = id
+	}
+	return report, nil
+}
+
+func reconcileIntegration(ctx context.Context, integration *model.BillingIntegrationRow, report *model.ReconcileReport, dryRun bool) model.ReconcileIntegrationReport {
+	ir := model.ReconcileIntegrationReport{
+		IntegrationID:      integration.ID,
+		AccountingOfficeID: integration.AccountingOfficeID,
+		Provider:           provider.NormalizeName(integration.Provider),
+		Items:              []model.ReconcileDriftItem{},
+	}
+	p, err := provider.NewContext(ctx, integration)
+	if err != nil {
+		ir.Error = err.Error()
+		return ir
+	}
+	ir.Provider = p.Name()
+
+	// Both windows, deduplicated by payment id (a recent payment due soon appears in both).
+	// listedAt dates the snapshot: webhooks applied after it are newer than what was listed.
+	listedAt := time.Now()
+	payments := map[string]provider.Payment{}
+	var order []string
+	for _, extra := range []map[string]string{
+		{"dateCreated[ge]": report.CreatedFrom},
+		{"dueDate[ge]": report.DueFrom, "dueDate[le]": report.DueTo},
+	} {
+		it := provider.Payments(p, provider.PaymentFilter{Extra: extra})
+		for it.Next() {
+			pay := it.Value()
+			if _, seen := payments[pay.ID]; !seen && strings.TrimSpace(pay.ID) != "" {
+				payments[pay.ID] = pay
+				order = append(order, pay.ID)
+			}
+		}
+		if err := it.Err(); err != nil {
+			ir.Error = err.Error()
+			log.Printf("[reconcile] ERROR listing payments: integration_id=%s err=%v", integration.ID, err)
+			return ir
+		}
+	}
+	ir.Scanned = len(order)
+
+	for start := 0; start < len(order); start += lookupBatch {
+		ids := order[start:min(start+lookupBatch, len(order))]
+		locals, err := supabase.ListChargesByProviderIDsContext(ctx, p.Name(), integration.AccountingOfficeID, ids)
+		if err != nil {
+			ir.Error = err.Error()
+			log.Printf("[reconcile] ERROR loading iam.charges: integration_id=%s err=%v", integration.ID, err)
+			return ir
+		}
+		byID := make(map[string]*model.IamChargeRow, len(locals))
+		for i := range locals {
+			byID[locals[i].ProviderChargeID] = &locals[i]
+		}
+		for _, id := range ids {
+			item, drifted := reconcilePayment(ctx, p.Name(), integration.AccountingOfficeID, byID[id], payments[id], listedAt, dryRun)
+			if !drifted {
+				continue
+			}
+			ir.Drifted++
+			switch item.Action {
+			case model.DriftActionFixed:
+				ir.Fixed++
+			case model.DriftActionInserted:
+				ir.Inserted++
+			case model.DriftActionUnresolved:
+				ir.Unresolved++
+			case model.DriftActionFailed:
+				ir.Failed++
+			}
+			ir.Items = append(ir.Items, item)
+		}
+	}
+
+	log.Printf("[reconcile] integration done: integration_id=%s office=%s scanned=%d drifted=%d fixed=%d inserted=%d unresolved=%d failed=%d",
+		integration.ID, integration.AccountingOfficeID, ir.Scanned, ir.Drifted, ir.Fixed, ir.Inserted, ir.Unresolved, ir.Failed)
+	return ir
+}
+
+// reconcilePayment diffs one provider payment against its iam.charges row (nil when missing)
+// and fixes it unless dryRun. drifted=false when both agree.
+//
+// Payments are listed once per run (at listedAt), so writes take the charge lock and re-read
+// the row first, like the webhook: a row changed since the listing (last_event_at or
+// updated_at not older than listedAt, see billing.ChangedSince) is left alone. Otherwise the
+// listing is the truth and no status precedence applies, so a status can also go down.
+// Missing payments are only inserted into contracts of the integration's office (officeID).
+func reconcilePayment(ctx context.Context, providerName, officeID string, local *model.IamChargeRow, pay provider.Payment, listedAt time.Time, dryRun bool) (item model.ReconcileDriftItem, drifted bool) {
+	item = model.ReconcileDriftItem{PaymentID: pay.ID, Remote: describePayment(pay)}
+
+	if local == nil {
+		item.Kinds = []string{model.DriftMissing}
+		if pay.Deleted {
+			return item, false
+		}
+		contract, err := billing.ResolveContractContextFromPayment(ctx, &pay)
+		if err != nil || contract == nil {
+			item.Action = model.DriftActionUnresolved
+			if err != nil {
+				item.Error = err.Error()
+			}
+			return item, true
+		}
+		if contract.AccountingOfficeID != officeID {
+			// Same rule as the webhook (ignoreForeignEvent): one provider account never
+			// writes charges into another office's contract.
+			item.Action = model.DriftActionUnresolved
+			item.Error = fmt.Sprintf("contract %s belongs to office %s, not to the integration's office %s", contract.ID, contract.AccountingOfficeID, officeID)
+			log.Printf("[reconcile] missing charge not inserted: payment=%s %s", pay.ID, item.Error)
+			return item, true
+		}
+		if dryRun {
+			item.Action = model.DriftActionPlanned
+			return item, true
+		}
+
+		defer billing.ChargeLocks.Lock(providerName + ":" + pay.ID)()
+		current, err := loadCharge(ctx, providerName, officeID, pay.ID)
+		if err != nil {
+			item.Action = model.DriftActionFailed
+			item.Error = err.Error()
+			return item, true
+		}
+		if current != nil {
+			log.Printf("[reconcile] missing charge inserted meanwhile, skipped: payment=%s", pay.ID)
+			return item, false
+		}
+		if err := supabase.UpsertChargesContext(ctx, billing.ChargeRowsForContract(providerName, contract, []provider.Payment{pay})); err != nil {
+			item.Action = model.DriftActionFailed
+			item.Error = err.Error()
+			log.Printf("[reconcile] ERROR inserting missing charge: payment=%s contract_id=%s err=%v", pay.ID, contract.ID, err)
+			return item, true
+		}
+		item.Action = model.DriftActionInserted
+		log.Printf("[reconcile] missing charge inserted: payment=%s contract_id=%s status=%s", pay.ID, contract.ID, pay.Status)
+		return item, true
+	}
+
+	item.Kinds = chargeDrift(local, pay)
+	if len(item.Kinds) == 0 {
+		return item, false
+	}
+	desc := describeCharge(local)
+	item.Local = &desc
+	if dryRun {
+		item.Action = model.DriftActionPlanned
+		return item, true
+	}
+
+	defer billing.ChargeLocks.Lock(providerName + ":" + pay.ID)()
+	current, err := loadCharge(ctx, providerName, officeID, pay.ID)
+	if err != nil {
+		item.Action = model.DriftActionFailed
+		item.Error = err.Error()
+		return item, true
+	}
+	if current == nil {
+		log.Printf("[reconcile] charge removed meanwhile, skipped: payment=%s", pay.ID)
+		return item, false
+	}
+	if changed, reason := billing.ChangedSince(current, listedAt); changed {
+		log.Printf("[reconcile] charge updated since the listing, skipped: payment=%s — %s", pay.ID, reason)
+		return item, false
+	}
+	if item.Kinds = chargeDrift(current, pay); len(item.Kinds) == 0 {
+		return item, false
+	}
+	desc = describeCharge(current)
+
+	row := billing.ChargeRowFromPayment(providerName, pay)
+	row.TenantID = current.TenantID
+	row.AccountingOfficeID = current.AccountingOfficeID
+	row.CompanyID = current.CompanyID
+	row.ContractID = current.ContractID
+	row.LastEventAt = current.LastEventAt
+	if row.InstallmentNumber == nil {
+		row.InstallmentNumber = current.InstallmentNumber
+	}
+	if row.ProviderInstallmentID == nil {
+		row.ProviderInstallmentID = current.ProviderInstallmentID
+	}
+	if row.ProviderSubscriptionID == nil {
+		row.ProviderSubscriptionID = current.ProviderSubscriptionID
+	}
+	if err := supabase.UpsertChargesContext(ctx, []model.IamChargeRow{row}); err != nil {
+		item.Action = model.DriftActionFailed
+		item.Error = err.Error()
+		log.Printf("[reconcile] ERROR fixing charge: payment=%s err=%v", pay.ID, err)
+		return item, true
+	}
+	if current.DeletedAt != nil && !pay.Deleted {
+		// Restored in the provider and the PAYMENT_RESTORED webhook was lost.
+		if err := supabase.RestoreChargeByProviderIDContext(ctx, providerName, pay.ID); err != nil {
+			item.Action = model.DriftActionFailed
+			item.Error = err.Error()
+			log.Printf("[reconcile] ERROR restoring charge: payment=%s err=%v", pay.ID, err)
+			return item, true
+		}
+	}
+	item.Action = model.DriftActionFixed
+	log.Printf("[reconcile] charge fixed: payment=%s drift=%v local={%s} remote={%s}", pay.ID, item.Kinds, desc, item.Remote)
+	return item, true
+}
+
+// loadCharge re-reads one iam.charges row of the office (nil when there is none).
+func loadCharge(ctx context.Context, providerName, officeID, paymentID string) (*model.IamChargeRow, error) {
+	rows, err := supabase.ListChargesByProviderIDsContext(ctx, providerName, officeID, []string{paymentID})
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// chargeDrift lists the fields where iam.charges differs from the provider.
+func chargeDrift(local *model.IamChargeRow, pay provider.Payment) []string {
+	var kinds []string
+	if !strings.EqualFold(deref(local.Status), strings.TrimSpace(pay.Status)) {
+		kinds = append(kinds, model.DriftStatus)
+	}
+	if math.Abs(local.Value-pay.Value) >= 0.005 {
+		kinds = append(kinds, model.DriftValue)
+	}
+	due := deref(local.DueDate)
+	if len(due) > 10 {
+		due = due[:10] // timestamp → YYYY-MM-DD
+	}
+	if due != strings.TrimSpace(pay.DueDate) {
+		kinds = append(kinds, model.DriftDueDate)
+	}
+	if local.DeletedAt != nil && !pay.Deleted {
+		kinds = append(kinds, model.DriftDeleted)
+	}
+	return kinds
+}
+
+func describeCharge(c *model.IamChargeRow) string {
+	return fmt.Sprintf("status=%s value=%.2f due_date=%s", deref(c.Status), c.Value, deref(c.DueDate))
+}
+
+func describePayment(p provider.Payment) string {
+	return fmt.Sprintf("status=%s value=%.2f due_date=%s", p.Status, p.Value, p.DueDate)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(*s)
+}
@@ -1,19 +1,27 @@
 package server
 
 import (
+	"net/http"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"github.com/seuuser/charges-service/internal/auth"
 	"github.com/seuuser/charges-service/internal/config"
 	"github.com/seuuser/charges-service/internal/handler"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
 
-func RegisterRoutes(r *chi.Mux, cfg config.Config) {
+func RegisterRoutes(r *chi.Mux, cfg config.Config, authn *auth.Authenticator) {
+	// Create endpoints accept an Idempotency-Key header (retries never bill twice).
+	idempotent := func(h http.HandlerFunc) http.HandlerFunc {
+		return handler.Idempotent(cfg.IdempotencyTTL, cfg.IdempotencyLockTimeout, h)
+	}
+
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.CorsAllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Origin", "X-Requested-With", "X-Client-Info", "apikey", "asaas-access-token"},
-		ExposedHeaders:   []string{"X-Total-Count", "Rate-Limit-Limit", "Rate-Limit-Remaining", "Rate-Limit-Reset"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Origin", "X-Requested-With", "X-Client-Info", "apikey", "asaas-access-token", "Idempotency-Key", "X-Admin-Token", "X-API-Key"},
+		ExposedHeaders:   []string{"X-Total-Count", "Rate-Limit-Limit", "Rate-Limit-Remaining", "Rate-Limit-Reset", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -27,20 +35,65 @@ func RegisterRoutes(r *chi.Mux, cfg config.Config) {
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 
 	// Asaas Webhook (fee charges)
-	r.Post("/asaas/feecharges", handler.ReceiveAsaasWebhook)
-
-	// Asaas (initial)
-	r.Post("/v1/asaas/customers", handler.CreateAsaasCustomer)
-	r.Post("/v1/asaas/charges", handler.CreateAsaasCharge)
-	r.Post("/v1/asaas/subscriptions", handler.CreateAsaasSubscription)
-	r.Put("/v1/asaas/subscriptions/{id}", handler.UpdateAsaasSubscription)
-	r.Get("/v1/asaas/charges", handler.ListAsaasCharges)
-	r.Put("/v1/asaas/charges/{id}", handler.UpdateAsaasCharge)
-	r.Delete("/v1/asaas/charges/{id}", handler.DeleteAsaasCharge)
-	r.Get("/v1/asaas/charges/{id}/digitable-line", handler.GetAsaasChargeDigitableLine)
-	r.Get("/v1/asaas/charges/{id}/pix-qrcode", handler.GetAsaasChargePixQrCode)
-	r.Get("/v1/asaas/customers/by-company", handler.GetAsaasCustomerByCompanyID)
-	r.Get("/v1/asaas/customers/{id}", handler.GetAsaasCustomerByID)
-	r.Put("/v1/asaas/customers/by-company", handler.UpdateAsaasCustomerByCompanyID)
-	r.Put("/v1/asaas/customers/{id}", handler.UpdateAsaasCustomerByID)
+	webhookAuth := handler.AsaasWebhookAuth{Secret: cfg.Webhook.Secret, Strict: cfg.Webhook.Strict}
+	r.Post("/asaas/feecharges", handler.ReceiveAsaasWebhook(webhookAuth))
+	r.Post("/asaas/feecharges/{integration_id}", handler.ReceiveAsaasWebhookForIntegration(webhookAuth))
+
+	// Admin (support tooling, X-Admin-Token)
+	r.Route("/v1/admin", func(r chi.Router) {
+		r.Use(handler.RequireAdminToken(cfg.AdminAPIToken))
+		r.Post("/webhooks/replay", handler.ReplayWebhookEvents)
+		r.Get("/webhooks/events", handler.ListWebhookJournal)
+		r.Get("/billing-integrations/{id}/webhook", handler.GetBillingIntegrationWebhook)
+		r.Post("/billing-integrations/{id}/webhook", handler.ProvisionBillingIntegrationWebhook)
+		r.Post("/reconcile", handler.RunReconciliation)
+		r.Get("/reconcile/runs", handler.ListReconciliationRuns)
+		r.Get("/circuit-breakers", handler.ListCircuitBreakers)
+	})
+
+	// Tenant API (Supabase JWT or X-API-Key). Handlers reject cross-tenant access.
+	r.Group(func(r chi.Router) {
+		r.Use(handler.RequireAuth(authn))
+
+		// Provider-neutral API (normalized JSON built from iam.charges)
+		r.Get("/v1/charges", handler.ListCharges)
+		r.Post("/v1/charges", idempotent(handler.CreateCharge))
+		r.Get("/v1/charges/{id}", handler.GetCharge)
+		r.Post("/v1/subscriptions", idempotent(handler.CreateSubscription))
+		r.Get("/v1/subscriptions/{id}", handler.GetSubscription)
+		r.Get("/v1/subscriptions/{id}/charges", handler.ListSubscriptionCharges)
+		r.Get("/v1/customers", handler.GetCustomer)
+		r.Post("/v1/customers", idempotent(handler.EnsureCustomer))
+
+		// Billing engine (charges generated from fee_contract_service_items)
+		r.Post("/v1/contracts/{id}/billing/generate", handler.GenerateContractBilling)
+
+		// Asaas (initial)
+		r.Post("/v1/asaas/customers", idempotent(handler.CreateAsaasCustomer))
+		r.Post("/v1/asaas/charges", idempotent(handler.CreateAsaasCharge))
+		r.Post("/v1/asaas/subscriptions", idempotent(handler.CreateAsaasSubscription))
+		r.Get("/v1/asaas/subscriptions", handler.ListAsaasSubscriptions)
+		r.Get("/v1/asaas/subscriptions/{id}", handler.GetAsaasSubscription)
+		r.Put("/v1/asaas/subscriptions/{id}", handler.UpdateAsaasSubscription)
+		r.Delete("/v1/asaas/subscriptions/{id}", handler.DeleteAsaasSubscription)
+		r.Get("/v1/asaas/subscriptions/{id}/payments", handler.ListAsaasSubscriptionPayments)
+		r.Get("/v1/asaas/charges", handler.ListAsaasCharges)
+		r.Put("/v1/asaas/charges/{id}", handler.UpdateAsaasCharge)
+		r.Delete("/v1/asaas/charges/{id}", handler.DeleteAsaasCharge)
+		r.Post("/v1/asaas/charges/{id}/restore", handler.RestoreAsaasCharge)
+		r.Post("/v1/asaas/charges/{id}/refund", idempotent(handler.RefundAsaasCharge))
+		r.Post("/v1/asaas/charges/{id}/receive-in-cash", idempotent(handler.ReceiveAsaasChargeInCash))
+		r.Post("/v1/asaas/charges/{id}/undo-received-in-cash", handler.UndoAsaasChargeReceivedInCash)
+		r.Get("/v1/asaas/charges/{id}/digitable-line", handler.GetAsaasChargeDigitableLine)
+		r.Get("/v1/asaas/charges/{id}/pix-qrcode", handler.GetAsaasChargePixQrCode)
+		r.Get("/v1/asaas/installments/{id}", handler.GetAsaasInstallment)
+		r.Get("/v1/asaas/installments/{id}/payments", handler.ListAsaasInstallmentPayments)
+		r.Delete("/v1/asaas/installments/{id}/payments", handler.CancelAsaasInstallmentPayments)
+		r.Post("/v1/asaas/installments/{id}/refund", idempotent(handler.RefundAsaasInstallment))
+		r.Get("/v1/asaas/installments/{id}/payment-book", handler.GetAsaasInstallmentPaymentBook)
+		r.Get("/v1/asaas/customers/by-company", handler.GetAsaasCustomerByCompanyID)
+		r.Get("/v1/asaas/customers/{id}", handler.GetAsaasCustomerByID)
+		r.Put("/v1/asaas/customers/by-company", handler.UpdateAsaasCustomerByCompanyID)
+		r.Put("/v1/asaas/customers/{id}", handler.UpdateAsaasCustomerByID)
+	})
 }
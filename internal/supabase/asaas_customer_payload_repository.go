@@ -1,6 +1,7 @@
 package supabase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -15,15 +16,15 @@ type CompanyAsaasCustomerPayload struct {
 	NotificationDisabled bool   `json:"notificationDisabled"`
 }
 
-// GetCompanyAsaasCustomerPayload loads minimal company data needed to create an Asaas customer.
+// GetCompanyAsaasCustomerPayloadContext loads minimal company data needed to create an Asaas customer.
 // It uses RPC in public schema because company schema might not be exposed in PostgREST.
-func GetCompanyAsaasCustomerPayload(companyID string) (*CompanyAsaasCustomerPayload, error) {
+func GetCompanyAsaasCustomerPayloadContext(ctx context.Context, companyID string) (*CompanyAsaasCustomerPayload, error) {
 	companyID = strings.TrimSpace(companyID)
 	if companyID == "" {
 		return nil, fmt.Errorf("company_id is required")
 	}
 
-	raw, err := RpcPublic("rpc_get_company_asaas_customer_payload", map[string]any{
+	raw, err := RpcPublicContext(ctx, "rpc_get_company_asaas_customer_payload", map[string]any{
 		"p_company_id": companyID,
 	})
 	if err != nil {
@@ -1,16 +1,17 @@
 package supabase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
 )
 
-// GetCompanyAsaasCustomerID returns the current Asaas customer id for a company,
+// GetCompanyAsaasCustomerIDContext returns the current Asaas customer id for a company,
 // scoped by the company's CURRENT tenant (resolved inside the RPC).
-func GetCompanyAsaasCustomerID(companyID string) (string, error) {
-	raw, err := RpcPublic("rpc_get_company_asaas_customer_id", map[string]any{
+func GetCompanyAsaasCustomerIDContext(ctx context.Context, companyID string) (string, error) {
+	raw, err := RpcPublicContext(ctx, "rpc_get_company_asaas_customer_id", map[string]any{
 		"p_company_id": companyID,
 	})
 	if err != nil {
@@ -32,9 +33,9 @@ func GetCompanyAsaasCustomerID(companyID string) (string, error) {
 	return strings.Trim(s, `"`), nil
 }
 
-// UpsertCompanyAsaasIntegration stores the Asaas customer id for the company (tenant is derived inside the RPC).
-func UpsertCompanyAsaasIntegration(companyID string, asaasCustomerID string) error {
-	_, err := RpcPublic("rpc_upsert_company_asaas_integration", map[string]any{
+// UpsertCompanyAsaasIntegrationContext stores the Asaas customer id for the company (tenant is derived inside the RPC).
+func UpsertCompanyAsaasIntegrationContext(ctx context.Context, companyID string, asaasCustomerID string) error {
+	_, err := RpcPublicContext(ctx, "rpc_upsert_company_asaas_integration", map[string]any{
 		"p_company_id":        companyID,
 		"p_asaas_customer_id": asaasCustomerID,
 	})
@@ -1,6 +1,7 @@
 package supabase
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -8,14 +9,17 @@ import (
 	"github.com/supabase-community/postgrest-go"
 )
 
-// GetBillingIntegrationForOffice loads the billing integration configuration for a given office/provider.
+// billingIntegrationColumns is the column list read for model.BillingIntegrationRow.
+const billingIntegrationColumns = "id, accounting_office_id, provider, environment, base_api, token, is_active, is_default, webhook_secret, provider_account_id, provider_webhook_id, updated_at, created_at"
+
+// GetBillingIntegrationForOfficeContext loads the billing integration configuration for a given office/provider.
 // It reads from schema `iam` (configured in InitClient via SUPABASE_SCHEMA).
 //
 // Rule:
 // - Always returns ONE active config (is_active = true)
 // - If multiple exist (e.g. HML/PRD), prefer is_default=true (you can enforce this in UI).
-func GetBillingIntegrationForOffice(accountingOfficeID string, provider string) (*model.BillingIntegrationRow, error) {
-	c := GetClient()
+func GetBillingIntegrationForOfficeContext(ctx context.Context, accountingOfficeID string, provider string) (*model.BillingIntegrationRow, error) {
+	c := iamDB(ctx)
 	if c == nil {
 		return nil, fmt.Errorf("supabase client não inicializado")
 	}
@@ -33,7 +37,7 @@ func GetBillingIntegrationForOffice(accountingOfficeID string, provider string)
 	var rows []model.BillingIntegrationRow
 	_, err := c.
 		From("billing_integrations").
-		Select("id, accounting_office_id, provider, environment, base_api, token, is_active, is_default, updated_at, created_at", "exact", false).
+		Select(billingIntegrationColumns, "exact", false).
 		Eq("accounting_office_id", accountingOfficeID).
 		// provider might be stored with different casing in DB; be case-insensitive here.
 		Ilike("provider", provider).
@@ -54,12 +58,12 @@ func GetBillingIntegrationForOffice(accountingOfficeID string, provider string)
 	return &rows[0], nil
 }
 
-// GetBillingIntegrationForOfficeAndEnvironment loads the active billing integration configuration
+// GetBillingIntegrationForOfficeAndEnvironmentContext loads the active billing integration configuration
 // for a given office/provider/environment (iam.billing_integrations).
 //
-// It follows the same ordering rule as GetBillingIntegrationForOffice: prefer is_default=true, then most recent.
-func GetBillingIntegrationForOfficeAndEnvironment(accountingOfficeID string, provider string, environment string) (*model.BillingIntegrationRow, error) {
-	c := GetClient()
+// It follows the same ordering rule as GetBillingIntegrationForOfficeContext: prefer is_default=true, then most recent.
+func GetBillingIntegrationForOfficeAndEnvironmentContext(ctx context.Context, accountingOfficeID string, provider string, environment string) (*model.BillingIntegrationRow, error) {
+	c := iamDB(ctx)
 	if c == nil {
 		return nil, fmt.Errorf("supabase client não inicializado")
 	}
@@ -81,7 +85,7 @@ func GetBillingIntegrationForOfficeAndEnvironment(accountingOfficeID string, pro
 	var rows []model.BillingIntegrationRow
 	_, err := c.
 		From("billing_integrations").
-		Select("id, accounting_office_id, provider, environment, base_api, token, is_active, is_default, updated_at, created_at", "exact", false).
+		Select(billingIntegrationColumns, "exact", false).
 		Eq("accounting_office_id", accountingOfficeID).
 		// provider might be stored with different casing in DB; be case-insensitive here.
 		Ilike("provider", provider).
@@ -103,10 +107,10 @@ func GetBillingIntegrationForOfficeAndEnvironment(accountingOfficeID string, pro
 	return &rows[0], nil
 }
 
-// GetBillingIntegrationByID loads a billing integration by id (iam.billing_integrations).
+// GetBillingIntegrationByIDContext loads a billing integration by id (iam.billing_integrations).
 // Useful when the contract explicitly selects a billing_integration_id.
-func GetBillingIntegrationByID(id string) (*model.BillingIntegrationRow, error) {
-	c := GetClient()
+func GetBillingIntegrationByIDContext(ctx context.Context, id string) (*model.BillingIntegrationRow, error) {
+	c := iamDB(ctx)
 	if c == nil {
 		return nil, fmt.Errorf("supabase client não inicializado")
 	}
@@ -119,7 +123,7 @@ func GetBillingIntegrationByID(id string) (*model.BillingIntegrationRow, error)
 	var rows []model.BillingIntegrationRow
 	_, err := c.
 		From("billing_integrations").
-		Select("id, accounting_office_id, provider, environment, base_api, token, is_active, is_default, updated_at, created_at", "exact", false).
+		Select(billingIntegrationColumns, "exact", false).
 		Eq("id", id).
 		Eq("is_active", "true").
 		Limit(1, "").
@@ -132,3 +136,95 @@ func GetBillingIntegrationByID(id string) (*model.BillingIntegrationRow, error)
 	}
 	return &rows[0], nil
 }
+
+// GetBillingIntegrationByProviderAccountContext finds the active integration of a provider account
+// (provider_account_id, e.g. the Asaas "account.id" sent in webhook events).
+// Returns (nil, nil) when no integration has that account.
+func GetBillingIntegrationByProviderAccountContext(ctx context.Context, provider, accountID string) (*model.BillingIntegrationRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase client não inicializado")
+	}
+
+	provider = strings.ToUpper(strings.TrimSpace(provider))
+	accountID = strings.TrimSpace(accountID)
+	if provider == "" || accountID == "" {
+		return nil, nil
+	}
+
+	var rows []model.BillingIntegrationRow
+	_, err := c.
+		From("billing_integrations").
+		Select(billingIntegrationColumns, "exact", false).
+		Ilike("provider", provider).
+		Eq("provider_account_id", accountID).
+		Eq("is_active", "true").
+		Order("is_default", &postgrest.OrderOpts{Ascending: false, NullsFirst: false}).
+		Order("updated_at", &postgrest.OrderOpts{Ascending: false, NullsFirst: false}).
+		Limit(1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// UpdateBillingIntegrationWebhookContext stores the webhook settings of an integration
+// (webhook_secret / provider_webhook_id). Empty values are left untouched.
+func UpdateBillingIntegrationWebhookContext(ctx context.Context, id, webhookSecret, providerWebhookID string) error {
+	c := iamDB(ctx)
+	if c == nil {
+		return fmt.Errorf("supabase client não inicializado")
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return fmt.Errorf("billing_integration_id vazio")
+	}
+
+	fields := map[string]any{}
+	if s := strings.TrimSpace(webhookSecret); s != "" {
+		fields["webhook_secret"] = s
+	}
+	if s := strings.TrimSpace(providerWebhookID); s != "" {
+		fields["provider_webhook_id"] = s
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	_, _, err := c.
+		From("billing_integrations").
+		Update(fields, "minimal", "").
+		Eq("id", id).
+		Execute()
+	return err
+}
+
+// ListActiveBillingIntegrationsContext lists every active integration (is_active = true),
+// optionally restricted to one provider (case-insensitive). Used by background jobs.
+func ListActiveBillingIntegrationsContext(ctx context.Context, provider string) ([]model.BillingIntegrationRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase client não inicializado")
+	}
+
+	q := c.
+		From("billing_integrations").
+		Select(billingIntegrationColumns, "exact", false).
+		Eq("is_active", "true")
+	if provider = strings.ToUpper(strings.TrimSpace(provider)); provider != "" {
+		q = q.Ilike("provider", provider)
+	}
+
+	var rows []model.BillingIntegrationRow
+	_, err := q.
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
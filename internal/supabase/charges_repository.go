@@ -1,16 +1,20 @@
 package supabase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/seuuser/charges-service/internal/model"
+	"github.com/supabase-community/postgrest-go"
 )
 
-// UpsertCharges stores provider-agnostic charges into iam.charges.
+// UpsertChargesContext stores provider-agnostic charges into iam.charges.
 // Requires a unique constraint matching (tenant_id, provider, provider_charge_id).
-func UpsertCharges(rows []model.IamChargeRow) error {
-	c := GetIAMClient()
+func UpsertChargesContext(ctx context.Context, rows []model.IamChargeRow) error {
+	c := iamDB(ctx)
 	if c == nil {
 		return fmt.Errorf("supabase iam client não inicializado")
 	}
@@ -29,9 +33,31 @@ func UpsertCharges(rows []model.IamChargeRow) error {
 	return nil
 }
 
-// GetChargeByProviderID retrieves a charge from iam.charges by provider and provider_charge_id.
-func GetChargeByProviderID(provider, providerChargeID string) (*model.IamChargeRow, error) {
-	c := GetIAMClient()
+// UpsertChargesReturningContext behaves like UpsertChargesContext but returns the stored rows
+// (including the generated id), for endpoints that answer with our own representation.
+func UpsertChargesReturningContext(ctx context.Context, rows []model.IamChargeRow) ([]model.IamChargeRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase iam client não inicializado")
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	var stored []model.IamChargeRow
+	_, err := c.
+		From("charges").
+		Upsert(rows, "tenant_id,provider,provider_charge_id", "representation", "").
+		ExecuteTo(&stored)
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// GetChargeByProviderIDContext retrieves a charge from iam.charges by provider and provider_charge_id.
+func GetChargeByProviderIDContext(ctx context.Context, provider, providerChargeID string) (*model.IamChargeRow, error) {
+	c := iamDB(ctx)
 	if c == nil {
 		return nil, fmt.Errorf("supabase iam client não inicializado")
 	}
@@ -55,10 +81,10 @@ func GetChargeByProviderID(provider, providerChargeID string) (*model.IamChargeR
 	return &charge, nil
 }
 
-// GetChargeByProviderIDAndOffice retrieves a charge from iam.charges by provider, provider_charge_id and accounting_office_id.
+// GetChargeByProviderIDAndOfficeContext retrieves a charge from iam.charges by provider, provider_charge_id and accounting_office_id.
 // This avoids ambiguity across offices/tenants when the same provider_charge_id exists elsewhere.
-func GetChargeByProviderIDAndOffice(provider, providerChargeID, accountingOfficeID string) (*model.IamChargeRow, error) {
-	c := GetIAMClient()
+func GetChargeByProviderIDAndOfficeContext(ctx context.Context, provider, providerChargeID, accountingOfficeID string) (*model.IamChargeRow, error) {
+	c := iamDB(ctx)
 	if c == nil {
 		return nil, fmt.Errorf("supabase iam client não inicializado")
 	}
@@ -83,22 +109,205 @@ func GetChargeByProviderIDAndOffice(provider, providerChargeID, accountingOffice
 	return &charge, nil
 }
 
-// DeleteChargeByProviderID deletes a charge from iam.charges by provider and provider_charge_id.
-func DeleteChargeByProviderID(provider, providerChargeID string) error {
-	c := GetIAMClient()
+// SoftDeleteChargeByProviderIDContext marks a charge as deleted (deleted_at, deleted_reason)
+// keeping the row and its contract context. A charge already deleted keeps its first reason.
+func SoftDeleteChargeByProviderIDContext(ctx context.Context, provider, providerChargeID, reason string) error {
+	c := iamDB(ctx)
 	if c == nil {
 		return fmt.Errorf("supabase iam client não inicializado")
 	}
 
+	now := time.Now().UTC().Format(time.RFC3339)
 	_, _, err := c.
 		From("charges").
-		Delete("", "").
+		Update(map[string]any{
+			"deleted_at":     now,
+			"deleted_reason": reason,
+			"updated_at":     now,
+		}, "minimal", "").
 		Eq("provider", provider).
 		Eq("provider_charge_id", providerChargeID).
+		Is("deleted_at", "null").
 		Execute()
 	if err != nil {
-		return fmt.Errorf("failed to delete charge: %w", err)
+		return fmt.Errorf("failed to soft delete charge: %w", err)
+	}
+	return nil
+}
+
+// RestoreChargeByProviderIDContext clears the soft delete of a charge (deleted_at, deleted_reason).
+func RestoreChargeByProviderIDContext(ctx context.Context, provider, providerChargeID string) error {
+	c := iamDB(ctx)
+	if c == nil {
+		return fmt.Errorf("supabase iam client não inicializado")
 	}
 
+	_, _, err := c.
+		From("charges").
+		Update(map[string]any{
+			"deleted_at":     nil,
+			"deleted_reason": nil,
+			"updated_at":     time.Now().UTC().Format(time.RFC3339),
+		}, "minimal", "").
+		Eq("provider", provider).
+		Eq("provider_charge_id", providerChargeID).
+		Not("deleted_at", "is", "null").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to restore charge: %w", err)
+	}
 	return nil
 }
+
+// ChargeListFilter selects rows in ListChargesContext. Empty fields are ignored.
+type ChargeListFilter struct {
+	ContractID             string
+	CompanyID              string
+	ProviderSubscriptionID string
+	ExternalReference      string
+	Status                 string
+	IncludeDeleted         bool // also list soft-deleted charges (deleted_at set)
+	Offset                 int
+	Limit                  int
+
+	// Scoped restricts the rows to TenantIDs or AccountingOfficeIDs (callers bound to
+	// tenants). A scoped filter with no ids matches nothing.
+	Scoped              bool
+	TenantIDs           []string
+	AccountingOfficeIDs []string
+}
+
+// ListChargesContext lists iam.charges ordered by due_date, returning the page and the total count.
+func ListChargesContext(ctx context.Context, f ChargeListFilter) ([]model.IamChargeRow, int64, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, 0, fmt.Errorf("supabase iam client não inicializado")
+	}
+	if f.ContractID == "" && f.CompanyID == "" && f.ProviderSubscriptionID == "" && f.ExternalReference == "" {
+		return nil, 0, fmt.Errorf("contract_id, company_id, provider_subscription_id or external_reference is required")
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+	if f.Scoped && len(f.TenantIDs) == 0 && len(f.AccountingOfficeIDs) == 0 {
+		return []model.IamChargeRow{}, 0, nil
+	}
+
+	q := c.
+		From("charges").
+		Select("*", "exact", false)
+	if f.Scoped {
+		var scope []string
+		if len(f.TenantIDs) > 0 {
+			scope = append(scope, "tenant_id.in.("+strings.Join(f.TenantIDs, ",")+")")
+		}
+		if len(f.AccountingOfficeIDs) > 0 {
+			scope = append(scope, "accounting_office_id.in.("+strings.Join(f.AccountingOfficeIDs, ",")+")")
+		}
+		q = q.Or(strings.Join(scope, ","), "")
+	}
+	if f.ContractID != "" {
+		q = q.Eq("contract_id", f.ContractID)
+	}
+	if f.CompanyID != "" {
+		q = q.Eq("company_id", f.CompanyID)
+	}
+	if f.ProviderSubscriptionID != "" {
+		q = q.Eq("provider_subscription_id", f.ProviderSubscriptionID)
+	}
+	if f.ExternalReference != "" {
+		q = q.Eq("external_reference", f.ExternalReference)
+	}
+	if f.Status != "" {
+		q = q.Eq("status", f.Status)
+	}
+	if !f.IncludeDeleted {
+		q = q.Is("deleted_at", "null")
+	}
+
+	var rows []model.IamChargeRow
+	count, err := q.
+		Order("due_date", &postgrest.OrderOpts{Ascending: true}).
+		Range(f.Offset, f.Offset+f.Limit-1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list charges: %w", err)
+	}
+	return rows, count, nil
+}
+
+// GetChargeByIDContext retrieves a charge from iam.charges by its primary key.
+// Returns (nil, nil) when no row matches.
+func GetChargeByIDContext(ctx context.Context, id string) (*model.IamChargeRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	var rows []model.IamChargeRow
+	_, err := c.
+		From("charges").
+		Select("*", "", false).
+		Eq("id", id).
+		Limit(1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch charge: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// ListChargesByProviderIDsContext loads the iam.charges rows of one office for a batch of
+// provider_charge_ids (rows that do not exist are simply absent from the result).
+func ListChargesByProviderIDsContext(ctx context.Context, provider, accountingOfficeID string, providerChargeIDs []string) ([]model.IamChargeRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase iam client não inicializado")
+	}
+	if len(providerChargeIDs) == 0 {
+		return nil, nil
+	}
+
+	q := c.
+		From("charges").
+		Select("*", "", false).
+		Eq("provider", provider).
+		In("provider_charge_id", providerChargeIDs)
+	if accountingOfficeID != "" {
+		q = q.Eq("accounting_office_id", accountingOfficeID)
+	}
+
+	var rows []model.IamChargeRow
+	if _, err := q.ExecuteTo(&rows); err != nil {
+		return nil, fmt.Errorf("failed to list charges by provider id: %w", err)
+	}
+	return rows, nil
+}
+
+// ListChargesByInstallmentIDContext loads the iam.charges rows of an installment plan
+// (provider_installment_id), soft-deleted rows included, ordered by installment_number.
+// accountingOfficeID is optional.
+func ListChargesByInstallmentIDContext(ctx context.Context, provider, providerInstallmentID, accountingOfficeID string) ([]model.IamChargeRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	q := c.
+		From("charges").
+		Select("*", "", false).
+		Eq("provider", provider).
+		Eq("provider_installment_id", providerInstallmentID)
+	if accountingOfficeID != "" {
+		q = q.Eq("accounting_office_id", accountingOfficeID)
+	}
+
+	var rows []model.IamChargeRow
+	if _, err := q.Order("installment_number", &postgrest.OrderOpts{Ascending: true}).ExecuteTo(&rows); err != nil {
+		return nil, fmt.Errorf("failed to list charges by installment: %w", err)
+	}
+	return rows, nil
+}
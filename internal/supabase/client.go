@@ -2,6 +2,7 @@ package supabase
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,7 +10,10 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/supabase-community/postgrest-go"
 	"github.com/supabase-community/supabase-go"
 )
 
@@ -58,8 +62,114 @@ func mustNewClient(url, key, schema string) *supabase.Client {
 	return c
 }
 
+// Options bounds every call to Supabase; the caller's context can only make them shorter.
+type Options struct {
+	QueryTimeout time.Duration // table reads/writes (one PostgREST request)
+	RPCTimeout   time.Duration // RPC functions
+}
+
+var (
+	optionsMu sync.RWMutex
+	options   = Options{QueryTimeout: 10 * time.Second, RPCTimeout: 15 * time.Second}
+)
+
+// Configure replaces the default Options. It is meant to be called once at startup (see cmd/api).
+func Configure(o Options) {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	options = o
+}
+
+func currentOptions() Options {
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+	return options
+}
+
+// iamDB, companyDB and logsDB return a PostgREST client whose requests are bound to ctx
+// (nil when InitClient was not called). postgrest-go has no context support, so the
+// context is attached by the transport (see ctxTransport).
+func iamDB(ctx context.Context) *postgrest.Client {
+	if iamClient == nil {
+		return nil
+	}
+	return restClient(ctx, "iam")
+}
+
+func companyDB(ctx context.Context) *postgrest.Client {
+	if companyClient == nil {
+		return nil
+	}
+	return restClient(ctx, "company")
+}
+
+func logsDB(ctx context.Context) *postgrest.Client {
+	if logsClient == nil {
+		return nil
+	}
+	return restClient(ctx, "logs")
+}
+
+func restClient(ctx context.Context, schema string) *postgrest.Client {
+	c := postgrest.NewClient(strings.TrimRight(supabaseURL, "/")+"/rest/v1", schema, map[string]string{
+		"Authorization": "Bearer " + supabaseKey,
+		"apikey":        supabaseKey,
+		"X-Client-Info": "carteira-contabil-charges-service",
+	})
+	if c.Transport != nil {
+		c.Transport.Parent = ctxTransport{ctx: ctx, timeout: currentOptions().QueryTimeout}
+	}
+	return c
+}
+
+// ctxTransport sends each request with ctx plus a per-request deadline.
+// The deadline is released when the body is read to the end or closed.
+type ctxTransport struct {
+	ctx     context.Context
+	timeout time.Duration
+}
+
+func (t ctxTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := withTimeout(t.ctx, t.timeout)
+	resp, err := http.DefaultTransport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.cancel()
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
 // rpcCall is the shared implementation for calling a PostgREST RPC endpoint.
-func rpcCall(name, schema string, body any) (string, error) {
+func rpcCall(ctx context.Context, name, schema string, body any) (string, error) {
 	if strings.TrimSpace(supabaseURL) == "" || strings.TrimSpace(supabaseKey) == "" {
 		return "", fmt.Errorf("supabase env não configurado (SUPABASE_URL/SUPABASE_KEY)")
 	}
@@ -77,7 +187,10 @@ func rpcCall(name, schema string, body any) (string, error) {
 		payload = b
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	ctx, cancel := withTimeout(ctx, currentOptions().RPCTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
 	if err != nil {
 		return "", fmt.Errorf("erro ao criar request RPC: %w", err)
 	}
@@ -104,12 +217,7 @@ func rpcCall(name, schema string, body any) (string, error) {
 	return strings.TrimSpace(string(respBody)), nil
 }
 
-// RpcPublic calls a PostgREST RPC under the public schema.
-func RpcPublic(name string, body any) (string, error) {
-	return rpcCall(name, "public", body)
-}
-
-// RpcIAM calls a PostgREST RPC under the iam schema.
-func RpcIAM(name string, body any) (string, error) {
-	return rpcCall(name, "iam", body)
+// RpcPublicContext calls a PostgREST RPC under the public schema.
+func RpcPublicContext(ctx context.Context, name string, body any) (string, error) {
+	return rpcCall(ctx, name, "public", body)
 }
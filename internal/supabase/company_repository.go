@@ -2,13 +2,14 @@ package supabase
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 )
 
-// GetCompanyTenantID resolves the tenant_id for a given company_id (company.companies).
-func GetCompanyTenantID(companyID string) (string, error) {
-	c := GetCompanyClient()
+// GetCompanyTenantIDContext resolves the tenant_id for a given company_id (company.companies).
+func GetCompanyTenantIDContext(ctx context.Context, companyID string) (string, error) {
+	c := companyDB(ctx)
 	if c == nil {
 		return "", fmt.Errorf("supabase company client não inicializado")
 	}
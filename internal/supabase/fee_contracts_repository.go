@@ -1,15 +1,17 @@
 package supabase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/seuuser/charges-service/internal/model"
+	"github.com/supabase-community/postgrest-go"
 )
 
-func GetFeeContractByID(contractID string) (*model.FeeContractRow, error) {
-	c := GetIAMClient()
+func GetFeeContractByIDContext(ctx context.Context, contractID string) (*model.FeeContractRow, error) {
+	c := iamDB(ctx)
 	if c == nil {
 		return nil, fmt.Errorf("supabase iam client não inicializado")
 	}
@@ -38,20 +40,55 @@ func GetFeeContractByID(contractID string) (*model.FeeContractRow, error) {
 	return &rows[0], nil
 }
 
+// GetLatestFeeContractForCompanyContext returns the most recent contract (by start_date) of a
+// company within an accounting office. Returns (nil, nil) when the company has none.
+func GetLatestFeeContractForCompanyContext(ctx context.Context, companyID, accountingOfficeID string) (*model.FeeContractRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase iam client não inicializado")
+	}
+	companyID = strings.TrimSpace(companyID)
+	accountingOfficeID = strings.TrimSpace(accountingOfficeID)
+	if companyID == "" || accountingOfficeID == "" {
+		return nil, fmt.Errorf("company_id and accounting_office_id are required")
+	}
+
+	var rows []model.FeeContractRow
+	_, err := c.
+		From("fee_contracts").
+		Select(
+			"id, tenant_id, accounting_office_id, company_id, contract_number, provider, provider_environment, billing_integration_id, start_date, end_date, interest_percentage, fine_type, fine_percentage, fine_value, discount_type, discount_percentage, discount_value, discount_due_limit_days",
+			"exact",
+			false,
+		).
+		Eq("company_id", companyID).
+		Eq("accounting_office_id", accountingOfficeID).
+		Order("start_date", &postgrest.OrderOpts{Ascending: false, NullsFirst: false}).
+		Limit(1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
 // feeContractSubscriptionRow is used only internally to unmarshal the contract_id
 // from iam.fee_contract_subscriptions.
 type feeContractSubscriptionRow struct {
 	ContractID string `json:"contract_id"`
 }
 
-// GetFeeContractBySubscriptionProviderID finds the fee contract linked to a given
+// GetFeeContractBySubscriptionProviderIDContext finds the fee contract linked to a given
 // Asaas subscription ID (e.g. "sub_VXJBYgP2u0eO") by querying
 // iam.fee_contract_subscriptions.provider_subscription_id and then fetching
 // the parent iam.fee_contracts row.
 //
 // Returns (nil, nil) when no matching subscription/contract is found.
-func GetFeeContractBySubscriptionProviderID(providerSubID string) (*model.FeeContractRow, error) {
-	c := GetIAMClient()
+func GetFeeContractBySubscriptionProviderIDContext(ctx context.Context, providerSubID string) (*model.FeeContractRow, error) {
+	c := iamDB(ctx)
 	if c == nil {
 		return nil, fmt.Errorf("supabase iam client não inicializado")
 	}
@@ -81,11 +118,11 @@ func GetFeeContractBySubscriptionProviderID(providerSubID string) (*model.FeeCon
 	}
 
 	// Step 2: fetch the full contract row
-	return GetFeeContractByID(sub.ContractID)
+	return GetFeeContractByIDContext(ctx, sub.ContractID)
 }
 
-func ListFeeContractServiceItems(contractID string) ([]model.FeeContractServiceItemRow, error) {
-	c := GetIAMClient()
+func ListFeeContractServiceItemsContext(ctx context.Context, contractID string) ([]model.FeeContractServiceItemRow, error) {
+	c := iamDB(ctx)
 	if c == nil {
 		return nil, fmt.Errorf("supabase iam client não inicializado")
 	}
@@ -107,3 +144,48 @@ func ListFeeContractServiceItems(contractID string) ([]model.FeeContractServiceI
 	return rows, nil
 }
 
+
+// GetFeeContractSubscriptionContext loads the iam.fee_contract_subscriptions row of a provider
+// subscription. Returns (nil, nil) when the subscription is not linked to any contract.
+func GetFeeContractSubscriptionContext(ctx context.Context, providerSubID string) (*model.FeeContractSubscriptionRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	var rows []model.FeeContractSubscriptionRow
+	_, err := c.
+		From("fee_contract_subscriptions").
+		Select("*", "", false).
+		Eq("provider_subscription_id", strings.TrimSpace(providerSubID)).
+		Limit(1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("fee_contract_subscriptions lookup failed (sub=%s): %w", providerSubID, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// UpsertFeeContractSubscriptionContext links (or refreshes) a provider subscription in
+// iam.fee_contract_subscriptions. Requires a unique constraint on provider_subscription_id.
+func UpsertFeeContractSubscriptionContext(ctx context.Context, row model.FeeContractSubscriptionRow) error {
+	c := iamDB(ctx)
+	if c == nil {
+		return fmt.Errorf("supabase iam client não inicializado")
+	}
+	if strings.TrimSpace(row.ProviderSubscriptionID) == "" {
+		return fmt.Errorf("provider_subscription_id is required")
+	}
+
+	_, _, err := c.
+		From("fee_contract_subscriptions").
+		Upsert(row, "provider_subscription_id", "minimal", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to upsert fee_contract_subscriptions (sub=%s): %w", row.ProviderSubscriptionID, err)
+	}
+	return nil
+}
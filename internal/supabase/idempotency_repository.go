@@ -0,0 +1,180 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// ErrIdempotencyKeyExists is returned by InsertIdempotencyKeyContext when another request
+// already claimed the same (idempotency_key, scope).
+var ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+
+// GetIdempotencyKeyContext loads the row for (key, scope). Returns (nil, nil) when there is none.
+// Expired rows are returned as well; the caller decides what to do with them.
+func GetIdempotencyKeyContext(ctx context.Context, key, scope string) (*model.IdempotencyKeyRow, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	var rows []model.IdempotencyKeyRow
+	_, err := c.
+		From("idempotency_keys").
+		Select("*", "", false).
+		Eq("idempotency_key", key).
+		Eq("scope", scope).
+		Limit(1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch idempotency key: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// InsertIdempotencyKeyContext claims (key, scope) with an in_progress row.
+// Requires a unique constraint on (idempotency_key, scope); a conflict returns ErrIdempotencyKeyExists.
+func InsertIdempotencyKeyContext(ctx context.Context, row model.IdempotencyKeyRow) error {
+	c := iamDB(ctx)
+	if c == nil {
+		return fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	_, _, err := c.
+		From("idempotency_keys").
+		Insert(row, false, "", "minimal", "").
+		Execute()
+	if err != nil {
+		if strings.Contains(err.Error(), "(23505)") {
+			return ErrIdempotencyKeyExists
+		}
+		return fmt.Errorf("failed to insert idempotency key: %w", err)
+	}
+	return nil
+}
+
+// TakeOverIdempotencyKeyContext hands an in_progress (key, scope) whose locked_until is
+// before now (or unset) to owner with a new lease, so a retry can run the request whose
+// owner crashed. Returns false when the row is no longer expired in_progress (e.g. another
+// retry took it first).
+func TakeOverIdempotencyKeyContext(ctx context.Context, key, scope, owner string, now, lockedUntil time.Time) (bool, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return false, fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	var rows []model.IdempotencyKeyRow
+	_, err := c.
+		From("idempotency_keys").
+		Update(map[string]any{
+			"lock_owner":   owner,
+			"locked_until": lockedUntil.UTC().Format(time.RFC3339),
+		}, "representation", "").
+		Eq("idempotency_key", key).
+		Eq("scope", scope).
+		Eq("status", model.IdempotencyStatusInProgress).
+		Or("locked_until.is.null,locked_until.lt."+now.UTC().Format(time.RFC3339), "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return false, fmt.Errorf("failed to take over idempotency key: %w", err)
+	}
+	return len(rows) > 0, nil
+}
+
+// RenewIdempotencyKeyContext extends the lease of an in_progress (key, scope) held by owner.
+// Returns false when owner no longer holds it (the lease expired and a retry took it over).
+func RenewIdempotencyKeyContext(ctx context.Context, key, scope, owner string, lockedUntil time.Time) (bool, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return false, fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	var rows []model.IdempotencyKeyRow
+	_, err := c.
+		From("idempotency_keys").
+		Update(map[string]any{"locked_until": lockedUntil.UTC().Format(time.RFC3339)}, "representation", "").
+		Eq("idempotency_key", key).
+		Eq("scope", scope).
+		Eq("status", model.IdempotencyStatusInProgress).
+		Eq("lock_owner", owner).
+		ExecuteTo(&rows)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew idempotency key: %w", err)
+	}
+	return len(rows) > 0, nil
+}
+
+// CompleteIdempotencyKeyContext stores the response of the request that owns (key, scope).
+// Returns false when owner lost the key to a takeover; the stored row is left untouched.
+func CompleteIdempotencyKeyContext(ctx context.Context, key, scope, owner string, status int, contentType, body string) (bool, error) {
+	c := iamDB(ctx)
+	if c == nil {
+		return false, fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	var rows []model.IdempotencyKeyRow
+	_, err := c.
+		From("idempotency_keys").
+		Update(map[string]any{
+			"status":                model.IdempotencyStatusCompleted,
+			"response_status":       status,
+			"response_content_type": contentType,
+			"response_body":         body,
+		}, "representation", "").
+		Eq("idempotency_key", key).
+		Eq("scope", scope).
+		Eq("lock_owner", owner).
+		ExecuteTo(&rows)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return len(rows) > 0, nil
+}
+
+// DeleteIdempotencyKeyContext releases (key, scope), e.g. when the row expired or the request panicked.
+func DeleteIdempotencyKeyContext(ctx context.Context, key, scope string) error {
+	c := iamDB(ctx)
+	if c == nil {
+		return fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	_, _, err := c.
+		From("idempotency_keys").
+		Delete("", "").
+		Eq("idempotency_key", key).
+		Eq("scope", scope).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKeyContext deletes an in_progress (key, scope) only while owner holds it,
+// so a request that lost its lease cannot drop the key of the request that took it over.
+func ReleaseIdempotencyKeyContext(ctx context.Context, key, scope, owner string) error {
+	c := iamDB(ctx)
+	if c == nil {
+		return fmt.Errorf("supabase iam client não inicializado")
+	}
+
+	_, _, err := c.
+		From("idempotency_keys").
+		Delete("", "").
+		Eq("idempotency_key", key).
+		Eq("scope", scope).
+		Eq("status", model.IdempotencyStatusInProgress).
+		Eq("lock_owner", owner).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package supabase
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -13,7 +14,7 @@ type OneOffSyncFields struct {
 	BillingType *string  // BOLETO|PIX|CREDIT_CARD|UNDEFINED → payment_method
 }
 
-// SyncOneOffChargeFromProvider calls public.sync_one_off_charge_from_provider via PostgREST.
+// SyncOneOffChargeFromProviderContext calls public.sync_one_off_charge_from_provider via PostgREST.
 //
 // The function (SECURITY DEFINER) handles two cases:
 //  1. Fast path  – row already linked (provider_charge_id set) → refreshes status + value fields.
@@ -22,10 +23,7 @@ type OneOffSyncFields struct {
 //
 // extra is optional; pass nil to skip updating value/dueDate/billingType.
 // external_reference expected format: "fee_contract:{uuid}:one_off:{description}"
-func SyncOneOffChargeFromProvider(
-	providerChargeID, providerStatus, externalReference string,
-	extra *OneOffSyncFields,
-) error {
+func SyncOneOffChargeFromProviderContext(ctx context.Context, providerChargeID, providerStatus, externalReference string, extra *OneOffSyncFields) error {
 	providerChargeID = strings.TrimSpace(providerChargeID)
 	if providerChargeID == "" {
 		return fmt.Errorf("provider_charge_id is required for one-off sync")
@@ -50,7 +48,7 @@ func SyncOneOffChargeFromProvider(
 		}
 	}
 
-	rawResp, err := RpcPublic("sync_one_off_charge_from_provider", body)
+	rawResp, err := RpcPublicContext(ctx, "sync_one_off_charge_from_provider", body)
 	if err != nil {
 		return fmt.Errorf("sync_one_off_charge_from_provider RPC failed (charge_id=%s): %w", providerChargeID, err)
 	}
@@ -0,0 +1,53 @@
+package supabase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// InsertReconcileRunContext stores a drift report in logs.charges_reconcile_runs and returns its id.
+func InsertReconcileRunContext(ctx context.Context, report model.ReconcileReport) (string, error) {
+	c := logsDB(ctx)
+	if c == nil {
+		return "", fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	var stored []model.ReconcileReport
+	_, err := c.
+		From("charges_reconcile_runs").
+		Insert(report, false, "", "representation", "").
+		ExecuteTo(&stored)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert reconcile run: %w", err)
+	}
+	if len(stored) == 0 {
+		return "", nil
+	}
+	return stored[0].ID, nil
+}
+
+// ListReconcileRunsContext returns the most recent drift reports, newest first.
+func ListReconcileRunsContext(ctx context.Context, offset, limit int) ([]model.ReconcileReport, int64, error) {
+	c := logsDB(ctx)
+	if c == nil {
+		return nil, 0, fmt.Errorf("supabase logs client não inicializado")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var rows []model.ReconcileReport
+	count, err := c.
+		From("charges_reconcile_runs").
+		Select("*", "exact", false).
+		Order("started_at", &postgrest.OrderOpts{Ascending: false}).
+		Range(offset, offset+limit-1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reconcile runs: %w", err)
+	}
+	return rows, count, nil
+}
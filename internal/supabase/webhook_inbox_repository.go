@@ -0,0 +1,139 @@
+package supabase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// InsertWebhookInboxContext persists a received webhook in logs.asaas_webhook_inbox and returns its id.
+// This is the only write done inside the webhook HTTP request.
+func InsertWebhookInboxContext(ctx context.Context, row model.AsaasWebhookInboxRow) (string, error) {
+	c := logsDB(ctx)
+	if c == nil {
+		return "", fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	var stored []model.AsaasWebhookInboxRow
+	_, err := c.
+		From("asaas_webhook_inbox").
+		Insert(row, false, "", "representation", "").
+		ExecuteTo(&stored)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert webhook inbox: %w", err)
+	}
+	if len(stored) == 0 {
+		return "", fmt.Errorf("failed to insert webhook inbox: empty response")
+	}
+	return stored[0].ID, nil
+}
+
+// ListDueWebhookInboxContext returns rows a worker may claim: pending rows whose next_attempt_at
+// has passed, and processing rows whose lock expired (worker crashed or was restarted).
+func ListDueWebhookInboxContext(ctx context.Context, now time.Time, limit int) ([]model.AsaasWebhookInboxRow, error) {
+	c := logsDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	ts := now.UTC().Format(time.RFC3339)
+	var rows []model.AsaasWebhookInboxRow
+	_, err := c.
+		From("asaas_webhook_inbox").
+		Select("*", "", false).
+		Or(fmt.Sprintf("and(status.eq.%s,next_attempt_at.lte.%s),and(status.eq.%s,locked_until.lt.%s)",
+			model.WebhookInboxPending, ts, model.WebhookInboxProcessing, ts), "").
+		Order("next_attempt_at", &postgrest.OrderOpts{Ascending: true}).
+		Limit(limit, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook inbox: %w", err)
+	}
+	return rows, nil
+}
+
+// ClaimWebhookInboxContext moves a row to processing until lockedUntil and counts the attempt
+// (attempts+1) right away, so an event whose worker crashes is still counted and eventually
+// dead-lettered. The update only applies if the row is still in the state it was listed
+// with, so two workers never get the same row.
+func ClaimWebhookInboxContext(ctx context.Context, row model.AsaasWebhookInboxRow, lockedUntil time.Time) (bool, error) {
+	c := logsDB(ctx)
+	if c == nil {
+		return false, fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	q := c.
+		From("asaas_webhook_inbox").
+		Update(map[string]any{
+			"status":       model.WebhookInboxProcessing,
+			"attempts":     row.Attempts + 1,
+			"locked_until": lockedUntil.UTC().Format(time.RFC3339),
+		}, "representation", "").
+		Eq("id", row.ID).
+		Eq("status", row.Status).
+		Eq("attempts", strconv.Itoa(row.Attempts))
+	if row.LockedUntil != nil {
+		q = q.Eq("locked_until", *row.LockedUntil)
+	}
+
+	var updated []model.AsaasWebhookInboxRow
+	if _, err := q.ExecuteTo(&updated); err != nil {
+		return false, fmt.Errorf("failed to claim webhook inbox %s: %w", row.ID, err)
+	}
+	return len(updated) > 0, nil
+}
+
+// MarkWebhookInboxDoneContext records a successful processing.
+func MarkWebhookInboxDoneContext(ctx context.Context, id string, attempts int) error {
+	return updateWebhookInbox(ctx, id, map[string]any{
+		"status":       model.WebhookInboxDone,
+		"attempts":     attempts,
+		"locked_until": nil,
+		"last_error":   nil,
+		"last_stage":   nil,
+	})
+}
+
+// MarkWebhookInboxRetryContext puts the row back in the queue for another attempt at nextAttemptAt.
+func MarkWebhookInboxRetryContext(ctx context.Context, id string, attempts int, nextAttemptAt time.Time, stage, lastError string) error {
+	return updateWebhookInbox(ctx, id, map[string]any{
+		"status":          model.WebhookInboxPending,
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt.UTC().Format(time.RFC3339),
+		"locked_until":    nil,
+		"last_error":      lastError,
+		"last_stage":      stage,
+	})
+}
+
+// MarkWebhookInboxDeadContext takes the row out of the queue after its last failed attempt.
+func MarkWebhookInboxDeadContext(ctx context.Context, id string, attempts int, stage, lastError string) error {
+	return updateWebhookInbox(ctx, id, map[string]any{
+		"status":       model.WebhookInboxDead,
+		"attempts":     attempts,
+		"locked_until": nil,
+		"last_error":   lastError,
+		"last_stage":   stage,
+	})
+}
+
+func updateWebhookInbox(ctx context.Context, id string, fields map[string]any) error {
+	c := logsDB(ctx)
+	if c == nil {
+		return fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	_, _, err := c.
+		From("asaas_webhook_inbox").
+		Update(fields, "minimal", "").
+		Eq("id", id).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update webhook inbox %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package supabase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// SaveWebhookJournalContext writes an entry to logs.asaas_webhook_journal: upsert on inbox_id for
+// queued deliveries (one row per delivery, updated after each attempt), insert otherwise.
+//
+// Like InsertAsaasWebhookEventLogContext it never fails the caller: the journal is for support
+// and audit, an error here is only logged.
+func SaveWebhookJournalContext(ctx context.Context, entry model.WebhookJournalEntry) {
+	c := logsDB(ctx)
+	if c == nil {
+		log.Printf("⚠️  [webhook_journal] logs client não inicializado — evento não registrado: event=%s payment=%s outcome=%s",
+			entry.EventType, entry.PaymentID, entry.Outcome)
+		return
+	}
+
+	var err error
+	if entry.InboxID != nil {
+		_, _, err = c.
+			From("asaas_webhook_journal").
+			Upsert(entry, "inbox_id", "minimal", "").
+			Execute()
+	} else {
+		_, _, err = c.
+			From("asaas_webhook_journal").
+			Insert(entry, false, "", "minimal", "").
+			Execute()
+	}
+	if err != nil {
+		log.Printf("❌ [webhook_journal] ERRO ao persistir em logs.asaas_webhook_journal: %v | event=%s payment=%s outcome=%s",
+			err, entry.EventType, entry.PaymentID, entry.Outcome)
+	}
+}
+
+// WebhookJournalFilter selects journal entries; at least one field should be set.
+type WebhookJournalFilter struct {
+	PaymentID      string
+	SubscriptionID string
+	CompanyID      string
+	From           time.Time // received_at >= (zero: unbounded)
+	To             time.Time // received_at <= (zero: unbounded)
+	ToExclusive    bool      // received_at < To instead, e.g. the day after a date-only bound
+	Offset         int
+	Limit          int // default 50
+}
+
+// ListWebhookJournalContext returns journal entries oldest first, with the total count.
+func ListWebhookJournalContext(ctx context.Context, f WebhookJournalFilter) ([]model.WebhookJournalEntry, int64, error) {
+	c := logsDB(ctx)
+	if c == nil {
+		return nil, 0, fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	q := c.
+		From("asaas_webhook_journal").
+		Select("*", "exact", false)
+	if s := strings.TrimSpace(f.PaymentID); s != "" {
+		q = q.Eq("payment_id", s)
+	}
+	if s := strings.TrimSpace(f.SubscriptionID); s != "" {
+		q = q.Eq("subscription_id", s)
+	}
+	if s := strings.TrimSpace(f.CompanyID); s != "" {
+		q = q.Eq("company_id", s)
+	}
+	// Range on one column: a single and=(...) (postgrest-go keeps one value per param).
+	// The bounds are formatted here, in UTC, so no caller text reaches the expression.
+	var conds []string
+	if !f.From.IsZero() {
+		conds = append(conds, "received_at.gte."+f.From.UTC().Format(time.RFC3339Nano))
+	}
+	if !f.To.IsZero() {
+		op := "lte"
+		if f.ToExclusive {
+			op = "lt"
+		}
+		conds = append(conds, "received_at."+op+"."+f.To.UTC().Format(time.RFC3339Nano))
+	}
+	if len(conds) > 0 {
+		q = q.And(strings.Join(conds, ","), "")
+	}
+
+	var rows []model.WebhookJournalEntry
+	count, err := q.
+		Order("received_at", &postgrest.OrderOpts{Ascending: true}).
+		Range(f.Offset, f.Offset+limit-1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook journal: %w", err)
+	}
+	return rows, count, nil
+}
@@ -1,20 +1,24 @@
 package supabase
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/seuuser/charges-service/internal/model"
+	"github.com/supabase-community/postgrest-go"
 )
 
-// InsertAsaasWebhookEventLog persists a failed or unprocessable Asaas webhook
+// InsertAsaasWebhookEventLogContext persists a failed or unprocessable Asaas webhook
 // event into logs.asaas_webhook_events for diagnostics and manual resolution.
 //
 // This function is intentionally non-fatal: if the insert itself fails, it logs
 // the error to stdout but does NOT propagate it — the caller should never fail
 // a webhook response just because the log write failed.
-func InsertAsaasWebhookEventLog(entry model.AsaasWebhookEventLog) {
-	c := GetLogsClient()
+func InsertAsaasWebhookEventLogContext(ctx context.Context, entry model.AsaasWebhookEventLog) {
+	c := logsDB(ctx)
 	if c == nil {
 		log.Printf("⚠️  [webhook_log] logs client não inicializado — impossível persistir log. stage=%s err=%s",
 			entry.ErrorStage, entry.ErrorMessage)
@@ -36,21 +40,109 @@ func InsertAsaasWebhookEventLog(entry model.AsaasWebhookEventLog) {
 		entry.EventType, entry.PaymentID, entry.ErrorStage)
 }
 
-// MustInsertAsaasWebhookEventLog is like InsertAsaasWebhookEventLog but returns
-// an error. Use this when the caller needs to be aware of log write failures
-// (e.g. in integration tests).
-func MustInsertAsaasWebhookEventLog(entry model.AsaasWebhookEventLog) error {
-	c := GetLogsClient()
+// WebhookEventLogFilter selects rows of logs.asaas_webhook_events (all fields optional).
+type WebhookEventLogFilter struct {
+	IDs         []string
+	Stage       string // error_stage
+	PaymentID   string
+	From        time.Time // created_at >= (zero: unbounded)
+	To          time.Time // created_at <= (zero: unbounded)
+	ToExclusive bool      // created_at < To instead, e.g. the day after a date-only bound
+
+	// IncludeResolved also returns rows already resolved by a replay.
+	IncludeResolved bool
+	Limit           int // default 50
+}
+
+// ListAsaasWebhookEventLogsContext returns logged events, oldest first.
+// Without a Stage filter, ignored events (status ignored_stale or ignored_foreign) are left out: they are not failures.
+func ListAsaasWebhookEventLogsContext(ctx context.Context, f WebhookEventLogFilter) ([]model.AsaasWebhookEventLog, error) {
+	c := logsDB(ctx)
 	if c == nil {
-		return fmt.Errorf("logs client não inicializado")
+		return nil, fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	q := c.
+		From("asaas_webhook_events").
+		Select("*", "", false)
+	if len(f.IDs) > 0 {
+		q = q.In("id", f.IDs)
+	}
+	if s := strings.TrimSpace(f.Stage); s != "" {
+		q = q.Eq("error_stage", s)
+	}
+	if s := strings.TrimSpace(f.PaymentID); s != "" {
+		q = q.Eq("payment_id", s)
+	}
+
+	// postgrest-go keeps one value per query param, so conditions that repeat a column
+	// (created_at range) or an operator (or) go together in a single and=(...).
+	// The bounds are formatted here, in UTC, so no caller text reaches the expression.
+	var conds []string
+	if strings.TrimSpace(f.Stage) == "" {
+		conds = append(conds, "or(status.is.null,status.not.in.("+model.WebhookLogStatusIgnoredStale+","+model.WebhookLogStatusIgnoredForeign+"))")
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "created_at.gte."+f.From.UTC().Format(time.RFC3339Nano))
+	}
+	if !f.To.IsZero() {
+		op := "lte"
+		if f.ToExclusive {
+			op = "lt"
+		}
+		conds = append(conds, "created_at."+op+"."+f.To.UTC().Format(time.RFC3339Nano))
+	}
+	if !f.IncludeResolved {
+		conds = append(conds, "or(resolved.is.null,resolved.is.false)")
+	}
+	if len(conds) > 0 {
+		q = q.And(strings.Join(conds, ","), "")
+	}
+
+	var rows []model.AsaasWebhookEventLog
+	_, err := q.
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		Limit(limit, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list asaas webhook event logs: %w", err)
+	}
+	return rows, nil
+}
+
+// RecordAsaasWebhookEventReplayContext stores the outcome of a replay on the log row:
+// replayErr=nil marks it resolved.
+func RecordAsaasWebhookEventReplayContext(ctx context.Context, entry model.AsaasWebhookEventLog, replayErr error) error {
+	c := logsDB(ctx)
+	if c == nil {
+		return fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	fields := map[string]any{
+		"replay_attempts": entry.ReplayAttempts + 1,
+		"last_replay_at":  now,
+	}
+	if replayErr == nil {
+		fields["resolved"] = true
+		fields["resolved_at"] = now
+		fields["last_replay_error"] = nil
+	} else {
+		fields["last_replay_error"] = replayErr.Error()
 	}
 
 	_, _, err := c.
 		From("asaas_webhook_events").
-		Insert(entry, false, "", "minimal", "").
+		Update(fields, "minimal", "").
+		Eq("id", entry.ID).
 		Execute()
 	if err != nil {
-		return fmt.Errorf("failed to insert asaas webhook event log: %w", err)
+		return fmt.Errorf("failed to record webhook replay (id=%s): %w", entry.ID, err)
 	}
 	return nil
 }
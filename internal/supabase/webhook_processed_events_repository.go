@@ -0,0 +1,56 @@
+package supabase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+// GetWebhookProcessedEventContext returns the processed-event row for (provider, eventID),
+// or (nil, nil) when the event was never processed.
+func GetWebhookProcessedEventContext(ctx context.Context, provider, eventID string) (*model.AsaasWebhookProcessedEventRow, error) {
+	c := logsDB(ctx)
+	if c == nil {
+		return nil, fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	var rows []model.AsaasWebhookProcessedEventRow
+	_, err := c.
+		From("asaas_webhook_processed_events").
+		Select("*", "", false).
+		Eq("provider", provider).
+		Eq("event_id", eventID).
+		Limit(1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch processed webhook event: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// InsertWebhookProcessedEventContext records the event as processed. Requires a unique
+// constraint on (provider, event_id): inserted=false means another worker (or
+// instance) recorded it first, so the caller must not fire side effects again.
+func InsertWebhookProcessedEventContext(ctx context.Context, row model.AsaasWebhookProcessedEventRow) (inserted bool, err error) {
+	c := logsDB(ctx)
+	if c == nil {
+		return false, fmt.Errorf("supabase logs client não inicializado")
+	}
+
+	_, _, err = c.
+		From("asaas_webhook_processed_events").
+		Insert(row, false, "", "minimal", "").
+		Execute()
+	if err != nil {
+		if strings.Contains(err.Error(), "(23505)") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to insert processed webhook event: %w", err)
+	}
+	return true, nil
+}
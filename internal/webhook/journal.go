@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// Result describes what processing an event did; it feeds logs.asaas_webhook_journal.
+type Result struct {
+	Outcome    string // model.WebhookOutcome* (applied, duplicate, ignored_stale, skipped)
+	CompanyID  string
+	ContractID string
+	StatusFrom string // charge status before the event ("" for a new charge)
+	StatusTo   string // charge status after the event
+}
+
+// attempt is one processing run of an event, as journaled.
+type attempt struct {
+	providerName string
+	source       string
+	inboxID      string
+	raw          []byte
+	event        *provider.WebhookEvent // nil when the payload could not be parsed
+	result       *Result
+	err          error
+	outcome      string // overrides result.Outcome (retrying, dead_letter, failed)
+	attempts     int
+	receivedAt   time.Time
+	started      time.Time
+	finished     time.Time
+}
+
+// journal records the attempt in logs.asaas_webhook_journal (best-effort).
+func journal(ctx context.Context, a attempt) {
+	entry := model.WebhookJournalEntry{
+		Provider:   a.providerName,
+		Source:     a.source,
+		Outcome:    a.outcome,
+		Attempts:   a.attempts,
+		RawPayload: a.raw,
+	}
+	if a.inboxID != "" {
+		id := a.inboxID
+		entry.InboxID = &id
+	}
+	if e := a.event; e != nil {
+		entry.EventID = e.ID
+		entry.EventType = e.Event
+		if t := e.DateCreated; t != "" {
+			entry.EventCreatedAt = &t
+		}
+		if p := e.Payment; p != nil {
+			entry.PaymentID = p.ID
+			entry.SubscriptionID = p.SubscriptionID
+			entry.ExternalReference = p.ExternalReference
+		}
+		if sub := e.Subscription; sub != nil {
+			entry.SubscriptionID = sub.ID
+			entry.ExternalReference = sub.ExternalReference
+		}
+	}
+	if r := a.result; r != nil {
+		if entry.Outcome == "" {
+			entry.Outcome = r.Outcome
+		}
+		entry.CompanyID = optional(r.CompanyID)
+		entry.ContractID = optional(r.ContractID)
+		entry.StatusFrom = optional(r.StatusFrom)
+		entry.StatusTo = optional(r.StatusTo)
+	}
+	if a.err != nil {
+		stage := "process_event"
+		msg := a.err.Error()
+		var se *StageError
+		if errors.As(a.err, &se) {
+			stage = se.Stage
+			msg = se.Err.Error()
+		}
+		entry.ErrorStage = &stage
+		entry.ErrorMessage = &msg
+	}
+
+	received := a.receivedAt.UTC().Format(time.RFC3339Nano)
+	processed := a.finished.UTC().Format(time.RFC3339Nano)
+	latency := a.finished.Sub(a.receivedAt).Milliseconds()
+	processing := a.finished.Sub(a.started).Milliseconds()
+	entry.ReceivedAt = &received
+	entry.ProcessedAt = &processed
+	entry.LatencyMs = &latency
+	entry.ProcessingMs = &processing
+
+	supabase.SaveWebhookJournalContext(ctx, entry)
+}
+
+// JournalRejected records a delivery refused before queueing (invalid payload).
+func JournalRejected(ctx context.Context, providerName string, raw []byte, receivedAt time.Time, err error) {
+	now := time.Now()
+	journal(ctx, attempt{
+		providerName: providerName,
+		source:       model.WebhookSourceDelivery,
+		raw:          raw,
+		err:          &StageError{Stage: "decode_payload", Err: err},
+		outcome:      model.WebhookOutcomeRejected,
+		receivedAt:   receivedAt,
+		started:      receivedAt,
+		finished:     now,
+	})
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
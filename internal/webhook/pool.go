@@ -0,0 +1,339 @@
+// Package webhook processes provider webhooks asynchronously.
+//
+// The HTTP endpoint only validates the request and persists the raw event in
+// logs.asaas_webhook_inbox (see supabase.InsertWebhookInboxContext). A pool of workers
+// claims due rows from that table, runs the Processor and retries failures with
+// jittered exponential backoff. Rows that exhaust their attempts are marked dead
+// and copied to logs.asaas_webhook_events (the dead-letter view used by support).
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// Processor applies one webhook event (e.g. updates iam.charges) and reports what it did.
+// integrationID is the billing integration the event was delivered for ("" when unknown).
+// Returning an error schedules a retry; wrap it in *StageError to say where it failed.
+// ctx bounds the processing: the pool's own context for queued events, the request's for
+// an admin replay.
+type Processor func(ctx context.Context, providerName, integrationID string, event *provider.WebhookEvent, raw json.RawMessage) (*Result, error)
+
+// StageError tags a processing error with the pipeline stage that failed
+// ("resolve_contract_context", "upsert_charge", ...), as stored in logs.asaas_webhook_events.
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string { return e.Stage + ": " + e.Err.Error() }
+func (e *StageError) Unwrap() error { return e.Err }
+
+// Config controls the worker pool (see config.WebhookConfig for the env vars).
+type Config struct {
+	Workers      int
+	MaxAttempts  int
+	PollInterval time.Duration
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+	LockTimeout  time.Duration
+}
+
+// Pool is the set of workers draining logs.asaas_webhook_inbox.
+type Pool struct {
+	cfg     Config
+	process Processor
+	// eventTimeout bounds one processing attempt. It stays under LockTimeout so the claim
+	// does not expire (and the row get picked up by another instance) while still running.
+	eventTimeout time.Duration
+	jobs         chan model.AsaasWebhookInboxRow
+	wake         chan struct{}
+	wg           sync.WaitGroup
+}
+
+var (
+	defaultMu   sync.RWMutex
+	defaultPool *Pool
+)
+
+// Start launches the dispatcher and workers until ctx is cancelled, and makes the
+// pool the target of Notify. Call Wait after cancelling ctx to drain in-flight events.
+func Start(ctx context.Context, cfg Config, process Processor) *Pool {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	p := &Pool{
+		cfg:          cfg,
+		process:      process,
+		eventTimeout: cfg.LockTimeout - cfg.LockTimeout/5,
+		jobs:         make(chan model.AsaasWebhookInboxRow),
+		wake:         make(chan struct{}, 1),
+	}
+
+	p.wg.Add(1 + cfg.Workers)
+	go p.dispatch(ctx)
+	for i := 0; i < cfg.Workers; i++ {
+		go p.work(i + 1)
+	}
+
+	defaultMu.Lock()
+	defaultPool = p
+	defaultMu.Unlock()
+
+	log.Printf("[webhook] worker pool started: workers=%d max_attempts=%d poll=%s retry_base=%s retry_max=%s event_timeout=%s",
+		cfg.Workers, cfg.MaxAttempts, cfg.PollInterval, cfg.RetryBase, cfg.RetryMax, p.eventTimeout)
+	return p
+}
+
+// Notify wakes the pool right away (instead of waiting for the next poll)
+// after a new event was written to the inbox. Safe to call when no pool is running.
+func Notify() {
+	defaultMu.RLock()
+	p := defaultPool
+	defaultMu.RUnlock()
+	if p == nil {
+		return
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wait blocks until the dispatcher and all workers have stopped.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+// dispatch polls the inbox and hands claimed rows to the workers.
+func (p *Pool) dispatch(ctx context.Context) {
+	defer p.wg.Done()
+	defer close(p.jobs)
+
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		p.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[webhook] worker pool stopping")
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+	}
+}
+
+func (p *Pool) dispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		rows, err := supabase.ListDueWebhookInboxContext(ctx, time.Now(), p.cfg.Workers*4)
+		if err != nil {
+			log.Printf("[webhook] ERROR polling inbox: %v", err)
+			return
+		}
+		if len(rows) == 0 {
+			return
+		}
+
+		claimed := 0
+		for _, row := range rows {
+			ok, err := supabase.ClaimWebhookInboxContext(ctx, row, time.Now().Add(p.cfg.LockTimeout))
+			if err != nil {
+				log.Printf("[webhook] ERROR claiming inbox row: id=%s err=%v", row.ID, err)
+				continue
+			}
+			if !ok {
+				continue // another instance got it first
+			}
+			claimed++
+			row.Attempts++ // counted by the claim
+			select {
+			case p.jobs <- row:
+			case <-ctx.Done():
+				// Not started: the lock expires and the row is picked up again after restart.
+				return
+			}
+		}
+		if claimed == 0 {
+			return
+		}
+	}
+}
+
+func (p *Pool) work(n int) {
+	defer p.wg.Done()
+	for row := range p.jobs {
+		// A claimed event is finished even during shutdown, so it is not bound to the pool
+		// context; handle bounds the processing itself with eventTimeout.
+		p.handle(context.Background(), n, row)
+	}
+}
+
+// handle runs one attempt, records its outcome on the inbox row and journals it.
+// row.Attempts already includes this attempt (the claim counts it), so a row whose
+// previous worker died mid-processing is dead-lettered here instead of run again forever.
+func (p *Pool) handle(ctx context.Context, worker int, row model.AsaasWebhookInboxRow) {
+	attempt := row.Attempts
+	started := time.Now()
+
+	var (
+		event  *provider.WebhookEvent
+		result *Result
+		err    error
+	)
+	if attempt > p.cfg.MaxAttempts {
+		err = exhaustedError(row, p.cfg.MaxAttempts)
+	} else {
+		event, result, err = p.runWithTimeout(ctx, row)
+	}
+	defer func() {
+		j := attemptOf(row, event, result, err, started)
+		j.attempts = attempt
+		switch {
+		case err == nil:
+		case attempt >= p.cfg.MaxAttempts:
+			j.outcome = model.WebhookOutcomeDeadLetter
+		default:
+			j.outcome = model.WebhookOutcomeRetrying
+		}
+		journal(ctx, j)
+	}()
+
+	if err == nil {
+		if mErr := supabase.MarkWebhookInboxDoneContext(ctx, row.ID, attempt); mErr != nil {
+			log.Printf("[webhook] ERROR marking inbox row done: id=%s err=%v", row.ID, mErr)
+		}
+		log.Printf("✅ [webhook] worker=%d event=%s id=%s payment=%s processed (attempt %d, %s)",
+			worker, row.EventType, row.EventID, row.PaymentID, attempt, time.Since(started).Round(time.Millisecond))
+		return
+	}
+
+	stage := "process_event"
+	var se *StageError
+	if errors.As(err, &se) {
+		stage = se.Stage
+	}
+
+	if attempt >= p.cfg.MaxAttempts {
+		log.Printf("❌ [webhook] worker=%d event=%s id=%s payment=%s DEAD after %d attempts: stage=%s err=%v",
+			worker, row.EventType, row.EventID, row.PaymentID, attempt, stage, err)
+		if mErr := supabase.MarkWebhookInboxDeadContext(ctx, row.ID, attempt, stage, err.Error()); mErr != nil {
+			log.Printf("[webhook] ERROR marking inbox row dead: id=%s err=%v", row.ID, mErr)
+		}
+		supabase.InsertAsaasWebhookEventLogContext(ctx, model.AsaasWebhookEventLog{
+			EventID:       row.EventID,
+			EventType:     row.EventType,
+			PaymentID:     row.PaymentID,
+			IntegrationID: row.IntegrationID,
+			ErrorStage:    stage,
+			ErrorMessage:  err.Error(),
+			RawPayload:    row.RawPayload,
+			Status:        model.WebhookLogStatusDeadLetter,
+			Attempts:      attempt,
+		})
+		return
+	}
+
+	next := time.Now().Add(p.backoff(attempt))
+	log.Printf("⚠️  [webhook] worker=%d event=%s id=%s payment=%s failed (attempt %d/%d), retry at %s: stage=%s err=%v",
+		worker, row.EventType, row.EventID, row.PaymentID, attempt, p.cfg.MaxAttempts, next.Format(time.RFC3339), stage, err)
+	if mErr := supabase.MarkWebhookInboxRetryContext(ctx, row.ID, attempt, next, stage, err.Error()); mErr != nil {
+		log.Printf("[webhook] ERROR scheduling inbox retry: id=%s err=%v", row.ID, mErr)
+	}
+}
+
+// runWithTimeout calls run under eventTimeout. The outcome is recorded with the caller's
+// ctx, which is still valid after the attempt times out.
+func (p *Pool) runWithTimeout(ctx context.Context, row model.AsaasWebhookInboxRow) (*provider.WebhookEvent, *Result, error) {
+	if p.eventTimeout <= 0 {
+		return p.run(ctx, row)
+	}
+	runCtx, cancel := context.WithTimeout(ctx, p.eventTimeout)
+	defer cancel()
+
+	event, result, err := p.run(runCtx, row)
+	var se *StageError
+	if err != nil && !errors.As(err, &se) && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		err = &StageError{Stage: "timeout", Err: fmt.Errorf("processing exceeded %s: %w", p.eventTimeout, err)}
+	}
+	return event, result, err
+}
+
+// exhaustedError is the error of a row claimed again after its last allowed attempt
+// never finished (worker crash or restart), so it is dead-lettered without running.
+func exhaustedError(row model.AsaasWebhookInboxRow, maxAttempts int) error {
+	msg := fmt.Sprintf("attempts exhausted (%d) without a recorded outcome", maxAttempts)
+	if row.LastStage != nil && row.LastError != nil {
+		msg += fmt.Sprintf("; last failure at %s: %s", *row.LastStage, *row.LastError)
+	}
+	return &StageError{Stage: "max_attempts", Err: errors.New(msg)}
+}
+
+// run parses the stored payload and calls the processor, turning panics into errors
+// so a bad event cannot kill a worker.
+func (p *Pool) run(ctx context.Context, row model.AsaasWebhookInboxRow) (event *provider.WebhookEvent, result *Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	chargeProvider, err := provider.Lookup(row.Provider)
+	if err != nil {
+		return nil, nil, &StageError{Stage: "lookup_provider", Err: err}
+	}
+	event, err = chargeProvider.ParseWebhookEvent(row.RawPayload)
+	if err != nil {
+		return nil, nil, &StageError{Stage: "decode_payload", Err: err}
+	}
+	result, err = p.process(ctx, chargeProvider.Name(), row.IntegrationID, event, row.RawPayload)
+	return event, result, err
+}
+
+// attemptOf builds the journal attempt of an inbox row; received_at is when the row was queued.
+func attemptOf(row model.AsaasWebhookInboxRow, event *provider.WebhookEvent, result *Result, err error, started time.Time) attempt {
+	receivedAt := started
+	if row.CreatedAt != nil {
+		if t, perr := time.Parse(time.RFC3339Nano, *row.CreatedAt); perr == nil {
+			receivedAt = t
+		}
+	}
+	return attempt{
+		providerName: row.Provider,
+		source:       model.WebhookSourceDelivery,
+		inboxID:      row.ID,
+		raw:          row.RawPayload,
+		event:        event,
+		result:       result,
+		err:          err,
+		receivedAt:   receivedAt,
+		started:      started,
+		finished:     time.Now(),
+	}
+}
+
+// backoff returns RetryBase * 2^(attempt-1), capped at RetryMax, with ±20% jitter.
+func (p *Pool) backoff(attempt int) time.Duration {
+	d := float64(p.cfg.RetryBase) * math.Pow(2, float64(attempt-1))
+	if max := float64(p.cfg.RetryMax); d > max {
+		d = max
+	}
+	d *= 0.8 + 0.4*rand.Float64()
+	return time.Duration(d)
+}
@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+)
+
+const testProvider = "POOLTEST"
+
+// testChargeProvider only parses webhook payloads; the pool needs nothing else.
+type testChargeProvider struct {
+	provider.ChargeProvider
+}
+
+func (testChargeProvider) Name() string { return testProvider }
+
+func (testChargeProvider) ParseWebhookEvent(body []byte) (*provider.WebhookEvent, error) {
+	var e provider.WebhookEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+var registerTestProvider sync.Once
+
+func testRow(attempts int) model.AsaasWebhookInboxRow {
+	registerTestProvider.Do(func() {
+		provider.Register(testProvider, func(*model.BillingIntegrationRow) (provider.ChargeProvider, error) {
+			return testChargeProvider{}, nil
+		})
+	})
+	return model.AsaasWebhookInboxRow{
+		ID:         "inbox-1",
+		Provider:   testProvider,
+		EventID:    "evt_1",
+		EventType:  "PAYMENT_RECEIVED",
+		PaymentID:  "pay_1",
+		RawPayload: json.RawMessage(`{"ID":"evt_1","Event":"PAYMENT_RECEIVED"}`),
+		Attempts:   attempts,
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := &Pool{cfg: Config{RetryBase: 10 * time.Second, RetryMax: 30 * time.Minute}}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{5, 160 * time.Second},
+		{8, 1280 * time.Second},
+		{9, 30 * time.Minute}, // 2560s capped
+		{40, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			got := p.backoff(tt.attempt)
+			lo, hi := time.Duration(float64(tt.want)*0.8), time.Duration(float64(tt.want)*1.2)
+			if got < lo || got > hi {
+				t.Fatalf("backoff(%d) = %s, want %s ±20%% [%s, %s]", tt.attempt, got, tt.want, lo, hi)
+			}
+		}
+	}
+}
+
+func TestHandleAttempts(t *testing.T) {
+	tests := []struct {
+		name        string
+		attempts    int // row.Attempts after the claim counted this attempt
+		maxAttempts int
+		wantRun     bool
+	}{
+		{"first attempt", 1, 3, true},
+		{"last allowed attempt", 3, 3, true},
+		{"claimed again after the last attempt crashed", 4, 3, false},
+		{"far past the limit", 10, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			p := &Pool{
+				cfg: Config{MaxAttempts: tt.maxAttempts, RetryBase: time.Second, RetryMax: time.Minute},
+				process: func(ctx context.Context, providerName, integrationID string, event *provider.WebhookEvent, raw json.RawMessage) (*Result, error) {
+					ran = true
+					return &Result{Outcome: model.WebhookOutcomeApplied}, nil
+				},
+			}
+			p.handle(context.Background(), 1, testRow(tt.attempts))
+			if ran != tt.wantRun {
+				t.Fatalf("processor ran = %v, want %v", ran, tt.wantRun)
+			}
+		})
+	}
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		process   Processor
+		wantStage string // "" for success
+	}{
+		{
+			name:    "finishes in time",
+			timeout: time.Second,
+			process: func(ctx context.Context, _, _ string, _ *provider.WebhookEvent, _ json.RawMessage) (*Result, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					return nil, errors.New("no deadline")
+				}
+				return &Result{}, nil
+			},
+		},
+		{
+			name:    "hung processor is cut at the timeout",
+			timeout: 20 * time.Millisecond,
+			process: func(ctx context.Context, _, _ string, _ *provider.WebhookEvent, _ json.RawMessage) (*Result, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wantStage: "timeout",
+		},
+		{
+			name:    "processor stage is kept on timeout",
+			timeout: 20 * time.Millisecond,
+			process: func(ctx context.Context, _, _ string, _ *provider.WebhookEvent, _ json.RawMessage) (*Result, error) {
+				<-ctx.Done()
+				return nil, &StageError{Stage: "upsert_charge", Err: ctx.Err()}
+			},
+			wantStage: "upsert_charge",
+		},
+		{
+			name:    "panic becomes an error",
+			timeout: time.Second,
+			process: func(context.Context, string, string, *provider.WebhookEvent, json.RawMessage) (*Result, error) {
+				panic("boom")
+			},
+			wantStage: "process_event",
+		},
+		{
+			name:    "no timeout configured",
+			timeout: 0,
+			process: func(ctx context.Context, _, _ string, _ *provider.WebhookEvent, _ json.RawMessage) (*Result, error) {
+				if _, ok := ctx.Deadline(); ok {
+					return nil, errors.New("unexpected deadline")
+				}
+				return &Result{}, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pool{process: tt.process, eventTimeout: tt.timeout}
+			_, _, err := p.runWithTimeout(context.Background(), testRow(1))
+			if tt.wantStage == "" {
+				if err != nil {
+					t.Fatalf("runWithTimeout() error = %v", err)
+				}
+				return
+			}
+			stage := "process_event"
+			var se *StageError
+			if errors.As(err, &se) {
+				stage = se.Stage
+			}
+			if err == nil || stage != tt.wantStage {
+				t.Fatalf("runWithTimeout() error = %v (stage %q), want stage %q", err, stage, tt.wantStage)
+			}
+		})
+	}
+}
+
+func TestExhaustedError(t *testing.T) {
+	stage, msg := "upsert_charge", "connection reset"
+	tests := []struct {
+		name string
+		row  model.AsaasWebhookInboxRow
+		want []string
+	}{
+		{"no previous failure", model.AsaasWebhookInboxRow{}, []string{"max_attempts", "attempts exhausted (3)"}},
+		{"previous failure kept", model.AsaasWebhookInboxRow{LastStage: &stage, LastError: &msg}, []string{"max_attempts", "upsert_charge", "connection reset"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := exhaustedError(tt.row, 3)
+			var se *StageError
+			if !errors.As(err, &se) || se.Stage != "max_attempts" {
+				t.Fatalf("exhaustedError() = %v, want a max_attempts StageError", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("exhaustedError() = %q, missing %q", err, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/seuuser/charges-service/internal/integrations/provider"
+	"github.com/seuuser/charges-service/internal/model"
+	"github.com/seuuser/charges-service/internal/supabase"
+)
+
+// Replay runs the logged events selected by f through process again, synchronously,
+// and records the outcome on each log row (resolved flag, replay_attempts, last error).
+// Rows are read with providerName's webhook parser (logs.asaas_webhook_events has no
+// provider column). With dryRun=true nothing is processed or recorded.
+//
+// Processing runs under ctx, so a cancelled replay stops before the next row; the outcome
+// of a row already processed is still recorded. The partial result is returned with the
+// context error.
+func Replay(ctx context.Context, providerName string, f supabase.WebhookEventLogFilter, dryRun bool, process Processor) (*model.WebhookReplayResult, error) {
+	chargeProvider, err := provider.Lookup(providerName)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := supabase.ListAsaasWebhookEventLogsContext(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.WebhookReplayResult{DryRun: dryRun, Selected: len(rows), Items: make([]model.WebhookReplayItem, 0, len(rows))}
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			log.Printf("[webhook] replay interrupted after %d of %d events: %v", i, len(rows), err)
+			return result, fmt.Errorf("replay interrupted after %d of %d events: %w", i, len(rows), err)
+		}
+		item := model.WebhookReplayItem{
+			LogID:          row.ID,
+			EventID:        row.EventID,
+			EventType:      row.EventType,
+			PaymentID:      row.PaymentID,
+			ErrorStage:     row.ErrorStage,
+			ReplayAttempts: row.ReplayAttempts,
+		}
+		if dryRun {
+			item.Result = model.WebhookReplayPlanned
+			result.Items = append(result.Items, item)
+			continue
+		}
+
+		started := time.Now()
+		event, res, replayErr := replayOne(ctx, chargeProvider, row, process)
+		j := attempt{
+			providerName: chargeProvider.Name(),
+			source:       model.WebhookSourceReplay,
+			raw:          row.RawPayload,
+			event:        event,
+			result:       res,
+			err:          replayErr,
+			attempts:     row.ReplayAttempts + 1,
+			receivedAt:   started,
+			started:      started,
+			finished:     time.Now(),
+		}
+		if replayErr != nil {
+			j.outcome = model.WebhookOutcomeFailed
+		}
+		// The row was processed: record it even if the caller went away meanwhile.
+		recCtx := context.WithoutCancel(ctx)
+		journal(recCtx, j)
+		if recErr := supabase.RecordAsaasWebhookEventReplayContext(recCtx, row, replayErr); recErr != nil {
+			log.Printf("[webhook] ERROR recording replay outcome: log_id=%s err=%v", row.ID, recErr)
+		}
+		item.ReplayAttempts++
+
+		if replayErr == nil {
+			item.Result = model.WebhookReplayResolved
+			result.Resolved++
+			log.Printf("✅ [webhook] replay resolved: log_id=%s event=%s payment=%s", row.ID, row.EventType, row.PaymentID)
+		} else {
+			item.Result = model.WebhookReplayFailed
+			item.Error = replayErr.Error()
+			var se *StageError
+			if errors.As(replayErr, &se) {
+				item.Stage = se.Stage
+				item.Error = se.Err.Error()
+			}
+			result.Failed++
+			log.Printf("❌ [webhook] replay failed: log_id=%s event=%s payment=%s err=%v", row.ID, row.EventType, row.PaymentID, replayErr)
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+func replayOne(ctx context.Context, chargeProvider provider.ChargeProvider, row model.AsaasWebhookEventLog, process Processor) (event *provider.WebhookEvent, result *Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if len(row.RawPayload) == 0 {
+		return nil, nil, &StageError{Stage: "decode_payload", Err: errors.New("log row has no raw_payload")}
+	}
+	event, err = chargeProvider.ParseWebhookEvent(row.RawPayload)
+	if err != nil {
+		return nil, nil, &StageError{Stage: "decode_payload", Err: err}
+	}
+	result, err = process(ctx, chargeProvider.Name(), row.IntegrationID, event, row.RawPayload)
+	return event, result, err
+}